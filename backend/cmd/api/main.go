@@ -1,6 +1,7 @@
 package main
 
 import (
+	"alert-center/internal/config"
 	"alert-center/internal/handlers"
 	"alert-center/internal/middleware"
 	"alert-center/internal/repository"
@@ -9,6 +10,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
 	"strings"
@@ -17,6 +19,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -34,7 +38,10 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	initConfig()
+	config.Init()
+	if err := config.Apply(); err != nil {
+		log.Fatal(err)
+	}
 
 	db, err := repository.NewDatabase()
 	if err != nil {
@@ -42,9 +49,10 @@ func main() {
 	}
 	defer db.Close()
 
-	if err := runMigrations(db); err != nil {
+	if err := repository.RunMigrations(db); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
+	checkSeverities(db)
 	seedDefaultUser(db)
 	seedDefaultBusinessGroups(db)
 	seedDefaultAlertTemplates(db)
@@ -54,50 +62,82 @@ func main() {
 	alertRuleRepo := repository.NewAlertRuleRepository(db)
 	alertChannelRepo := repository.NewAlertChannelRepository(db)
 	alertHistoryRepo := repository.NewAlertHistoryRepository(db)
+	notificationLogRepo := repository.NewNotificationLogRepository(db)
 
 	userService := services.NewUserService(userRepo)
 	alertRuleService := services.NewAlertRuleService(alertRuleRepo, alertChannelRepo, alertHistoryRepo)
-	alertChannelService := services.NewAlertChannelService(alertChannelRepo)
+	alertChannelService := services.NewAlertChannelService(alertChannelRepo).WithNotificationLog(notificationLogRepo)
 	templateService := services.NewAlertTemplateService(db.Pool)
 	bindingService := services.NewAlertChannelBindingService(db.Pool)
 	userMgmtService := services.NewUserManagementService(db.Pool)
 	auditLogService := services.NewAuditLogService(db.Pool)
 	dataSourceService := services.NewDataSourceService(db.Pool)
 	statisticsService := services.NewAlertStatisticsService(db.Pool)
+	presetService := services.NewAlertRulePresetService(db.Pool, alertRuleService, dataSourceService)
+	if err := presetService.SeedDefaultPresets(ctx); err != nil {
+		log.Printf("Failed to seed rule presets: %v", err)
+	}
 	silenceService := services.NewAlertSilenceService(db.Pool)
 	slaConfigRepo := repository.NewSLAConfigRepository(db)
 	slaRepo := repository.NewAlertSLARepository(db)
 	oncallScheduleRepo := repository.NewOnCallScheduleRepository(db)
 	oncallMemberRepo := repository.NewOnCallMemberRepository(db)
 	oncallAssignmentRepo := repository.NewOnCallAssignmentRepository(db)
+	oncallOverrideRepo := repository.NewOnCallOverrideRepository(db)
 	correlationService := services.NewAlertCorrelationService(db.Pool)
-	escalationService := services.NewAlertEscalationMgmtService(db.Pool)
+	stateWebhook := services.NewStateWebhook(viper.GetString("state_webhook.url"), viper.GetDuration("state_webhook.timeout"))
+	escalationService := services.NewAlertEscalationMgmtService(db.Pool).WithStateWebhook(stateWebhook)
 	schedulingService := services.NewSchedulingService(db.Pool)
-	sender := services.NewNotificationSender(db.Pool)
-	wsHandler := handlers.NewWebSocketHandler()
-	slaBreachService := services.NewSLABreachService(db.Pool, sender, wsHandler)
+	sender := services.NewNotificationSender(db.Pool).WithDefaultChannel(viper.GetString("channels.default_channel_id")).
+		WithNotificationLog(notificationLogRepo)
+	wsHandler := handlers.NewWebSocketHandler().WithJWTSecret(viper.GetString("jwt.secret"))
+	slaBreachService := services.NewSLABreachService(db.Pool, sender, wsHandler).
+		WithAutoTicket(viper.GetBool("sla.auto_ticket_on_breach"))
 
 	userHandler := handlers.NewUserHandler(userService)
-	alertRuleHandler := handlers.NewAlertRuleHandler(alertRuleService, bindingService)
+	alertRuleHandler := handlers.NewAlertRuleHandler(alertRuleService, bindingService).
+		WithAlertEvaluator(services.NewAlertEvaluator(1 * time.Minute)).
+		WithPresetService(presetService)
 	alertChannelHandler := handlers.NewAlertChannelHandler(alertChannelService)
 	businessGroupHandler := handlers.NewBusinessGroupHandler(businessGroupRepo)
-	alertHistoryHandler := handlers.NewAlertHistoryHandler(alertHistoryRepo)
+	groupMembershipRepo := repository.NewGroupMembershipRepository(db)
+	wsHandler.WithGroupScope(groupMembershipRepo)
+	groupMembershipHandler := handlers.NewGroupMembershipHandler(groupMembershipRepo)
+	alertHistoryHandler := handlers.NewAlertHistoryHandler(alertHistoryRepo).WithNotificationLog(notificationLogRepo).WithAckEvents(wsHandler, stateWebhook).
+		WithOnCall(oncallScheduleRepo, oncallAssignmentRepo).WithBulkActions(services.NewAlertBulkActionService(db.Pool))
 	templateHandler := handlers.NewAlertTemplateHandler(templateService)
-	bindingHandler := handlers.NewAlertChannelBindingHandler(bindingService)
+	bindingHandler := handlers.NewAlertChannelBindingHandler(bindingService).WithAuditLogService(auditLogService)
 	userMgmtHandler := handlers.NewUserManagementHandler(userMgmtService)
 	auditLogHandler := handlers.NewAuditLogHandler(auditLogService)
 	dataSourceHandler := handlers.NewDataSourceHandler(dataSourceService)
 	statisticsHandler := handlers.NewAlertStatisticsHandler(statisticsService)
 	silenceHandler := handlers.NewAlertSilenceHandler(silenceService)
-	batchHandler := handlers.NewBatchImportHandler(alertRuleService, silenceService)
+	bundleService := services.NewConfigBundleService(businessGroupRepo, alertRuleService, alertChannelService, templateService, bindingService, silenceService)
+	batchHandler := handlers.NewBatchImportHandler(alertRuleService, silenceService).
+		WithConfigBundleService(bundleService).
+		WithChannelService(alertChannelService).
+		WithBusinessGroupRepository(businessGroupRepo).
+		WithRuleValidator(services.NewRuleValidator(db.Pool))
 	slaHandler := handlers.NewSLAHandler(slaConfigRepo).WithAlertSLARepository(slaRepo)
-	oncallHandler := handlers.NewOnCallHandler(oncallScheduleRepo).WithRepositories(oncallMemberRepo, oncallAssignmentRepo)
-	correlationHandler := handlers.NewCorrelationHandler(correlationService)
+	oncallHandler := handlers.NewOnCallHandler(oncallScheduleRepo).WithRepositories(oncallMemberRepo, oncallAssignmentRepo).WithOverrides(oncallOverrideRepo)
+	correlationHandler := handlers.NewCorrelationHandler(correlationService).
+		WithAlertSilenceService(silenceService)
 	escalationHandler := handlers.NewEscalationHandler(escalationService)
 	schedulingHandler := handlers.NewSchedulingHandler(schedulingService)
 	slaBreachHandler := handlers.NewSLABreachHandler(slaBreachService)
 	escalationHistoryHandler := handlers.NewEscalationHistoryHandler(db)
-	ticketHandler := handlers.NewTicketHandler(db, wsHandler)
+	ticketHandler := handlers.NewTicketHandler(db, wsHandler).WithAuditLogService(auditLogService)
+	workerStatus := services.NewWorkerStatus()
+	healthHandler := handlers.NewHealthHandler(workerStatus)
+	prometheus.MustRegister(services.MetricsCollectors()...)
+	prometheus.MustRegister(workerStatus, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alert_center_websocket_clients",
+		Help: "Connected WebSocket clients.",
+	}, func() float64 { return float64(wsHandler.ClientCount()) }))
+	deadLetterHandler := handlers.NewNotificationDeadLetterHandler(services.NewNotificationDeadLetter(db.Pool, sender))
+	federationHandler := handlers.NewFederationHandler(services.NewFederationService(db.Pool, federationSourcesFromConfig()).
+		WithSLA(services.NewSLAService(db.Pool)).
+		WithBroadcaster(wsHandler))
 
 	router := initRouter(
 		wsHandler,
@@ -105,6 +145,7 @@ func main() {
 		alertRuleHandler,
 		alertChannelHandler,
 		businessGroupHandler,
+		groupMembershipHandler,
 		alertHistoryHandler,
 		templateHandler,
 		bindingHandler,
@@ -122,6 +163,10 @@ func main() {
 		slaBreachHandler,
 		escalationHistoryHandler,
 		ticketHandler,
+		healthHandler,
+		deadLetterHandler,
+		federationHandler,
+		groupMembershipRepo,
 	)
 
 	addr := fmt.Sprintf("%s:%d", viper.GetString("app.host"), viper.GetInt("app.port"))
@@ -138,7 +183,21 @@ func main() {
 		}
 	}()
 
-	go startWorker(ctx, db, wsHandler)
+	go stateWebhook.Run(ctx)
+	go startWorker(ctx, db, wsHandler, workerStatus, stateWebhook)
+	go statisticsService.StartDailyRollup(ctx)
+	if viper.GetBool("digest.enabled") {
+		digest := services.NewAlertDigestService(db.Pool, statisticsService, slaRepo, services.DigestConfig{
+			Hour:       viper.GetInt("digest.hour"),
+			Recipients: viper.GetStringSlice("digest.recipients"),
+			SMTPHost:   viper.GetString("digest.smtp_host"),
+			SMTPPort:   viper.GetInt("digest.smtp_port"),
+			Username:   viper.GetString("digest.username"),
+			Password:   viper.GetString("digest.password"),
+			From:       viper.GetString("digest.from"),
+		})
+		go digest.Run(ctx)
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -156,11 +215,12 @@ func main() {
 	log.Println("Server exited")
 }
 
-func startWorker(ctx context.Context, db *repository.Database, broadcaster services.Broadcaster) {
+func startWorker(ctx context.Context, db *repository.Database, broadcaster services.Broadcaster, status *services.WorkerStatus, stateWebhook *services.StateWebhook) {
 	ruleRepo := repository.NewAlertRuleRepository(db)
 	historyRepo := repository.NewAlertHistoryRepository(db)
 	evaluator := services.NewAlertEvaluator(1 * time.Minute)
-	sender := services.NewNotificationSender(db.Pool)
+	sender := services.NewNotificationSender(db.Pool).WithDefaultChannel(viper.GetString("channels.default_channel_id")).
+		WithNotificationLog(repository.NewNotificationLogRepository(db))
 	templateSvc := services.NewAlertTemplateService(db.Pool)
 	silenceSvc := services.NewAlertSilenceService(db.Pool)
 	slaSvc := services.NewSLAService(db.Pool)
@@ -170,315 +230,57 @@ func startWorker(ctx context.Context, db *repository.Database, broadcaster servi
 		log.Printf("Failed to seed SLA configs: %v", err)
 	}
 
-	worker := services.NewAlertNotificationWorker(db.Pool, ruleRepo, historyRepo, evaluator, sender, templateSvc, silenceSvc, slaSvc, slaBreachService, broadcaster, 1*time.Minute)
-
-	if err := worker.Start(ctx); err != nil {
-		log.Printf("Failed to start worker: %v", err)
-	}
-}
+	worker := services.NewAlertNotificationWorker(db.Pool, ruleRepo, historyRepo, evaluator, sender, templateSvc, silenceSvc, slaSvc, slaBreachService, broadcaster, 1*time.Minute).
+		WithEvalJitter(viper.GetDuration("worker.eval_jitter")).
+		WithRuleBatch(viper.GetInt("worker.batch_size"), viper.GetInt("worker.max_rules")).
+		WithDataSourceBreaker(viper.GetInt("data_sources.breaker_failures"), viper.GetDuration("data_sources.breaker_cooldown")).
+		WithStatus(status).
+		WithStateWebhook(stateWebhook)
 
-func initConfig() {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./config")
-	viper.AddConfigPath("/etc/alert-center")
-	viper.AutomaticEnv()
-	// So env vars like DATABASE_HOST (not DATABASE.HOST) override config keys like database.host
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.ReadInConfig()
+	worker.Run(ctx)
 }
 
-func runMigrations(db *repository.Database) error {
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id UUID PRIMARY KEY,
-			username VARCHAR(64) UNIQUE NOT NULL,
-			password VARCHAR(255) NOT NULL,
-			email VARCHAR(128) UNIQUE,
-			phone VARCHAR(32),
-			role VARCHAR(32) DEFAULT 'user',
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL,
-			last_login_at TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS business_groups (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			parent_id UUID,
-			manager_id UUID,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_channels (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			type VARCHAR(32) NOT NULL,
-			description VARCHAR(512),
-			config JSONB,
-			group_id UUID,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_templates (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			content TEXT NOT NULL,
-			variables JSONB,
-			type VARCHAR(32) DEFAULT 'markdown',
-			group_id UUID,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_rules (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			expression TEXT NOT NULL,
-			evaluation_interval_seconds INT DEFAULT 60,
-			for_duration INT DEFAULT 60,
-			severity VARCHAR(32) NOT NULL,
-			labels JSONB,
-			annotations JSONB,
-			template_id UUID,
-			group_id UUID NOT NULL,
-			data_source_type VARCHAR(32) DEFAULT 'prometheus',
-			data_source_url VARCHAR(512),
-			status INT DEFAULT 1,
-			effective_start_time VARCHAR(5) DEFAULT '00:00',
-			effective_end_time VARCHAR(5) DEFAULT '23:59',
-			exclusion_windows JSONB DEFAULT '[]',
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS effective_start_time VARCHAR(5) DEFAULT '00:00'`,
-		`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS effective_end_time VARCHAR(5) DEFAULT '23:59'`,
-		`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS exclusion_windows JSONB DEFAULT '[]'`,
-		`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS evaluation_interval_seconds INT DEFAULT 60`,
-		`ALTER TABLE alert_history ADD COLUMN IF NOT EXISTS alert_no VARCHAR(32) UNIQUE`,
-		`CREATE TABLE IF NOT EXISTS alert_channel_bindings (
-			id UUID PRIMARY KEY,
-			rule_id UUID NOT NULL,
-			channel_id UUID NOT NULL,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL,
-			UNIQUE(rule_id, channel_id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_history (
-			id UUID PRIMARY KEY,
-			alert_no VARCHAR(32) UNIQUE,
-			rule_id UUID NOT NULL,
-			fingerprint VARCHAR(256),
-			severity VARCHAR(32),
-			status VARCHAR(32),
-			started_at TIMESTAMP NOT NULL,
-			ended_at TIMESTAMP,
-			labels JSONB,
-			annotations JSONB,
-			payload TEXT,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS operation_logs (
-			id UUID PRIMARY KEY,
-			user_id UUID,
-			action VARCHAR(64),
-			resource VARCHAR(128),
-			resource_id VARCHAR(128),
-			detail TEXT,
-			ip VARCHAR(64),
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS data_sources (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			type VARCHAR(32) NOT NULL,
-			description VARCHAR(512),
-			endpoint VARCHAR(512) NOT NULL,
-			config JSONB,
-			status INT DEFAULT 1,
-			health_status VARCHAR(32) DEFAULT 'unknown',
-			last_check_at TIMESTAMP,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_silences (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			matchers JSONB,
-			start_time TIMESTAMP NOT NULL,
-			end_time TIMESTAMP NOT NULL,
-			created_by UUID,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_escalations (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			rule_id UUID NOT NULL,
-			severity VARCHAR(32) NOT NULL,
-			escalate_to VARCHAR(32) NOT NULL,
-			wait_minutes INT DEFAULT 5,
-			channel_id UUID,
-			repeat_count INT DEFAULT 0,
-			repeat_minutes INT DEFAULT 30,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_escalation_logs (
-			id UUID PRIMARY KEY,
-			escalation_id UUID NOT NULL,
-			alert_id UUID NOT NULL,
-			from_severity VARCHAR(32),
-			to_severity VARCHAR(32),
-			channel_id UUID,
-			notified_at TIMESTAMP,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS notification_templates (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			type VARCHAR(32) DEFAULT 'markdown',
-			channel_type VARCHAR(32) NOT NULL,
-			subject VARCHAR(256),
-			content TEXT,
-			variables JSONB,
-			status INT DEFAULT 1,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS sla_configs (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			severity VARCHAR(32) NOT NULL,
-			response_time_mins INT NOT NULL,
-			resolution_time_mins INT NOT NULL,
-			priority INT DEFAULT 0,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS alert_slas (
-			id UUID PRIMARY KEY,
-			alert_id UUID NOT NULL,
-			rule_id UUID NOT NULL,
-			severity VARCHAR(32) NOT NULL,
-			sla_config_id UUID,
-			response_deadline TIMESTAMP,
-			resolution_deadline TIMESTAMP,
-			first_acked_at TIMESTAMP,
-			resolved_at TIMESTAMP,
-			status VARCHAR(32) DEFAULT 'pending',
-			response_breached BOOLEAN DEFAULT FALSE,
-			resolution_breached BOOLEAN DEFAULT FALSE,
-			response_time_secs FLOAT,
-			resolution_time_secs FLOAT,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS oncall_schedules (
-			id UUID PRIMARY KEY,
-			name VARCHAR(128) NOT NULL,
-			description VARCHAR(512),
-			timezone VARCHAR(64) DEFAULT 'UTC',
-			rotation_type VARCHAR(32) DEFAULT 'weekly',
-			rotation_start TIMESTAMP,
-			enabled BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS oncall_members (
-			id UUID PRIMARY KEY,
-			schedule_id UUID NOT NULL,
-			user_id UUID NOT NULL,
-			username VARCHAR(64) NOT NULL,
-			email VARCHAR(128),
-			phone VARCHAR(32),
-			priority INT DEFAULT 0,
-			start_time TIMESTAMP,
-			end_time TIMESTAMP,
-			is_active BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS oncall_assignments (
-			id UUID PRIMARY KEY,
-			schedule_id UUID NOT NULL,
-			user_id UUID NOT NULL,
-			username VARCHAR(64) NOT NULL,
-			start_time TIMESTAMP NOT NULL,
-			end_time TIMESTAMP NOT NULL,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS oncall_escalations (
-			id UUID PRIMARY KEY,
-			schedule_id UUID NOT NULL,
-			from_user_id UUID NOT NULL,
-			to_user_id UUID NOT NULL,
-			escalated_at TIMESTAMP NOT NULL,
-			reason TEXT,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS sla_breaches (
-			id UUID PRIMARY KEY,
-			alert_id UUID NOT NULL,
-			rule_id UUID NOT NULL,
-			severity VARCHAR(32) NOT NULL,
-			breach_type VARCHAR(32) NOT NULL,
-			breach_time TIMESTAMP NOT NULL,
-			response_time FLOAT,
-			assigned_to UUID,
-			assigned_name VARCHAR(64),
-			notified BOOLEAN DEFAULT FALSE,
-			created_at TIMESTAMP NOT NULL
-		)`,
-		`CREATE TABLE IF NOT EXISTS tickets (
-			id UUID PRIMARY KEY,
-			title VARCHAR(256) NOT NULL,
-			description TEXT,
-			alert_id UUID,
-			rule_id UUID,
-			priority VARCHAR(32) NOT NULL DEFAULT 'medium',
-			status VARCHAR(32) NOT NULL DEFAULT 'open',
-			assignee_id UUID,
-			assignee_name VARCHAR(64),
-			creator_id UUID NOT NULL,
-			creator_name VARCHAR(64) NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL,
-			resolved_at TIMESTAMP,
-			closed_at TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS user_escalations (
-			id UUID PRIMARY KEY,
-			alert_id UUID NOT NULL,
-			from_user_id UUID NOT NULL,
-			from_username VARCHAR(64) NOT NULL,
-			to_user_id UUID NOT NULL,
-			to_username VARCHAR(64) NOT NULL,
-			reason TEXT,
-			status VARCHAR(32) NOT NULL DEFAULT 'pending',
-			created_at TIMESTAMP NOT NULL,
-			resolved_at TIMESTAMP
-		)`,
+// federationSourcesFromConfig reads federation.sources, the instances allowed to forward alerts here:
+// a list of {name, api_key, group_id}. Entries without a name, key or valid group are skipped.
+func federationSourcesFromConfig() []services.FederationSource {
+	var sources []services.FederationSource
+	items, _ := viper.Get("federation.sources").([]interface{})
+	for i, item := range items {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := v["name"].(string)
+		apiKey, _ := v["api_key"].(string)
+		groupID, _ := v["group_id"].(string)
+		gid, err := uuid.Parse(groupID)
+		if name == "" || apiKey == "" || err != nil {
+			log.Printf("federation.sources[%d]: name, api_key and a valid group_id are required; skipped", i)
+			continue
+		}
+		sources = append(sources, services.FederationSource{Name: name, APIKey: apiKey, GroupID: gid})
 	}
+	return sources
+}
 
+// checkSeverities logs rules and SLA configs whose severity is outside the configured set. They keep
+// working but never match a severity-based SLA config, and saving them again fails validation.
+func checkSeverities(db *repository.Database) {
 	ctx := context.Background()
-	for _, migration := range migrations {
-		if _, err := db.Pool.Exec(ctx, migration); err != nil {
-			return err
+	for _, table := range []string{"alert_rules", "sla_configs"} {
+		rows, err := db.Pool.Query(ctx, `SELECT name, severity FROM `+table+` WHERE NOT (severity = ANY($1))`, services.Severities())
+		if err != nil {
+			log.Printf("Severity check on %s failed: %v", table, err)
+			continue
 		}
+		for rows.Next() {
+			var name, severity string
+			if err := rows.Scan(&name, &severity); err == nil {
+				log.Printf("Warning: %s %q has unknown severity %q (allowed: %s)", table, name, severity, strings.Join(services.Severities(), ", "))
+			}
+		}
+		rows.Close()
 	}
-
-	return nil
 }
 
 // seedDefaultUser creates default admin if no user exists.
@@ -548,14 +350,15 @@ func seedDefaultAlertTemplates(db *repository.Database) {
 		"**严重级别**: {{severity}}\n" +
 		"**状态**: {{status}}\n" +
 		"**触发时间**: {{startTime}}\n" +
-		"**持续时间**: {{duration}}\n\n" +
+		"**持续时间**: {{duration}}\n" +
+		"**近 24 小时触发**: {{recentCount}} 次（上次恢复: {{lastResolved}}）\n\n" +
 		"### 标签 (Labels)\n" +
 		"{{labelsFormatted}}\n\n" +
 		"### 注释 (Annotations)\n" +
 		"{{annotationsFormatted}}\n\n" +
 		"### 处理建议\n" +
 		"根据上述标签定位资源（如 namespace/pod/node/job 等），检查事件与日志：`kubectl describe` / `kubectl logs`。"
-	variables := `{"ruleName":"规则名称","severity":"严重级别","status":"状态","startTime":"触发时间","duration":"持续时间","labelsFormatted":"标签键值（自动适配）","annotationsFormatted":"注释键值（自动适配）","labels":"原始 labels JSON","annotations":"原始 annotations JSON"}`
+	variables := `{"ruleName":"规则名称","severity":"严重级别","status":"状态","startTime":"触发时间","duration":"持续时间","labelsFormatted":"标签键值（自动适配）","annotationsFormatted":"注释键值（自动适配）","labels":"原始 labels JSON","annotations":"原始 annotations JSON","value":"当前值（按规则数值格式）","rawValue":"当前原始值","summary":"聚合模式下的触发序列 Top N","recentCount":"该告警近 24 小时触发次数（含本次）","lastResolved":"该告警上次恢复时间"}`
 	desc := "动态适配任意 Prometheus 告警：标签与注释按实际键值自动展示，无需固定格式"
 	if n > 0 {
 		_, _ = db.Pool.Exec(ctx, `
@@ -577,12 +380,30 @@ func seedDefaultAlertTemplates(db *repository.Database) {
 	log.Printf("Default alert template seeded: K8s Prometheus 默认告警模板")
 }
 
+// registerPprofRoutes mounts net/http/pprof under /debug/pprof, restricted to admin users.
+func registerPprofRoutes(router *gin.Engine) {
+	debug := router.Group("/debug/pprof")
+	debug.Use(middleware.AuthMiddleware(viper.GetString("jwt.secret")), middleware.RoleMiddleware("admin"))
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+		for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+			debug.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+		}
+	}
+}
+
 func initRouter(
 	wsHandler *handlers.WebSocketHandler,
 	userHandler *handlers.UserHandler,
 	alertRuleHandler *handlers.AlertRuleHandler,
 	alertChannelHandler *handlers.AlertChannelHandler,
 	businessGroupHandler *handlers.BusinessGroupHandler,
+	groupMembershipHandler *handlers.GroupMembershipHandler,
 	alertHistoryHandler *handlers.AlertHistoryHandler,
 	templateHandler *handlers.AlertTemplateHandler,
 	bindingHandler *handlers.AlertChannelBindingHandler,
@@ -599,7 +420,11 @@ func initRouter(
 	schedulingHandler *handlers.SchedulingHandler,
 	slaBreachHandler *handlers.SLABreachHandler,
 	escalationHistoryHandler *handlers.EscalationHistoryHandler,
-	ticketHandler *handlers.TicketHandler) *gin.Engine {
+	ticketHandler *handlers.TicketHandler,
+	healthHandler *handlers.HealthHandler,
+	deadLetterHandler *handlers.NotificationDeadLetterHandler,
+	federationHandler *handlers.FederationHandler,
+	groupScope middleware.GroupScopeResolver) *gin.Engine {
 
 	router := gin.New()
 	router.Use(middleware.RecoveryMiddleware())
@@ -610,22 +435,37 @@ func initRouter(
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	go wsHandler.HandleBroadcast()
 	router.GET("/api/v1/ws", wsHandler.HandleConnection)
 
+	if viper.GetBool("debug.pprof") {
+		registerPprofRoutes(router)
+	}
+
 	public := router.Group("/api/v1")
 	{
 		public.POST("/auth/login", userHandler.Login)
+		public.GET("/health/worker", healthHandler.Worker)
+		public.POST("/federation/alerts", federationHandler.Ingest)
 	}
 
 	api := router.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware(viper.GetString("jwt.secret")))
+	api.Use(middleware.GroupScopeMiddleware(groupScope))
 	{
 		api.GET("/profile", userHandler.GetProfile)
+		api.GET("/severities", alertRuleHandler.Severities)
 
 		api.GET("/business-groups", businessGroupHandler.List)
+		api.PUT("/business-groups/:id/default-channel", middleware.RoleMiddleware("admin"), businessGroupHandler.SetDefaultChannel)
+
+		api.GET("/group-memberships", middleware.RoleMiddleware("admin"), groupMembershipHandler.List)
+		api.POST("/group-memberships", middleware.RoleMiddleware("admin"), groupMembershipHandler.Create)
+		api.PUT("/group-memberships/:id", middleware.RoleMiddleware("admin"), groupMembershipHandler.Update)
+		api.DELETE("/group-memberships/:id", middleware.RoleMiddleware("admin"), groupMembershipHandler.Delete)
 
 		api.POST("/users", userMgmtHandler.Create)
 		api.GET("/users", userMgmtHandler.List)
@@ -636,21 +476,28 @@ func initRouter(
 
 		api.POST("/alert-rules", alertRuleHandler.Create)
 		api.POST("/alert-rules/test-expression", alertRuleHandler.TestExpression)
+		api.POST("/alert-rules/:id/backtest", middleware.RoleMiddleware("admin"), alertRuleHandler.Backtest)
 		api.GET("/alert-rules", alertRuleHandler.List)
 		api.GET("/alert-rules/:id", alertRuleHandler.GetByID)
 		api.PUT("/alert-rules/:id", alertRuleHandler.Update)
 		api.DELETE("/alert-rules/:id", alertRuleHandler.Delete)
 		api.GET("/alert-rules/export", alertRuleHandler.Export)
+		api.GET("/alert-rules/presets", alertRuleHandler.Presets)
+		api.POST("/alert-rules/from-preset/:id", alertRuleHandler.CreateFromPreset)
+		api.POST("/alert-rules/bulk-move", alertRuleHandler.BulkMove)
 		api.GET("/alert-rules/:id/bindings", alertRuleHandler.GetBindings)
+		api.GET("/alert-rules/:id/status", alertRuleHandler.Status)
 		api.POST("/alert-rules/:id/bindings", bindingHandler.BindChannels)
 
 		api.POST("/channels", alertChannelHandler.Create)
 		api.GET("/channels", alertChannelHandler.List)
+		api.GET("/channels/types", alertChannelHandler.Types)
 		api.GET("/channels/:id", alertChannelHandler.GetByID)
 		api.PUT("/channels/:id", alertChannelHandler.Update)
 		api.DELETE("/channels/:id", alertChannelHandler.Delete)
 		api.POST("/channels/:id/test", alertChannelHandler.Test)
 		api.POST("/channels/test-config", alertChannelHandler.TestWithConfig)
+		api.POST("/channels/validate-config", alertChannelHandler.ValidateConfig)
 
 		api.GET("/templates", templateHandler.List)
 		api.POST("/templates", templateHandler.Create)
@@ -659,16 +506,28 @@ func initRouter(
 		api.DELETE("/templates/:id", templateHandler.Delete)
 
 		api.GET("/alert-history", alertHistoryHandler.List)
+		api.GET("/alert-history/export", alertHistoryHandler.Export)
+		api.GET("/alert-history/by-no/:alert_no", alertHistoryHandler.GetByNo)
+		api.GET("/alert-history/:id", alertHistoryHandler.Get)
+		api.GET("/alert-history/:id/notifications", alertHistoryHandler.Notifications)
+		api.POST("/alert-history/:id/ack", alertHistoryHandler.Ack)
+		api.POST("/alert-history/bulk/ack", alertHistoryHandler.BulkAck)
+		api.POST("/alert-history/bulk/resolve", alertHistoryHandler.BulkResolve)
+		api.GET("/alerts/active", alertHistoryHandler.Active)
+		api.GET("/notifications/deadletter", deadLetterHandler.List)
+		api.POST("/notifications/deadletter/:id/retry", middleware.RoleMiddleware("admin"), deadLetterHandler.Retry)
 
 		api.GET("/audit-logs", auditLogHandler.List)
 		api.GET("/audit-logs/export", auditLogHandler.Export)
 
 		api.GET("/data-sources", dataSourceHandler.List)
+		api.GET("/data-sources/types", dataSourceHandler.Types)
 		api.POST("/data-sources", dataSourceHandler.Create)
 		api.GET("/data-sources/:id", dataSourceHandler.GetByID)
 		api.PUT("/data-sources/:id", dataSourceHandler.Update)
 		api.DELETE("/data-sources/:id", dataSourceHandler.Delete)
 		api.POST("/data-sources/:id/health-check", dataSourceHandler.HealthCheck)
+		api.GET("/data-sources/:id/health-history", dataSourceHandler.HealthHistory)
 
 		api.GET("/statistics", statisticsHandler.Statistics)
 		api.GET("/dashboard", statisticsHandler.Dashboard)
@@ -678,12 +537,20 @@ func initRouter(
 		api.PUT("/silences/:id", silenceHandler.Update)
 		api.DELETE("/silences/:id", silenceHandler.Delete)
 		api.POST("/silences/check", silenceHandler.Check)
+		api.POST("/silences/preview", silenceHandler.Preview)
+		api.GET("/silences/active-matches", silenceHandler.ActiveMatches)
 
 		api.POST("/batch/import/rules", batchHandler.ImportRules)
+		api.POST("/batch/validate/rules", batchHandler.ValidateRules)
+		api.POST("/batch/import/rules/prometheus", batchHandler.ImportPrometheusRules)
+		api.GET("/batch/export/rules/prometheus", batchHandler.ExportPrometheusRules)
 		api.GET("/batch/export/rules", batchHandler.ExportRules)
 		api.GET("/batch/export/channels", batchHandler.ExportChannels)
+		api.POST("/batch/import/channels", batchHandler.ImportChannels)
 		api.POST("/batch/import/silences", batchHandler.ImportSilences)
 		api.GET("/batch/export/silences", batchHandler.ExportSilences)
+		api.GET("/batch/export/all", batchHandler.ExportAll)
+		api.POST("/batch/import/all", batchHandler.ImportAll)
 
 		api.GET("/sla/configs", slaHandler.ListSLAConfigs)
 		api.POST("/sla/configs", slaHandler.CreateSLAConfig)
@@ -704,6 +571,9 @@ func initRouter(
 		api.DELETE("/oncall/schedules/:id/members/:member_id", oncallHandler.DeleteMember)
 		api.GET("/oncall/schedules/:id/assignments", oncallHandler.GetScheduleAssignments)
 		api.POST("/oncall/schedules/:id/generate-rotations", oncallHandler.GenerateRotations)
+		api.GET("/oncall/schedules/:id/overrides", oncallHandler.GetOverrides)
+		api.POST("/oncall/schedules/:id/overrides", oncallHandler.CreateOverride)
+		api.DELETE("/oncall/schedules/:id/overrides/:override_id", oncallHandler.DeleteOverride)
 		api.POST("/oncall/schedules/:id/escalate", oncallHandler.Escalate)
 		api.GET("/oncall/current", oncallHandler.GetCurrentOnCall)
 		api.GET("/oncall/who", oncallHandler.WhoIsOnCall)
@@ -716,6 +586,7 @@ func initRouter(
 		api.GET("/correlation/timeline/:fingerprint", correlationHandler.GenerateTimeline)
 		api.GET("/correlation/flapping", correlationHandler.DetectFlapping)
 		api.GET("/correlation/predict/:rule_id", correlationHandler.PredictAlerts)
+		api.POST("/correlation/suppress", correlationHandler.Suppress)
 
 		api.GET("/escalations", escalationHistoryHandler.GetHistory)
 		api.GET("/escalations/stats", escalationHistoryHandler.GetStats)
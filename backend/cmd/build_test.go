@@ -0,0 +1,18 @@
+package cmd_test
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// TestBinariesBuild builds the API server and the standalone worker, which share config loading and
+// migrations through internal packages.
+func TestBinariesBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds both binaries")
+	}
+	out, err := exec.Command("go", "build", "./api", "./worker").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+}
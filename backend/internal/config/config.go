@@ -0,0 +1,80 @@
+// Package config loads the configuration shared by the API server and the standalone worker and
+// applies the process-wide settings of the services package, so both binaries behave the same.
+package config
+
+import (
+	"alert-center/internal/services"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Init reads config.yaml from the working directory, ./config or /etc/alert-center. Environment
+// variables override it.
+func Init() {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(".")
+	viper.AddConfigPath("./config")
+	viper.AddConfigPath("/etc/alert-center")
+	viper.AutomaticEnv()
+	// So env vars like DATABASE_HOST (not DATABASE.HOST) override config keys like database.host
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.ReadInConfig()
+}
+
+// Apply configures the services package from the loaded config: the outbound HTTP proxy, severity
+// levels, notification dry-run, channel and data source retries and the dead-letter sweep.
+func Apply() error {
+	if err := services.SetHTTPProxy(viper.GetString("http.proxy_url")); err != nil {
+		return fmt.Errorf("invalid http.proxy_url: %w", err)
+	}
+	services.SetSeverityLevels(SeverityLevels())
+	services.SetNotificationsDryRun(viper.GetBool("notifications.dry_run"))
+	channelRetries := 3
+	if viper.IsSet("notifications.max_retries") {
+		channelRetries = viper.GetInt("notifications.max_retries")
+	}
+	services.SetChannelRetry(channelRetries, time.Duration(viper.GetInt("notifications.retry_base_ms"))*time.Millisecond)
+	dataSourceAttempts := 3
+	if viper.IsSet("data_sources.max_attempts") {
+		dataSourceAttempts = viper.GetInt("data_sources.max_attempts")
+	}
+	services.SetDataSourceRetry(dataSourceAttempts, time.Duration(viper.GetInt("data_sources.retry_base_ms"))*time.Millisecond)
+	services.SetDeadLetterSweep(viper.GetDuration("notifications.deadletter_interval"), viper.GetDuration("notifications.deadletter_max_age"))
+	if services.NotificationsDryRun() {
+		log.Println("notifications.dry_run is on: alert notifications and channel tests are logged, not sent")
+	}
+	return nil
+}
+
+// SeverityLevels reads the severities list, whose entries are either a plain name or a map with name,
+// color and optional response_mins/resolution_mins. A string value (e.g. from the SEVERITIES env var)
+// is split on commas.
+func SeverityLevels() []services.SeverityLevel {
+	var levels []services.SeverityLevel
+	switch raw := viper.Get("severities").(type) {
+	case string:
+		for _, name := range strings.Split(raw, ",") {
+			levels = append(levels, services.SeverityLevel{Name: name})
+		}
+	case []interface{}:
+		for _, item := range raw {
+			switch v := item.(type) {
+			case string:
+				levels = append(levels, services.SeverityLevel{Name: v})
+			case map[string]interface{}:
+				level := services.SeverityLevel{}
+				level.Name, _ = v["name"].(string)
+				level.Color, _ = v["color"].(string)
+				level.ResponseMins, _ = v["response_mins"].(int)
+				level.ResolutionMins, _ = v["resolution_mins"].(int)
+				levels = append(levels, level)
+			}
+		}
+	}
+	return levels
+}
@@ -10,13 +10,20 @@ import (
 )
 
 type AlertChannelBindingHandler struct {
-	service *services.AlertChannelBindingService
+	service      *services.AlertChannelBindingService
+	auditService *services.AuditLogService
 }
 
 func NewAlertChannelBindingHandler(service *services.AlertChannelBindingService) *AlertChannelBindingHandler {
 	return &AlertChannelBindingHandler{service: service}
 }
 
+// WithAuditLogService makes BindChannels record an audit entry in the same transaction as the bindings.
+func (h *AlertChannelBindingHandler) WithAuditLogService(auditService *services.AuditLogService) *AlertChannelBindingHandler {
+	h.auditService = auditService
+	return h
+}
+
 func (h *AlertChannelBindingHandler) BindChannels(c *gin.Context) {
 	ruleID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -24,15 +31,39 @@ func (h *AlertChannelBindingHandler) BindChannels(c *gin.Context) {
 		return
 	}
 
+	// bindings (with per-channel min_severity and effective window) takes precedence over the plain channel_ids list.
 	var req struct {
-		ChannelIDs []uuid.UUID `json:"channel_ids" binding:"required"`
+		ChannelIDs []uuid.UUID                       `json:"channel_ids"`
+		Bindings   []services.ChannelBindingRequest `json:"bindings"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if req.ChannelIDs == nil && req.Bindings == nil {
+		response.Error(c, http.StatusBadRequest, "channel_ids or bindings is required")
+		return
+	}
 
-	if err := h.service.BindChannels(c.Request.Context(), ruleID, req.ChannelIDs); err != nil {
+	bindings := req.Bindings
+	if bindings == nil {
+		for _, id := range req.ChannelIDs {
+			bindings = append(bindings, services.ChannelBindingRequest{ChannelID: id})
+		}
+	}
+	for _, b := range bindings {
+		if err := services.ValidateBinding(b); err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if userID, ok := c.Get("user_id"); ok && h.auditService != nil {
+		err = h.service.SetBindingsAudited(c.Request.Context(), h.auditService, userID.(uuid.UUID), ruleID, bindings)
+	} else {
+		err = h.service.SetBindings(c.Request.Context(), ruleID, bindings)
+	}
+	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
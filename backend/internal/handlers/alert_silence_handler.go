@@ -3,6 +3,7 @@ package handlers
 import (
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -29,13 +30,21 @@ func (h *AlertSilenceHandler) Create(c *gin.Context) {
 
 	silence, err := h.service.Create(c.Request.Context(), &req, userID.(uuid.UUID))
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		response.Error(c, silenceErrorStatus(err), err.Error())
 		return
 	}
 
 	response.Success(c, silence)
 }
 
+// silenceErrorStatus maps validation failures to 400 and anything else to 500.
+func silenceErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidSilence) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *AlertSilenceHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -70,7 +79,7 @@ func (h *AlertSilenceHandler) Update(c *gin.Context) {
 
 	silence, err := h.service.Update(c.Request.Context(), id, &req)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		response.Error(c, silenceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -92,16 +101,46 @@ func (h *AlertSilenceHandler) Delete(c *gin.Context) {
 	response.Success(c, nil)
 }
 
+// ActiveMatches lists every active silence with the currently firing alerts it is suppressing.
+func (h *AlertSilenceHandler) ActiveMatches(c *gin.Context) {
+	matches, err := h.service.ActiveMatches(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"data": matches})
+}
+
+// Preview returns the firing alerts a proposed silence would suppress, so broad matchers can be
+// caught before the silence is saved.
+func (h *AlertSilenceHandler) Preview(c *gin.Context) {
+	var req services.PreviewSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preview, err := h.service.Preview(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, silenceErrorStatus(err), err.Error())
+		return
+	}
+
+	response.Success(c, preview)
+}
+
 func (h *AlertSilenceHandler) Check(c *gin.Context) {
 	var req struct {
 		Labels map[string]string `json:"labels" binding:"required"`
+		RuleID uuid.UUID         `json:"rule_id"` // optional; applies the rule's group-level silences
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	silenced, err := h.service.IsSilenced(c.Request.Context(), req.Labels)
+	silenced, err := h.service.IsSilenced(c.Request.Context(), req.RuleID, req.Labels)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"alert-center/internal/repository"
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +17,10 @@ import (
 type BatchImportHandler struct {
 	alertRuleService   *services.AlertRuleService
 	alertSilenceService *services.AlertSilenceService
+	bundleService       *services.ConfigBundleService
+	alertChannelService *services.AlertChannelService
+	businessGroupRepo   *repository.BusinessGroupRepository
+	ruleValidator       *services.RuleValidator
 }
 
 func NewBatchImportHandler(alertRuleService *services.AlertRuleService, alertSilenceService *services.AlertSilenceService) *BatchImportHandler {
@@ -24,40 +30,133 @@ func NewBatchImportHandler(alertRuleService *services.AlertRuleService, alertSil
 	}
 }
 
+// WithRuleValidator sets the validator run before rules are imported.
+func (h *BatchImportHandler) WithRuleValidator(v *services.RuleValidator) *BatchImportHandler {
+	h.ruleValidator = v
+	return h
+}
+
+// WithConfigBundleService sets the service used for full configuration export/import.
+func (h *BatchImportHandler) WithConfigBundleService(svc *services.ConfigBundleService) *BatchImportHandler {
+	h.bundleService = svc
+	return h
+}
+
+// WithChannelService sets the service used for channel export/import.
+func (h *BatchImportHandler) WithChannelService(svc *services.AlertChannelService) *BatchImportHandler {
+	h.alertChannelService = svc
+	return h
+}
+
+// WithBusinessGroupRepository sets the repository used to name the groups of Prometheus rule exports.
+func (h *BatchImportHandler) WithBusinessGroupRepository(repo *repository.BusinessGroupRepository) *BatchImportHandler {
+	h.businessGroupRepo = repo
+	return h
+}
+
 type ImportRequest struct {
 	Rules []services.CreateAlertRuleRequest `json:"rules" binding:"required"`
 }
 
 type ImportResult struct {
 	Success int      `json:"success"`
+	Created  int      `json:"created"`
+	Updated  int      `json:"updated"`
 	Failed   int      `json:"failed"`
 	Errors   []string `json:"errors"`
 }
 
+// ImportRules creates the rules in the request. With ?mode=upsert, a rule matching an existing one
+// by slug (or name within the same group) updates it instead of creating a duplicate.
 func (h *BatchImportHandler) ImportRules(c *gin.Context) {
+	upsert := c.Query("mode") == "upsert"
 	var req ImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	names := make([]string, len(req.Rules))
+	for i := range req.Rules {
+		names[i] = strconv.Itoa(i)
+	}
+	result, err := h.importRules(c, req.Rules, names, upsert)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// importRules validates and creates (or with upsert, upserts) rules, naming rule i by names[i] in
+// the result errors.
+func (h *BatchImportHandler) importRules(c *gin.Context, rules []services.CreateAlertRuleRequest, names []string, upsert bool) (*ImportResult, error) {
 	result := &ImportResult{
 		Success: 0,
 		Failed:   0,
 		Errors:   []string{},
 	}
 
-	for i, rule := range req.Rules {
-		_, err := h.alertRuleService.Create(c.Request.Context(), &rule)
+	var diagnostics []services.RuleDiagnostic
+	if h.ruleValidator != nil {
+		var err error
+		diagnostics, err = h.ruleValidator.Validate(c.Request.Context(), rules)
 		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, rule := range rules {
+		if diagnostics != nil && !diagnostics[i].Valid {
 			result.Failed++
-			result.Errors = append(result.Errors, "Rule "+strconv.Itoa(i)+": "+err.Error())
+			result.Errors = append(result.Errors, "Rule "+names[i]+": "+strings.Join(diagnostics[i].Errors, "; "))
+			continue
+		}
+		var err error
+		updated := false
+		if upsert {
+			_, updated, err = h.alertRuleService.Upsert(c.Request.Context(), &rule)
+		} else {
+			_, err = h.alertRuleService.Create(c.Request.Context(), &rule)
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, "Rule "+names[i]+": "+err.Error())
 		} else {
 			result.Success++
+			if updated {
+				result.Updated++
+			} else {
+				result.Created++
+			}
 		}
 	}
+	return result, nil
+}
 
-	response.Success(c, result)
+// ValidateRules runs the same checks as ImportRules and returns per-rule diagnostics without writing anything.
+func (h *BatchImportHandler) ValidateRules(c *gin.Context) {
+	var req ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	diagnostics, err := h.ruleValidator.Validate(c.Request.Context(), req.Rules)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	valid := true
+	for _, d := range diagnostics {
+		if !d.Valid {
+			valid = false
+			break
+		}
+	}
+	response.Success(c, gin.H{"valid": valid, "rules": diagnostics})
 }
 
 type ExportRequest struct {
@@ -86,6 +185,7 @@ func (h *BatchImportHandler) ExportRules(c *gin.Context) {
 
 	type ExportRule struct {
 		Name            string   `json:"name"`
+		Slug            string   `json:"slug,omitempty"`
 		Description    string   `json:"description"`
 		Expression     string   `json:"expression"`
 		ForDuration     int      `json:"for_duration"`
@@ -101,6 +201,7 @@ func (h *BatchImportHandler) ExportRules(c *gin.Context) {
 	for _, rule := range rules {
 		exportRules = append(exportRules, ExportRule{
 			Name:            rule.Name,
+			Slug:            rule.Slug,
 			Description:    rule.Description,
 			Expression:     rule.Expression,
 			ForDuration:     rule.ForDuration,
@@ -118,13 +219,151 @@ func (h *BatchImportHandler) ExportRules(c *gin.Context) {
 	c.JSON(http.StatusOK, exportRules)
 }
 
+// ImportPrometheusRules creates rules from a Prometheus rule file (`groups:` YAML) in the business
+// group given by ?group_id. Rules without a severity label get ?default_severity (warning). Rules
+// that cannot be mapped, such as recording rules, are reported as failed; ?mode=upsert works as for
+// ImportRules.
+func (h *BatchImportHandler) ImportPrometheusRules(c *gin.Context) {
+	groupID, err := uuid.Parse(c.Query("group_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "group_id is required")
+		return
+	}
+	defaultSeverity := c.DefaultQuery("default_severity", "warning")
+	data, err := c.GetRawData()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	parsed, err := services.ParsePrometheusRules(data, groupID, defaultSeverity)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var rules []services.CreateAlertRuleRequest
+	var names, failed []string
+	for _, p := range parsed {
+		name := p.Group + "/" + p.Name
+		if p.Err != nil {
+			failed = append(failed, "Rule "+name+": "+p.Err.Error())
+			continue
+		}
+		rules = append(rules, *p.Rule)
+		names = append(names, name)
+	}
+
+	result, err := h.importRules(c, rules, names, c.Query("mode") == "upsert")
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result.Failed += len(failed)
+	result.Errors = append(failed, result.Errors...)
+
+	response.Success(c, result)
+}
+
+// ExportPrometheusRules downloads the rules matching the ExportRules filters as a Prometheus rule
+// file, one group per business group and evaluation interval.
+func (h *BatchImportHandler) ExportPrometheusRules(c *gin.Context) {
+	rules, _, err := h.alertRuleService.List(c.Request.Context(), &services.ListAlertRuleRequest{
+		Page:     1,
+		PageSize: 10000,
+		GroupID:  c.Query("group_id"),
+		Severity: c.Query("severity"),
+		Status:   c.Query("status"),
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	groupNames := make(map[uuid.UUID]string)
+	if h.businessGroupRepo != nil {
+		for _, rule := range rules {
+			if _, ok := groupNames[rule.GroupID]; ok {
+				continue
+			}
+			groupNames[rule.GroupID] = ""
+			if g, err := h.businessGroupRepo.GetByID(c.Request.Context(), rule.GroupID); err == nil {
+				groupNames[rule.GroupID] = g.Name
+			}
+		}
+	}
+
+	data, err := services.MarshalPrometheusRules(rules, groupNames)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=alert_rules_export_"+time.Now().Format("20060102150405")+".yml")
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
 type ExportChannelRequest struct {
-	Type string `json:"type"`
+	Type           string `form:"type"`
+	IncludeSecrets bool   `form:"include_secrets"`
 }
 
+// ExportChannels returns the enabled channels as a downloadable JSON array in the ImportChannels
+// format. Secret config keys are left out unless an admin passes include_secrets=true.
 func (h *BatchImportHandler) ExportChannels(c *gin.Context) {
-	// Similar implementation for channels
-	c.JSON(http.StatusOK, gin.H{"message": "export channels"})
+	var req ExportChannelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.IncludeSecrets && c.GetString("role") != "admin" {
+		response.Error(c, http.StatusForbidden, "include_secrets requires the admin role")
+		return
+	}
+
+	channels, err := h.alertChannelService.Export(c.Request.Context(), req.Type, req.IncludeSecrets)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", "attachment; filename=alert_channels_export_"+time.Now().Format("20060102150405")+".json")
+	c.JSON(http.StatusOK, channels)
+}
+
+type ImportChannelRequest struct {
+	Channels []services.CreateChannelRequest `json:"channels" binding:"required"`
+}
+
+// ImportChannels creates the channels in the request, in the ExportChannels format. With ?mode=upsert,
+// a channel matching an existing one by slug updates it, keeping its secrets when the import has none.
+func (h *BatchImportHandler) ImportChannels(c *gin.Context) {
+	upsert := c.Query("mode") == "upsert"
+	var req ImportChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := &ImportResult{Errors: []string{}}
+	for i := range req.Channels {
+		ch := &req.Channels[i]
+		updated, err := h.alertChannelService.Import(c.Request.Context(), ch, upsert)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, "Channel "+strconv.Itoa(i)+" ("+ch.Name+"): "+err.Error())
+			continue
+		}
+		result.Success++
+		if updated {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+	}
+
+	response.Success(c, result)
 }
 
 type ImportSilenceRequest struct {
@@ -151,6 +390,7 @@ func (h *BatchImportHandler) ImportSilences(c *gin.Context) {
 			result.Errors = append(result.Errors, "Silence "+strconv.Itoa(i)+": "+err.Error())
 		} else {
 			result.Success++
+			result.Created++
 		}
 	}
 
@@ -167,14 +407,14 @@ func (h *BatchImportHandler) ExportSilences(c *gin.Context) {
 	type ExportSilence struct {
 		Name        string            `json:"name"`
 		Description string            `json:"description"`
-		Matchers    []map[string]string `json:"matchers"`
+		Matchers    services.SilenceMatchers `json:"matchers"`
 		StartTime   time.Time          `json:"start_time"`
 		EndTime     time.Time          `json:"end_time"`
 	}
 
 	var exportSilences []ExportSilence
 	for _, silence := range list {
-		var matchers []map[string]string
+		var matchers services.SilenceMatchers
 		json.Unmarshal([]byte(silence.Matchers), &matchers)
 		exportSilences = append(exportSilences, ExportSilence{
 			Name:        silence.Name,
@@ -189,3 +429,43 @@ func (h *BatchImportHandler) ExportSilences(c *gin.Context) {
 	c.Header("Content-Disposition", "attachment; filename=alert_silences_export_"+time.Now().Format("20060102150405")+".json")
 	c.JSON(http.StatusOK, exportSilences)
 }
+
+// ExportAll exports templates, channels, rules, bindings and silences as one bundle.
+// References between entities are written by name so the bundle can be imported elsewhere.
+// Channel secrets are left out unless an admin passes include_secrets=true.
+func (h *BatchImportHandler) ExportAll(c *gin.Context) {
+	includeSecrets := c.Query("include_secrets") == "true"
+	if includeSecrets && c.GetString("role") != "admin" {
+		response.Error(c, http.StatusForbidden, "include_secrets requires the admin role")
+		return
+	}
+
+	bundle, err := h.bundleService.Export(c.Request.Context(), includeSecrets)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", "attachment; filename=alert_center_export_"+time.Now().Format("20060102150405")+".json")
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportAll imports a bundle produced by ExportAll, resolving name references to IDs.
+func (h *BatchImportHandler) ImportAll(c *gin.Context) {
+	var bundle services.ConfigBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uuid.UUID)
+	result, err := h.bundleService.ImportBundle(c.Request.Context(), &bundle, uid)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
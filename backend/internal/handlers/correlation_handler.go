@@ -3,6 +3,7 @@ package handlers
 import (
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,13 +13,27 @@ import (
 )
 
 type CorrelationHandler struct {
-	service *services.AlertCorrelationService
+	service        *services.AlertCorrelationService
+	silenceService *services.AlertSilenceService
 }
 
 func NewCorrelationHandler(service *services.AlertCorrelationService) *CorrelationHandler {
 	return &CorrelationHandler{service: service}
 }
 
+// WithAlertSilenceService enables Suppress, which creates silences for correlated alerts.
+func (h *CorrelationHandler) WithAlertSilenceService(silenceService *services.AlertSilenceService) *CorrelationHandler {
+	h.silenceService = silenceService
+	return h
+}
+
+// SuppressRequest silences the non-root-cause alerts of a correlation analysis.
+type SuppressRequest struct {
+	AlertID         uuid.UUID `json:"alert_id" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required,min=1"`
+	WindowMinutes   int       `json:"window_minutes"` // correlation window, default 30
+}
+
 func (h *CorrelationHandler) AnalyzeCorrelations(c *gin.Context) {
 	alertID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -38,6 +53,63 @@ func (h *CorrelationHandler) AnalyzeCorrelations(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// Suppress creates one silence matching the related (non-root-cause) alerts of the given alert,
+// so only the root cause keeps notifying for the requested duration.
+func (h *CorrelationHandler) Suppress(c *gin.Context) {
+	if h.silenceService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "silence service not configured")
+		return
+	}
+
+	var req SuppressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	windowMinutes := req.WindowMinutes
+	if windowMinutes <= 0 {
+		windowMinutes = 30
+	}
+
+	result, matchers, skipped, err := h.service.SuppressionMatchers(c.Request.Context(), req.AlertID, time.Duration(windowMinutes)*time.Minute)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(matchers) == 0 {
+		response.Error(c, http.StatusBadRequest, "no related alerts can be suppressed without also silencing the root cause")
+		return
+	}
+
+	rootNo := result.RootCause.AlertNo
+	if rootNo == "" {
+		rootNo = result.RootCause.ID.String()
+	}
+	now := time.Now()
+	userID, _ := c.Get("user_id")
+	silence, err := h.silenceService.Create(c.Request.Context(), &services.CreateSilenceRequest{
+		Name:        fmt.Sprintf("Correlated with %s", rootNo),
+		Description: fmt.Sprintf("Suppresses alerts correlated with root cause %s (analyzed from alert %s)", rootNo, req.AlertID),
+		Matchers:    services.SilenceMatchers{Sets: matchers},
+		StartTime:   now,
+		EndTime:     now.Add(time.Duration(req.DurationMinutes) * time.Minute),
+	}, userID.(uuid.UUID))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	skippedIDs := make([]uuid.UUID, 0, len(skipped))
+	for _, a := range skipped {
+		skippedIDs = append(skippedIDs, a.ID)
+	}
+	response.Success(c, gin.H{
+		"silence":    silence,
+		"root_cause": result.RootCause,
+		"skipped":    skippedIDs,
+	})
+}
+
 func (h *CorrelationHandler) FindPatterns(c *gin.Context) {
 	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
 	minOccurrences, _ := strconv.Atoi(c.DefaultQuery("min_occurrences", "3"))
@@ -113,13 +185,16 @@ func (h *CorrelationHandler) PredictAlerts(c *gin.Context) {
 	}
 
 	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
-	timeWindow := time.Duration(hours) * time.Hour
+	if hours <= 0 {
+		hours = 24
+	}
+	horizon := time.Duration(hours) * time.Hour
 
-	predictions, err := h.service.PredictFutureAlerts(c.Request.Context(), ruleID, timeWindow)
+	prediction, err := h.service.PredictFutureAlerts(c.Request.Context(), ruleID, horizon)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{"data": predictions})
+	response.Success(c, gin.H{"data": prediction})
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvExport streams a CSV attachment. The response is started on the first row (or on Finish), so an
+// error before any row can still be answered with a JSON error.
+type csvExport struct {
+	c        *gin.Context
+	filename string
+	header   []string
+	w        *csv.Writer
+}
+
+func newCSVExport(c *gin.Context, filename string, header []string) *csvExport {
+	return &csvExport{c: c, filename: filename, header: header}
+}
+
+// Started reports whether the response has been started, after which errors can no longer change it.
+func (e *csvExport) Started() bool {
+	return e.w != nil
+}
+
+func (e *csvExport) start() error {
+	if e.w != nil {
+		return nil
+	}
+	e.c.Header("Content-Type", "text/csv; charset=utf-8")
+	e.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", e.filename))
+	e.c.Status(http.StatusOK)
+	// A UTF-8 byte order mark makes Excel decode the file as UTF-8 rather than the local code page.
+	if _, err := e.c.Writer.WriteString("\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+	e.w = csv.NewWriter(e.c.Writer)
+	return e.w.Write(e.header)
+}
+
+// Write writes one record; encoding/csv quotes fields containing commas, quotes or newlines (RFC 4180).
+func (e *csvExport) Write(record []string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.w.Write(record)
+}
+
+// Finish writes the header if no row was written and flushes the output.
+func (e *csvExport) Finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvTime formats t as RFC 3339, or "" when t is nil.
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -3,12 +3,14 @@ package handlers
 import (
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type DataSourceHandler struct {
@@ -27,6 +29,10 @@ func (h *DataSourceHandler) Create(c *gin.Context) {
 	}
 
 	ds, err := h.service.Create(c.Request.Context(), &req)
+	if errors.Is(err, services.ErrInvalidDataSourceConfig) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -67,6 +73,11 @@ func (h *DataSourceHandler) GetByID(c *gin.Context) {
 	response.Success(c, ds)
 }
 
+// Types returns the supported data source types with their config/auth fields and health-check path.
+func (h *DataSourceHandler) Types(c *gin.Context) {
+	response.Success(c, services.SupportedDataSourceTypes())
+}
+
 func (h *DataSourceHandler) HealthCheck(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -74,12 +85,34 @@ func (h *DataSourceHandler) HealthCheck(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.HealthCheck(c.Request.Context(), id); err != nil {
+	check, err := h.service.HealthCheck(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.Error(c, http.StatusNotFound, "data source not found")
+		return
+	}
+	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{"message": "health check completed"})
+	response.Success(c, check)
+}
+
+// HealthHistory returns the data source's recent health checks, newest first (limit defaults to 100).
+func (h *DataSourceHandler) HealthHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	list, err := h.service.HealthHistory(c.Request.Context(), id, limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"data": list})
 }
 
 func (h *DataSourceHandler) Update(c *gin.Context) {
@@ -96,6 +129,10 @@ func (h *DataSourceHandler) Update(c *gin.Context) {
 	}
 
 	ds, err := h.service.Update(c.Request.Context(), id, &req)
+	if errors.Is(err, services.ErrInvalidDataSourceConfig) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -129,11 +166,24 @@ func NewAlertStatisticsHandler(service *services.AlertStatisticsService) *AlertS
 
 func (h *AlertStatisticsHandler) Statistics(c *gin.Context) {
 	startTime, endTime := parseTimeRange(c)
-	var groupID *string
-	if g := c.Query("group_id"); g != "" {
-		groupID = &g
+	filter := services.StatisticsFilter{
+		StartTime:  startTime,
+		EndTime:    endTime,
+		GroupID:    c.Query("group_id"),
+		LabelKey:   c.Query("label_key"),
+		LabelValue: c.Query("label_value"),
+	}
+	if filter.GroupID != "" {
+		if _, err := uuid.Parse(filter.GroupID); err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid group_id")
+			return
+		}
+	}
+	if filter.LabelValue != "" && filter.LabelKey == "" {
+		response.Error(c, http.StatusBadRequest, "label_value requires label_key")
+		return
 	}
-	stats, err := h.service.GetStatistics(c.Request.Context(), startTime, endTime, groupID)
+	stats, err := h.service.GetStatistics(c.Request.Context(), filter)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -141,8 +191,11 @@ func (h *AlertStatisticsHandler) Statistics(c *gin.Context) {
 	response.Success(c, stats)
 }
 
+// Dashboard returns the dashboard summary. Admins can pass ?refresh=true to bypass the summary cache.
 func (h *AlertStatisticsHandler) Dashboard(c *gin.Context) {
-	summary, err := h.service.GetDashboardSummary(c.Request.Context())
+	role, _ := c.Get("role")
+	bypassCache := c.Query("refresh") == "true" && role == "admin"
+	summary, err := h.service.GetDashboardSummary(c.Request.Context(), bypassCache)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"alert-center/internal/services"
+	"alert-center/pkg/response"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FederationHandler receives alerts forwarded by other instances' federation channels.
+type FederationHandler struct {
+	service *services.FederationService
+}
+
+func NewFederationHandler(service *services.FederationService) *FederationHandler {
+	return &FederationHandler{service: service}
+}
+
+// Ingest records a forwarded alert. It authenticates with the X-API-Key header instead of a JWT.
+func (h *FederationHandler) Ingest(c *gin.Context) {
+	source, ok := h.service.Authenticate(c.GetHeader(services.FederationAPIKeyHeader))
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "invalid federation API key")
+		return
+	}
+
+	var req services.FederatedAlert
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Test {
+		response.Success(c, gin.H{"source": source.Name, "test": true})
+		return
+	}
+
+	alert, err := h.service.Ingest(c.Request.Context(), source, &req)
+	switch {
+	case errors.Is(err, services.ErrInvalidFederatedAlert):
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, services.ErrFederationConflict):
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"id": alert.ID, "alert_no": alert.AlertNo, "status": alert.Status, "source": source.Name})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"alert-center/pkg/response"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GroupMembershipHandler manages which business groups users belong to.
+type GroupMembershipHandler struct {
+	repo *repository.GroupMembershipRepository
+}
+
+func NewGroupMembershipHandler(repo *repository.GroupMembershipRepository) *GroupMembershipHandler {
+	return &GroupMembershipHandler{repo: repo}
+}
+
+// List returns memberships, optionally filtered by user_id and group_id.
+func (h *GroupMembershipHandler) List(c *gin.Context) {
+	var userID, groupID *uuid.UUID
+	for param, dst := range map[string]**uuid.UUID{"user_id": &userID, "group_id": &groupID} {
+		if v := c.Query(param); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				response.Error(c, http.StatusBadRequest, "invalid "+param)
+				return
+			}
+			*dst = &id
+		}
+	}
+
+	memberships, err := h.repo.List(c.Request.Context(), userID, groupID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"data": memberships, "total": len(memberships)})
+}
+
+// CreateGroupMembershipRequest adds a user to a business group; role defaults to member.
+type CreateGroupMembershipRequest struct {
+	UserID  uuid.UUID `json:"user_id" binding:"required"`
+	GroupID uuid.UUID `json:"group_id" binding:"required"`
+	Role    string    `json:"role"`
+}
+
+func (h *GroupMembershipHandler) Create(c *gin.Context) {
+	var req CreateGroupMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	m := &models.UserGroupMembership{UserID: req.UserID, GroupID: req.GroupID, Role: req.Role}
+	if err := h.repo.Create(c.Request.Context(), m); err != nil {
+		response.Error(c, groupMembershipErrorStatus(err), err.Error())
+		return
+	}
+	created, err := h.repo.GetByID(c.Request.Context(), m.ID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, created)
+}
+
+// UpdateGroupMembershipRequest changes a user's role in a group.
+type UpdateGroupMembershipRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (h *GroupMembershipHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var req UpdateGroupMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repo.UpdateRole(c.Request.Context(), id, req.Role); err != nil {
+		response.Error(c, groupMembershipErrorStatus(err), err.Error())
+		return
+	}
+	m, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, m)
+}
+
+func (h *GroupMembershipHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, groupMembershipErrorStatus(err), err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+func groupMembershipErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrInvalidGroupRole), errors.Is(err, repository.ErrMembershipTarget):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrMembershipExists):
+		return http.StatusConflict
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
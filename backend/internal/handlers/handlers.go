@@ -5,11 +5,15 @@ import (
 	"alert-center/internal/repository"
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserHandler struct {
@@ -52,33 +56,170 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 type AlertRuleHandler struct {
 	service        *services.AlertRuleService
 	bindingService *services.AlertChannelBindingService
+	evaluator      *services.AlertEvaluator
+	presets        *services.AlertRulePresetService
 }
 
 func NewAlertRuleHandler(service *services.AlertRuleService, bindingService *services.AlertChannelBindingService) *AlertRuleHandler {
 	return &AlertRuleHandler{service: service, bindingService: bindingService}
 }
 
+// WithAlertEvaluator sets the evaluator used for backtesting rules.
+func (h *AlertRuleHandler) WithAlertEvaluator(evaluator *services.AlertEvaluator) *AlertRuleHandler {
+	h.evaluator = evaluator
+	return h
+}
+
+// WithPresetService sets the service backing the rule preset library.
+func (h *AlertRuleHandler) WithPresetService(presets *services.AlertRulePresetService) *AlertRuleHandler {
+	h.presets = presets
+	return h
+}
+
+// createAlertRuleRequest is the rule create body; ChannelIDs, when present, become the rule's bindings.
+type createAlertRuleRequest struct {
+	services.CreateAlertRuleRequest
+	ChannelIDs *[]uuid.UUID `json:"channel_ids"`
+}
+
+// updateAlertRuleRequest is the rule update body; ChannelIDs, when present, replace the rule's bindings.
+type updateAlertRuleRequest struct {
+	services.UpdateAlertRuleRequest
+	ChannelIDs *[]uuid.UUID `json:"channel_ids"`
+}
+
+// savedAlertRule is the create/update response. NoChannels is set when the rule has no bound channels and
+// its business group has no default channel, i.e. the rule can fire but nobody would be notified.
+type savedAlertRule struct {
+	*models.AlertRule
+	NoChannels bool `json:"no_channels"`
+}
+
+// saveBindingsAndRespond applies channelIDs (if given) to the saved rule and responds with its NoChannels flag.
+func (h *AlertRuleHandler) saveBindingsAndRespond(c *gin.Context, rule *models.AlertRule, channelIDs *[]uuid.UUID) {
+	ctx := c.Request.Context()
+	if channelIDs != nil {
+		if err := h.bindingService.BindChannels(ctx, rule.ID, *channelIDs); err != nil {
+			response.Error(c, http.StatusInternalServerError, "rule saved but binding channels failed: "+err.Error())
+			return
+		}
+	}
+
+	resp := savedAlertRule{AlertRule: rule}
+	bound, err := h.bindingService.GetByRuleID(ctx, rule.ID)
+	if err == nil && len(bound) == 0 {
+		defaults, err := h.bindingService.GetGroupDefaultChannelsByRuleIDs(ctx, []uuid.UUID{rule.ID})
+		if err == nil {
+			_, covered := defaults[rule.ID]
+			resp.NoChannels = !covered
+		}
+	}
+	response.Success(c, resp)
+}
+
+// Severities returns the configured severity levels, most severe first, with their display colors.
+func (h *AlertRuleHandler) Severities(c *gin.Context) {
+	response.Success(c, services.SeverityLevels())
+}
+
+// Presets lists the built-in rule presets.
+func (h *AlertRuleHandler) Presets(c *gin.Context) {
+	presets, err := h.presets.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, presets)
+}
+
+// fromPresetRequest is the instantiate-preset body; ChannelIDs, when present, become the new rule's bindings.
+type fromPresetRequest struct {
+	services.InstantiatePresetRequest
+	ChannelIDs *[]uuid.UUID `json:"channel_ids"`
+}
+
+// CreateFromPreset creates a rule from the preset :id in the requested business group and data source.
+func (h *AlertRuleHandler) CreateFromPreset(c *gin.Context) {
+	var req fromPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.presets.Instantiate(c.Request.Context(), c.Param("id"), &req.InstantiatePresetRequest)
+	if err != nil {
+		status := ruleErrorStatus(err)
+		if errors.Is(err, services.ErrPresetNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	h.saveBindingsAndRespond(c, rule, req.ChannelIDs)
+}
+
+// ruleErrorStatus maps a rule create/update error to 400 for invalid input, 500 otherwise.
+func ruleErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidSeverity) || errors.Is(err, services.ErrInvalidPresetTarget) ||
+		errors.Is(err, services.ErrInvalidBulkMove) || errors.Is(err, services.ErrInvalidRangeCondition) ||
+		errors.Is(err, services.ErrInvalidComparison) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *AlertRuleHandler) Create(c *gin.Context) {
-	var req services.CreateAlertRuleRequest
+	var req createAlertRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	rule, err := h.service.Create(c.Request.Context(), &req)
+	rule, err := h.service.Create(c.Request.Context(), &req.CreateAlertRuleRequest)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		response.Error(c, ruleErrorStatus(err), err.Error())
 		return
 	}
 
-	response.Success(c, rule)
+	h.saveBindingsAndRespond(c, rule, req.ChannelIDs)
 }
 
-// TestExpressionRequest is the body for testing a PromQL expression against a data source.
+// BulkMove moves many rules to another business group in one transaction.
+func (h *AlertRuleHandler) BulkMove(c *gin.Context) {
+	var req services.BulkMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.BulkMove(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, ruleErrorStatus(err), err.Error())
+		return
+	}
+	response.Success(c, result)
+}
+
+// TestExpressionRequest is the body for testing a PromQL expression against a data source. In range
+// mode the expression runs as a range query and each series is reduced by range_condition, as the worker
+// would evaluate a range-mode rule.
 type TestExpressionRequest struct {
 	Expression      string `json:"expression" binding:"required"`
 	DataSourceType  string `json:"data_source_type"`
 	DataSourceURL   string `json:"data_source_url" binding:"required"`
+	EvaluationMode  string `json:"evaluation_mode" binding:"omitempty,oneof=instant range"`
+	RangeDuration   int    `json:"range_duration"`
+	RangeStep       int    `json:"range_step"`
+	RangeCondition  string `json:"range_condition"`
+}
+
+// rangeTestSeries is one series of a range-mode expression test, with its value reduced by the condition.
+type rangeTestSeries struct {
+	Metric  map[string]string `json:"metric"`
+	Samples int               `json:"samples"`
+	Value   float64           `json:"value"`
+	Firing  bool              `json:"firing"`
 }
 
 func (h *AlertRuleHandler) TestExpression(c *gin.Context) {
@@ -91,6 +232,10 @@ func (h *AlertRuleHandler) TestExpression(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "data_source_url is required")
 		return
 	}
+	if req.EvaluationMode == models.EvaluationModeRange {
+		h.testRangeExpression(c, &req)
+		return
+	}
 
 	ctx := c.Request.Context()
 	var results []models.QueryResult
@@ -115,6 +260,126 @@ func (h *AlertRuleHandler) TestExpression(c *gin.Context) {
 	})
 }
 
+// testRangeExpression runs a range-mode expression test over the last range_duration (default 600s) at
+// range_step (default 60s) and reports the reduced value of each series and whether it would fire.
+func (h *AlertRuleHandler) testRangeExpression(c *gin.Context, req *TestExpressionRequest) {
+	duration, step := req.RangeDuration, req.RangeStep
+	if duration <= 0 {
+		duration = 600
+	}
+	if step <= 0 {
+		step = 60
+	}
+	if err := services.ValidateRangeSettings(req.EvaluationMode, duration, step, req.RangeCondition); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	cond, _ := services.ParseRangeCondition(req.RangeCondition)
+
+	ctx := c.Request.Context()
+	end := time.Now()
+	start := end.Add(-time.Duration(duration) * time.Second)
+	stepStr := strconv.Itoa(step)
+	var results []models.QueryResult
+	var err error
+	switch req.DataSourceType {
+	case "victoria-metrics":
+		vm := services.NewVictoriaMetricsClient(req.DataSourceURL)
+		results, err = vm.QueryRange(ctx, req.Expression, start, end, stepStr)
+	default:
+		prom := services.NewPrometheusClient(req.DataSourceURL)
+		results, err = prom.QueryRange(ctx, req.Expression, start, end, stepStr)
+	}
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	series := make([]rangeTestSeries, 0, len(results))
+	firing := 0
+	for _, r := range results {
+		v, ok := cond.Evaluate(r.Values)
+		if ok {
+			firing++
+		}
+		series = append(series, rangeTestSeries{Metric: r.Metric, Samples: len(r.Values), Value: v, Firing: ok})
+	}
+	response.Success(c, gin.H{
+		"result_type": "matrix",
+		"count":       len(series),
+		"firing":      firing,
+		"data":        series,
+	})
+}
+
+// BacktestRequest is the body for backtesting a rule. Start defaults to 24h before end, end to now, step to 60s.
+type BacktestRequest struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	Step  string     `json:"step"`
+}
+
+// maxBacktestPoints bounds the samples per series, matching Prometheus' query_range limit.
+const maxBacktestPoints = 11000
+
+// Backtest replays a rule's expression over a past window and reports when it would have fired,
+// without creating alert history or sending notifications.
+func (h *AlertRuleHandler) Backtest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req BacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	end := time.Now()
+	if req.End != nil {
+		end = *req.End
+	}
+	start := end.Add(-24 * time.Hour)
+	if req.Start != nil {
+		start = *req.Start
+	}
+	step := time.Minute
+	if req.Step != "" {
+		if step, err = time.ParseDuration(req.Step); err != nil || step <= 0 {
+			response.Error(c, http.StatusBadRequest, "invalid step")
+			return
+		}
+	}
+	if !start.Before(end) {
+		response.Error(c, http.StatusBadRequest, "start must be before end")
+		return
+	}
+	if end.Sub(start)/step > maxBacktestPoints {
+		response.Error(c, http.StatusBadRequest, "window too large for step; increase step or shorten the window")
+		return
+	}
+
+	rule, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "rule not found")
+		return
+	}
+	if rule.DataSourceURL == "" {
+		response.Error(c, http.StatusBadRequest, "rule has no data source")
+		return
+	}
+
+	ds := models.DataSource{Type: rule.DataSourceType, Endpoint: rule.DataSourceURL}
+	result, err := h.evaluator.Backtest(c.Request.Context(), *rule, ds, start, end, step)
+	if err != nil {
+		response.Error(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
 func (h *AlertRuleHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -131,6 +396,27 @@ func (h *AlertRuleHandler) GetByID(c *gin.Context) {
 	response.Success(c, rule)
 }
 
+// Status returns the rule's last evaluation outcome and the number of its series currently firing.
+func (h *AlertRuleHandler) Status(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	status, err := h.service.Status(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(c, http.StatusNotFound, "rule not found")
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, status)
+}
+
 func (h *AlertRuleHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -182,19 +468,19 @@ func (h *AlertRuleHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var req services.UpdateAlertRuleRequest
+	var req updateAlertRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	rule, err := h.service.Update(c.Request.Context(), id, &req)
+	rule, err := h.service.Update(c.Request.Context(), id, &req.UpdateAlertRuleRequest)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		response.Error(c, ruleErrorStatus(err), err.Error())
 		return
 	}
 
-	response.Success(c, rule)
+	h.saveBindingsAndRespond(c, rule, req.ChannelIDs)
 }
 
 func (h *AlertRuleHandler) Delete(c *gin.Context) {
@@ -261,6 +547,10 @@ func (h *AlertChannelHandler) Create(c *gin.Context) {
 	}
 
 	channel, err := h.service.Create(c.Request.Context(), &req)
+	if errors.Is(err, services.ErrInvalidChannelFormat) || errors.Is(err, services.ErrInvalidFieldMapping) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -302,10 +592,14 @@ func (h *AlertChannelHandler) GetByID(c *gin.Context) {
 	}
 
 	channel, err := h.service.GetByID(c.Request.Context(), id)
-	if err != nil {
+	if errors.Is(err, services.ErrChannelNotFound) {
 		response.Error(c, http.StatusNotFound, "channel not found")
 		return
 	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	response.Success(c, channel)
 }
@@ -324,6 +618,10 @@ func (h *AlertChannelHandler) Update(c *gin.Context) {
 	}
 
 	channel, err := h.service.Update(c.Request.Context(), id, &req)
+	if errors.Is(err, services.ErrInvalidChannelFormat) || errors.Is(err, services.ErrInvalidFieldMapping) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -340,7 +638,11 @@ func (h *AlertChannelHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrChannelNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
 
@@ -354,10 +656,27 @@ func (h *AlertChannelHandler) Test(c *gin.Context) {
 		return
 	}
 	if err := h.service.SendTest(c.Request.Context(), id); err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrChannelNotFound) {
+			status = http.StatusNotFound
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
-	response.Success(c, gin.H{"message": "test sent"})
+	response.Success(c, gin.H{"message": testSentMessage()})
+}
+
+// Types returns the supported channel types and their config field schemas.
+func (h *AlertChannelHandler) Types(c *gin.Context) {
+	response.Success(c, services.SupportedChannelTypes())
+}
+
+// testSentMessage is the channel test response; in dry-run mode nothing is actually sent.
+func testSentMessage() string {
+	if services.NotificationsDryRun() {
+		return "dry run: test logged, not sent"
+	}
+	return "test sent"
 }
 
 // TestWithConfigRequest is the body for testing a channel with type and config (e.g. before save).
@@ -376,7 +695,22 @@ func (h *AlertChannelHandler) TestWithConfig(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	response.Success(c, gin.H{"message": "test sent"})
+	response.Success(c, gin.H{"message": testSentMessage()})
+}
+
+// ValidateConfig statically checks a channel config against the required keys and types of its channel
+// type and reports field-level errors, without sending a test message.
+func (h *AlertChannelHandler) ValidateConfig(c *gin.Context) {
+	var req TestWithConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	errs := services.ValidateChannelConfig(req.Type, req.Config)
+	if errs == nil {
+		errs = []services.ChannelConfigError{}
+	}
+	response.Success(c, gin.H{"valid": len(errs) == 0, "errors": errs})
 }
 
 type BusinessGroupHandler struct {
@@ -406,14 +740,397 @@ func (h *BusinessGroupHandler) List(c *gin.Context) {
 	})
 }
 
+// SetDefaultChannelRequest sets or clears (null) a business group's fallback channel.
+type SetDefaultChannelRequest struct {
+	ChannelID *uuid.UUID `json:"channel_id"`
+}
+
+// SetDefaultChannel sets the channel that receives alerts of the group's rules that have no bound channels.
+func (h *BusinessGroupHandler) SetDefaultChannel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var req SetDefaultChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	group, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "business group not found")
+		return
+	}
+
+	if err := h.repo.SetDefaultChannel(c.Request.Context(), id, req.ChannelID); err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	group.DefaultChannelID = req.ChannelID
+	response.Success(c, group)
+}
+
 type AlertHistoryHandler struct {
-	repo *repository.AlertHistoryRepository
+	repo         *repository.AlertHistoryRepository
+	logs         *repository.NotificationLogRepository
+	broadcaster  services.Broadcaster
+	stateWebhook *services.StateWebhook
+	schedules    *repository.OnCallScheduleRepository
+	assignments  *repository.OnCallAssignmentRepository
+	bulk         *services.AlertBulkActionService
 }
 
 func NewAlertHistoryHandler(repo *repository.AlertHistoryRepository) *AlertHistoryHandler {
 	return &AlertHistoryHandler{repo: repo}
 }
 
+// WithNotificationLog sets the repository backing the per-alert delivery log.
+func (h *AlertHistoryHandler) WithNotificationLog(logs *repository.NotificationLogRepository) *AlertHistoryHandler {
+	h.logs = logs
+	return h
+}
+
+// WithAckEvents broadcasts acknowledgements to dashboards and emits them to the state-change webhook.
+// Either may be nil.
+func (h *AlertHistoryHandler) WithAckEvents(broadcaster services.Broadcaster, stateWebhook *services.StateWebhook) *AlertHistoryHandler {
+	h.broadcaster = broadcaster
+	h.stateWebhook = stateWebhook
+	return h
+}
+
+// WithBulkActions enables acknowledging and resolving alerts by label selector.
+func (h *AlertHistoryHandler) WithBulkActions(bulk *services.AlertBulkActionService) *AlertHistoryHandler {
+	h.bulk = bulk
+	return h
+}
+
+// WithOnCall sets the repositories used to report who is on call alongside the active alerts.
+func (h *AlertHistoryHandler) WithOnCall(schedules *repository.OnCallScheduleRepository, assignments *repository.OnCallAssignmentRepository) *AlertHistoryHandler {
+	h.schedules = schedules
+	h.assignments = assignments
+	return h
+}
+
+// Active returns every firing alert for triage, unacknowledged and most severe first, with who is
+// currently on call for each enabled schedule. Alerts are not routed to a schedule, so the on-call list
+// is reported once rather than per alert. With group_by (rule, group, severity or label:<key>) the
+// alerts are nested per group in "groups" instead of listed in "data".
+func (h *AlertHistoryHandler) Active(c *gin.Context) {
+	ctx := c.Request.Context()
+	groupBy := c.Query("group_by")
+	if groupBy != "" {
+		if err := services.ValidateGroupBy(groupBy); err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	now := time.Now()
+	alerts, err := h.repo.ListActive(ctx, services.Severities(), now)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	oncall := []gin.H{}
+	if h.schedules != nil && h.assignments != nil {
+		schedules, err := h.schedules.ListAll(ctx)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, s := range schedules {
+			if !s.Enabled {
+				continue
+			}
+			a, err := h.assignments.GetAtByScheduleID(ctx, s.ID, now)
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				response.Error(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+			oncall = append(oncall, gin.H{"schedule_id": s.ID, "schedule_name": s.Name, "assignment": a})
+		}
+	}
+	result := gin.H{"total": len(alerts), "oncall": oncall, "generated_at": now}
+	if groupBy == "" {
+		result["data"] = alerts
+	} else {
+		groups, err := services.GroupActiveAlerts(alerts, groupBy)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		result["group_by"], result["groups"] = groupBy, groups
+	}
+	response.Success(c, result)
+}
+
+// Ack acknowledges a firing alert as the current user, stopping its response SLA clock and escalations.
+// It returns 409 when the alert is already acknowledged or resolved.
+func (h *AlertHistoryHandler) Ack(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	uid, _ := userID.(uuid.UUID)
+	name, _ := username.(string)
+
+	alert, groupID, err := h.repo.Acknowledge(c.Request.Context(), id, uid, name, time.Now())
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		response.Error(c, http.StatusNotFound, "alert not found")
+		return
+	case errors.Is(err, repository.ErrAlertAcknowledged), errors.Is(err, repository.ErrAlertResolved):
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if h.broadcaster != nil {
+		notification := &services.AlertAckNotification{
+			AlertID:     alert.ID.String(),
+			AlertNo:     alert.AlertNo,
+			RuleID:      alert.RuleID.String(),
+			Severity:    alert.Severity,
+			AckedBy:     uid.String(),
+			AckedByName: name,
+			Timestamp:   *alert.AckedAt,
+		}
+		if groupID != nil {
+			notification.GroupID = groupID.String()
+		}
+		h.broadcaster.SendAlertAckNotification(notification)
+	}
+	h.stateWebhook.Emit(services.AlertStateEvent{
+		Event:     services.AlertEventAcked,
+		AlertID:   alert.ID,
+		AlertNo:   alert.AlertNo,
+		RuleID:    alert.RuleID,
+		Severity:  alert.Severity,
+		OldState:  alert.Status,
+		NewState:  services.AlertEventAcked,
+		Timestamp: *alert.AckedAt,
+	})
+	response.Success(c, alert)
+}
+
+// BulkAck acknowledges every unacknowledged firing alert matching the request's label matchers (and
+// rule_id, if given) as the current user, and returns how many were acknowledged.
+func (h *AlertHistoryHandler) BulkAck(c *gin.Context) {
+	var req services.BulkAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	uid, _ := userID.(uuid.UUID)
+	name, _ := username.(string)
+
+	now := time.Now()
+	alerts, err := h.bulk.BulkAck(c.Request.Context(), &req, uid, name, now)
+	if err != nil {
+		response.Error(c, bulkErrorStatus(err), err.Error())
+		return
+	}
+
+	for _, a := range alerts {
+		if h.broadcaster != nil {
+			notification := &services.AlertAckNotification{
+				AlertID:     a.ID.String(),
+				AlertNo:     a.AlertNo,
+				RuleID:      a.RuleID.String(),
+				Severity:    a.Severity,
+				AckedBy:     uid.String(),
+				AckedByName: name,
+				Timestamp:   now,
+			}
+			if a.GroupID != nil {
+				notification.GroupID = a.GroupID.String()
+			}
+			h.broadcaster.SendAlertAckNotification(notification)
+		}
+		h.stateWebhook.Emit(services.AlertStateEvent{
+			Event:     services.AlertEventAcked,
+			AlertID:   a.ID,
+			AlertNo:   a.AlertNo,
+			RuleID:    a.RuleID,
+			RuleName:  a.RuleName,
+			Severity:  a.Severity,
+			OldState:  "firing",
+			NewState:  services.AlertEventAcked,
+			Timestamp: now,
+		})
+	}
+	response.Success(c, gin.H{"count": len(alerts), "alerts": alerts})
+}
+
+// BulkResolve resolves every firing alert matching the request's label matchers (and rule_id, if
+// given), and returns how many were resolved.
+func (h *AlertHistoryHandler) BulkResolve(c *gin.Context) {
+	var req services.BulkAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	alerts, err := h.bulk.BulkResolve(c.Request.Context(), &req, now)
+	if err != nil {
+		response.Error(c, bulkErrorStatus(err), err.Error())
+		return
+	}
+
+	for _, a := range alerts {
+		if h.broadcaster != nil {
+			notification := &services.AlertNotification{
+				AlertID:   a.ID.String(),
+				RuleID:    a.RuleID.String(),
+				RuleName:  a.RuleName,
+				Severity:  a.Severity,
+				Status:    "resolved",
+				Labels:    a.Labels,
+				Timestamp: now,
+			}
+			if a.GroupID != nil {
+				notification.GroupID = a.GroupID.String()
+			}
+			h.broadcaster.SendAlertNotification(notification)
+		}
+		h.stateWebhook.Emit(services.AlertStateEvent{
+			Event:     services.AlertEventResolved,
+			AlertID:   a.ID,
+			AlertNo:   a.AlertNo,
+			RuleID:    a.RuleID,
+			RuleName:  a.RuleName,
+			Severity:  a.Severity,
+			OldState:  "firing",
+			NewState:  "resolved",
+			Timestamp: now,
+		})
+	}
+	response.Success(c, gin.H{"count": len(alerts), "alerts": alerts})
+}
+
+func bulkErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidBulkSelector) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Notifications returns the per-channel delivery log of an alert, oldest first.
+func (h *AlertHistoryHandler) Notifications(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	logs, err := h.logs.ListByAlertID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, logs)
+}
+
+// Get returns one alert by ID with its full payload, rule name and SLA state.
+func (h *AlertHistoryHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	alert, err := h.repo.GetByID(c.Request.Context(), id)
+	respondAlertDetail(c, alert, err)
+}
+
+// GetByNo is Get by alert_no (e.g. AL20250205143022-a1b2c3), for links from tickets and notifications.
+func (h *AlertHistoryHandler) GetByNo(c *gin.Context) {
+	alert, err := h.repo.GetByNo(c.Request.Context(), c.Param("alert_no"))
+	respondAlertDetail(c, alert, err)
+}
+
+func respondAlertDetail(c *gin.Context, alert *models.AlertHistoryDetail, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		response.Error(c, http.StatusNotFound, "alert not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, alert)
+}
+
+// Export streams the alerts matching the List filters (rule_id, status, label_key/label_value) and an
+// optional start_time/end_time (YYYY-MM-DD, on started_at) as CSV, newest first, without paging.
+func (h *AlertHistoryHandler) Export(c *gin.Context) {
+	var ruleID *uuid.UUID
+	if ruleIDStr := c.Query("rule_id"); ruleIDStr != "" {
+		id, err := uuid.Parse(ruleIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid rule_id")
+			return
+		}
+		ruleID = &id
+	}
+	label := repository.LabelFilter{Key: c.Query("label_key"), Value: c.Query("label_value")}
+	if label.Value != "" && label.Key == "" {
+		response.Error(c, http.StatusBadRequest, "label_value requires label_key")
+		return
+	}
+	var startTime, endTime *time.Time
+	if st := c.Query("start_time"); st != "" {
+		t, err := time.Parse("2006-01-02", st)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid start_time, expected YYYY-MM-DD")
+			return
+		}
+		startTime = &t
+	}
+	if et := c.Query("end_time"); et != "" {
+		t, err := time.Parse("2006-01-02", et)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid end_time, expected YYYY-MM-DD")
+			return
+		}
+		// The end date is inclusive.
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		endTime = &t
+	}
+
+	export := newCSVExport(c, fmt.Sprintf("alert_history_%s.csv", time.Now().Format("20060102150405")),
+		[]string{"id", "alert_no", "rule_id", "fingerprint", "severity", "status", "started_at", "ended_at",
+			"acked_by_name", "acked_at", "labels", "annotations"})
+	err := h.repo.Export(c.Request.Context(), ruleID, c.Query("status"), startTime, endTime, label, func(a *models.AlertHistory) error {
+		return export.Write([]string{a.ID.String(), a.AlertNo, a.RuleID.String(), a.Fingerprint, a.Severity, a.Status,
+			csvTime(&a.StartedAt), csvTime(a.EndedAt), a.AckedByName, csvTime(a.AckedAt), a.Labels, a.Annotations})
+	})
+	if err != nil && !export.Started() {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err == nil {
+		err = export.Finish()
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
 func (h *AlertHistoryHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -437,7 +1154,13 @@ func (h *AlertHistoryHandler) List(c *gin.Context) {
 		ruleID = &id
 	}
 
-	histories, total, err := h.repo.List(c.Request.Context(), page, pageSize, ruleID, c.Query("status"), nil, nil)
+	label := repository.LabelFilter{Key: c.Query("label_key"), Value: c.Query("label_value")}
+	if label.Value != "" && label.Key == "" {
+		response.Error(c, http.StatusBadRequest, "label_value requires label_key")
+		return
+	}
+
+	histories, total, err := h.repo.List(c.Request.Context(), page, pageSize, ruleID, c.Query("status"), nil, nil, label)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
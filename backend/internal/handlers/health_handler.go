@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"alert-center/internal/services"
+	"alert-center/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	workerStatus *services.WorkerStatus
+}
+
+func NewHealthHandler(workerStatus *services.WorkerStatus) *HealthHandler {
+	return &HealthHandler{workerStatus: workerStatus}
+}
+
+// Worker reports the alert worker's last evaluation cycle. It responds 503 when the worker is stale
+// (no completed cycle within three check intervals), so external monitors can alert on a dead worker.
+func (h *HealthHandler) Worker(c *gin.Context) {
+	snap := h.workerStatus.Snapshot()
+	if snap.Status == "stale" {
+		c.JSON(http.StatusServiceUnavailable, response.Response{Code: http.StatusServiceUnavailable, Message: "worker stale", Data: snap})
+		return
+	}
+	response.Success(c, snap)
+}
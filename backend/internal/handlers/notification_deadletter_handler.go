@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"alert-center/internal/services"
+	"alert-center/pkg/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationDeadLetterHandler struct {
+	deadLetter *services.NotificationDeadLetter
+}
+
+func NewNotificationDeadLetterHandler(deadLetter *services.NotificationDeadLetter) *NotificationDeadLetterHandler {
+	return &NotificationDeadLetterHandler{deadLetter: deadLetter}
+}
+
+// List returns dead-lettered notifications, optionally filtered by status (pending, delivered, expired).
+func (h *NotificationDeadLetterHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	list, total, err := h.deadLetter.List(c.Request.Context(), c.Query("status"), page, pageSize)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  list,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// Retry sends a dead-lettered notification now. The returned entry is "delivered" on success; otherwise
+// it stays pending with the failure in last_error.
+func (h *NotificationDeadLetterHandler) Retry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	dl, err := h.deadLetter.Retry(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, services.ErrDeadLetterNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, services.ErrDeadLetterDelivered):
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, dl)
+}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"alert-center/internal/repository"
 	"alert-center/pkg/response"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // OnCallHandler handles on-call schedule and assignment APIs.
@@ -15,6 +18,7 @@ type OnCallHandler struct {
 	scheduleRepo   *repository.OnCallScheduleRepository
 	memberRepo     *repository.OnCallMemberRepository
 	assignmentRepo *repository.OnCallAssignmentRepository
+	overrideRepo   *repository.OnCallOverrideRepository
 }
 
 // NewOnCallHandler returns a new OnCallHandler.
@@ -29,13 +33,39 @@ func (h *OnCallHandler) WithRepositories(memberRepo *repository.OnCallMemberRepo
 	return h
 }
 
+// WithOverrides sets the override repository.
+func (h *OnCallHandler) WithOverrides(overrideRepo *repository.OnCallOverrideRepository) *OnCallHandler {
+	h.overrideRepo = overrideRepo
+	return h
+}
+
+// GetSchedules lists schedules, newest first, paged by page and page_size (default 20, at most 100) and
+// optionally filtered by enabled=true|false.
 func (h *OnCallHandler) GetSchedules(c *gin.Context) {
-	list, err := h.scheduleRepo.List(c.Request.Context())
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	var enabled *bool
+	if v := c.Query("enabled"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "enabled must be true or false")
+			return
+		}
+		enabled = &b
+	}
+
+	list, total, err := h.scheduleRepo.List(c.Request.Context(), page, pageSize, enabled)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	response.Success(c, gin.H{"data": list})
+	response.Success(c, gin.H{"data": list, "total": total, "page": page, "page_size": pageSize})
 }
 
 func (h *OnCallHandler) CreateSchedule(c *gin.Context) {
@@ -252,12 +282,198 @@ func (h *OnCallHandler) GenerateRotations(c *gin.Context) {
 		return
 	}
 	var req struct {
-		EndTime time.Time `json:"end_time"`
+		EndTime time.Time `json:"end_time" binding:"required"`
 	}
-	c.ShouldBindJSON(&req)
-	_ = scheduleID
-	_ = req
-	response.Success(c, gin.H{"message": "rotations generated"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	if !req.EndTime.After(now) {
+		response.Error(c, http.StatusBadRequest, "end_time must be in the future")
+		return
+	}
+	if req.EndTime.After(now.AddDate(1, 0, 0)) {
+		response.Error(c, http.StatusBadRequest, "end_time must be within one year")
+		return
+	}
+
+	ctx := c.Request.Context()
+	schedule, err := h.scheduleRepo.GetByID(ctx, scheduleID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "schedule not found")
+		return
+	}
+	days, ok := rotationDays[schedule.RotationType]
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "unsupported rotation_type: "+schedule.RotationType)
+		return
+	}
+	if schedule.RotationStart.IsZero() {
+		response.Error(c, http.StatusBadRequest, "schedule has no rotation_start")
+		return
+	}
+	tz := schedule.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid schedule timezone: "+tz)
+		return
+	}
+	members, err := h.memberRepo.GetByScheduleID(ctx, scheduleID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(members) == 0 {
+		response.Error(c, http.StatusBadRequest, "schedule has no active members")
+		return
+	}
+
+	assignments := generateRotations(schedule.RotationStart.In(loc), days, members, now, req.EndTime)
+	if err := h.assignmentRepo.ReplaceFrom(ctx, scheduleID, now, assignments); err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"data": assignments, "total": len(assignments)})
+}
+
+// rotationDays is the length in days of one rotation of each rotation_type.
+var rotationDays = map[string]int{"daily": 1, "weekly": 7, "biweekly": 14}
+
+// generateRotations hands rotations of the given length to members round-robin, starting with the
+// first member at start, and returns those overlapping [from, until). Boundaries are stepped by
+// calendar days in start's location, so they stay at the same local time of day across DST changes.
+func generateRotations(start time.Time, days int, members []repository.OnCallMember, from, until time.Time) []repository.OnCallAssignment {
+	assignments := []repository.OnCallAssignment{}
+	for i := 0; ; i++ {
+		begin := start.AddDate(0, 0, i*days)
+		if !begin.Before(until) {
+			break
+		}
+		end := start.AddDate(0, 0, (i+1)*days)
+		if !end.After(from) {
+			continue
+		}
+		member := members[i%len(members)]
+		assignments = append(assignments, repository.OnCallAssignment{
+			UserID:    member.UserID,
+			Username:  member.Username,
+			Email:     member.Email,
+			Phone:     member.Phone,
+			StartTime: begin,
+			EndTime:   end,
+		})
+	}
+	return assignments
+}
+
+// GetOverrides lists the schedule's overrides that have not ended.
+func (h *OnCallHandler) GetOverrides(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid schedule_id")
+		return
+	}
+	list, err := h.overrideRepo.ListByScheduleID(c.Request.Context(), scheduleID, time.Now())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"data": list})
+}
+
+// CreateOverride hands [start_time, end_time) of an assignment to override_user_id. The window must lie
+// within one assignment; original_user_id defaults to that assignment's user and must match it when set.
+func (h *OnCallHandler) CreateOverride(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid schedule_id")
+		return
+	}
+	var req struct {
+		OriginalUserID *uuid.UUID `json:"original_user_id"`
+		OverrideUserID uuid.UUID  `json:"override_user_id" binding:"required"`
+		StartTime      time.Time  `json:"start_time" binding:"required"`
+		EndTime        time.Time  `json:"end_time" binding:"required"`
+		Reason         string     `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.EndTime.After(req.StartTime) {
+		response.Error(c, http.StatusBadRequest, "end_time must be after start_time")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.scheduleRepo.GetByID(ctx, scheduleID); err != nil {
+		response.Error(c, http.StatusNotFound, "schedule not found")
+		return
+	}
+	assignment, err := h.assignmentRepo.GetCoveringByScheduleID(ctx, scheduleID, req.StartTime, req.EndTime)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.Error(c, http.StatusBadRequest, "override window must lie within an existing assignment")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if req.OriginalUserID != nil && *req.OriginalUserID != assignment.UserID {
+		response.Error(c, http.StatusBadRequest, "original_user_id is not on call for the override window")
+		return
+	}
+	if req.OverrideUserID == assignment.UserID {
+		response.Error(c, http.StatusBadRequest, "override_user_id is already on call for the override window")
+		return
+	}
+
+	override := &repository.OnCallOverride{
+		ScheduleID:       scheduleID,
+		OriginalUserID:   assignment.UserID,
+		OriginalUsername: assignment.Username,
+		OverrideUserID:   req.OverrideUserID,
+		StartTime:        req.StartTime,
+		EndTime:          req.EndTime,
+		Reason:           req.Reason,
+	}
+	if err := h.overrideRepo.Create(ctx, override); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(c, http.StatusBadRequest, "override user not found")
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, override)
+}
+
+// DeleteOverride removes an override, handing its window back to the assignment's user.
+func (h *OnCallHandler) DeleteOverride(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid schedule_id")
+		return
+	}
+	overrideID, err := uuid.Parse(c.Param("override_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid override_id")
+		return
+	}
+	deleted, err := h.overrideRepo.Delete(c.Request.Context(), scheduleID, overrideID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !deleted {
+		response.Error(c, http.StatusNotFound, "override not found")
+		return
+	}
+	response.Success(c, nil)
 }
 
 func (h *OnCallHandler) Escalate(c *gin.Context) {
@@ -277,7 +493,7 @@ func (h *OnCallHandler) Escalate(c *gin.Context) {
 }
 
 func (h *OnCallHandler) GetCurrentOnCall(c *gin.Context) {
-	schedules, _ := h.scheduleRepo.List(c.Request.Context())
+	schedules, _ := h.scheduleRepo.ListAll(c.Request.Context())
 	var result []repository.OnCallAssignment
 	for _, s := range schedules {
 		a, err := h.assignmentRepo.GetCurrentByScheduleID(c.Request.Context(), s.ID)
@@ -289,23 +505,27 @@ func (h *OnCallHandler) GetCurrentOnCall(c *gin.Context) {
 }
 
 func (h *OnCallHandler) WhoIsOnCall(c *gin.Context) {
-	atTime := c.Query("at_time")
-	var t time.Time
-	if atTime != "" {
-		t, _ = time.Parse(time.RFC3339, atTime)
-	} else {
-		t = time.Now()
+	t := time.Now()
+	if atTime := c.Query("at_time"); atTime != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339, atTime); err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid at_time, expected RFC3339")
+			return
+		}
 	}
-	_ = t
-	schedules, _ := h.scheduleRepo.List(c.Request.Context())
-	var result []repository.OnCallAssignment
+	schedules, err := h.scheduleRepo.ListAll(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := []repository.OnCallAssignment{}
 	for _, s := range schedules {
-		a, err := h.assignmentRepo.GetCurrentByScheduleID(c.Request.Context(), s.ID)
+		a, err := h.assignmentRepo.GetAtByScheduleID(c.Request.Context(), s.ID, t)
 		if err == nil {
 			result = append(result, *a)
 		}
 	}
-	response.Success(c, gin.H{"data": result})
+	response.Success(c, gin.H{"data": result, "at_time": t})
 }
 
 func (h *OnCallHandler) GetOnCallReport(c *gin.Context) {
@@ -313,7 +533,7 @@ func (h *OnCallHandler) GetOnCallReport(c *gin.Context) {
 }
 
 func (h *OnCallHandler) SeedDefaultSchedules(c *gin.Context) {
-	list, _ := h.scheduleRepo.List(c.Request.Context())
+	list, _ := h.scheduleRepo.ListAll(c.Request.Context())
 	if len(list) > 0 {
 		response.Success(c, gin.H{"message": "schedules already exist"})
 		return
@@ -20,16 +20,47 @@ func NewSLABreachHandler(service *services.SLABreachService) *SLABreachHandler {
 	return &SLABreachHandler{service: service}
 }
 
+// GetBreaches lists breaches, newest first, filtered by breach_type, severity and a start_time/end_time
+// date range (YYYY-MM-DD, both inclusive). total counts all matching breaches.
 func (h *SLABreachHandler) GetBreaches(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	status := c.Query("status")
-	list, total, err := h.service.GetBreaches(c.Request.Context(), page, pageSize, status)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	filter := services.SLABreachFilter{BreachType: c.Query("breach_type"), Severity: c.Query("severity")}
+	if filter.BreachType != "" && filter.BreachType != "response" && filter.BreachType != "resolution" {
+		response.Error(c, http.StatusBadRequest, "breach_type must be response or resolution")
+		return
+	}
+	const layout = "2006-01-02"
+	if st := c.Query("start_time"); st != "" {
+		t, err := time.Parse(layout, st)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid start_time, expected YYYY-MM-DD")
+			return
+		}
+		filter.Start = &t
+	}
+	if et := c.Query("end_time"); et != "" {
+		t, err := time.Parse(layout, et)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid end_time, expected YYYY-MM-DD")
+			return
+		}
+		end := t.AddDate(0, 0, 1)
+		filter.End = &end
+	}
+
+	list, total, err := h.service.GetBreaches(c.Request.Context(), page, pageSize, filter)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	response.Success(c, gin.H{"data": list, "total": total})
+	response.Success(c, gin.H{"data": list, "total": total, "page": page, "page_size": pageSize})
 }
 
 func (h *SLABreachHandler) GetBreachStats(c *gin.Context) {
@@ -64,10 +95,10 @@ func (h *SLABreachHandler) TriggerCheck(c *gin.Context) {
 }
 
 func (h *SLABreachHandler) TriggerNotifications(c *gin.Context) {
-	count, err := h.service.TriggerNotifications(c.Request.Context())
+	count, ticketIDs, err := h.service.TriggerNotifications(c.Request.Context())
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	response.Success(c, gin.H{"notifications": count})
+	response.Success(c, gin.H{"notifications": count, "ticket_ids": ticketIDs})
 }
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"alert-center/internal/repository"
+	"alert-center/internal/services"
 	"alert-center/pkg/response"
 	"net/http"
 	"time"
@@ -48,9 +49,14 @@ func (h *SLAHandler) CreateSLAConfig(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	severity, err := services.NormalizeSeverity(req.Severity)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	config := &repository.SLAConfig{
 		Name:               req.Name,
-		Severity:           req.Severity,
+		Severity:           severity,
 		ResponseTimeMins:   req.ResponseTimeMins,
 		ResolutionTimeMins: req.ResolutionTimeMins,
 		Priority:           req.Priority,
@@ -102,7 +108,12 @@ func (h *SLAHandler) UpdateSLAConfig(c *gin.Context) {
 		config.Name = *req.Name
 	}
 	if req.Severity != nil {
-		config.Severity = *req.Severity
+		severity, err := services.NormalizeSeverity(*req.Severity)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		config.Severity = severity
 	}
 	if req.ResponseTimeMins != nil {
 		config.ResponseTimeMins = *req.ResponseTimeMins
@@ -143,11 +154,7 @@ func (h *SLAHandler) SeedDefaultSLAConfigs(c *gin.Context) {
 		response.Success(c, gin.H{"message": "configs already exist"})
 		return
 	}
-	defaults := []repository.SLAConfig{
-		{Name: "Critical SLA", Severity: "critical", ResponseTimeMins: 15, ResolutionTimeMins: 60, Priority: 100},
-		{Name: "Warning SLA", Severity: "warning", ResponseTimeMins: 30, ResolutionTimeMins: 120, Priority: 50},
-		{Name: "Info SLA", Severity: "info", ResponseTimeMins: 60, ResolutionTimeMins: 240, Priority: 10},
-	}
+	defaults := services.DefaultSLAConfigs()
 	for i := range defaults {
 		if err := h.slaConfigRepo.Create(c.Request.Context(), &defaults[i]); err != nil {
 			response.Error(c, http.StatusInternalServerError, err.Error())
@@ -175,28 +182,35 @@ func (h *SLAHandler) GetAlertSLA(c *gin.Context) {
 	response.Success(c, sla)
 }
 
+// GetSLAReport returns the SLA compliance of alerts started between start_time and end_time (dates,
+// end inclusive; default the last 30 days), overall, per severity and per SLA config.
 func (h *SLAHandler) GetSLAReport(c *gin.Context) {
-	var startTime, endTime *time.Time
+	today := time.Now().Truncate(24 * time.Hour)
+	start, end := today.AddDate(0, 0, -29), today
 	if st := c.Query("start_time"); st != "" {
 		t, err := time.Parse("2006-01-02", st)
-		if err == nil {
-			startTime = &t
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid start_time, expected YYYY-MM-DD")
+			return
 		}
+		start = t
 	}
 	if et := c.Query("end_time"); et != "" {
 		t, err := time.Parse("2006-01-02", et)
-		if err == nil {
-			endTime = &t
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "invalid end_time, expected YYYY-MM-DD")
+			return
 		}
+		end = t
+	}
+	if end.Before(start) {
+		response.Error(c, http.StatusBadRequest, "end_time must not be before start_time")
+		return
+	}
+	report, err := h.slaRepo.Report(c.Request.Context(), start, end.AddDate(0, 0, 1), time.Now(), services.Severities(), nil)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
 	}
-	_ = startTime
-	_ = endTime
-	response.Success(c, gin.H{
-		"period_start":  nil,
-		"period_end":    nil,
-		"total_alerts":  0,
-		"met_count":     0,
-		"breached_count": 0,
-		"compliance_rate": 0,
-	})
+	response.Success(c, report)
 }
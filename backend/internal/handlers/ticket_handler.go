@@ -4,18 +4,25 @@ import (
 	"alert-center/internal/repository"
 	"alert-center/internal/services"
 	"alert-center/pkg/response"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// errTicketAlertNotFound is returned when a ticket is created for an alert that does not exist.
+var errTicketAlertNotFound = errors.New("alert not found")
+
 // TicketHandler handles ticket APIs.
 type TicketHandler struct {
-	db          *repository.Database
-	broadcaster services.Broadcaster
+	db           *repository.Database
+	broadcaster  services.Broadcaster
+	auditService *services.AuditLogService
 }
 
 // NewTicketHandler returns a new TicketHandler.
@@ -23,6 +30,22 @@ func NewTicketHandler(db *repository.Database, broadcaster services.Broadcaster)
 	return &TicketHandler{db: db, broadcaster: broadcaster}
 }
 
+// WithAuditLogService makes Create record an audit entry in the same transaction as the ticket.
+func (h *TicketHandler) WithAuditLogService(auditService *services.AuditLogService) *TicketHandler {
+	h.auditService = auditService
+	return h
+}
+
+// ticketGroupID returns the business group of the ticket's rule, which scopes its WebSocket
+// notifications, or "" when the ticket has no rule or does not exist.
+func (h *TicketHandler) ticketGroupID(ctx context.Context, id uuid.UUID) string {
+	var groupID string
+	h.db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(r.group_id::text, '') FROM tickets t LEFT JOIN alert_rules r ON r.id = t.rule_id WHERE t.id = $1
+	`, id).Scan(&groupID)
+	return groupID
+}
+
 func (h *TicketHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -104,10 +127,40 @@ func (h *TicketHandler) Create(c *gin.Context) {
 		r, _ := uuid.Parse(*req.RuleID)
 		ruleID = &r
 	}
-	_, err := h.db.Pool.Exec(c.Request.Context(), `
-		INSERT INTO tickets (id, title, description, alert_id, rule_id, priority, status, assignee_name, creator_id, creator_name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, 'open', $7, $8, $9, $10, $10)
-	`, id, req.Title, req.Description, alertID, ruleID, req.Priority, req.AssigneeName, userID.(uuid.UUID), username.(string), now)
+	// Look up the alert, insert the ticket and audit it atomically; broadcast only after commit.
+	err := repository.WithTx(c.Request.Context(), h.db.Pool, func(ctx context.Context) error {
+		db := repository.Conn(ctx, h.db.Pool)
+		if alertID != nil {
+			var alertRuleID uuid.UUID
+			if err := db.QueryRow(ctx, `SELECT rule_id FROM alert_history WHERE id = $1`, *alertID).Scan(&alertRuleID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errTicketAlertNotFound
+				}
+				return err
+			}
+			if ruleID == nil {
+				ruleID = &alertRuleID
+			}
+		}
+		if _, err := db.Exec(ctx, `
+			INSERT INTO tickets (id, title, description, alert_id, rule_id, priority, status, assignee_name, creator_id, creator_name, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'open', $7, $8, $9, $10, $10)
+		`, id, req.Title, req.Description, alertID, ruleID, req.Priority, req.AssigneeName, userID.(uuid.UUID), username.(string), now); err != nil {
+			return err
+		}
+		if h.auditService == nil {
+			return nil
+		}
+		return h.auditService.CreateWithDetail(ctx, userID.(uuid.UUID), "create", "ticket", id.String(), map[string]interface{}{
+			"title":    req.Title,
+			"alert_id": alertID,
+			"rule_id":  ruleID,
+		})
+	})
+	if errors.Is(err, errTicketAlertNotFound) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -119,6 +172,7 @@ func (h *TicketHandler) Create(c *gin.Context) {
 			Title:     req.Title,
 			Status:    "open",
 			Action:    "created",
+			GroupID:   h.ticketGroupID(c.Request.Context(), id),
 			Timestamp: now,
 		})
 	}
@@ -183,6 +237,7 @@ func (h *TicketHandler) Update(c *gin.Context) {
 			Title:     "",
 			Status:    "updated",
 			Action:    "updated",
+			GroupID:   h.ticketGroupID(c.Request.Context(), id),
 			Timestamp: time.Now(),
 		})
 	}
@@ -207,6 +262,7 @@ func (h *TicketHandler) Resolve(c *gin.Context) {
 			Title:     "",
 			Status:    "resolved",
 			Action:    "resolved",
+			GroupID:   h.ticketGroupID(c.Request.Context(), id),
 			Timestamp: now,
 		})
 	}
@@ -231,6 +287,7 @@ func (h *TicketHandler) Close(c *gin.Context) {
 			Title:     "",
 			Status:    "closed",
 			Action:    "closed",
+			GroupID:   h.ticketGroupID(c.Request.Context(), id),
 			Timestamp: now,
 		})
 	}
@@ -242,6 +299,7 @@ func (h *TicketHandler) Delete(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "invalid id")
 		return
 	}
+	groupID := h.ticketGroupID(c.Request.Context(), id)
 	_, err = h.db.Pool.Exec(c.Request.Context(), `DELETE FROM tickets WHERE id = $1`, id)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
@@ -254,6 +312,7 @@ func (h *TicketHandler) Delete(c *gin.Context) {
 			Title:     "",
 			Status:    "deleted",
 			Action:    "deleted",
+			GroupID:   groupID,
 			Timestamp: time.Now(),
 		})
 	}
@@ -180,6 +180,7 @@ func (h *AuditLogHandler) List(c *gin.Context) {
 	})
 }
 
+// Export downloads the audit logs matching the List filters as JSON, or as CSV with format=csv.
 func (h *AuditLogHandler) Export(c *gin.Context) {
 	var startTime, endTime *time.Time
 	if st := c.Query("start_time"); st != "" {
@@ -211,6 +212,22 @@ func (h *AuditLogHandler) Export(c *gin.Context) {
 		return
 	}
 
+	if c.Query("format") == "csv" {
+		export := newCSVExport(c, "audit_logs.csv",
+			[]string{"id", "user_id", "action", "resource", "resource_id", "detail", "ip", "created_at"})
+		for _, l := range logs {
+			if err := export.Write([]string{l.ID.String(), l.UserID.String(), l.Action, l.Resource, l.ResourceID,
+				l.Detail, l.IP, csvTime(&l.CreatedAt)}); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+		if err := export.Finish(); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
 	c.Header("Content-Type", "application/json")
 	c.Header("Content-Disposition", "attachment; filename=audit_logs.json")
 	c.JSON(http.StatusOK, logs)
@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"alert-center/internal/middleware"
 	"alert-center/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -22,112 +23,294 @@ var upgrader = websocket.Upgrader{
 }
 
 type WebSocketHandler struct {
-	clients map[string]*Client
-	mu      sync.RWMutex
-	broadcast chan []byte
+	clients    map[string]*Client // by connection ID; a user may have several connections
+	mu         sync.RWMutex
+	broadcast  chan outboundMessage
+	jwtSecret  string
+	groupScope middleware.GroupScopeResolver
 }
 
 type Client struct {
+	id     string
 	conn   *websocket.Conn
 	send   chan []byte
 	userID string
+	filter SubscriptionFilter // guarded by WebSocketHandler.mu
+	// allowedGroups are the business groups a non-admin user may see alerts of, resolved at connect
+	// time; nil for admins, who see every group.
+	allowedGroups map[string]bool
 }
 
+// wsTokenProtocol is the Sec-WebSocket-Protocol a browser offers before the JWT, since it cannot set
+// an Authorization header on the upgrade: new WebSocket(url, ["bearer", token]).
+const wsTokenProtocol = "bearer"
+
 type WebSocketMessage struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
 }
 
+// SubscriptionFilter selects the alert notifications a client receives. Empty fields match
+// everything, and notifications that do not carry a field (e.g. no group) are not filtered on it.
+type SubscriptionFilter struct {
+	Severity string `json:"severity,omitempty"`
+	GroupID  string `json:"group_id,omitempty"`
+	RuleID   string `json:"rule_id,omitempty"`
+}
+
+// matches reports whether the client should receive alert; messages other than alert notifications
+// (alert is nil) always match.
+func (f SubscriptionFilter) matches(alert *services.AlertNotification) bool {
+	if alert == nil {
+		return true
+	}
+	for _, c := range []struct{ want, got string }{
+		{f.Severity, alert.Severity},
+		{f.GroupID, alert.GroupID},
+		{f.RuleID, alert.RuleID},
+	} {
+		if c.want != "" && c.got != "" && c.want != c.got {
+			return false
+		}
+	}
+	return true
+}
+
+// outboundMessage is an encoded broadcast, with the alert notification it carries for filtering.
+// Messages about an alert (alerts, acks, SLA breaches and tickets) are scoped to the business group of
+// its rule, groupID ("" when unknown).
+type outboundMessage struct {
+	data    []byte
+	alert   *services.AlertNotification
+	scoped  bool
+	groupID string
+}
+
+// visibleTo reports whether c may receive m: admins receive everything, other users only the alert
+// messages of their business groups and messages not about an alert.
+func (m outboundMessage) visibleTo(c *Client) bool {
+	return !m.scoped || c.allowedGroups == nil || c.allowedGroups[m.groupID]
+}
+
+// clientMessage is a message sent by a client. The only type is "subscribe", which replaces the
+// client's filter; an empty filter subscribes to everything again.
+type clientMessage struct {
+	Type   string             `json:"type"`
+	Filter SubscriptionFilter `json:"filter"`
+}
+
 func NewWebSocketHandler() *WebSocketHandler {
 	return &WebSocketHandler{
 		clients:   make(map[string]*Client),
-		broadcast: make(chan []byte, 256),
+		broadcast: make(chan outboundMessage, 256),
 	}
 }
 
+// WithJWTSecret sets the secret connection tokens are verified with; it must be the one
+// AuthMiddleware uses. Without it every connection is rejected.
+func (h *WebSocketHandler) WithJWTSecret(secret string) *WebSocketHandler {
+	h.jwtSecret = secret
+	return h
+}
+
+// WithGroupScope sets how the business groups of non-admin users are resolved, as for
+// GroupScopeMiddleware. Without it non-admin users receive no alert messages.
+func (h *WebSocketHandler) WithGroupScope(resolver middleware.GroupScopeResolver) *WebSocketHandler {
+	h.groupScope = resolver
+	return h
+}
+
+// wsToken returns the JWT of an upgrade request, offered either as the Sec-WebSocket-Protocol
+// pair "bearer, <token>" or as the token query parameter, and whether it came as a subprotocol.
+func wsToken(r *http.Request) (string, bool) {
+	protocols := websocket.Subprotocols(r)
+	for i := 0; i+1 < len(protocols); i++ {
+		if strings.EqualFold(protocols[i], wsTokenProtocol) {
+			return protocols[i+1], true
+		}
+	}
+	return r.URL.Query().Get("token"), false
+}
+
+// HandleConnection upgrades an authenticated request to a WebSocket connection. The route is outside
+// the AuthMiddleware group, so the JWT is verified here and the upgrade refused with 401 when it is
+// missing or invalid.
 func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	token, viaProtocol := wsToken(c.Request)
+	if token == "" || h.jwtSecret == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token required"})
+		return
+	}
+	claims, userID, err := middleware.ParseToken(h.jwtSecret, token)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	var allowedGroups map[string]bool
+	if claims.Role != middleware.RoleAdmin {
+		allowedGroups = make(map[string]bool)
+		if h.groupScope != nil {
+			groupIDs, err := h.groupScope.AllowedGroupIDs(c.Request.Context(), userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, id := range groupIDs {
+				allowedGroups[id.String()] = true
+			}
+		}
+	}
 
-	userIDVal, _ := c.Get("user_id")
-	usernameVal, _ := c.Get("username")
-	userID := fmt.Sprintf("anon-%s", uuid.New().String()[:8])
-	if id, ok := userIDVal.(string); ok && id != "" {
-		userID = id
+	var responseHeader http.Header
+	if viaProtocol {
+		// The browser fails the handshake unless one offered subprotocol is echoed back.
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": {wsTokenProtocol}}
 	}
-	username := ""
-	if name, ok := usernameVal.(string); ok {
-		username = name
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, responseHeader)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
 	}
 
 	client := &Client{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		userID: userID,
+		id:            uuid.New().String(),
+		conn:          conn,
+		send:          make(chan []byte, 256),
+		userID:        userID.String(),
+		allowedGroups: allowedGroups,
 	}
 
 	h.mu.Lock()
-	h.clients[userID] = client
+	h.clients[client.id] = client
 	h.mu.Unlock()
 
-	log.Printf("WebSocket client connected: %s (%s)", username, userID)
+	log.Printf("WebSocket client connected: %s (%s)", claims.Username, client.userID)
 
 	go client.writePump()
 	go client.readPump(h)
 }
 
-func (h *WebSocketHandler) RemoveClient(userID string) {
+// RemoveClient closes and forgets the connection with the given connection ID.
+func (h *WebSocketHandler) RemoveClient(id string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if client, ok := h.clients[userID]; ok {
+	if client, ok := h.clients[id]; ok {
 		close(client.send)
-		delete(h.clients, userID)
-		log.Printf("WebSocket client disconnected: %s", userID)
+		delete(h.clients, id)
+		log.Printf("WebSocket client disconnected: %s", client.userID)
 	}
 }
 
+// ClientCount returns the number of connected clients.
+func (h *WebSocketHandler) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
 func (h *WebSocketHandler) Broadcast(message WebSocketMessage) {
 	data, _ := json.Marshal(message)
-	h.broadcast <- data
+	out := outboundMessage{data: data}
+	switch payload := message.Payload.(type) {
+	case *services.AlertNotification:
+		out.alert = payload
+		out.scoped, out.groupID = true, payload.GroupID
+	case *services.AlertAckNotification:
+		out.scoped, out.groupID = true, payload.GroupID
+	case *services.SLABreachNotification:
+		out.scoped, out.groupID = true, payload.GroupID
+	case *services.TicketNotification:
+		out.scoped, out.groupID = true, payload.GroupID
+	}
+	h.broadcast <- out
 }
 
+// SendToUser sends message to every connection of the user.
 func (h *WebSocketHandler) SendToUser(userID string, message WebSocketMessage) {
+	data, _ := json.Marshal(message)
+	var slow []string
 	h.mu.RLock()
-	client, ok := h.clients[userID]
-	h.mu.RUnlock()
-
-	if ok {
-		data, _ := json.Marshal(message)
+	for id, client := range h.clients {
+		if client.userID != userID {
+			continue
+		}
 		select {
 		case client.send <- data:
 		default:
-			h.RemoveClient(userID)
+			slow = append(slow, id)
 		}
 	}
+	h.mu.RUnlock()
+	for _, id := range slow {
+		h.RemoveClient(id)
+	}
 }
 
+// HandleBroadcast forwards broadcast messages to every client allowed to see them (see visibleTo) whose
+// subscription filter matches. Clients whose send buffer is full are disconnected.
 func (h *WebSocketHandler) HandleBroadcast() {
 	for {
 		message := <-h.broadcast
+		var slow []string
 		h.mu.RLock()
-		for _, client := range h.clients {
+		for id, client := range h.clients {
+			if !message.visibleTo(client) || !client.filter.matches(message.alert) {
+				continue
+			}
 			select {
-			case client.send <- message:
+			case client.send <- message.data:
 			default:
-				h.RemoveClient(client.userID)
+				slow = append(slow, id)
 			}
 		}
 		h.mu.RUnlock()
+		for _, id := range slow {
+			h.RemoveClient(id)
+		}
+	}
+}
+
+// subscribe replaces the client's filter and confirms it with a "subscribed" message. A filter on a
+// business group the user may not see is refused with an "error" message and the filter is kept.
+func (h *WebSocketHandler) subscribe(c *Client, filter SubscriptionFilter) {
+	if filter.GroupID != "" && c.allowedGroups != nil && !c.allowedGroups[filter.GroupID] {
+		data, _ := json.Marshal(WebSocketMessage{Type: "error", Payload: "group_id is not one of your business groups"})
+		h.sendToClient(c, data)
+		log.Printf("WebSocket client %s refused subscription to group %s", c.userID, filter.GroupID)
+		return
+	}
+	data, _ := json.Marshal(WebSocketMessage{Type: "subscribed", Payload: filter})
+	h.mu.Lock()
+	if h.clients[c.id] != c {
+		h.mu.Unlock()
+		return
+	}
+	c.filter = filter
+	select {
+	case c.send <- data:
+	default:
+	}
+	h.mu.Unlock()
+	log.Printf("WebSocket client %s subscribed: %+v", c.userID, filter)
+}
+
+// sendToClient queues data for c unless it has disconnected or its buffer is full.
+func (h *WebSocketHandler) sendToClient(c *Client, data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.clients[c.id] != c {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
 	}
 }
 
 func (c *Client) readPump(h *WebSocketHandler) {
 	defer func() {
-		h.RemoveClient(c.userID)
+		h.RemoveClient(c.id)
 		c.conn.Close()
 	}()
 
@@ -139,13 +322,20 @@ func (c *Client) readPump(h *WebSocketHandler) {
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.Type == "subscribe" {
+			h.subscribe(c, msg.Filter)
+		}
 	}
 }
 
@@ -223,3 +413,11 @@ func (h *WebSocketHandler) SendTicketNotification(notification *services.TicketN
 	}
 	h.Broadcast(message)
 }
+
+func (h *WebSocketHandler) SendAlertAckNotification(notification *services.AlertAckNotification) {
+	message := WebSocketMessage{
+		Type:    "alert_ack",
+		Payload: notification,
+	}
+	h.Broadcast(message)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"alert-center/internal/middleware"
+	"alert-center/internal/services"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const testJWTSecret = "test-secret"
+
+// staticGroupScope resolves every user to the same groups.
+type staticGroupScope []uuid.UUID
+
+func (s staticGroupScope) UserGroups(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]string, error) {
+	return nil, nil
+}
+
+func (s staticGroupScope) AllowedGroupIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return s, nil
+}
+
+func signToken(t *testing.T, secret, role string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
+		UserID:   uuid.New().String(),
+		Username: role + "-user",
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newWebSocketServer(t *testing.T, h *WebSocketHandler) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", h.HandleConnection)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+}
+
+func TestWebSocketRejectsInvalidToken(t *testing.T) {
+	url := newWebSocketServer(t, NewWebSocketHandler().WithJWTSecret(testJWTSecret))
+
+	for name, token := range map[string]string{
+		"missing":      "",
+		"garbage":      "not-a-jwt",
+		"wrong secret": signToken(t, "other-secret", "admin"),
+	} {
+		conn, resp, err := websocket.DefaultDialer.Dial(url+"?token="+token, nil)
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s token: connection accepted", name)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s token: got response %v, want 401", name, resp)
+		}
+	}
+}
+
+// readMessages reads the next frame from conn and returns the messages in it (writePump batches
+// queued messages into one frame, separated by newlines).
+func readMessages(t *testing.T, conn *websocket.Conn) []WebSocketMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var out []WebSocketMessage
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		var m WebSocketMessage
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatalf("decode %s: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func dialAs(t *testing.T, h *WebSocketHandler, url, role string) *websocket.Conn {
+	t.Helper()
+	before := h.ClientCount()
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": {"bearer, " + signToken(t, testJWTSecret, role)}})
+	if err != nil {
+		t.Fatalf("dial as %s: %v", role, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	for deadline := time.Now().Add(2 * time.Second); h.ClientCount() == before; {
+		if time.Now().After(deadline) {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func TestWebSocketScopesAlertsToUserGroups(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	h := NewWebSocketHandler().WithJWTSecret(testJWTSecret).WithGroupScope(staticGroupScope{own})
+	go h.HandleBroadcast()
+	url := newWebSocketServer(t, h)
+
+	user := dialAs(t, h, url, "user")
+	admin := dialAs(t, h, url, "admin")
+
+	h.SendAlertNotification(&services.AlertNotification{RuleName: "other group", GroupID: other.String()})
+	h.SendAlertAckNotification(&services.AlertAckNotification{AlertNo: "AL-other", GroupID: other.String()})
+	h.SendSLABreachNotification(&services.SLABreachNotification{AlertID: "sla-other", GroupID: other.String()})
+	h.SendTicketNotification(&services.TicketNotification{Title: "ticket-other", GroupID: other.String()})
+	h.SendTicketNotification(&services.TicketNotification{Title: "ticket without a rule"})
+	h.SendAlertNotification(&services.AlertNotification{RuleName: "no group"})
+	h.SendAlertNotification(&services.AlertNotification{RuleName: "own group", GroupID: own.String()})
+	h.SendSLABreachNotification(&services.SLABreachNotification{AlertID: "sla-own", GroupID: own.String()})
+
+	var got []WebSocketMessage
+	for len(got) < 2 {
+		got = append(got, readMessages(t, user)...)
+	}
+	if len(got) != 2 || got[0].Payload.(map[string]interface{})["rule_name"] != "own group" ||
+		got[1].Type != "sla_breach" || got[1].Payload.(map[string]interface{})["alert_id"] != "sla-own" {
+		t.Errorf("user received %+v, want only the own group alert and SLA breach", got)
+	}
+
+	var adminGot []WebSocketMessage
+	for len(adminGot) < 8 {
+		adminGot = append(adminGot, readMessages(t, admin)...)
+	}
+	if len(adminGot) != 8 {
+		t.Errorf("admin received %d messages, want all 8", len(adminGot))
+	}
+}
+
+func TestWebSocketRefusesSubscriptionOutsideScope(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	h := NewWebSocketHandler().WithJWTSecret(testJWTSecret).WithGroupScope(staticGroupScope{own})
+	go h.HandleBroadcast()
+	url := newWebSocketServer(t, h)
+	user := dialAs(t, h, url, "user")
+
+	user.WriteJSON(clientMessage{Type: "subscribe", Filter: SubscriptionFilter{GroupID: other.String()}})
+	if got := readMessages(t, user); got[0].Type != "error" {
+		t.Errorf("subscribe to another group: got %q, want error", got[0].Type)
+	}
+
+	user.WriteJSON(clientMessage{Type: "subscribe", Filter: SubscriptionFilter{GroupID: own.String()}})
+	if got := readMessages(t, user); got[0].Type != "subscribed" {
+		t.Errorf("subscribe to own group: got %q, want subscribed", got[0].Type)
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"alert-center/internal/repository"
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -30,25 +33,9 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
-
+		claims, userID, err := ParseToken(jwtSecret, parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		claims, ok := token.Claims.(*Claims)
-		if !ok || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
-		}
-
-		userID, err := uuid.Parse(claims.UserID)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id in token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 		c.Set("user_id", userID)
@@ -59,6 +46,28 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// ParseToken verifies a JWT issued at login with jwtSecret and returns its claims and user ID. The
+// error text is suitable for a 401 response.
+func ParseToken(jwtSecret, tokenString string) (*Claims, uuid.UUID, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, uuid.Nil, errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, uuid.Nil, errors.New("Invalid token claims")
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, uuid.Nil, errors.New("Invalid user_id in token")
+	}
+	return claims, userID, nil
+}
+
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
@@ -151,3 +160,45 @@ func HasPermission(role string, permission string) bool {
 	}
 	return false
 }
+
+// GroupScopeResolver returns a user's business groups.
+type GroupScopeResolver interface {
+	// UserGroups returns the user's role in each group it belongs to or manages.
+	UserGroups(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]string, error)
+	// AllowedGroupIDs returns the groups the user may read: its groups and their subgroups.
+	AllowedGroupIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// GroupScopeMiddleware resolves the user's business groups into the context as "group_roles" (group ID
+// to role in the group) and restricts the list reads of non-admin users to the groups they belong to or
+// manage, and their subgroups, by putting the allowed groups in the request context (see
+// repository.WithGroupScope) and in "allowed_group_ids". Admins are unrestricted. Must run after
+// AuthMiddleware.
+func GroupScopeMiddleware(resolver GroupScopeResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User not found"})
+			return
+		}
+		groupRoles, err := resolver.UserGroups(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set("group_roles", groupRoles)
+
+		if role, _ := c.Get("role"); role == RoleAdmin {
+			c.Next()
+			return
+		}
+		groupIDs, err := resolver.AllowedGroupIDs(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set("allowed_group_ids", groupIDs)
+		c.Request = c.Request.WithContext(repository.WithGroupScope(c.Request.Context(), groupIDs))
+		c.Next()
+	}
+}
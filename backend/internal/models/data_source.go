@@ -35,12 +35,24 @@ type DataSource struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// DataSourceHealthCheck 数据源健康检查记录
+type DataSourceHealthCheck struct {
+	ID           uuid.UUID `json:"id"`
+	DataSourceID uuid.UUID `json:"data_source_id"`
+	Status       string    `json:"status"`     // healthy, unhealthy
+	Source       string    `json:"source"`     // manual: 手动检查, breaker: 熔断器状态变化
+	LatencyMs    int64     `json:"latency_ms"` // 检查耗时，熔断器记录为 0
+	Error        string    `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
 // AlertSilence 告警静默规则
 type AlertSilence struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	Name        string     `json:"name" gorm:"size:128;not null"`
 	Description string     `json:"description" gorm:"size:512"`
 	Matchers    string     `json:"matchers" gorm:"type:jsonb"` // 标签匹配规则
+	BusinessGroupID *uuid.UUID `json:"business_group_id,omitempty" gorm:"type:uuid"` // 业务组静默：只匹配该组规则的告警，matchers 可为空
 	StartTime  time.Time  `json:"start_time" gorm:"not null"`
 	EndTime    time.Time  `json:"end_time" gorm:"not null"`
 	CreatedBy   uuid.UUID  `json:"created_by" gorm:"type:uuid"`
@@ -88,7 +100,7 @@ type NotificationTemplate struct {
 	Name        string     `json:"name" gorm:"size:128;not null"`
 	Description string     `json:"description" gorm:"size:512"`
 	Type        string     `json:"type" gorm:"size:32"` // markdown, text, html
-	ChannelType string     `json:"channel_type" gorm:"size:32"` // lark, telegram, email, webhook
+	ChannelType string     `json:"channel_type" gorm:"size:32"` // lark, dingtalk, telegram, email, webhook
 	Subject     string     `json:"subject" gorm:"size:256"`
 	Content     string     `json:"content" gorm:"type:text"`
 	Variables   string     `json:"variables" gorm:"type:jsonb"`
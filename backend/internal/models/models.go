@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
@@ -23,23 +24,41 @@ type User struct {
 type BusinessGroup struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	Name        string     `json:"name" gorm:"size:128;not null"`
+	Slug        string     `json:"slug" gorm:"size:128;uniqueIndex"` // 跨环境唯一标识(可选)
 	Description string     `json:"description" gorm:"size:512"`
 	ParentID    *uuid.UUID `json:"parent_id" gorm:"type:uuid"`
 	ManagerID   *uuid.UUID `json:"manager_id" gorm:"type:uuid"`
+	DefaultChannelID *uuid.UUID `json:"default_channel_id" gorm:"type:uuid"` // 兜底渠道: 组内规则未绑定任何渠道时发送到此渠道
 	Status      int        `json:"status" gorm:"default:1"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// UserGroupMembership 用户所属业务组: 非管理员只能查看所属(或管理)业务组及其子组的数据
+type UserGroupMembership struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"` // 只读
+	GroupID   uuid.UUID `json:"group_id"`
+	GroupName string    `json:"group_name"` // 只读
+	Role      string    `json:"role"`       // 组内角色: manager / member
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // AlertChannel 告警渠道
 type AlertChannel struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	Name        string     `json:"name" gorm:"size:128;not null"`
-	Type        string     `json:"type" gorm:"size:32;not null"`  // lark, telegram, email, webhook
+	Slug        string     `json:"slug" gorm:"size:128;uniqueIndex"` // 跨环境唯一标识(可选)
+	Type        string     `json:"type" gorm:"size:32;not null"`  // lark, dingtalk, telegram, email, webhook
 	Description string     `json:"description" gorm:"size:512"`
 	Config      string     `json:"config" gorm:"type:jsonb"`  // JSON配置
 	GroupID     *uuid.UUID `json:"group_id" gorm:"type:uuid"`  // 所属业务组
 	Status      int        `json:"status" gorm:"default:1"`
+	MinSeverity string     `json:"min_severity,omitempty" gorm:"-"` // 绑定的最低告警级别(仅按规则查询绑定渠道时填充)
+	EffectiveStartTime string `json:"effective_start_time,omitempty" gorm:"-"` // 绑定生效开始时间 HH:MM(仅按规则查询绑定渠道时填充)
+	EffectiveEndTime   string `json:"effective_end_time,omitempty" gorm:"-"`   // 绑定生效结束时间 HH:MM
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -48,6 +67,7 @@ type AlertChannel struct {
 type AlertTemplate struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	Name        string     `json:"name" gorm:"size:128;not null"`
+	Slug        string     `json:"slug" gorm:"size:128;uniqueIndex"` // 跨环境唯一标识(可选)
 	Description string     `json:"description" gorm:"size:512"`
 	Content     string     `json:"content" gorm:"type:text;not null"`  // 模板内容
 	Variables   string     `json:"variables" gorm:"type:jsonb"`  // 模板变量定义
@@ -69,6 +89,7 @@ type ExclusionWindow struct {
 type AlertRule struct {
 	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	Name               string     `json:"name" gorm:"size:128;not null"`
+	Slug               string     `json:"slug" gorm:"size:128;uniqueIndex"`        // 跨环境唯一标识(可选)
 	Description        string     `json:"description" gorm:"size:512"`
 	Expression               string     `json:"expression" gorm:"type:text;not null"`       // PromQL表达式
 	EvaluationIntervalSeconds int        `json:"evaluation_interval_seconds" gorm:"default:60"` // 执行频率(秒)，规则评估间隔
@@ -80,19 +101,76 @@ type AlertRule struct {
 	GroupID            uuid.UUID  `json:"group_id" gorm:"type:uuid;not null"`      // 所属业务组
 	DataSourceType     string     `json:"data_source_type" gorm:"size:32;default:prometheus"`
 	DataSourceURL      string     `json:"data_source_url" gorm:"size:512"`
+	DataSourceIDs      []uuid.UUID `json:"data_source_ids" gorm:"type:jsonb"`          // 按顺序尝试的数据源(故障切换), 为空则使用 data_source_url
 	Status             int        `json:"status" gorm:"default:1"`                    // 0: disabled, 1: enabled
 	EffectiveStartTime string     `json:"effective_start_time" gorm:"size:5;default:00:00"` // 生效开始时间(每日), HH:MM, default 24h
 	EffectiveEndTime   string     `json:"effective_end_time" gorm:"size:5;default:23:59"`   // 生效结束时间(每日), HH:MM
 	ExclusionWindows   string     `json:"exclusion_windows" gorm:"type:jsonb"`              // 排除时间 JSON array of ExclusionWindow
+	SeverityLabel      string     `json:"severity_label" gorm:"size:64"`                     // 从查询结果标签/注释读取级别(可选), 为空则使用 severity
+	ResolveConfirmations int      `json:"resolve_confirmations" gorm:"default:1"`            // 连续N次评估未命中才判定恢复, default 1
+	ValueFormat        string     `json:"value_format" gorm:"size:32"`                       // 通知中数值格式: percent, bytes, duration; 为空则原样输出
+	NotifyMode         string     `json:"notify_mode" gorm:"size:16;default:per_series"`     // per_series: 每个序列单独告警; aggregate: 合并为一条告警并列出 Top N
+	AggregateTopN      int        `json:"aggregate_top_n" gorm:"default:10"`                 // aggregate 模式下通知中列出的序列数
+	NotifyOnResolve    bool       `json:"notify_on_resolve" gorm:"default:true"`             // 恢复时是否发送恢复通知, default true
+	GroupRecovery      bool       `json:"group_recovery" gorm:"default:false"`               // 同一轮评估中多个序列恢复时合并为一条恢复通知, default false
+	LastEvaluatedAt    *time.Time `json:"last_evaluated_at"`                                 // 最近一次评估时间(由 worker 写入)
+	LastEvalStatus     string     `json:"last_eval_status" gorm:"size:16"`                   // 最近一次评估结果: ok, error, no_data; 未评估过为空
+	LastEvalError      string     `json:"last_eval_error,omitempty" gorm:"type:text"`        // last_eval_status 为 error 时的错误信息
+	LastEvalDataSourceID *uuid.UUID `json:"last_eval_data_source_id,omitempty" gorm:"type:uuid"` // 最近一次成功评估所用的数据源
+	EvaluationMode     string     `json:"evaluation_mode" gorm:"size:16;default:instant"`    // instant: 即时查询, 序列值>0即告警; range: 区间查询并按 range_condition 判断
+	RangeDuration      int        `json:"range_duration" gorm:"default:600"`                 // range 模式的查询区间(秒), default 600
+	RangeStep          int        `json:"range_step" gorm:"default:60"`                      // range 模式的查询步长(秒), default 60
+	RangeCondition     string     `json:"range_condition" gorm:"size:64"`                    // range 模式的条件, 如 "change_percent > 50"
+	ComparisonOperator string     `json:"comparison_operator" gorm:"size:8"`                 // instant 模式阈值比较: gt, gte, lt, lte, eq, neq; 为空则序列值>0即告警
+	Threshold          *float64   `json:"threshold"`                                         // 与 comparison_operator 配合使用的阈值
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
+// Rule notify modes: one alert per firing series, or one alert per rule listing the top offenders.
+const (
+	NotifyModePerSeries = "per_series"
+	NotifyModeAggregate = "aggregate"
+)
+
+// Rule evaluation outcomes, stored as last_eval_status: the query succeeded and returned series, it
+// failed, or it succeeded without returning any series.
+const (
+	RuleEvalOK     = "ok"
+	RuleEvalError  = "error"
+	RuleEvalNoData = "no_data"
+)
+
+// Rule evaluation modes: an instant query firing on every positive series, or a range query whose
+// samples are reduced and compared by the rule's range_condition.
+const (
+	EvaluationModeInstant = "instant"
+	EvaluationModeRange   = "range"
+)
+
+// AlertRulePreset 内置规则预设，可一键实例化为某业务组/数据源下的规则
+type AlertRulePreset struct {
+	ID          string    `json:"id" gorm:"size:64;primary_key"` // 稳定标识，如 node-down
+	Name        string    `json:"name" gorm:"size:128;not null"`
+	Category    string    `json:"category" gorm:"size:64"` // node, kubernetes, ...
+	Description string    `json:"description" gorm:"size:512"`
+	Expression  string    `json:"expression" gorm:"type:text;not null"`
+	ForDuration int       `json:"for_duration"` // 持续时间(秒)
+	Severity    string    `json:"severity" gorm:"size:32;not null"`
+	Labels      string    `json:"labels" gorm:"type:jsonb"`
+	Annotations string    `json:"annotations" gorm:"type:jsonb"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 // AlertChannelBinding 告警渠道绑定
 type AlertChannelBinding struct {
 	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
 	RuleID     uuid.UUID  `json:"rule_id" gorm:"type:uuid;not null"`
 	ChannelID  uuid.UUID  `json:"channel_id" gorm:"type:uuid;not null"`
+	MinSeverity string    `json:"min_severity" gorm:"size:32"` // 最低告警级别: info, warning, critical; 为空则全部发送
+	EffectiveStartTime string `json:"effective_start_time" gorm:"size:5"` // 生效开始时间(每日), HH:MM; 开始和结束都为空则全天生效
+	EffectiveEndTime   string `json:"effective_end_time" gorm:"size:5"`   // 生效结束时间(每日), HH:MM; 可跨午夜, 如 18:00-09:00
 	Status     int        `json:"status" gorm:"default:1"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
@@ -112,6 +190,128 @@ type AlertHistory struct {
 	Annotations  string     `json:"annotations" gorm:"type:jsonb"`
 	Payload     string     `json:"payload" gorm:"type:text"`  // 原始告警数据
 	CreatedAt   time.Time  `json:"created_at"`
+	AckedBy     *uuid.UUID `json:"acked_by,omitempty"`      // 确认人
+	AckedByName string     `json:"acked_by_name,omitempty"` // 确认人用户名
+	AckedAt     *time.Time `json:"acked_at,omitempty"`      // 确认时间
+
+	labelMap     map[string]string // Labels 解析缓存, 由 LabelMap 延迟填充
+	parsedLabels string            // the Labels value labelMap was parsed from
+}
+
+// LabelMap returns Labels decoded as a map, parsing the JSON only on first use and again after Labels
+// is reassigned. It returns nil when Labels is not a valid JSON object. Callers must not modify the
+// returned map.
+func (h *AlertHistory) LabelMap() map[string]string {
+	if h.parsedLabels != h.Labels {
+		h.labelMap = DecodeLabels([]byte(h.Labels))
+		h.parsedLabels = h.Labels
+	}
+	return h.labelMap
+}
+
+// DecodeLabels decodes a labels JSON object into strings. Label values are strings when written by
+// the evaluator, but older or imported rows may hold numbers, booleans or nested values; those keep
+// their JSON text (e.g. 8080, true) and null becomes "". It returns nil when raw is not a JSON object.
+func DecodeLabels(raw []byte) map[string]string {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil || values == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(values))
+	for k, v := range values {
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			labels[k] = str
+		} else if string(v) != "null" {
+			labels[k] = string(v)
+		} else {
+			labels[k] = ""
+		}
+	}
+	return labels
+}
+
+// AlertHistoryDetail is one alert with its full payload, the rule and business group it belongs to and
+// its SLA state, for pages that link to a single alert.
+type AlertHistoryDetail struct {
+	AlertHistory
+	RuleName           string     `json:"rule_name"`
+	GroupID            *uuid.UUID `json:"group_id,omitempty"` // 规则所属业务组
+	GroupName          string     `json:"group_name,omitempty"`
+	SLAStatus          string     `json:"sla_status,omitempty"` // pending, acknowledged, resolved, breached; 无 SLA 时为空
+	ResponseDeadline   *time.Time `json:"response_deadline,omitempty"`
+	ResolutionDeadline *time.Time `json:"resolution_deadline,omitempty"`
+	ResponseBreached   bool       `json:"response_breached"`
+	ResolutionBreached bool       `json:"resolution_breached"`
+}
+
+// ActiveAlert is a firing alert with the state a triage view needs: SLA progress, age and acknowledgement.
+type ActiveAlert struct {
+	ID                 uuid.UUID  `json:"id"`
+	AlertNo            string     `json:"alert_no"`
+	RuleID             uuid.UUID  `json:"rule_id"`
+	RuleName           string     `json:"rule_name"`
+	GroupID            *uuid.UUID `json:"group_id,omitempty"` // 规则所属业务组
+	GroupName          string     `json:"group_name,omitempty"`
+	Fingerprint        string     `json:"fingerprint"`
+	Severity           string     `json:"severity"`
+	Labels             string     `json:"labels"`
+	StartedAt          time.Time  `json:"started_at"`
+	AgeSecs            int64      `json:"age_secs"` // 已持续秒数
+	Acked              bool       `json:"acked"`
+	AckedByName        string     `json:"acked_by_name,omitempty"`
+	AckedAt            *time.Time `json:"acked_at,omitempty"`
+	SLAStatus          string     `json:"sla_status,omitempty"` // pending, acknowledged, breached; 无 SLA 时为空
+	ResponseDeadline   *time.Time `json:"response_deadline,omitempty"`
+	ResolutionDeadline *time.Time `json:"resolution_deadline,omitempty"`
+	ResponseBreached   bool       `json:"response_breached"`
+	ResolutionBreached bool       `json:"resolution_breached"`
+}
+
+// ActiveAlertGroup is the firing alerts sharing a rule, business group, severity or label value.
+type ActiveAlertGroup struct {
+	Key      string        `json:"key"`      // 分组键: 规则/业务组 ID, 级别或标签值
+	Name     string        `json:"name"`     // 展示名称
+	Count    int           `json:"count"`    // 告警数
+	Unacked  int           `json:"unacked"`  // 未确认数
+	Severity string        `json:"severity"` // 组内最高级别
+	Alerts   []ActiveAlert `json:"alerts"`
+}
+
+// NotificationLog 通知投递记录, 每次渠道发送一条
+type NotificationLog struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	AlertID     *uuid.UUID `json:"alert_id" gorm:"type:uuid;index"` // 告警历史 ID, 测试发送时为空
+	AlertNo     string     `json:"alert_no" gorm:"size:32"`
+	RuleID      *uuid.UUID `json:"rule_id" gorm:"type:uuid"`
+	ChannelID   uuid.UUID  `json:"channel_id" gorm:"type:uuid"`
+	ChannelName string     `json:"channel_name" gorm:"size:128"`
+	ChannelType string     `json:"channel_type" gorm:"size:32"`
+	AlertStatus string     `json:"alert_status" gorm:"size:32"` // firing, resolved
+	Success     bool       `json:"success"`
+	HTTPStatus  int        `json:"http_status"` // 最后一次响应状态码, 无 HTTP 响应(如邮件/连接失败)时为 0
+	Attempts    int        `json:"attempts"`    // 含重试的请求次数
+	LatencyMs   *int64     `json:"latency_ms"`  // 告警开始(started_at)到成功送达的耗时(毫秒), 仅告警触发的成功发送记录
+	Error       string     `json:"error" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// NotificationDeadLetter 死信通知: 发件箱重试耗尽且所有渠道均失败的通知, 由清扫任务继续重试
+type NotificationDeadLetter struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	AlertID       uuid.UUID  `json:"alert_id" gorm:"type:uuid"`
+	RuleID        uuid.UUID  `json:"rule_id" gorm:"type:uuid"`
+	AlertNo       string     `json:"alert_no"` // 取自 payload
+	RuleName      string     `json:"rule_name"`
+	Severity      string     `json:"severity"`
+	AlertStatus   string     `json:"alert_status"`              // firing, resolved
+	Payload       string     `json:"payload" gorm:"type:jsonb"` // 原始通知内容
+	Status        string     `json:"status" gorm:"size:16"`     // pending, delivered, expired
+	Attempts      int        `json:"attempts"`                  // 进入死信后的重试次数
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
 }
 
 // OperationLog 操作日志
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestLabelMapFollowsReassignedLabels(t *testing.T) {
+	h := &AlertHistory{Labels: `{"instance": "db-1"}`}
+	if got := h.LabelMap()["instance"]; got != "db-1" {
+		t.Fatalf("instance = %q, want db-1", got)
+	}
+	h.Labels = `{"job": "node"}`
+	m := h.LabelMap()
+	if m["job"] != "node" || len(m) != 1 {
+		t.Errorf("after reassigning Labels: %v, want only job=node", m)
+	}
+	h.Labels = "not json"
+	if m := h.LabelMap(); m != nil {
+		t.Errorf("invalid Labels: %v, want nil", m)
+	}
+}
+
+func TestLabelMapKeepsNonStringValues(t *testing.T) {
+	h := &AlertHistory{Labels: `{"instance": "db-1", "port": 8080, "canary": true, "zone": null, "tags": ["a"]}`}
+	want := map[string]string{"instance": "db-1", "port": "8080", "canary": "true", "zone": "", "tags": `["a"]`}
+	got := h.LabelMap()
+	if len(got) != len(want) {
+		t.Fatalf("LabelMap() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	for _, raw := range []string{"", "null", "[1]", `"x"`} {
+		if m := DecodeLabels([]byte(raw)); m != nil {
+			t.Errorf("DecodeLabels(%q) = %v, want nil", raw, m)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// firingAlertOfGroup inserts a rule of groupID and a firing alert of it.
+func firingAlertOfGroup(t *testing.T, db *Database, groupID uuid.UUID) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	ruleID := uuid.New()
+	if _, err := db.Pool.Exec(ctx, `
+		INSERT INTO alert_rules (id, name, expression, severity, group_id, created_at, updated_at)
+		VALUES ($1, 'scope test', 'up == 0', 'critical', $2, NOW(), NOW())
+	`, ruleID, groupID); err != nil {
+		t.Fatalf("insert rule: %v", err)
+	}
+	h := &models.AlertHistory{RuleID: ruleID, Fingerprint: uuid.NewString(), Severity: "critical", Status: "firing", StartedAt: time.Now()}
+	if err := NewAlertHistoryRepository(db).Create(ctx, h); err != nil {
+		t.Fatalf("insert alert: %v", err)
+	}
+	return h.ID
+}
+
+func TestAcknowledgeRespectsGroupScope(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewAlertHistoryRepository(db)
+	own, other := uuid.New(), uuid.New()
+	alertID := firingAlertOfGroup(t, db, other)
+	user := uuid.New()
+
+	scoped := WithGroupScope(context.Background(), []uuid.UUID{own})
+	if _, _, err := repo.Acknowledge(scoped, alertID, user, "scoped", time.Now()); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("ack outside the scope: want pgx.ErrNoRows, got %v", err)
+	}
+
+	scoped = WithGroupScope(context.Background(), []uuid.UUID{other})
+	h, groupID, err := repo.Acknowledge(scoped, alertID, user, "scoped", time.Now())
+	if err != nil {
+		t.Fatalf("ack inside the scope: %v", err)
+	}
+	if h.AckedAt == nil || groupID == nil || *groupID != other {
+		t.Errorf("ack inside the scope: acked_at %v, group %v", h.AckedAt, groupID)
+	}
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Roles of a user within a business group. A group's manager_id counts as a manager membership.
+const (
+	GroupRoleManager = "manager"
+	GroupRoleMember  = "member"
+)
+
+var (
+	// ErrInvalidGroupRole is returned for a membership role other than manager or member.
+	ErrInvalidGroupRole = errors.New("role must be manager or member")
+	// ErrMembershipExists is returned when adding a user to a group it already belongs to.
+	ErrMembershipExists = errors.New("user is already a member of the group")
+	// ErrMembershipTarget is returned when the membership's user or business group does not exist.
+	ErrMembershipTarget = errors.New("user or business group not found")
+)
+
+// GroupMembershipRepository stores which business groups users belong to, and their role in each.
+type GroupMembershipRepository struct {
+	db *Database
+}
+
+func NewGroupMembershipRepository(db *Database) *GroupMembershipRepository {
+	return &GroupMembershipRepository{db: db}
+}
+
+func validGroupRole(role string) bool {
+	return role == GroupRoleManager || role == GroupRoleMember
+}
+
+const groupMembershipColumns = `m.id, m.user_id, COALESCE(u.username, ''), m.group_id, COALESCE(g.name, ''), m.role, m.created_at, m.updated_at`
+
+const groupMembershipFrom = `
+		FROM user_group_memberships m
+		LEFT JOIN users u ON u.id = m.user_id
+		LEFT JOIN business_groups g ON g.id = m.group_id`
+
+func scanGroupMembership(row pgx.Row) (models.UserGroupMembership, error) {
+	var m models.UserGroupMembership
+	err := row.Scan(&m.ID, &m.UserID, &m.Username, &m.GroupID, &m.GroupName, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+// List returns the memberships of userID and/or groupID; nil matches any.
+func (r *GroupMembershipRepository) List(ctx context.Context, userID, groupID *uuid.UUID) ([]models.UserGroupMembership, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+groupMembershipColumns+groupMembershipFrom+`
+		WHERE ($1::uuid IS NULL OR m.user_id = $1) AND ($2::uuid IS NULL OR m.group_id = $2)
+		ORDER BY g.name, u.username
+	`, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []models.UserGroupMembership{}
+	for rows.Next() {
+		m, err := scanGroupMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, rows.Err()
+}
+
+func (r *GroupMembershipRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UserGroupMembership, error) {
+	m, err := scanGroupMembership(r.db.Pool.QueryRow(ctx, `
+		SELECT `+groupMembershipColumns+groupMembershipFrom+`
+		WHERE m.id = $1
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Create adds the user to the group. It returns ErrInvalidGroupRole, ErrMembershipTarget when the user
+// or group does not exist, or ErrMembershipExists.
+func (r *GroupMembershipRepository) Create(ctx context.Context, m *models.UserGroupMembership) error {
+	if m.Role == "" {
+		m.Role = GroupRoleMember
+	}
+	if !validGroupRole(m.Role) {
+		return ErrInvalidGroupRole
+	}
+	var exists bool
+	if err := r.db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE id = $1) AND EXISTS (SELECT 1 FROM business_groups WHERE id = $2)
+	`, m.UserID, m.GroupID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrMembershipTarget
+	}
+
+	m.ID = uuid.New()
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = m.CreatedAt
+	tag, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO user_group_memberships (id, user_id, group_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, group_id) DO NOTHING
+	`, m.ID, m.UserID, m.GroupID, m.Role, m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMembershipExists
+	}
+	return nil
+}
+
+// UpdateRole changes a membership's role. It returns ErrInvalidGroupRole, or pgx.ErrNoRows when the
+// membership does not exist.
+func (r *GroupMembershipRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string) error {
+	if !validGroupRole(role) {
+		return ErrInvalidGroupRole
+	}
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_group_memberships SET role = $1, updated_at = $2 WHERE id = $3
+	`, role, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes a membership, returning pgx.ErrNoRows when it does not exist.
+func (r *GroupMembershipRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM user_group_memberships WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UserGroups returns the role of userID in each business group it directly belongs to, including the
+// groups it is manager_id of.
+func (r *GroupMembershipRepository) UserGroups(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, $2::text FROM business_groups WHERE manager_id = $1
+		UNION ALL
+		SELECT group_id, role FROM user_group_memberships WHERE user_id = $1
+	`, userID, GroupRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var groupID uuid.UUID
+		var role string
+		if err := rows.Scan(&groupID, &role); err != nil {
+			return nil, err
+		}
+		if groups[groupID] != GroupRoleManager {
+			groups[groupID] = role
+		}
+	}
+	return groups, rows.Err()
+}
+
+// AllowedGroupIDs returns the business groups userID may read: those it belongs to or manages, and all
+// their descendant groups.
+func (r *GroupMembershipRepository) AllowedGroupIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		WITH RECURSIVE scoped AS (
+			SELECT id FROM business_groups WHERE manager_id = $1
+			UNION
+			SELECT group_id FROM user_group_memberships WHERE user_id = $1
+			UNION
+			SELECT g.id FROM business_groups g JOIN scoped s ON g.parent_id = s.id
+		)
+		SELECT id FROM scoped
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
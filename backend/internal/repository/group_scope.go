@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type groupScopeKey struct{}
+
+// WithGroupScope restricts the list reads made with the returned context to the given business groups;
+// rows of no group are excluded as well. Without a scope, as for admins and background workers, reads
+// are unrestricted.
+func WithGroupScope(ctx context.Context, groupIDs []uuid.UUID) context.Context {
+	if groupIDs == nil {
+		groupIDs = []uuid.UUID{}
+	}
+	return context.WithValue(ctx, groupScopeKey{}, groupIDs)
+}
+
+// GroupScope returns the business groups reads in ctx are restricted to, and false when unrestricted.
+func GroupScope(ctx context.Context) ([]uuid.UUID, bool) {
+	ids, ok := ctx.Value(groupScopeKey{}).([]uuid.UUID)
+	return ids, ok
+}
+
+// groupScopeCondition returns the condition restricting column to the scope in ctx with its argument
+// bound to $n, or "" when ctx is unrestricted.
+func groupScopeCondition(ctx context.Context, column string, n int) (string, interface{}) {
+	ids, ok := GroupScope(ctx)
+	if !ok {
+		return "", nil
+	}
+	return fmt.Sprintf("%s = ANY($%d::uuid[])", column, n), ids
+}
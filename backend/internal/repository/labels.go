@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LabelFilter narrows alert_history by labels with JSONB operators, which the GIN index
+// idx_alert_history_labels serves: Key alone matches rows carrying the label (?), Key with Value
+// matches rows whose labels contain key=value (@>).
+type LabelFilter struct {
+	Key   string
+	Value string
+}
+
+// Condition returns the SQL condition on column with its single argument bound to $n, and that
+// argument. It returns "" when Key is empty.
+func (f LabelFilter) Condition(column string, n int) (string, interface{}) {
+	if f.Key == "" {
+		return "", nil
+	}
+	if f.Value == "" {
+		return fmt.Sprintf("%s ? $%d", column, n), f.Key
+	}
+	containment, _ := json.Marshal(map[string]string{f.Key: f.Value})
+	return fmt.Sprintf("%s @> $%d::jsonb", column, n), string(containment)
+}
@@ -0,0 +1,467 @@
+package repository
+
+import "context"
+
+// migrations are the schema statements, in order. Every statement is idempotent, and a table must be
+// created before the statements that alter or index it.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+			id UUID PRIMARY KEY,
+			username VARCHAR(64) UNIQUE NOT NULL,
+			password VARCHAR(255) NOT NULL,
+			email VARCHAR(128) UNIQUE,
+			phone VARCHAR(32),
+			role VARCHAR(32) DEFAULT 'user',
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			last_login_at TIMESTAMP
+		)`,
+	`CREATE TABLE IF NOT EXISTS business_groups (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			parent_id UUID,
+			manager_id UUID,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_channels (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			type VARCHAR(32) NOT NULL,
+			description VARCHAR(512),
+			config JSONB,
+			group_id UUID,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_templates (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			content TEXT NOT NULL,
+			variables JSONB,
+			type VARCHAR(32) DEFAULT 'markdown',
+			group_id UUID,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_rules (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			expression TEXT NOT NULL,
+			evaluation_interval_seconds INT DEFAULT 60,
+			for_duration INT DEFAULT 60,
+			severity VARCHAR(32) NOT NULL,
+			labels JSONB,
+			annotations JSONB,
+			template_id UUID,
+			group_id UUID NOT NULL,
+			data_source_type VARCHAR(32) DEFAULT 'prometheus',
+			data_source_url VARCHAR(512),
+			status INT DEFAULT 1,
+			effective_start_time VARCHAR(5) DEFAULT '00:00',
+			effective_end_time VARCHAR(5) DEFAULT '23:59',
+			exclusion_windows JSONB DEFAULT '[]',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS effective_start_time VARCHAR(5) DEFAULT '00:00'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS effective_end_time VARCHAR(5) DEFAULT '23:59'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS exclusion_windows JSONB DEFAULT '[]'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS severity_label VARCHAR(64) DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS value_format VARCHAR(32) DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS notify_mode VARCHAR(16) DEFAULT 'per_series'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS aggregate_top_n INT DEFAULT 10`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS notify_on_resolve BOOLEAN DEFAULT TRUE`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS group_recovery BOOLEAN DEFAULT FALSE`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS last_evaluated_at TIMESTAMP`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS last_eval_status VARCHAR(16) DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS last_eval_error TEXT DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS last_eval_data_source_id UUID`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS data_source_ids JSONB DEFAULT '[]'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS resolve_confirmations INT DEFAULT 1`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS evaluation_interval_seconds INT DEFAULT 60`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS evaluation_mode VARCHAR(16) DEFAULT 'instant'`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS range_duration INT DEFAULT 600`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS range_step INT DEFAULT 60`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS range_condition VARCHAR(64) DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS comparison_operator VARCHAR(8) DEFAULT ''`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS threshold DOUBLE PRECISION`,
+	`ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS slug VARCHAR(128)`,
+	`ALTER TABLE alert_channels ADD COLUMN IF NOT EXISTS slug VARCHAR(128)`,
+	`ALTER TABLE alert_templates ADD COLUMN IF NOT EXISTS slug VARCHAR(128)`,
+	`ALTER TABLE business_groups ADD COLUMN IF NOT EXISTS slug VARCHAR(128)`,
+	`ALTER TABLE business_groups ADD COLUMN IF NOT EXISTS default_channel_id UUID`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_rules_slug ON alert_rules(slug) WHERE slug IS NOT NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_channels_slug ON alert_channels(slug) WHERE slug IS NOT NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_templates_slug ON alert_templates(slug) WHERE slug IS NOT NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_business_groups_slug ON business_groups(slug) WHERE slug IS NOT NULL`,
+	`CREATE TABLE IF NOT EXISTS alert_rule_presets (
+			id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			category VARCHAR(64),
+			description VARCHAR(512),
+			expression TEXT NOT NULL,
+			for_duration INT DEFAULT 60,
+			severity VARCHAR(32) NOT NULL,
+			labels JSONB,
+			annotations JSONB,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_channel_bindings (
+			id UUID PRIMARY KEY,
+			rule_id UUID NOT NULL,
+			channel_id UUID NOT NULL,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			UNIQUE(rule_id, channel_id)
+		)`,
+	`ALTER TABLE alert_channel_bindings ADD COLUMN IF NOT EXISTS min_severity VARCHAR(32) DEFAULT ''`,
+	`ALTER TABLE alert_channel_bindings ADD COLUMN IF NOT EXISTS effective_start_time VARCHAR(5) DEFAULT ''`,
+	`ALTER TABLE alert_channel_bindings ADD COLUMN IF NOT EXISTS effective_end_time VARCHAR(5) DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS alert_history (
+			id UUID PRIMARY KEY,
+			alert_no VARCHAR(32) UNIQUE,
+			rule_id UUID NOT NULL,
+			fingerprint VARCHAR(256),
+			severity VARCHAR(32),
+			status VARCHAR(32),
+			started_at TIMESTAMP NOT NULL,
+			ended_at TIMESTAMP,
+			labels JSONB,
+			annotations JSONB,
+			payload TEXT,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`ALTER TABLE alert_history ADD COLUMN IF NOT EXISTS alert_no VARCHAR(32) UNIQUE`,
+	`ALTER TABLE alert_history ADD COLUMN IF NOT EXISTS acked_by UUID`,
+	`ALTER TABLE alert_history ADD COLUMN IF NOT EXISTS acked_by_name VARCHAR(64)`,
+	`ALTER TABLE alert_history ADD COLUMN IF NOT EXISTS acked_at TIMESTAMP`,
+	`CREATE TABLE IF NOT EXISTS operation_logs (
+			id UUID PRIMARY KEY,
+			user_id UUID,
+			action VARCHAR(64),
+			resource VARCHAR(128),
+			resource_id VARCHAR(128),
+			detail TEXT,
+			ip VARCHAR(64),
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS data_sources (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			type VARCHAR(32) NOT NULL,
+			description VARCHAR(512),
+			endpoint VARCHAR(512) NOT NULL,
+			config JSONB,
+			status INT DEFAULT 1,
+			health_status VARCHAR(32) DEFAULT 'unknown',
+			last_check_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS data_source_health_checks (
+			id UUID PRIMARY KEY,
+			data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+			status VARCHAR(32) NOT NULL,
+			source VARCHAR(32) NOT NULL,
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			error TEXT,
+			checked_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_data_source_health_checks_ds ON data_source_health_checks(data_source_id, checked_at DESC)`,
+	`CREATE TABLE IF NOT EXISTS alert_silences (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			matchers JSONB,
+			start_time TIMESTAMP NOT NULL,
+			end_time TIMESTAMP NOT NULL,
+			created_by UUID,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`ALTER TABLE alert_silences ADD COLUMN IF NOT EXISTS business_group_id UUID`,
+	`CREATE TABLE IF NOT EXISTS alert_escalations (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			rule_id UUID NOT NULL,
+			severity VARCHAR(32) NOT NULL,
+			escalate_to VARCHAR(32) NOT NULL,
+			wait_minutes INT DEFAULT 5,
+			channel_id UUID,
+			repeat_count INT DEFAULT 0,
+			repeat_minutes INT DEFAULT 30,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_escalation_logs (
+			id UUID PRIMARY KEY,
+			escalation_id UUID NOT NULL,
+			alert_id UUID NOT NULL,
+			from_severity VARCHAR(32),
+			to_severity VARCHAR(32),
+			channel_id UUID,
+			notified_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_escalation_logs_escalation_alert ON alert_escalation_logs(escalation_id, alert_id)`,
+	`CREATE TABLE IF NOT EXISTS notification_templates (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			type VARCHAR(32) DEFAULT 'markdown',
+			channel_type VARCHAR(32) NOT NULL,
+			subject VARCHAR(256),
+			content TEXT,
+			variables JSONB,
+			status INT DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS sla_configs (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			severity VARCHAR(32) NOT NULL,
+			response_time_mins INT NOT NULL,
+			resolution_time_mins INT NOT NULL,
+			priority INT DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_slas (
+			id UUID PRIMARY KEY,
+			alert_id UUID NOT NULL,
+			rule_id UUID NOT NULL,
+			severity VARCHAR(32) NOT NULL,
+			sla_config_id UUID,
+			response_deadline TIMESTAMP,
+			resolution_deadline TIMESTAMP,
+			first_acked_at TIMESTAMP,
+			resolved_at TIMESTAMP,
+			status VARCHAR(32) DEFAULT 'pending',
+			response_breached BOOLEAN DEFAULT FALSE,
+			resolution_breached BOOLEAN DEFAULT FALSE,
+			response_time_secs FLOAT,
+			resolution_time_secs FLOAT,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS oncall_schedules (
+			id UUID PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			description VARCHAR(512),
+			timezone VARCHAR(64) DEFAULT 'UTC',
+			rotation_type VARCHAR(32) DEFAULT 'weekly',
+			rotation_start TIMESTAMP,
+			enabled BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS oncall_members (
+			id UUID PRIMARY KEY,
+			schedule_id UUID NOT NULL,
+			user_id UUID NOT NULL,
+			username VARCHAR(64) NOT NULL,
+			email VARCHAR(128),
+			phone VARCHAR(32),
+			priority INT DEFAULT 0,
+			start_time TIMESTAMP,
+			end_time TIMESTAMP,
+			is_active BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS oncall_assignments (
+			id UUID PRIMARY KEY,
+			schedule_id UUID NOT NULL,
+			user_id UUID NOT NULL,
+			username VARCHAR(64) NOT NULL,
+			start_time TIMESTAMP NOT NULL,
+			end_time TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS oncall_overrides (
+			id UUID PRIMARY KEY,
+			schedule_id UUID NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+			original_user_id UUID NOT NULL,
+			original_username VARCHAR(64) NOT NULL,
+			override_user_id UUID NOT NULL,
+			override_username VARCHAR(64) NOT NULL,
+			start_time TIMESTAMP NOT NULL,
+			end_time TIMESTAMP NOT NULL,
+			reason TEXT,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_oncall_overrides_schedule ON oncall_overrides(schedule_id, start_time)`,
+	`CREATE TABLE IF NOT EXISTS oncall_escalations (
+			id UUID PRIMARY KEY,
+			schedule_id UUID NOT NULL,
+			from_user_id UUID NOT NULL,
+			to_user_id UUID NOT NULL,
+			escalated_at TIMESTAMP NOT NULL,
+			reason TEXT,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE TABLE IF NOT EXISTS sla_breaches (
+			id UUID PRIMARY KEY,
+			alert_id UUID NOT NULL,
+			rule_id UUID NOT NULL,
+			severity VARCHAR(32) NOT NULL,
+			breach_type VARCHAR(32) NOT NULL,
+			breach_time TIMESTAMP NOT NULL,
+			response_time FLOAT,
+			assigned_to UUID,
+			assigned_name VARCHAR(64),
+			notified BOOLEAN DEFAULT FALSE,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_sla_breaches_time ON sla_breaches(breach_time)`,
+	`CREATE TABLE IF NOT EXISTS tickets (
+			id UUID PRIMARY KEY,
+			title VARCHAR(256) NOT NULL,
+			description TEXT,
+			alert_id UUID,
+			rule_id UUID,
+			priority VARCHAR(32) NOT NULL DEFAULT 'medium',
+			status VARCHAR(32) NOT NULL DEFAULT 'open',
+			assignee_id UUID,
+			assignee_name VARCHAR(64),
+			creator_id UUID NOT NULL,
+			creator_name VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			resolved_at TIMESTAMP,
+			closed_at TIMESTAMP
+		)`,
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sla_breach_type VARCHAR(32)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_tickets_sla_breach ON tickets(alert_id, sla_breach_type) WHERE sla_breach_type IS NOT NULL`,
+	`CREATE TABLE IF NOT EXISTS user_escalations (
+			id UUID PRIMARY KEY,
+			alert_id UUID NOT NULL,
+			from_user_id UUID NOT NULL,
+			from_username VARCHAR(64) NOT NULL,
+			to_user_id UUID NOT NULL,
+			to_username VARCHAR(64) NOT NULL,
+			reason TEXT,
+			status VARCHAR(32) NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP NOT NULL,
+			resolved_at TIMESTAMP
+		)`,
+	`CREATE TABLE IF NOT EXISTS pending_notifications (
+			id UUID PRIMARY KEY,
+			alert_id UUID NOT NULL,
+			rule_id UUID NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_error TEXT,
+			created_at TIMESTAMP NOT NULL,
+			sent_at TIMESTAMP
+		)`,
+	`ALTER TABLE pending_notifications ADD COLUMN IF NOT EXISTS dry_run_channels TEXT`,
+	`ALTER TABLE pending_notifications ADD COLUMN IF NOT EXISTS delivered_channels UUID[] NOT NULL DEFAULT '{}'`,
+	`CREATE INDEX IF NOT EXISTS idx_pending_notifications_due ON pending_notifications(next_attempt_at) WHERE status = 'pending'`,
+	`CREATE TABLE IF NOT EXISTS alert_digests (
+			group_id UUID NOT NULL,
+			digest_date DATE NOT NULL,
+			sent_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (group_id, digest_date)
+		)`,
+	`CREATE TABLE IF NOT EXISTS alert_stats_daily (
+			date DATE NOT NULL,
+			group_id UUID,
+			rule_id UUID NOT NULL,
+			severity VARCHAR(32) NOT NULL,
+			total INT NOT NULL DEFAULT 0,
+			firing INT NOT NULL DEFAULT 0,
+			resolved INT NOT NULL DEFAULT 0,
+			avg_resolve_secs FLOAT,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (date, rule_id, severity)
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_history_labels ON alert_history USING GIN (labels)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_history_rule_fingerprint ON alert_history(rule_id, fingerprint, started_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_history_firing ON alert_history(started_at) WHERE status = 'firing'`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_slas_alert ON alert_slas(alert_id, created_at)`,
+	`CREATE TABLE IF NOT EXISTS notification_logs (
+			id UUID PRIMARY KEY,
+			alert_id UUID,
+			alert_no VARCHAR(32),
+			rule_id UUID,
+			channel_id UUID NOT NULL,
+			channel_name VARCHAR(128),
+			channel_type VARCHAR(32) NOT NULL,
+			alert_status VARCHAR(32),
+			success BOOLEAN NOT NULL,
+			http_status INT,
+			attempts INT,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_notification_logs_alert ON notification_logs(alert_id, created_at)`,
+	`ALTER TABLE notification_logs ADD COLUMN IF NOT EXISTS latency_ms BIGINT`,
+	`CREATE INDEX IF NOT EXISTS idx_notification_logs_latency ON notification_logs(created_at) WHERE latency_ms IS NOT NULL`,
+	`CREATE TABLE IF NOT EXISTS notification_deadletter (
+			id UUID PRIMARY KEY,
+			alert_id UUID NOT NULL,
+			rule_id UUID NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP NOT NULL,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_notification_deadletter_due ON notification_deadletter(next_attempt_at) WHERE status = 'pending'`,
+	`CREATE TABLE IF NOT EXISTS user_group_memberships (
+			id UUID PRIMARY KEY,
+			user_id UUID NOT NULL,
+			group_id UUID NOT NULL,
+			role VARCHAR(32) NOT NULL DEFAULT 'member',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			UNIQUE (user_id, group_id)
+		)`,
+	`CREATE INDEX IF NOT EXISTS idx_user_group_memberships_group ON user_group_memberships(group_id)`,
+	// business_group_members held plain memberships before roles in groups; copy its rows over once.
+	// The old table is kept, renamed, so nothing is lost and later removals are not copied back.
+	`DO $$ BEGIN
+			IF to_regclass('business_group_members') IS NOT NULL THEN
+				INSERT INTO user_group_memberships (id, user_id, group_id, role, created_at, updated_at)
+				SELECT gen_random_uuid(), user_id, group_id, 'member', created_at, created_at FROM business_group_members
+				ON CONFLICT (user_id, group_id) DO NOTHING;
+				ALTER TABLE business_group_members RENAME TO business_group_members_legacy;
+			END IF;
+		END $$`,
+	// Severities are case-sensitive in SLA lookup and statistics; fold legacy "Critical " etc. to lowercase.
+	`UPDATE alert_rules SET severity = LOWER(TRIM(severity)) WHERE severity <> LOWER(TRIM(severity))`,
+	`UPDATE sla_configs SET severity = LOWER(TRIM(severity)) WHERE severity <> LOWER(TRIM(severity))`,
+	`UPDATE alert_history SET severity = LOWER(TRIM(severity)) WHERE severity <> LOWER(TRIM(severity))`,
+	`UPDATE alert_slas SET severity = LOWER(TRIM(severity)) WHERE severity <> LOWER(TRIM(severity))`,
+	`UPDATE alert_channel_bindings SET min_severity = LOWER(TRIM(min_severity)) WHERE min_severity <> LOWER(TRIM(min_severity))`,
+}
+
+// RunMigrations brings the schema up to date. The API server and the standalone worker both run the
+// full list at startup.
+func RunMigrations(db *Database) error {
+	ctx := context.Background()
+	for _, migration := range migrations {
+		if _, err := db.Pool.Exec(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDatabase connects to the Postgres database in ALERT_CENTER_TEST_DATABASE_URL and migrates it.
+// Tests using it are skipped when the variable is unset.
+func testDatabase(t *testing.T) *Database {
+	t.Helper()
+	url := os.Getenv("ALERT_CENTER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("ALERT_CENTER_TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	db := &Database{Pool: pool}
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestRunMigrationsTwice(t *testing.T) {
+	db := testDatabase(t)
+	// A second binary starting against the same database runs the list again.
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	var n int
+	if err := db.Pool.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'pending_notifications' AND column_name = 'delivered_channels'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("pending_notifications.delivered_channels missing after migrations")
+	}
+}
+
+var (
+	createdTable = regexp.MustCompile(`(?i)^CREATE TABLE IF NOT EXISTS (\w+)`)
+	alteredTable = regexp.MustCompile(`(?i)^(?:ALTER TABLE (?:IF EXISTS )?|CREATE (?:UNIQUE )?INDEX IF NOT EXISTS \w+ ON )(\w+)`)
+)
+
+// A fresh database runs the list once from the top, so a statement altering or indexing a table
+// created further down fails there even though existing databases never notice.
+func TestMigrationsCreateTablesBeforeAltering(t *testing.T) {
+	created := map[string]bool{}
+	for i, migration := range migrations {
+		stmt := strings.TrimSpace(migration)
+		if m := createdTable.FindStringSubmatch(stmt); m != nil {
+			created[strings.ToLower(m[1])] = true
+			continue
+		}
+		if m := alteredTable.FindStringSubmatch(stmt); m != nil && !created[strings.ToLower(m[1])] {
+			t.Errorf("migration %d runs before table %s is created: %s", i, m[1], stmt)
+		}
+	}
+}
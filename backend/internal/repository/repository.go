@@ -3,10 +3,14 @@ package repository
 import (
 	"alert-center/internal/models"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/viper"
 )
@@ -15,6 +19,11 @@ type Database struct {
 	Pool *pgxpool.Pool
 }
 
+// execer is implemented by both *pgxpool.Pool and pgx.Tx, so writes can run inside a caller's transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 func NewDatabase() (*Database, error) {
 	connStr := fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -131,19 +140,19 @@ func (r *BusinessGroupRepository) Create(ctx context.Context, group *models.Busi
 	group.UpdatedAt = time.Now()
 
 	_, err := r.db.Pool.Exec(ctx, `
-		INSERT INTO business_groups (id, name, description, parent_id, manager_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`, group.ID, group.Name, group.Description, group.ParentID, group.ManagerID, group.Status, group.CreatedAt, group.UpdatedAt)
+		INSERT INTO business_groups (id, name, slug, description, parent_id, manager_id, default_channel_id, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10)
+	`, group.ID, group.Name, group.Slug, group.Description, group.ParentID, group.ManagerID, group.DefaultChannelID, group.Status, group.CreatedAt, group.UpdatedAt)
 	return err
 }
 
 func (r *BusinessGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BusinessGroup, error) {
 	var group models.BusinessGroup
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, name, description, parent_id, manager_id, status, created_at, updated_at
+		SELECT id, name, COALESCE(slug, ''), description, parent_id, manager_id, default_channel_id, status, created_at, updated_at
 		FROM business_groups WHERE id = $1
-	`, id).Scan(&group.ID, &group.Name, &group.Description, &group.ParentID,
-		&group.ManagerID, &group.Status, &group.CreatedAt, &group.UpdatedAt)
+	`, id).Scan(&group.ID, &group.Name, &group.Slug, &group.Description, &group.ParentID,
+		&group.ManagerID, &group.DefaultChannelID, &group.Status, &group.CreatedAt, &group.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -153,14 +162,20 @@ func (r *BusinessGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 func (r *BusinessGroupRepository) List(ctx context.Context, page, pageSize int, status int) ([]models.BusinessGroup, int, error) {
 	offset := (page - 1) * pageSize
 
+	where := `
+		WHERE ($1 = -1 OR status = $1)`
+	args := []interface{}{status}
+	if cond, arg := groupScopeCondition(ctx, "id", len(args)+1); cond != "" {
+		where += " AND " + cond
+		args = append(args, arg)
+	}
+
 	var groups []models.BusinessGroup
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, name, description, parent_id, manager_id, status, created_at, updated_at
-		FROM business_groups
-		WHERE ($1 = -1 OR status = $1)
+		SELECT id, name, COALESCE(slug, ''), description, parent_id, manager_id, default_channel_id, status, created_at, updated_at
+		FROM business_groups`+where+fmt.Sprintf(`
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`, status, pageSize, offset)
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2), append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -168,20 +183,27 @@ func (r *BusinessGroupRepository) List(ctx context.Context, page, pageSize int,
 
 	for rows.Next() {
 		var group models.BusinessGroup
-		if err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.ParentID,
-			&group.ManagerID, &group.Status, &group.CreatedAt, &group.UpdatedAt); err != nil {
+		if err := rows.Scan(&group.ID, &group.Name, &group.Slug, &group.Description, &group.ParentID,
+			&group.ManagerID, &group.DefaultChannelID, &group.Status, &group.CreatedAt, &group.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		groups = append(groups, group)
 	}
 
 	var total int
-	r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM business_groups WHERE ($1 = -1 OR status = $1)`,
-		status).Scan(&total)
+	r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM business_groups`+where, args...).Scan(&total)
 
 	return groups, total, nil
 }
 
+// SetDefaultChannel sets the group's fallback channel; a nil channelID clears it.
+func (r *BusinessGroupRepository) SetDefaultChannel(ctx context.Context, id uuid.UUID, channelID *uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE business_groups SET default_channel_id = $1, updated_at = $2 WHERE id = $3
+	`, channelID, time.Now(), id)
+	return err
+}
+
 // AlertRule Repository
 type AlertRuleRepository struct {
 	db *Database
@@ -212,52 +234,138 @@ func (r *AlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule
 	if evalInterval <= 0 {
 		evalInterval = 60
 	}
+	resolveConfirmations := rule.ResolveConfirmations
+	if resolveConfirmations <= 0 {
+		resolveConfirmations = 1
+	}
+	notifyMode := rule.NotifyMode
+	if notifyMode == "" {
+		notifyMode = models.NotifyModePerSeries
+	}
+	topN := rule.AggregateTopN
+	if topN <= 0 {
+		topN = 10
+	}
+	evalMode, rangeDuration, rangeStep := ruleRangeSettings(rule)
 	_, err := r.db.Pool.Exec(ctx, `
 		INSERT INTO alert_rules (id, name, description, expression, evaluation_interval_seconds, for_duration, severity,
 			labels, annotations, template_id, group_id, data_source_type, data_source_url, status,
-			effective_start_time, effective_end_time, exclusion_windows, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+			effective_start_time, effective_end_time, exclusion_windows, created_at, updated_at, slug, severity_label, resolve_confirmations, value_format,
+			notify_mode, aggregate_top_n, notify_on_resolve, evaluation_mode, range_duration, range_step, range_condition,
+			comparison_operator, threshold, group_recovery, data_source_ids)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULLIF($20, ''), $21, $22, $23, $24, $25, $26,
+			$27, $28, $29, $30, $31, $32, $33, $34)
 	`, rule.ID, rule.Name, rule.Description, rule.Expression, evalInterval, rule.ForDuration, rule.Severity,
 		rule.Labels, rule.Annotations, rule.TemplateID, rule.GroupID, rule.DataSourceType,
-		rule.DataSourceURL, rule.Status, effectiveStart, effectiveEnd, excl, rule.CreatedAt, rule.UpdatedAt)
+		rule.DataSourceURL, rule.Status, effectiveStart, effectiveEnd, excl, rule.CreatedAt, rule.UpdatedAt, rule.Slug, rule.SeverityLabel, resolveConfirmations, rule.ValueFormat,
+		notifyMode, topN, rule.NotifyOnResolve, evalMode, rangeDuration, rangeStep, rule.RangeCondition,
+		rule.ComparisonOperator, rule.Threshold, rule.GroupRecovery, dataSourceIDsJSON(rule.DataSourceIDs))
 	return err
 }
 
 func (r *AlertRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertRule, error) {
-	var rule models.AlertRule
-	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, name, description, expression, COALESCE(evaluation_interval_seconds, 60), for_duration, severity, labels, annotations,
-			template_id, group_id, data_source_type, data_source_url, status,
-			COALESCE(effective_start_time, '00:00'), COALESCE(effective_end_time, '23:59'), COALESCE(exclusion_windows::text, '[]'),
-			created_at, updated_at
-		FROM alert_rules WHERE id = $1
-	`, id).Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Expression, &rule.EvaluationIntervalSeconds, &rule.ForDuration,
-		&rule.Severity, &rule.Labels, &rule.Annotations, &rule.TemplateID, &rule.GroupID,
-		&rule.DataSourceType, &rule.DataSourceURL, &rule.Status,
-		&rule.EffectiveStartTime, &rule.EffectiveEndTime, &rule.ExclusionWindows, &rule.CreatedAt, &rule.UpdatedAt)
+	rule, err := scanAlertRule(r.db.Pool.QueryRow(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id))
 	if err != nil {
 		return nil, err
 	}
 	return &rule, nil
 }
 
+// GetBySlug returns the rule with the given slug.
+func (r *AlertRuleRepository) GetBySlug(ctx context.Context, slug string) (*models.AlertRule, error) {
+	var id uuid.UUID
+	if err := r.db.Pool.QueryRow(ctx, `SELECT id FROM alert_rules WHERE slug = $1`, slug).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// GetByNameAndGroup returns the rule with the given name in the given business group.
+func (r *AlertRuleRepository) GetByNameAndGroup(ctx context.Context, name string, groupID uuid.UUID) (*models.AlertRule, error) {
+	var id uuid.UUID
+	if err := r.db.Pool.QueryRow(ctx, `
+		SELECT id FROM alert_rules WHERE name = $1 AND group_id = $2
+		ORDER BY created_at LIMIT 1
+	`, name, groupID).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// alertRuleColumns selects an alert_rules row for scanAlertRule.
+const alertRuleColumns = `id, name, description, expression, COALESCE(evaluation_interval_seconds, 60), for_duration, severity, labels, annotations,
+	template_id, group_id, data_source_type, data_source_url, status,
+	COALESCE(effective_start_time, '00:00'), COALESCE(effective_end_time, '23:59'), COALESCE(exclusion_windows::text, '[]'),
+	created_at, updated_at, COALESCE(slug, ''), COALESCE(severity_label, ''),
+	COALESCE(resolve_confirmations, 1), COALESCE(value_format, ''),
+	COALESCE(notify_mode, 'per_series'), COALESCE(aggregate_top_n, 10), COALESCE(notify_on_resolve, TRUE),
+	COALESCE(evaluation_mode, 'instant'), COALESCE(range_duration, 600), COALESCE(range_step, 60), COALESCE(range_condition, ''),
+	COALESCE(comparison_operator, ''), threshold, COALESCE(group_recovery, FALSE),
+	last_evaluated_at, COALESCE(last_eval_status, ''), COALESCE(last_eval_error, ''), last_eval_data_source_id,
+	COALESCE(data_source_ids::text, '[]')`
+
+func scanAlertRule(row pgx.Row) (models.AlertRule, error) {
+	var rule models.AlertRule
+	var dataSourceIDs string
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Expression, &rule.EvaluationIntervalSeconds, &rule.ForDuration,
+		&rule.Severity, &rule.Labels, &rule.Annotations, &rule.TemplateID, &rule.GroupID,
+		&rule.DataSourceType, &rule.DataSourceURL, &rule.Status,
+		&rule.EffectiveStartTime, &rule.EffectiveEndTime, &rule.ExclusionWindows, &rule.CreatedAt, &rule.UpdatedAt, &rule.Slug, &rule.SeverityLabel,
+		&rule.ResolveConfirmations, &rule.ValueFormat, &rule.NotifyMode, &rule.AggregateTopN, &rule.NotifyOnResolve,
+		&rule.EvaluationMode, &rule.RangeDuration, &rule.RangeStep, &rule.RangeCondition,
+		&rule.ComparisonOperator, &rule.Threshold, &rule.GroupRecovery,
+		&rule.LastEvaluatedAt, &rule.LastEvalStatus, &rule.LastEvalError, &rule.LastEvalDataSourceID,
+		&dataSourceIDs)
+	if err == nil {
+		json.Unmarshal([]byte(dataSourceIDs), &rule.DataSourceIDs)
+	}
+	return rule, err
+}
+
+// dataSourceIDsJSON encodes a rule's data_source_ids, storing none as an empty array.
+func dataSourceIDsJSON(ids []uuid.UUID) string {
+	if len(ids) == 0 {
+		return "[]"
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+// ruleRangeSettings returns the rule's evaluation mode, range duration and range step with defaults applied.
+func ruleRangeSettings(rule *models.AlertRule) (string, int, int) {
+	mode := rule.EvaluationMode
+	if mode == "" {
+		mode = models.EvaluationModeInstant
+	}
+	duration := rule.RangeDuration
+	if duration <= 0 {
+		duration = 600
+	}
+	step := rule.RangeStep
+	if step <= 0 {
+		step = 60
+	}
+	return mode, duration, step
+}
+
 func (r *AlertRuleRepository) List(ctx context.Context, page, pageSize int, groupID *uuid.UUID, severity, status string) ([]models.AlertRule, int, error) {
 	offset := (page - 1) * pageSize
 
-	query := `
-		SELECT id, name, description, expression, COALESCE(evaluation_interval_seconds, 60), for_duration, severity, labels, annotations,
-			template_id, group_id, data_source_type, data_source_url, status,
-			COALESCE(effective_start_time, '00:00'), COALESCE(effective_end_time, '23:59'), COALESCE(exclusion_windows::text, '[]'),
-			created_at, updated_at
-		FROM alert_rules
+	where := `
 		WHERE ($1::uuid IS NULL OR group_id = $1)
 			AND ($2 = '' OR severity = $2)
-			AND ($3 = '' OR status::text = $3)
-		ORDER BY created_at DESC
-		LIMIT $4 OFFSET $5
-	`
+			AND ($3 = '' OR status::text = $3)`
+	args := []interface{}{groupID, severity, status}
+	if cond, arg := groupScopeCondition(ctx, "group_id", len(args)+1); cond != "" {
+		where += " AND " + cond
+		args = append(args, arg)
+	}
 
-	rows, err := r.db.Pool.Query(ctx, query, groupID, severity, status, pageSize, offset)
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules`+where+fmt.Sprintf(`
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2), append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -265,28 +373,45 @@ func (r *AlertRuleRepository) List(ctx context.Context, page, pageSize int, grou
 
 	var rules []models.AlertRule
 	for rows.Next() {
-		var rule models.AlertRule
-		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Expression, &rule.EvaluationIntervalSeconds, &rule.ForDuration,
-			&rule.Severity, &rule.Labels, &rule.Annotations, &rule.TemplateID, &rule.GroupID,
-			&rule.DataSourceType, &rule.DataSourceURL, &rule.Status,
-			&rule.EffectiveStartTime, &rule.EffectiveEndTime, &rule.ExclusionWindows, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
 			return nil, 0, err
 		}
 		rules = append(rules, rule)
 	}
 
 	var total int
-	countQuery := `
-		SELECT COUNT(*) FROM alert_rules
-		WHERE ($1::uuid IS NULL OR group_id = $1)
-			AND ($2 = '' OR severity = $2)
-			AND ($3 = '' OR status::text = $3)
-	`
-	r.db.Pool.QueryRow(ctx, countQuery, groupID, severity, status).Scan(&total)
+	r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM alert_rules`+where, args...).Scan(&total)
 
 	return rules, total, nil
 }
 
+// ListEnabledAfter returns up to limit enabled rules with an ID greater than after, ordered by ID, so
+// the worker can page through all rules in bounded batches. Pass uuid.Nil for the first batch.
+func (r *AlertRuleRepository) ListEnabledAfter(ctx context.Context, after uuid.UUID, limit int) ([]models.AlertRule, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules
+		WHERE status = 1 AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
 func (r *AlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
 	rule.UpdatedAt = time.Now()
 	effectiveStart := rule.EffectiveStartTime
@@ -305,15 +430,65 @@ func (r *AlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule
 	if evalInterval <= 0 {
 		evalInterval = 60
 	}
+	resolveConfirmations := rule.ResolveConfirmations
+	if resolveConfirmations <= 0 {
+		resolveConfirmations = 1
+	}
+	notifyMode := rule.NotifyMode
+	if notifyMode == "" {
+		notifyMode = models.NotifyModePerSeries
+	}
+	topN := rule.AggregateTopN
+	if topN <= 0 {
+		topN = 10
+	}
+	evalMode, rangeDuration, rangeStep := ruleRangeSettings(rule)
 	_, err := r.db.Pool.Exec(ctx, `
 		UPDATE alert_rules SET name=$1, description=$2, expression=$3, evaluation_interval_seconds=$4, for_duration=$5,
 			severity=$6, labels=$7, annotations=$8, template_id=$9, group_id=$10,
 			data_source_type=$11, data_source_url=$12, status=$13,
-			effective_start_time=$14, effective_end_time=$15, exclusion_windows=$16, updated_at=$17
-		WHERE id=$18
+			effective_start_time=$14, effective_end_time=$15, exclusion_windows=$16, updated_at=$17, slug=NULLIF($18, ''),
+			severity_label=$19, resolve_confirmations=$20, value_format=$21, notify_mode=$22, aggregate_top_n=$23,
+			notify_on_resolve=$24, evaluation_mode=$25, range_duration=$26, range_step=$27, range_condition=$28,
+			comparison_operator=$29, threshold=$30, group_recovery=$31, data_source_ids=$32
+		WHERE id=$33
 	`, rule.Name, rule.Description, rule.Expression, evalInterval, rule.ForDuration, rule.Severity,
 		rule.Labels, rule.Annotations, rule.TemplateID, rule.GroupID, rule.DataSourceType,
-		rule.DataSourceURL, rule.Status, effectiveStart, effectiveEnd, excl, rule.UpdatedAt, rule.ID)
+		rule.DataSourceURL, rule.Status, effectiveStart, effectiveEnd, excl, rule.UpdatedAt, rule.Slug, rule.SeverityLabel, resolveConfirmations, rule.ValueFormat,
+		notifyMode, topN, rule.NotifyOnResolve, evalMode, rangeDuration, rangeStep, rule.RangeCondition,
+		rule.ComparisonOperator, rule.Threshold, rule.GroupRecovery, dataSourceIDsJSON(rule.DataSourceIDs), rule.ID)
+	return err
+}
+
+// RuleEvaluation is the outcome of one rule evaluation, see models.RuleEvalOK. DataSourceID is the
+// registered data source that served it, if any.
+type RuleEvaluation struct {
+	RuleID       uuid.UUID
+	At           time.Time
+	Status       string
+	Error        string
+	DataSourceID *uuid.UUID
+}
+
+// RecordEvaluations stores the last evaluation outcome of each rule in one statement.
+func (r *AlertRuleRepository) RecordEvaluations(ctx context.Context, evals []RuleEvaluation) error {
+	if len(evals) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(evals))
+	ats := make([]time.Time, len(evals))
+	statuses := make([]string, len(evals))
+	errs := make([]string, len(evals))
+	sources := make([]*uuid.UUID, len(evals))
+	for i, e := range evals {
+		ids[i], ats[i], statuses[i], errs[i], sources[i] = e.RuleID, e.At, e.Status, e.Error, e.DataSourceID
+	}
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE alert_rules r SET last_evaluated_at = e.at, last_eval_status = e.status, last_eval_error = e.err,
+			last_eval_data_source_id = CASE WHEN e.status = 'error' THEN r.last_eval_data_source_id ELSE e.source END
+		FROM unnest($1::uuid[], $2::timestamp[], $3::text[], $4::text[], $5::uuid[]) AS e(id, at, status, err, source)
+		WHERE r.id = e.id
+	`, ids, ats, statuses, errs, sources)
 	return err
 }
 
@@ -322,6 +497,41 @@ func (r *AlertRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+// MoveToGroup moves the given rules to groupID in one transaction and returns the ids that exist and
+// were moved. It fails with pgx.ErrNoRows, moving nothing, when the group does not exist.
+func (r *AlertRuleRepository) MoveToGroup(ctx context.Context, ruleIDs []uuid.UUID, groupID uuid.UUID) ([]uuid.UUID, error) {
+	var moved []uuid.UUID
+	err := WithTx(ctx, r.db.Pool, func(ctx context.Context) error {
+		q := Conn(ctx, r.db.Pool)
+		// Lock the group so it cannot be deleted before the move commits.
+		var id uuid.UUID
+		if err := q.QueryRow(ctx, `SELECT id FROM business_groups WHERE id = $1 FOR SHARE`, groupID).Scan(&id); err != nil {
+			return fmt.Errorf("business group %s: %w", groupID, err)
+		}
+		rows, err := q.Query(ctx, `
+			UPDATE alert_rules SET group_id = $1, updated_at = $2
+			WHERE id = ANY($3)
+			RETURNING id
+		`, groupID, time.Now(), ruleIDs)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			moved = append(moved, id)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
 // AlertChannel Repository
 type AlertChannelRepository struct {
 	db *Database
@@ -337,9 +547,9 @@ func (r *AlertChannelRepository) Create(ctx context.Context, channel *models.Ale
 	channel.UpdatedAt = time.Now()
 
 	_, err := r.db.Pool.Exec(ctx, `
-		INSERT INTO alert_channels (id, name, type, description, config, group_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`, channel.ID, channel.Name, channel.Type, channel.Description, channel.Config,
+		INSERT INTO alert_channels (id, name, slug, type, description, config, group_id, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10)
+	`, channel.ID, channel.Name, channel.Slug, channel.Type, channel.Description, channel.Config,
 		channel.GroupID, channel.Status, channel.CreatedAt, channel.UpdatedAt)
 	return err
 }
@@ -347,9 +557,9 @@ func (r *AlertChannelRepository) Create(ctx context.Context, channel *models.Ale
 func (r *AlertChannelRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertChannel, error) {
 	var ch models.AlertChannel
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, name, type, description, config, group_id, status, created_at, updated_at
+		SELECT id, name, COALESCE(slug, ''), type, description, config, group_id, status, created_at, updated_at
 		FROM alert_channels WHERE id = $1
-	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
+	`, id).Scan(&ch.ID, &ch.Name, &ch.Slug, &ch.Type, &ch.Description, &ch.Config,
 		&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -357,25 +567,57 @@ func (r *AlertChannelRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return &ch, nil
 }
 
+// GetBySlug returns the channel with the given slug.
+func (r *AlertChannelRepository) GetBySlug(ctx context.Context, slug string) (*models.AlertChannel, error) {
+	var id uuid.UUID
+	if err := r.db.Pool.QueryRow(ctx, `SELECT id FROM alert_channels WHERE slug = $1`, slug).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
 func (r *AlertChannelRepository) Update(ctx context.Context, channel *models.AlertChannel) error {
 	channel.UpdatedAt = time.Now()
 	_, err := r.db.Pool.Exec(ctx, `
-		UPDATE alert_channels SET name=$1, type=$2, description=$3, config=$4, group_id=$5, status=$6, updated_at=$7
-		WHERE id=$8
-	`, channel.Name, channel.Type, channel.Description, channel.Config, channel.GroupID, channel.Status, channel.UpdatedAt, channel.ID)
+		UPDATE alert_channels SET name=$1, type=$2, description=$3, config=$4, group_id=$5, status=$6, updated_at=$7, slug=NULLIF($8, '')
+		WHERE id=$9
+	`, channel.Name, channel.Type, channel.Description, channel.Config, channel.GroupID, channel.Status, channel.UpdatedAt, channel.Slug, channel.ID)
 	return err
 }
 
+// SoftDelete disables the channel (status = 0) and removes its rule bindings in one transaction, so
+// no rule sends to it any more. It returns pgx.ErrNoRows when the channel does not exist.
+func (r *AlertChannelRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	return WithTx(ctx, r.db.Pool, func(ctx context.Context) error {
+		q := Conn(ctx, r.db.Pool)
+		tag, err := q.Exec(ctx, `UPDATE alert_channels SET status = 0, updated_at = $1 WHERE id = $2`, time.Now(), id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		_, err = q.Exec(ctx, `DELETE FROM alert_channel_bindings WHERE channel_id = $1`, id)
+		return err
+	})
+}
+
 func (r *AlertChannelRepository) List(ctx context.Context, page, pageSize int, channelType string, status int) ([]models.AlertChannel, int, error) {
 	offset := (page - 1) * pageSize
 
+	where := `
+		WHERE ($1 = '' OR type = $1) AND ($2 = -1 OR status = $2)`
+	args := []interface{}{channelType, status}
+	if cond, arg := groupScopeCondition(ctx, "group_id", len(args)+1); cond != "" {
+		where += " AND " + cond
+		args = append(args, arg)
+	}
+
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, name, type, description, config, group_id, status, created_at, updated_at
-		FROM alert_channels
-		WHERE ($1 = '' OR type = $1) AND ($2 = -1 OR status = $2)
+		SELECT id, name, COALESCE(slug, ''), type, description, config, group_id, status, created_at, updated_at
+		FROM alert_channels`+where+fmt.Sprintf(`
 		ORDER BY created_at DESC
-		LIMIT $3 OFFSET $4
-	`, channelType, status, pageSize, offset)
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2), append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -384,7 +626,7 @@ func (r *AlertChannelRepository) List(ctx context.Context, page, pageSize int, c
 	var channels []models.AlertChannel
 	for rows.Next() {
 		var ch models.AlertChannel
-		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Slug, &ch.Type, &ch.Description, &ch.Config,
 			&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
@@ -392,10 +634,7 @@ func (r *AlertChannelRepository) List(ctx context.Context, page, pageSize int, c
 	}
 
 	var total int
-	r.db.Pool.QueryRow(ctx, `
-		SELECT COUNT(*) FROM alert_channels
-		WHERE ($1 = '' OR type = $1) AND ($2 = -1 OR status = $2)
-	`, channelType, status).Scan(&total)
+	r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM alert_channels`+where, args...).Scan(&total)
 
 	return channels, total, nil
 }
@@ -417,6 +656,15 @@ func alertNo() string {
 }
 
 func (r *AlertHistoryRepository) Create(ctx context.Context, history *models.AlertHistory) error {
+	return r.create(ctx, r.db.Pool, history)
+}
+
+// CreateTx inserts the history record within tx.
+func (r *AlertHistoryRepository) CreateTx(ctx context.Context, tx pgx.Tx, history *models.AlertHistory) error {
+	return r.create(ctx, tx, history)
+}
+
+func (r *AlertHistoryRepository) create(ctx context.Context, db execer, history *models.AlertHistory) error {
 	history.ID = uuid.New()
 	history.CreatedAt = time.Now()
 	if history.AlertNo == "" {
@@ -432,7 +680,7 @@ func (r *AlertHistoryRepository) Create(ctx context.Context, history *models.Ale
 		annotations = "{}"
 	}
 
-	_, err := r.db.Pool.Exec(ctx, `
+	_, err := db.Exec(ctx, `
 		INSERT INTO alert_history (id, alert_no, rule_id, fingerprint, severity, status, started_at, ended_at, labels, annotations, payload, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`, history.ID, history.AlertNo, history.RuleID, history.Fingerprint, history.Severity, history.Status,
@@ -441,7 +689,7 @@ func (r *AlertHistoryRepository) Create(ctx context.Context, history *models.Ale
 }
 
 func (r *AlertHistoryRepository) List(ctx context.Context, page, pageSize int, ruleID *uuid.UUID, status string,
-	startTime, endTime *time.Time) ([]models.AlertHistory, int, error) {
+	startTime, endTime *time.Time, label LabelFilter) ([]models.AlertHistory, int, error) {
 
 	if page < 1 {
 		page = 1
@@ -453,6 +701,42 @@ func (r *AlertHistoryRepository) List(ctx context.Context, page, pageSize int, r
 	if offset < 0 {
 		offset = 0
 	}
+	where, args := historyFilter(ctx, ruleID, status, startTime, endTime, label)
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, COALESCE(alert_no, ''), rule_id, fingerprint, severity, status, started_at, ended_at,
+			COALESCE(labels::text, ''), COALESCE(annotations::text, ''), payload, created_at,
+			acked_by, COALESCE(acked_by_name, ''), acked_at
+		FROM alert_history`+where+fmt.Sprintf(`
+		ORDER BY started_at DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2), append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var histories []models.AlertHistory
+	for rows.Next() {
+		var h models.AlertHistory
+		if err := rows.Scan(&h.ID, &h.AlertNo, &h.RuleID, &h.Fingerprint, &h.Severity, &h.Status,
+			&h.StartedAt, &h.EndedAt, &h.Labels, &h.Annotations, &h.Payload, &h.CreatedAt,
+			&h.AckedBy, &h.AckedByName, &h.AckedAt); err != nil {
+			return nil, 0, err
+		}
+		histories = append(histories, h)
+	}
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	return histories, total, nil
+}
+
+// historyFilter returns the WHERE clause and arguments shared by List and Export: optional rule and
+// status, started_at within the times (unbounded when nil), the label filter and the group scope in ctx.
+func historyFilter(ctx context.Context, ruleID *uuid.UUID, status string, startTime, endTime *time.Time,
+	label LabelFilter) (string, []interface{}) {
 	// Use sentinel times when nil so PostgreSQL gets typed params (avoids 42P08)
 	startArg := startTime
 	if startArg == nil {
@@ -465,41 +749,219 @@ func (r *AlertHistoryRepository) List(ctx context.Context, page, pageSize int, r
 		endArg = &t
 	}
 
-	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, COALESCE(alert_no, ''), rule_id, fingerprint, severity, status, started_at, ended_at,
-			COALESCE(labels::text, ''), COALESCE(annotations::text, ''), payload, created_at
-		FROM alert_history
+	where := `
 		WHERE ($1::uuid IS NULL OR rule_id = $1)
 			AND ($2 = '' OR status = $2)
-			AND (started_at >= $3 AND started_at <= $4)
-		ORDER BY started_at DESC
-		LIMIT $5 OFFSET $6
-	`, ruleID, status, startArg, endArg, pageSize, offset)
+			AND (started_at >= $3 AND started_at <= $4)`
+	args := []interface{}{ruleID, status, startArg, endArg}
+	if cond, arg := label.Condition("labels", len(args)+1); cond != "" {
+		where += " AND " + cond
+		args = append(args, arg)
+	}
+	if cond, arg := groupScopeCondition(ctx, "group_id", len(args)+1); cond != "" {
+		where += " AND rule_id IN (SELECT id FROM alert_rules WHERE " + cond + ")"
+		args = append(args, arg)
+	}
+	return where, args
+}
+
+// Export calls fn for every alert matching the filters of List, newest first, without paging, so callers
+// can stream large exports. It stops at the first error fn returns.
+func (r *AlertHistoryRepository) Export(ctx context.Context, ruleID *uuid.UUID, status string,
+	startTime, endTime *time.Time, label LabelFilter, fn func(*models.AlertHistory) error) error {
+	where, args := historyFilter(ctx, ruleID, status, startTime, endTime, label)
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, COALESCE(alert_no, ''), rule_id, fingerprint, severity, status, started_at, ended_at,
+			COALESCE(labels::text, ''), COALESCE(annotations::text, ''), payload, created_at,
+			acked_by, COALESCE(acked_by_name, ''), acked_at
+		FROM alert_history`+where+`
+		ORDER BY started_at DESC`, args...)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
 	defer rows.Close()
 
-	var histories []models.AlertHistory
 	for rows.Next() {
 		var h models.AlertHistory
 		if err := rows.Scan(&h.ID, &h.AlertNo, &h.RuleID, &h.Fingerprint, &h.Severity, &h.Status,
-			&h.StartedAt, &h.EndedAt, &h.Labels, &h.Annotations, &h.Payload, &h.CreatedAt); err != nil {
-			return nil, 0, err
+			&h.StartedAt, &h.EndedAt, &h.Labels, &h.Annotations, &h.Payload, &h.CreatedAt,
+			&h.AckedBy, &h.AckedByName, &h.AckedAt); err != nil {
+			return err
+		}
+		if err := fn(&h); err != nil {
+			return err
 		}
-		histories = append(histories, h)
 	}
+	return rows.Err()
+}
 
-	var total int
-	if err := r.db.Pool.QueryRow(ctx, `
-		SELECT COUNT(*) FROM alert_history
-		WHERE ($1::uuid IS NULL OR rule_id = $1)
-			AND ($2 = '' OR status = $2)
-			AND (started_at >= $3 AND started_at <= $4)
-	`, ruleID, status, startArg, endArg).Scan(&total); err != nil {
-		return nil, 0, err
+var (
+	// ErrAlertAcknowledged is returned when acknowledging an alert that is already acknowledged.
+	ErrAlertAcknowledged = errors.New("alert already acknowledged")
+	// ErrAlertResolved is returned when acknowledging an alert that is already resolved.
+	ErrAlertResolved = errors.New("alert already resolved")
+)
+
+// Acknowledge records that user acknowledged the firing alert at the given time and, when the alert has
+// an SLA record, sets its first_acked_at, response_time_secs (from the alert start) and status
+// acknowledged. It returns the updated alert and the business group of its rule (nil when unknown),
+// pgx.ErrNoRows when it does not exist or is outside the group scope of ctx, or ErrAlertAcknowledged /
+// ErrAlertResolved.
+func (r *AlertHistoryRepository) Acknowledge(ctx context.Context, id, userID uuid.UUID, username string, at time.Time) (*models.AlertHistory, *uuid.UUID, error) {
+	var h models.AlertHistory
+	var groupID *uuid.UUID
+	where := "h.id = $1"
+	args := []interface{}{id}
+	// An alert outside the caller's groups is reported as not found, as the list reads hide it.
+	if cond, arg := groupScopeCondition(ctx, "r.group_id", len(args)+1); cond != "" {
+		where += " AND " + cond
+		args = append(args, arg)
 	}
-	return histories, total, nil
+	err := WithTx(ctx, r.db.Pool, func(ctx context.Context) error {
+		q := Conn(ctx, r.db.Pool)
+		err := q.QueryRow(ctx, `
+			SELECT h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(h.fingerprint, ''), COALESCE(h.severity, ''), COALESCE(h.status, ''),
+				h.started_at, h.ended_at, COALESCE(h.labels::text, ''), h.acked_at, r.group_id
+			FROM alert_history h
+			LEFT JOIN alert_rules r ON r.id = h.rule_id
+			WHERE `+where+`
+			FOR UPDATE OF h
+		`, args...).Scan(&h.ID, &h.AlertNo, &h.RuleID, &h.Fingerprint, &h.Severity, &h.Status,
+			&h.StartedAt, &h.EndedAt, &h.Labels, &h.AckedAt, &groupID)
+		if err != nil {
+			return err
+		}
+		if h.AckedAt != nil {
+			return ErrAlertAcknowledged
+		}
+		if h.Status == "resolved" {
+			return ErrAlertResolved
+		}
+
+		if _, err := q.Exec(ctx, `
+			UPDATE alert_history SET acked_by = $1, acked_by_name = $2, acked_at = $3 WHERE id = $4
+		`, userID, username, at, id); err != nil {
+			return err
+		}
+		h.AckedBy, h.AckedByName, h.AckedAt = &userID, username, &at
+		_, err = q.Exec(ctx, `
+			UPDATE alert_slas SET first_acked_at = $1, response_time_secs = $2, status = 'acknowledged'
+			WHERE alert_id = $3 AND first_acked_at IS NULL
+		`, at, at.Sub(h.StartedAt).Seconds(), id)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &h, groupID, nil
+}
+
+// ListActive returns every firing alert with its rule and business group, SLA state and acknowledgement, ordered
+// unacknowledged first, then by severity (severityOrder, most severe first; unknown last), then oldest
+// first. Ages are computed against now.
+func (r *AlertHistoryRepository) ListActive(ctx context.Context, severityOrder []string, now time.Time) ([]models.ActiveAlert, error) {
+	scope := ""
+	args := []interface{}{severityOrder}
+	if cond, arg := groupScopeCondition(ctx, "r.group_id", len(args)+1); cond != "" {
+		scope = " AND " + cond
+		args = append(args, arg)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(r.name, ''), r.group_id, COALESCE(g.name, ''),
+			COALESCE(h.fingerprint, ''),
+			COALESCE(h.severity, ''), COALESCE(h.labels::text, '{}'), h.started_at,
+			COALESCE(h.acked_by_name, ''), COALESCE(h.acked_at, s.first_acked_at),
+			COALESCE(s.status, ''), s.response_deadline, s.resolution_deadline,
+			COALESCE(s.response_breached, FALSE), COALESCE(s.resolution_breached, FALSE)
+		FROM alert_history h
+		LEFT JOIN alert_rules r ON r.id = h.rule_id
+		LEFT JOIN business_groups g ON g.id = r.group_id
+		LEFT JOIN LATERAL (
+			SELECT status, response_deadline, resolution_deadline, response_breached, resolution_breached, first_acked_at
+			FROM alert_slas WHERE alert_id = h.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) s ON TRUE
+		WHERE h.status = 'firing'`+scope+`
+		ORDER BY (COALESCE(h.acked_at, s.first_acked_at) IS NOT NULL),
+			array_position($1::text[], h.severity::text) NULLS LAST,
+			h.started_at
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.ActiveAlert{}
+	for rows.Next() {
+		var a models.ActiveAlert
+		if err := rows.Scan(&a.ID, &a.AlertNo, &a.RuleID, &a.RuleName, &a.GroupID, &a.GroupName, &a.Fingerprint,
+			&a.Severity, &a.Labels, &a.StartedAt,
+			&a.AckedByName, &a.AckedAt,
+			&a.SLAStatus, &a.ResponseDeadline, &a.ResolutionDeadline,
+			&a.ResponseBreached, &a.ResolutionBreached); err != nil {
+			return nil, err
+		}
+		a.Acked = a.AckedAt != nil
+		if age := now.Sub(a.StartedAt); age > 0 {
+			a.AgeSecs = int64(age / time.Second)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// GetByID returns the alert with its rule, business group and latest SLA, or pgx.ErrNoRows when it does
+// not exist or is outside the group scope in ctx.
+func (r *AlertHistoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertHistoryDetail, error) {
+	return r.getDetail(ctx, "h.id = $1", id)
+}
+
+// GetByNo is GetByID by alert_no.
+func (r *AlertHistoryRepository) GetByNo(ctx context.Context, alertNo string) (*models.AlertHistoryDetail, error) {
+	return r.getDetail(ctx, "h.alert_no = $1", alertNo)
+}
+
+func (r *AlertHistoryRepository) getDetail(ctx context.Context, cond string, arg interface{}) (*models.AlertHistoryDetail, error) {
+	args := []interface{}{arg}
+	if scope, scopeArg := groupScopeCondition(ctx, "r.group_id", len(args)+1); scope != "" {
+		cond += " AND " + scope
+		args = append(args, scopeArg)
+	}
+
+	var d models.AlertHistoryDetail
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(h.fingerprint, ''), COALESCE(h.severity, ''),
+			COALESCE(h.status, ''), h.started_at, h.ended_at,
+			COALESCE(h.labels::text, '{}'), COALESCE(h.annotations::text, '{}'), COALESCE(h.payload, ''), h.created_at,
+			h.acked_by, COALESCE(h.acked_by_name, ''), h.acked_at,
+			COALESCE(r.name, ''), r.group_id, COALESCE(g.name, ''),
+			COALESCE(s.status, ''), s.response_deadline, s.resolution_deadline,
+			COALESCE(s.response_breached, FALSE), COALESCE(s.resolution_breached, FALSE)
+		FROM alert_history h
+		LEFT JOIN alert_rules r ON r.id = h.rule_id
+		LEFT JOIN business_groups g ON g.id = r.group_id
+		LEFT JOIN LATERAL (
+			SELECT status, response_deadline, resolution_deadline, response_breached, resolution_breached
+			FROM alert_slas WHERE alert_id = h.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) s ON TRUE
+		WHERE `+cond+`
+		ORDER BY h.started_at DESC
+		LIMIT 1
+	`, args...).Scan(&d.ID, &d.AlertNo, &d.RuleID, &d.Fingerprint, &d.Severity,
+		&d.Status, &d.StartedAt, &d.EndedAt,
+		&d.Labels, &d.Annotations, &d.Payload, &d.CreatedAt,
+		&d.AckedBy, &d.AckedByName, &d.AckedAt,
+		&d.RuleName, &d.GroupID, &d.GroupName,
+		&d.SLAStatus, &d.ResponseDeadline, &d.ResolutionDeadline,
+		&d.ResponseBreached, &d.ResolutionBreached)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
 }
 
 // GetLatestFiringByRuleAndFingerprint returns the most recent alert_history row with status='firing' for the given rule and fingerprint.
@@ -520,9 +982,73 @@ func (r *AlertHistoryRepository) GetLatestFiringByRuleAndFingerprint(ctx context
 	return &h, nil
 }
 
+// FiringAlert identifies a firing alert_history row by its rule and series.
+type FiringAlert struct {
+	RuleID      uuid.UUID
+	Fingerprint string
+	StartedAt   time.Time
+}
+
+// ListFiring returns the firing alerts of enabled rules, one per (rule_id, fingerprint) with its latest
+// start, so the worker can restore its dedup state after a restart.
+func (r *AlertHistoryRepository) ListFiring(ctx context.Context) ([]FiringAlert, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT DISTINCT ON (h.rule_id, h.fingerprint) h.rule_id, h.fingerprint, h.started_at
+		FROM alert_history h
+		JOIN alert_rules r ON r.id = h.rule_id
+		WHERE h.status = 'firing' AND r.status = 1
+		ORDER BY h.rule_id, h.fingerprint, h.started_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var firing []FiringAlert
+	for rows.Next() {
+		var f FiringAlert
+		if err := rows.Scan(&f.RuleID, &f.Fingerprint, &f.StartedAt); err != nil {
+			return nil, err
+		}
+		firing = append(firing, f)
+	}
+	return firing, rows.Err()
+}
+
+// RecentOccurrences returns how many alerts for (rule_id, fingerprint) started at or after since, and
+// when the most recently resolved one ended (nil if none has resolved).
+// CountFiringByRule returns the number of distinct series of the rule currently firing.
+func (r *AlertHistoryRepository) CountFiringByRule(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT fingerprint) FROM alert_history WHERE rule_id = $1 AND status = 'firing'
+	`, ruleID).Scan(&n)
+	return n, err
+}
+
+func (r *AlertHistoryRepository) RecentOccurrences(ctx context.Context, ruleID uuid.UUID, fingerprint string, since time.Time) (int, *time.Time, error) {
+	var count int
+	var lastResolved *time.Time
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE started_at >= $3), MAX(ended_at) FILTER (WHERE status = 'resolved')
+		FROM alert_history
+		WHERE rule_id = $1 AND fingerprint = $2
+	`, ruleID, fingerprint, since).Scan(&count, &lastResolved)
+	return count, lastResolved, err
+}
+
 // MarkResolvedByRuleAndFingerprint sets the latest firing record for (rule_id, fingerprint) to status='resolved' and ended_at.
 func (r *AlertHistoryRepository) MarkResolvedByRuleAndFingerprint(ctx context.Context, ruleID uuid.UUID, fingerprint string, endedAt time.Time) error {
-	_, err := r.db.Pool.Exec(ctx, `
+	return r.markResolvedByRuleAndFingerprint(ctx, r.db.Pool, ruleID, fingerprint, endedAt)
+}
+
+// MarkResolvedByRuleAndFingerprintTx resolves the latest firing record within tx.
+func (r *AlertHistoryRepository) MarkResolvedByRuleAndFingerprintTx(ctx context.Context, tx pgx.Tx, ruleID uuid.UUID, fingerprint string, endedAt time.Time) error {
+	return r.markResolvedByRuleAndFingerprint(ctx, tx, ruleID, fingerprint, endedAt)
+}
+
+func (r *AlertHistoryRepository) markResolvedByRuleAndFingerprint(ctx context.Context, db execer, ruleID uuid.UUID, fingerprint string, endedAt time.Time) error {
+	_, err := db.Exec(ctx, `
 		UPDATE alert_history SET status = 'resolved', ended_at = $1
 		WHERE id = (
 			SELECT id FROM alert_history
@@ -534,45 +1060,58 @@ func (r *AlertHistoryRepository) MarkResolvedByRuleAndFingerprint(ctx context.Co
 	return err
 }
 
-func (r *AlertHistoryRepository) GetStatistics(ctx context.Context, startTime, endTime *time.Time, groupID *uuid.UUID) (map[string]interface{}, error) {
+// GetStatistics returns total/firing/resolved counts plus one count per severity. Every name in
+// severities is present (zero when unused); severities seen in history but not listed are included too.
+func (r *AlertHistoryRepository) GetStatistics(ctx context.Context, startTime, endTime *time.Time, groupID *uuid.UUID, severities []string) (map[string]interface{}, error) {
 	query := `
 		SELECT
+			COALESCE(severity, ''),
 			COUNT(*) as total,
 			COUNT(*) FILTER (WHERE status = 'firing') as firing,
-			COUNT(*) FILTER (WHERE status = 'resolved') as resolved,
-			COUNT(*) FILTER (WHERE severity = 'critical') as critical,
-			COUNT(*) FILTER (WHERE severity = 'warning') as warning,
-			COUNT(*) FILTER (WHERE severity = 'info') as info
+			COUNT(*) FILTER (WHERE status = 'resolved') as resolved
 		FROM alert_history
 		WHERE started_at >= $1 AND started_at <= $2
 			AND ($3::uuid IS NULL OR rule_id IN (
 				SELECT id FROM alert_rules WHERE group_id = $3
 			))
+		GROUP BY 1
 	`
 
-	var result struct {
-		Total    int `db:"total"`
-		Firing   int `db:"firing"`
-		Resolved int `db:"resolved"`
-		Critical int `db:"critical"`
-		Warning  int `db:"warning"`
-		Info     int `db:"info"`
+	rows, err := r.db.Pool.Query(ctx, query, startTime, endTime, groupID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	err := r.db.Pool.QueryRow(ctx, query, startTime, endTime, groupID).Scan(
-		&result.Total, &result.Firing, &result.Resolved, &result.Critical, &result.Warning, &result.Info)
-	if err != nil {
+	var total, firing, resolved int
+	bySeverity := make(map[string]int)
+	for _, sev := range severities {
+		bySeverity[sev] = 0
+	}
+	for rows.Next() {
+		var sev string
+		var t, f, res int
+		if err := rows.Scan(&sev, &t, &f, &res); err != nil {
+			return nil, err
+		}
+		total += t
+		firing += f
+		resolved += res
+		bySeverity[sev] += t
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"total":    result.Total,
-		"firing":   result.Firing,
-		"resolved": result.Resolved,
-		"critical": result.Critical,
-		"warning":  result.Warning,
-		"info":     result.Info,
-	}, nil
+	result := map[string]interface{}{
+		"total":    total,
+		"firing":   firing,
+		"resolved": resolved,
+	}
+	for sev, count := range bySeverity {
+		result[sev] = count
+	}
+	return result, nil
 }
 
 // SLA Config Repository
@@ -721,17 +1260,41 @@ func (r *OnCallScheduleRepository) GetByID(ctx context.Context, id uuid.UUID) (*
 	return &schedule, nil
 }
 
-func (r *OnCallScheduleRepository) List(ctx context.Context) ([]OnCallSchedule, error) {
-	rows, err := r.db.Pool.Query(ctx, `
+// List returns a page of schedules, newest first, and the number of schedules matching enabled; a nil
+// enabled matches all.
+func (r *OnCallScheduleRepository) List(ctx context.Context, page, pageSize int, enabled *bool) ([]OnCallSchedule, int, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM oncall_schedules WHERE ($1::boolean IS NULL OR enabled = $1)
+	`, enabled).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	schedules, err := r.query(ctx, `
+		SELECT id, name, description, timezone, rotation_type, rotation_start, enabled, created_at, updated_at
+		FROM oncall_schedules WHERE ($1::boolean IS NULL OR enabled = $1)
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3
+	`, enabled, pageSize, offset)
+	return schedules, total, err
+}
+
+// ListAll returns every schedule, newest first, for callers that resolve on-call across all schedules.
+func (r *OnCallScheduleRepository) ListAll(ctx context.Context) ([]OnCallSchedule, error) {
+	return r.query(ctx, `
 		SELECT id, name, description, timezone, rotation_type, rotation_start, enabled, created_at, updated_at
 		FROM oncall_schedules ORDER BY created_at DESC
 	`)
+}
+
+func (r *OnCallScheduleRepository) query(ctx context.Context, sql string, args ...interface{}) ([]OnCallSchedule, error) {
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var schedules []OnCallSchedule
+	schedules := []OnCallSchedule{}
 	for rows.Next() {
 		var schedule OnCallSchedule
 		if err := rows.Scan(&schedule.ID, &schedule.Name, &schedule.Description, &schedule.Timezone, &schedule.RotationType, &schedule.RotationStart, &schedule.Enabled, &schedule.CreatedAt, &schedule.UpdatedAt); err != nil {
@@ -739,7 +1302,7 @@ func (r *OnCallScheduleRepository) List(ctx context.Context) ([]OnCallSchedule,
 		}
 		schedules = append(schedules, schedule)
 	}
-	return schedules, nil
+	return schedules, rows.Err()
 }
 
 func (r *OnCallScheduleRepository) Update(ctx context.Context, schedule *OnCallSchedule) error {
@@ -836,29 +1399,91 @@ type OnCallAssignment struct {
 	Email      string    `db:"email" json:"email"`
 	Phone      string    `db:"phone" json:"phone"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	// OverrideID is set when an override hands this assignment's window to another user.
+	OverrideID *uuid.UUID `db:"-" json:"override_id,omitempty"`
 }
 
+// Assignment start_time and end_time are wall-clock times in the schedule's timezone, so rotation
+// boundaries stay put when the server or the database runs in another zone. The queries below convert
+// them to and from instants with scheduleTimezone.
+const scheduleTimezone = `(SELECT COALESCE(NULLIF(timezone, ''), 'UTC') FROM oncall_schedules WHERE id = $1)`
+
+const assignmentColumns = `a.id, a.schedule_id, a.user_id, a.username,
+	a.start_time AT TIME ZONE ` + scheduleTimezone + `, a.end_time AT TIME ZONE ` + scheduleTimezone + `,
+	u.email, COALESCE(m.phone, ''), a.created_at`
+
 func (r *OnCallAssignmentRepository) Create(ctx context.Context, assignment *OnCallAssignment) error {
 	assignment.ID = uuid.New()
 	assignment.CreatedAt = time.Now()
 
 	_, err := r.db.Pool.Exec(ctx, `
 		INSERT INTO oncall_assignments (id, schedule_id, user_id, username, start_time, end_time, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, assignment.ID, assignment.ScheduleID, assignment.UserID, assignment.Username, assignment.StartTime, assignment.EndTime, assignment.CreatedAt)
+		VALUES ($2, $1, $3, $4, $5::timestamptz AT TIME ZONE `+scheduleTimezone+`, $6::timestamptz AT TIME ZONE `+scheduleTimezone+`, $7)
+	`, assignment.ScheduleID, assignment.ID, assignment.UserID, assignment.Username, assignment.StartTime, assignment.EndTime, assignment.CreatedAt)
 	return err
 }
 
 func (r *OnCallAssignmentRepository) GetCurrentByScheduleID(ctx context.Context, scheduleID uuid.UUID) (*OnCallAssignment, error) {
+	return r.GetAtByScheduleID(ctx, scheduleID, time.Now())
+}
+
+// GetAtByScheduleID returns who is on call for the schedule at the instant at, evaluated in the
+// schedule's timezone: the covering assignment, or the override user with the override window when an
+// override of that assignment's user covers at.
+func (r *OnCallAssignmentRepository) GetAtByScheduleID(ctx context.Context, scheduleID uuid.UUID, at time.Time) (*OnCallAssignment, error) {
 	var assignment OnCallAssignment
-	now := time.Now()
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT a.id, a.schedule_id, a.user_id, a.username, a.start_time, a.end_time, u.email, COALESCE(m.phone, ''), a.created_at
+		SELECT `+assignmentColumns+`
 		FROM oncall_assignments a
 		LEFT JOIN users u ON a.user_id = u.id
 		LEFT JOIN oncall_members m ON a.schedule_id = m.schedule_id AND a.user_id = m.user_id
-		WHERE a.schedule_id = $1 AND a.start_time <= $2 AND a.end_time > $2
-	`, scheduleID, now).Scan(&assignment.ID, &assignment.ScheduleID, &assignment.UserID, &assignment.Username, &assignment.StartTime, &assignment.EndTime, &assignment.Email, &assignment.Phone, &assignment.CreatedAt)
+		WHERE a.schedule_id = $1
+			AND a.start_time <= $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+			AND a.end_time > $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+		ORDER BY a.start_time DESC
+		LIMIT 1
+	`, scheduleID, at).Scan(&assignment.ID, &assignment.ScheduleID, &assignment.UserID, &assignment.Username, &assignment.StartTime, &assignment.EndTime, &assignment.Email, &assignment.Phone, &assignment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrideID uuid.UUID
+	err = r.db.Pool.QueryRow(ctx, `
+		SELECT o.id, o.override_user_id, o.override_username,
+			o.start_time AT TIME ZONE `+scheduleTimezone+`, o.end_time AT TIME ZONE `+scheduleTimezone+`,
+			COALESCE(u.email, ''), COALESCE(m.phone, '')
+		FROM oncall_overrides o
+		LEFT JOIN users u ON o.override_user_id = u.id
+		LEFT JOIN oncall_members m ON o.schedule_id = m.schedule_id AND o.override_user_id = m.user_id
+		WHERE o.schedule_id = $1 AND o.original_user_id = $3
+			AND o.start_time <= $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+			AND o.end_time > $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+		ORDER BY o.created_at DESC
+		LIMIT 1
+	`, scheduleID, at, assignment.UserID).Scan(&overrideID, &assignment.UserID, &assignment.Username,
+		&assignment.StartTime, &assignment.EndTime, &assignment.Email, &assignment.Phone)
+	if err == nil {
+		assignment.OverrideID = &overrideID
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// GetCoveringByScheduleID returns the schedule's assignment whose window contains [startTime, endTime].
+func (r *OnCallAssignmentRepository) GetCoveringByScheduleID(ctx context.Context, scheduleID uuid.UUID, startTime, endTime time.Time) (*OnCallAssignment, error) {
+	var assignment OnCallAssignment
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT `+assignmentColumns+`
+		FROM oncall_assignments a
+		LEFT JOIN users u ON a.user_id = u.id
+		LEFT JOIN oncall_members m ON a.schedule_id = m.schedule_id AND a.user_id = m.user_id
+		WHERE a.schedule_id = $1
+			AND a.start_time <= $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+			AND a.end_time >= $3::timestamptz AT TIME ZONE `+scheduleTimezone+`
+		ORDER BY a.start_time DESC
+		LIMIT 1
+	`, scheduleID, startTime, endTime).Scan(&assignment.ID, &assignment.ScheduleID, &assignment.UserID, &assignment.Username, &assignment.StartTime, &assignment.EndTime, &assignment.Email, &assignment.Phone, &assignment.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -867,11 +1492,13 @@ func (r *OnCallAssignmentRepository) GetCurrentByScheduleID(ctx context.Context,
 
 func (r *OnCallAssignmentRepository) GetByScheduleID(ctx context.Context, scheduleID uuid.UUID, startTime, endTime time.Time) ([]OnCallAssignment, error) {
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT a.id, a.schedule_id, a.user_id, a.username, a.start_time, a.end_time, u.email, COALESCE(m.phone, ''), a.created_at
+		SELECT `+assignmentColumns+`
 		FROM oncall_assignments a
 		LEFT JOIN users u ON a.user_id = u.id
 		LEFT JOIN oncall_members m ON a.schedule_id = m.schedule_id AND a.user_id = m.user_id
-		WHERE a.schedule_id = $1 AND a.start_time < $2 AND a.end_time > $3
+		WHERE a.schedule_id = $1
+			AND a.start_time < $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+			AND a.end_time > $3::timestamptz AT TIME ZONE `+scheduleTimezone+`
 		ORDER BY a.start_time ASC
 	`, scheduleID, endTime, startTime)
 	if err != nil {
@@ -890,6 +1517,107 @@ func (r *OnCallAssignmentRepository) GetByScheduleID(ctx context.Context, schedu
 	return assignments, nil
 }
 
+// ReplaceFrom deletes the schedule's assignments that have not ended by from and inserts assignments
+// in their place, in one transaction so regenerating a rotation never leaves it half empty.
+func (r *OnCallAssignmentRepository) ReplaceFrom(ctx context.Context, scheduleID uuid.UUID, from time.Time, assignments []OnCallAssignment) error {
+	return WithTx(ctx, r.db.Pool, func(ctx context.Context) error {
+		q := Conn(ctx, r.db.Pool)
+		if _, err := q.Exec(ctx, `
+			DELETE FROM oncall_assignments WHERE schedule_id = $1 AND end_time > $2::timestamptz AT TIME ZONE `+scheduleTimezone,
+			scheduleID, from); err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range assignments {
+			a := &assignments[i]
+			a.ID, a.ScheduleID, a.CreatedAt = uuid.New(), scheduleID, now
+			if _, err := q.Exec(ctx, `
+				INSERT INTO oncall_assignments (id, schedule_id, user_id, username, start_time, end_time, created_at)
+				VALUES ($2, $1, $3, $4, $5::timestamptz AT TIME ZONE `+scheduleTimezone+`, $6::timestamptz AT TIME ZONE `+scheduleTimezone+`, $7)
+			`, a.ScheduleID, a.ID, a.UserID, a.Username, a.StartTime, a.EndTime, a.CreatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// OnCall Override Repository
+type OnCallOverrideRepository struct {
+	db *Database
+}
+
+func NewOnCallOverrideRepository(db *Database) *OnCallOverrideRepository {
+	return &OnCallOverrideRepository{db: db}
+}
+
+// OnCallOverride hands part of an assignment to another user (a shift swap) without regenerating the
+// rotation. Like assignments, its times are stored as wall-clock times in the schedule's timezone.
+type OnCallOverride struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	ScheduleID       uuid.UUID `db:"schedule_id" json:"schedule_id"`
+	OriginalUserID   uuid.UUID `db:"original_user_id" json:"original_user_id"`
+	OriginalUsername string    `db:"original_username" json:"original_username"`
+	OverrideUserID   uuid.UUID `db:"override_user_id" json:"override_user_id"`
+	OverrideUsername string    `db:"override_username" json:"override_username"`
+	StartTime        time.Time `db:"start_time" json:"start_time"`
+	EndTime          time.Time `db:"end_time" json:"end_time"`
+	Reason           string    `db:"reason" json:"reason"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+// Create inserts the override, taking the override user's username from users. It returns
+// pgx.ErrNoRows when the override user does not exist.
+func (r *OnCallOverrideRepository) Create(ctx context.Context, override *OnCallOverride) error {
+	override.ID = uuid.New()
+	override.CreatedAt = time.Now()
+
+	return r.db.Pool.QueryRow(ctx, `
+		INSERT INTO oncall_overrides (id, schedule_id, original_user_id, original_username, override_user_id, override_username,
+			start_time, end_time, reason, created_at)
+		SELECT $2, $1, $3, $4, u.id, u.username,
+			$5::timestamptz AT TIME ZONE `+scheduleTimezone+`, $6::timestamptz AT TIME ZONE `+scheduleTimezone+`, $7, $8
+		FROM users u WHERE u.id = $9
+		RETURNING override_username
+	`, override.ScheduleID, override.ID, override.OriginalUserID, override.OriginalUsername, override.StartTime, override.EndTime,
+		override.Reason, override.CreatedAt, override.OverrideUserID).Scan(&override.OverrideUsername)
+}
+
+// ListByScheduleID returns the schedule's overrides that end after since, in start order.
+func (r *OnCallOverrideRepository) ListByScheduleID(ctx context.Context, scheduleID uuid.UUID, since time.Time) ([]OnCallOverride, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, schedule_id, original_user_id, original_username, override_user_id, override_username,
+			start_time AT TIME ZONE `+scheduleTimezone+`, end_time AT TIME ZONE `+scheduleTimezone+`, COALESCE(reason, ''), created_at
+		FROM oncall_overrides
+		WHERE schedule_id = $1 AND end_time > $2::timestamptz AT TIME ZONE `+scheduleTimezone+`
+		ORDER BY start_time ASC
+	`, scheduleID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []OnCallOverride{}
+	for rows.Next() {
+		var o OnCallOverride
+		if err := rows.Scan(&o.ID, &o.ScheduleID, &o.OriginalUserID, &o.OriginalUsername, &o.OverrideUserID, &o.OverrideUsername,
+			&o.StartTime, &o.EndTime, &o.Reason, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// Delete removes the schedule's override; it reports false when there was none.
+func (r *OnCallOverrideRepository) Delete(ctx context.Context, scheduleID, id uuid.UUID) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM oncall_overrides WHERE id = $1 AND schedule_id = $2`, id, scheduleID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // Alert SLA Repository (for tracking per-alert SLA)
 type AlertSLARepository struct {
 	db *Database
@@ -951,3 +1679,162 @@ func (r *AlertSLARepository) Update(ctx context.Context, sla *AlertSLA) error {
 		sla.ResponseTimeSecs, sla.ResolutionTimeSecs, sla.AlertID)
 	return err
 }
+
+// SLAReportBucket aggregates the alert SLAs of a report period, overall, for one severity or for one SLA
+// config. An alert has responded once acknowledged or resolved; a target is breached when flagged by
+// the breach checker, met late, or still open past its deadline. Met alerts met both targets, breached
+// alerts missed either, pending alerts have not responded yet and in-progress alerts responded but are
+// unresolved, both still within their deadlines. Compliance rates are met / (met + breached) in percent,
+// 100 when nothing is decided yet.
+type SLAReportBucket struct {
+	Severity                 string     `json:"severity,omitempty"`
+	SLAConfigID              *uuid.UUID `json:"sla_config_id,omitempty"`
+	SLAConfigName            string     `json:"sla_config_name,omitempty"`
+	TotalAlerts              int        `json:"total_alerts"`
+	MetCount                 int        `json:"met_count"`
+	BreachedCount            int        `json:"breached_count"`
+	PendingCount             int        `json:"pending_count"`
+	InProgressCount          int        `json:"in_progress_count"`
+	ResponseMet              int        `json:"response_met"`
+	ResponseBreached         int        `json:"response_breached"`
+	ResolutionMet            int        `json:"resolution_met"`
+	ResolutionBreached       int        `json:"resolution_breached"`
+	ComplianceRate           float64    `json:"compliance_rate"`
+	ResponseComplianceRate   float64    `json:"response_compliance_rate"`
+	ResolutionComplianceRate float64    `json:"resolution_compliance_rate"`
+	AvgResponseTimeSecs      float64    `json:"avg_response_time_secs"`
+	AvgResolutionTimeSecs    float64    `json:"avg_resolution_time_secs"`
+}
+
+// SLAReport is the SLA compliance of the alerts started in [PeriodStart, PeriodEnd).
+type SLAReport struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	SLAReportBucket
+	BySeverity []SLAReportBucket `json:"by_severity"`
+	ByConfig   []SLAReportBucket `json:"by_config"`
+}
+
+// complianceRate returns met / (met + breached) in percent, or 100 when neither.
+func complianceRate(met, breached int) float64 {
+	if met+breached == 0 {
+		return 100
+	}
+	return float64(met) * 100 / float64(met+breached)
+}
+
+// Report aggregates the alert SLAs created in [start, end), judging open deadlines against now, overall,
+// per severity (ordered by severityOrder, most severe first) and per SLA config. A non-nil groupID limits
+// it to the alerts of that business group's rules.
+func (r *AlertSLARepository) Report(ctx context.Context, start, end, now time.Time, severityOrder []string, groupID *uuid.UUID) (*SLAReport, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		WITH s AS (
+			SELECT a.severity, a.sla_config_id, c.name AS config_name, a.response_time_secs, a.resolution_time_secs,
+				COALESCE(a.first_acked_at, a.resolved_at) AS responded_at, a.resolved_at,
+				COALESCE(a.response_breached, FALSE)
+					OR COALESCE(COALESCE(a.first_acked_at, a.resolved_at) > a.response_deadline, FALSE)
+					OR (COALESCE(a.first_acked_at, a.resolved_at) IS NULL AND COALESCE(a.response_deadline <= $3, FALSE)) AS response_breached,
+				COALESCE(a.resolution_breached, FALSE)
+					OR COALESCE(a.resolved_at > a.resolution_deadline, FALSE)
+					OR (a.resolved_at IS NULL AND COALESCE(a.resolution_deadline <= $3, FALSE)) AS resolution_breached
+			FROM alert_slas a
+			LEFT JOIN sla_configs c ON c.id = a.sla_config_id
+			WHERE a.created_at >= $1 AND a.created_at < $2
+				AND ($5::uuid IS NULL OR a.rule_id IN (SELECT id FROM alert_rules WHERE group_id = $5))
+		)
+		SELECT GROUPING(severity), GROUPING(sla_config_id), COALESCE(severity, ''), sla_config_id, COALESCE(MAX(config_name), ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE NOT response_breached AND responded_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE response_breached),
+			COUNT(*) FILTER (WHERE NOT resolution_breached AND resolved_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE resolution_breached),
+			COUNT(*) FILTER (WHERE NOT response_breached AND NOT resolution_breached AND resolved_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE response_breached OR resolution_breached),
+			COUNT(*) FILTER (WHERE NOT response_breached AND NOT resolution_breached AND responded_at IS NULL),
+			COUNT(*) FILTER (WHERE NOT response_breached AND NOT resolution_breached AND responded_at IS NOT NULL AND resolved_at IS NULL),
+			COALESCE(AVG(response_time_secs), 0), COALESCE(AVG(resolution_time_secs), 0)
+		FROM s
+		GROUP BY GROUPING SETS ((), (severity), (sla_config_id))
+		ORDER BY array_position($4::text[], severity::text) NULLS LAST, severity, MAX(config_name)
+	`, start, end, now, severityOrder, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &SLAReport{PeriodStart: start, PeriodEnd: end, BySeverity: []SLAReportBucket{}, ByConfig: []SLAReportBucket{}}
+	report.ComplianceRate, report.ResponseComplianceRate, report.ResolutionComplianceRate = 100, 100, 100
+	for rows.Next() {
+		var b SLAReportBucket
+		var noSeverity, noConfig int
+		if err := rows.Scan(&noSeverity, &noConfig, &b.Severity, &b.SLAConfigID, &b.SLAConfigName,
+			&b.TotalAlerts, &b.ResponseMet, &b.ResponseBreached, &b.ResolutionMet, &b.ResolutionBreached,
+			&b.MetCount, &b.BreachedCount, &b.PendingCount, &b.InProgressCount,
+			&b.AvgResponseTimeSecs, &b.AvgResolutionTimeSecs); err != nil {
+			return nil, err
+		}
+		b.ComplianceRate = complianceRate(b.MetCount, b.BreachedCount)
+		b.ResponseComplianceRate = complianceRate(b.ResponseMet, b.ResponseBreached)
+		b.ResolutionComplianceRate = complianceRate(b.ResolutionMet, b.ResolutionBreached)
+		switch {
+		case noSeverity == 1 && noConfig == 1:
+			report.SLAReportBucket = b
+		case noConfig == 1:
+			report.BySeverity = append(report.BySeverity, b)
+		default:
+			b.Severity = ""
+			report.ByConfig = append(report.ByConfig, b)
+		}
+	}
+	return report, rows.Err()
+}
+
+// NotificationLog Repository
+type NotificationLogRepository struct {
+	db *Database
+}
+
+func NewNotificationLogRepository(db *Database) *NotificationLogRepository {
+	return &NotificationLogRepository{db: db}
+}
+
+// Create records one delivery attempt to a channel.
+func (r *NotificationLogRepository) Create(ctx context.Context, entry *models.NotificationLog) error {
+	entry.ID = uuid.New()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO notification_logs (id, alert_id, alert_no, rule_id, channel_id, channel_name, channel_type, alert_status,
+			success, http_status, attempts, error, created_at, latency_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, entry.ID, entry.AlertID, entry.AlertNo, entry.RuleID, entry.ChannelID, entry.ChannelName, entry.ChannelType, entry.AlertStatus,
+		entry.Success, entry.HTTPStatus, entry.Attempts, entry.Error, entry.CreatedAt, entry.LatencyMs)
+	return err
+}
+
+// ListByAlertID returns the delivery log of an alert, oldest first.
+func (r *NotificationLogRepository) ListByAlertID(ctx context.Context, alertID uuid.UUID) ([]models.NotificationLog, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, alert_id, COALESCE(alert_no, ''), rule_id, channel_id, COALESCE(channel_name, ''), channel_type,
+			COALESCE(alert_status, ''), success, COALESCE(http_status, 0), COALESCE(attempts, 0), COALESCE(error, ''), created_at,
+			latency_ms
+		FROM notification_logs WHERE alert_id = $1
+		ORDER BY created_at
+	`, alertID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []models.NotificationLog{}
+	for rows.Next() {
+		var l models.NotificationLog
+		if err := rows.Scan(&l.ID, &l.AlertID, &l.AlertNo, &l.RuleID, &l.ChannelID, &l.ChannelName, &l.ChannelType,
+			&l.AlertStatus, &l.Success, &l.HTTPStatus, &l.Attempts, &l.Error, &l.CreatedAt, &l.LatencyMs); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
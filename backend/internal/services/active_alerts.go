@@ -0,0 +1,82 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidGroupBy is returned for an active-alerts group_by other than rule, group, severity or label:<key>.
+var ErrInvalidGroupBy = errors.New("invalid group_by: must be rule, group, severity or label:<key>")
+
+// groupByLabelPrefix selects grouping by a label value, e.g. label:deployment.
+const groupByLabelPrefix = "label:"
+
+// ValidateGroupBy checks an active-alerts group_by value.
+func ValidateGroupBy(by string) error {
+	switch by {
+	case "rule", "group", "severity":
+		return nil
+	}
+	if key := strings.TrimPrefix(by, groupByLabelPrefix); key != by && key != "" {
+		return nil
+	}
+	return ErrInvalidGroupBy
+}
+
+// GroupActiveAlerts collapses active alerts by rule, business group, severity or the value of a label
+// (label:<key>), so a storm of alerts from one rule or deployment reads as one line. Groups keep the
+// order of their first alert, so with ListActive ordering the group needing attention most comes first.
+// Alerts without the business group or label fall into a group with an empty key.
+func GroupActiveAlerts(alerts []models.ActiveAlert, by string) ([]models.ActiveAlertGroup, error) {
+	if err := ValidateGroupBy(by); err != nil {
+		return nil, err
+	}
+	labelKey := strings.TrimPrefix(by, groupByLabelPrefix)
+
+	rank := make(map[string]int)
+	for i, name := range Severities() {
+		rank[name] = i + 1
+	}
+	moreSevere := func(a, b string) bool {
+		ra, rb := rank[a], rank[b]
+		return ra != 0 && (rb == 0 || ra < rb)
+	}
+
+	groups := []models.ActiveAlertGroup{}
+	index := make(map[string]int)
+	for _, a := range alerts {
+		var key, name string
+		switch by {
+		case "rule":
+			key, name = a.RuleID.String(), a.RuleName
+		case "group":
+			if a.GroupID != nil {
+				key, name = a.GroupID.String(), a.GroupName
+			}
+		case "severity":
+			key, name = a.Severity, a.Severity
+		default:
+			h := models.AlertHistory{Labels: a.Labels}
+			key = h.LabelMap()[labelKey]
+			name = key
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.ActiveAlertGroup{Key: key, Name: name, Severity: a.Severity})
+		}
+		g := &groups[i]
+		g.Count++
+		if !a.Acked {
+			g.Unacked++
+		}
+		if moreSevere(a.Severity, g.Severity) {
+			g.Severity = a.Severity
+		}
+		g.Alerts = append(g.Alerts, a)
+	}
+	return groups, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrInvalidBulkSelector is wrapped by the errors BulkAck and BulkResolve return for selectors that
+// could never match or would match every alert.
+var ErrInvalidBulkSelector = errors.New("invalid alert selector")
+
+// BulkAlertRequest selects firing alerts by label matchers, in the silence matcher format, and
+// optionally by rule. At least one matcher is required so a request cannot select every alert.
+type BulkAlertRequest struct {
+	Matchers SilenceMatchers `json:"matchers" binding:"required"`
+	RuleID   *uuid.UUID      `json:"rule_id"`
+}
+
+// BulkAlert is a firing alert a bulk action was applied to.
+type BulkAlert struct {
+	ID       uuid.UUID  `json:"id"`
+	AlertNo  string     `json:"alert_no"`
+	RuleID   uuid.UUID  `json:"rule_id"`
+	RuleName string     `json:"rule_name"`
+	GroupID  *uuid.UUID `json:"group_id,omitempty"`
+	Severity string     `json:"severity"`
+	// Labels are decoded for matching and for the WebSocket notification, not returned.
+	Labels map[string]string `json:"-"`
+}
+
+// AlertBulkActionService acknowledges or resolves many firing alerts at once, e.g. every alert of a
+// failing cluster during an incident.
+type AlertBulkActionService struct {
+	db *pgxpool.Pool
+}
+
+func NewAlertBulkActionService(db *pgxpool.Pool) *AlertBulkActionService {
+	return &AlertBulkActionService{db: db}
+}
+
+// BulkAck acknowledges every unacknowledged firing alert the request selects as the given user, like
+// a single acknowledgement: the alert records who acked it and its SLA gets first_acked_at,
+// response_time_secs and status acknowledged. All alerts are updated in one transaction.
+func (s *AlertBulkActionService) BulkAck(ctx context.Context, req *BulkAlertRequest, userID uuid.UUID, username string, at time.Time) ([]BulkAlert, error) {
+	return s.apply(ctx, req, "h.acked_at IS NULL", func(ctx context.Context, ids []uuid.UUID) error {
+		q := repository.Conn(ctx, s.db)
+		if _, err := q.Exec(ctx, `
+			UPDATE alert_history SET acked_by = $1, acked_by_name = $2, acked_at = $3 WHERE id = ANY($4)
+		`, userID, username, at, ids); err != nil {
+			return err
+		}
+		_, err := q.Exec(ctx, `
+			UPDATE alert_slas s SET first_acked_at = $1, status = 'acknowledged',
+				response_time_secs = EXTRACT(EPOCH FROM ($1 - h.started_at))
+			FROM alert_history h
+			WHERE h.id = s.alert_id AND s.alert_id = ANY($2) AND s.first_acked_at IS NULL
+		`, at, ids)
+		return err
+	})
+}
+
+// BulkResolve marks every firing alert the request selects as resolved at the given time and resolves
+// their SLAs, in one transaction. No recovery notification is sent.
+func (s *AlertBulkActionService) BulkResolve(ctx context.Context, req *BulkAlertRequest, at time.Time) ([]BulkAlert, error) {
+	return s.apply(ctx, req, "", func(ctx context.Context, ids []uuid.UUID) error {
+		q := repository.Conn(ctx, s.db)
+		if _, err := q.Exec(ctx, `
+			UPDATE alert_history SET status = 'resolved', ended_at = $1 WHERE id = ANY($2)
+		`, at, ids); err != nil {
+			return err
+		}
+		_, err := q.Exec(ctx, `
+			UPDATE alert_slas SET resolved_at = $1, status = 'resolved',
+				resolution_time_secs = EXTRACT(EPOCH FROM ($1 - created_at))
+			WHERE alert_id = ANY($2)
+		`, at, ids)
+		return err
+	})
+}
+
+// apply locks the firing alerts matching req (and the extra condition on alert_history h, if any),
+// limited to the business groups of the caller's scope, and runs update on their IDs in the same
+// transaction. It returns the updated alerts, oldest first.
+func (s *AlertBulkActionService) apply(ctx context.Context, req *BulkAlertRequest, extra string,
+	update func(ctx context.Context, ids []uuid.UUID) error) ([]BulkAlert, error) {
+	sets, err := req.Matchers.compile()
+	if err != nil {
+		msg := strings.TrimPrefix(err.Error(), ErrInvalidSilence.Error()+": ")
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBulkSelector, msg)
+	}
+
+	cond, args := silenceLabelCondition(sets, "h.labels")
+	where := "h.status = 'firing' AND (" + cond + ")"
+	if extra != "" {
+		where += " AND " + extra
+	}
+	if req.RuleID != nil {
+		args = append(args, *req.RuleID)
+		where += fmt.Sprintf(" AND h.rule_id = $%d", len(args))
+	}
+	if groupIDs, ok := repository.GroupScope(ctx); ok {
+		args = append(args, groupIDs)
+		where += fmt.Sprintf(" AND r.group_id = ANY($%d::uuid[])", len(args))
+	}
+
+	alerts := []BulkAlert{}
+	err = repository.WithTx(ctx, s.db, func(ctx context.Context) error {
+		rows, err := repository.Conn(ctx, s.db).Query(ctx, `
+			SELECT h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(r.name, ''), r.group_id, COALESCE(h.severity, ''),
+				COALESCE(h.labels::text, '{}')
+			FROM alert_history h
+			LEFT JOIN alert_rules r ON r.id = h.rule_id
+			WHERE `+where+`
+			ORDER BY h.started_at
+			FOR UPDATE OF h
+		`, args...)
+		if err != nil {
+			return err
+		}
+		var ids []uuid.UUID
+		for rows.Next() {
+			var a BulkAlert
+			var labels string
+			if err := rows.Scan(&a.ID, &a.AlertNo, &a.RuleID, &a.RuleName, &a.GroupID, &a.Severity, &labels); err != nil {
+				rows.Close()
+				return err
+			}
+			a.Labels = models.DecodeLabels([]byte(labels))
+			if !silenceMatches(sets, a.Labels) {
+				continue
+			}
+			alerts = append(alerts, a)
+			ids = append(ids, a.ID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return update(ctx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"alert-center/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedFiringAlerts inserts a rule, with labels of its own, and one firing alert of it per labels JSON
+// object. It returns the alert IDs, in order, and removes the rows when the test ends.
+func seedFiringAlerts(t *testing.T, pool *pgxpool.Pool, labels ...string) []uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	ruleID := uuid.New()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO alert_rules (id, name, expression, severity, labels, group_id, created_at, updated_at)
+		VALUES ($1, 'seeded rule', 'up == 0', 'critical', '{"env": "prod"}', $2, NOW(), NOW())
+	`, ruleID, uuid.New()); err != nil {
+		t.Fatalf("seed rule: %v", err)
+	}
+	var ids []uuid.UUID
+	for _, l := range labels {
+		id := uuid.New()
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO alert_history (id, rule_id, fingerprint, severity, status, started_at, labels, created_at)
+			VALUES ($1, $2, $3, 'critical', 'firing', NOW(), $4, NOW())
+		`, id, ruleID, uuid.NewString(), l); err != nil {
+			t.Fatalf("seed alert: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	t.Cleanup(func() {
+		pool.Exec(ctx, `DELETE FROM alert_slas WHERE alert_id = ANY($1)`, ids)
+		pool.Exec(ctx, `DELETE FROM alert_history WHERE rule_id = $1`, ruleID)
+		pool.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1`, ruleID)
+	})
+	return ids
+}
+
+func TestBulkAckAndResolveByLabels(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	// A label unique to this run keeps alerts of other runs out of the selection.
+	run := uuid.NewString()
+	ids := seedFiringAlerts(t, pool,
+		`{"env": "prod", "run": "`+run+`"}`,
+		`{"env": "prod", "run": "`+run+`", "instance": "db-2"}`,
+		`{"env": "dev", "run": "`+run+`"}`)
+	req := &BulkAlertRequest{Matchers: SilenceMatchers{Matchers: []SilenceMatcher{
+		{Name: "env", Operator: MatchEqual, Value: "prod"},
+		{Name: "run", Operator: MatchEqual, Value: run},
+	}}}
+	s := NewAlertBulkActionService(pool)
+	status := func(id uuid.UUID) (string, bool) {
+		t.Helper()
+		var st string
+		var acked bool
+		if err := pool.QueryRow(ctx, `SELECT status, acked_at IS NOT NULL FROM alert_history WHERE id = $1`, id).Scan(&st, &acked); err != nil {
+			t.Fatalf("alert %s: %v", id, err)
+		}
+		return st, acked
+	}
+
+	acked, err := s.BulkAck(ctx, req, uuid.New(), "oncall", time.Now())
+	if err != nil {
+		t.Fatalf("BulkAck: %v", err)
+	}
+	if len(acked) != 2 || acked[0].RuleName != "seeded rule" {
+		t.Fatalf("BulkAck selected %+v, want the two prod alerts", acked)
+	}
+	for i, want := range []bool{true, true, false} {
+		if _, got := status(ids[i]); got != want {
+			t.Errorf("after BulkAck alert %d acked = %v, want %v", i, got, want)
+		}
+	}
+	if again, err := s.BulkAck(ctx, req, uuid.New(), "oncall", time.Now()); err != nil || len(again) != 0 {
+		t.Errorf("second BulkAck: %d alerts, %v; want none", len(again), err)
+	}
+
+	resolved, err := s.BulkResolve(ctx, req, time.Now())
+	if err != nil {
+		t.Fatalf("BulkResolve: %v", err)
+	}
+	if len(resolved) != 2 {
+		t.Fatalf("BulkResolve selected %d alerts, want 2", len(resolved))
+	}
+	for i, want := range []string{"resolved", "resolved", "firing"} {
+		if got, _ := status(ids[i]); got != want {
+			t.Errorf("after BulkResolve alert %d is %s, want %s", i, got, want)
+		}
+	}
+
+	// The group scope limits the selection to the caller's groups.
+	scoped := repository.WithGroupScope(ctx, []uuid.UUID{uuid.New()})
+	devReq := &BulkAlertRequest{Matchers: SilenceMatchers{Matchers: []SilenceMatcher{{Name: "run", Operator: MatchEqual, Value: run}}}}
+	if out, err := s.BulkResolve(scoped, devReq, time.Now()); err != nil || len(out) != 0 {
+		t.Errorf("BulkResolve outside the scope: %d alerts, %v; want none", len(out), err)
+	}
+}
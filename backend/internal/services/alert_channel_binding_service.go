@@ -2,15 +2,17 @@ package services
 
 import (
 	"alert-center/internal/models"
-	"bytes"
+	"alert-center/internal/repository"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -22,40 +24,117 @@ func NewAlertChannelBindingService(db *pgxpool.Pool) *AlertChannelBindingService
 	return &AlertChannelBindingService{db: db}
 }
 
-func (s *AlertChannelBindingService) BindChannels(ctx context.Context, ruleID uuid.UUID, channelIDs []uuid.UUID) error {
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		return err
+// ChannelBindingRequest binds one channel to a rule. MinSeverity (info, warning, critical) limits the
+// channel to alerts at or above that severity; empty sends every severity. EffectiveStartTime/EffectiveEndTime
+// (HH:MM, may span midnight) limit the channel to a daily window; both empty means always.
+type ChannelBindingRequest struct {
+	ChannelID          uuid.UUID `json:"channel_id" binding:"required"`
+	MinSeverity        string    `json:"min_severity"`
+	EffectiveStartTime string    `json:"effective_start_time"`
+	EffectiveEndTime   string    `json:"effective_end_time"`
+}
+
+// ValidateBinding checks a binding's min_severity and effective window.
+func ValidateBinding(b ChannelBindingRequest) error {
+	if b.MinSeverity != "" && severityRank(b.MinSeverity) == 0 {
+		return fmt.Errorf("invalid min_severity %q (must be one of %s)", b.MinSeverity, strings.Join(Severities(), ", "))
+	}
+	for _, v := range []string{b.EffectiveStartTime, b.EffectiveEndTime} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", v); err != nil {
+			return fmt.Errorf("invalid effective time %q (must be HH:MM)", v)
+		}
 	}
-	defer tx.Rollback(ctx)
+	return nil
+}
 
-	_, err = tx.Exec(ctx, `DELETE FROM alert_channel_bindings WHERE rule_id = $1`, ruleID)
-	if err != nil {
-		return err
+// bindingActive reports whether a bound channel should receive an alert of the given severity at t,
+// according to its binding's min_severity and effective window.
+func bindingActive(channel models.AlertChannel, severity string, t time.Time) bool {
+	if !meetsMinSeverity(severity, channel.MinSeverity) {
+		return false
 	}
+	if channel.EffectiveStartTime == "" && channel.EffectiveEndTime == "" {
+		return true
+	}
+	return inDailyWindow(channel.EffectiveStartTime, channel.EffectiveEndTime, t)
+}
 
-	for _, channelID := range channelIDs {
-		binding := &models.AlertChannelBinding{
-			ID:        uuid.New(),
-			RuleID:    ruleID,
-			ChannelID: channelID,
-			Status:    1,
-		}
-		_, err = tx.Exec(ctx, `
-			INSERT INTO alert_channel_bindings (id, rule_id, channel_id, status, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, NOW(), NOW())
-		`, binding.ID, binding.RuleID, binding.ChannelID, binding.Status)
-		if err != nil {
+// meetsMinSeverity reports whether an alert of the given severity should go to a channel bound with
+// minSeverity. Alerts with an unknown severity are always delivered rather than silently dropped.
+func meetsMinSeverity(severity, minSeverity string) bool {
+	if minSeverity == "" || severityRank(severity) == 0 {
+		return true
+	}
+	return severityRank(severity) >= severityRank(minSeverity)
+}
+
+// BindChannels replaces the rule's bindings with the given channels, without severity thresholds or windows.
+func (s *AlertChannelBindingService) BindChannels(ctx context.Context, ruleID uuid.UUID, channelIDs []uuid.UUID) error {
+	bindings := make([]ChannelBindingRequest, 0, len(channelIDs))
+	for _, id := range channelIDs {
+		bindings = append(bindings, ChannelBindingRequest{ChannelID: id})
+	}
+	return s.SetBindings(ctx, ruleID, bindings)
+}
+
+// SetBindings replaces the rule's bindings with the given channels, severity thresholds and windows.
+func (s *AlertChannelBindingService) SetBindings(ctx context.Context, ruleID uuid.UUID, bindings []ChannelBindingRequest) error {
+	for _, b := range bindings {
+		if err := ValidateBinding(b); err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit(ctx)
+	return repository.WithTx(ctx, s.db, func(ctx context.Context) error {
+		db := repository.Conn(ctx, s.db)
+		if _, err := db.Exec(ctx, `DELETE FROM alert_channel_bindings WHERE rule_id = $1`, ruleID); err != nil {
+			return err
+		}
+
+		for _, b := range bindings {
+			binding := &models.AlertChannelBinding{
+				ID:                 uuid.New(),
+				RuleID:             ruleID,
+				ChannelID:          b.ChannelID,
+				MinSeverity:        b.MinSeverity,
+				EffectiveStartTime: b.EffectiveStartTime,
+				EffectiveEndTime:   b.EffectiveEndTime,
+				Status:             1,
+			}
+			_, err := db.Exec(ctx, `
+				INSERT INTO alert_channel_bindings (id, rule_id, channel_id, min_severity, effective_start_time, effective_end_time,
+					status, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+			`, binding.ID, binding.RuleID, binding.ChannelID, binding.MinSeverity, binding.EffectiveStartTime,
+				binding.EffectiveEndTime, binding.Status)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetBindingsAudited replaces the rule's bindings and records who did it in the audit log, in one
+// transaction: if the audit entry cannot be written the bindings are left unchanged.
+func (s *AlertChannelBindingService) SetBindingsAudited(ctx context.Context, audit *AuditLogService, userID uuid.UUID, ruleID uuid.UUID, bindings []ChannelBindingRequest) error {
+	return repository.WithTx(ctx, s.db, func(ctx context.Context) error {
+		if err := s.SetBindings(ctx, ruleID, bindings); err != nil {
+			return err
+		}
+		return audit.CreateWithDetail(ctx, userID, "bind_channels", "alert_rule", ruleID.String(), map[string]interface{}{
+			"bindings": bindings,
+		})
+	})
 }
 
 func (s *AlertChannelBindingService) GetByRuleID(ctx context.Context, ruleID uuid.UUID) ([]models.AlertChannel, error) {
 	rows, err := s.db.Query(ctx, `
-		SELECT ac.id, ac.name, ac.type, ac.description, ac.config, ac.group_id, ac.status, ac.created_at, ac.updated_at
+		SELECT ac.id, ac.name, ac.type, ac.description, ac.config, ac.group_id, ac.status, ac.created_at, ac.updated_at,
+			COALESCE(acb.min_severity, ''), COALESCE(acb.effective_start_time, ''), COALESCE(acb.effective_end_time, '')
 		FROM alert_channels ac
 		INNER JOIN alert_channel_bindings acb ON ac.id = acb.channel_id
 		WHERE acb.rule_id = $1 AND ac.status = 1
@@ -69,7 +148,8 @@ func (s *AlertChannelBindingService) GetByRuleID(ctx context.Context, ruleID uui
 	for rows.Next() {
 		var ch models.AlertChannel
 		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
-			&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
+			&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt, &ch.MinSeverity,
+			&ch.EffectiveStartTime, &ch.EffectiveEndTime); err != nil {
 			return nil, err
 		}
 		channels = append(channels, ch)
@@ -78,14 +158,15 @@ func (s *AlertChannelBindingService) GetByRuleID(ctx context.Context, ruleID uui
 	return channels, nil
 }
 
-// GetChannelsByRuleIDs returns bound channels (id, name, type) for the given rule IDs in one query.
+// GetChannelsByRuleIDs returns bound channels (id, name, type and binding options) for the given rule IDs in one query.
 // Result map: rule_id -> list of channels (minimal fields for display).
 func (s *AlertChannelBindingService) GetChannelsByRuleIDs(ctx context.Context, ruleIDs []uuid.UUID) (map[uuid.UUID][]models.AlertChannel, error) {
 	if len(ruleIDs) == 0 {
 		return nil, nil
 	}
 	rows, err := s.db.Query(ctx, `
-		SELECT acb.rule_id, ac.id, ac.name, ac.type
+		SELECT acb.rule_id, ac.id, ac.name, ac.type, COALESCE(acb.min_severity, ''),
+			COALESCE(acb.effective_start_time, ''), COALESCE(acb.effective_end_time, '')
 		FROM alert_channels ac
 		INNER JOIN alert_channel_bindings acb ON ac.id = acb.channel_id
 		WHERE acb.rule_id = ANY($1) AND ac.status = 1
@@ -97,37 +178,201 @@ func (s *AlertChannelBindingService) GetChannelsByRuleIDs(ctx context.Context, r
 
 	out := make(map[uuid.UUID][]models.AlertChannel)
 	for rows.Next() {
-		var ruleID, chID uuid.UUID
-		var name, chType string
-		if err := rows.Scan(&ruleID, &chID, &name, &chType); err != nil {
+		var ruleID uuid.UUID
+		var ch models.AlertChannel
+		if err := rows.Scan(&ruleID, &ch.ID, &ch.Name, &ch.Type, &ch.MinSeverity,
+			&ch.EffectiveStartTime, &ch.EffectiveEndTime); err != nil {
 			return nil, err
 		}
-		out[ruleID] = append(out[ruleID], models.AlertChannel{ID: chID, Name: name, Type: chType})
+		out[ruleID] = append(out[ruleID], ch)
 	}
 	return out, nil
 }
 
+// GetBoundChannelsByRuleIDs returns the enabled channels, including their config, bound to each of
+// the given rules in one query. Result map: rule_id -> channels ready for sending.
+func (s *AlertChannelBindingService) GetBoundChannelsByRuleIDs(ctx context.Context, ruleIDs []uuid.UUID) (map[uuid.UUID][]models.AlertChannel, error) {
+	out := make(map[uuid.UUID][]models.AlertChannel)
+	if len(ruleIDs) == 0 {
+		return out, nil
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT acb.rule_id, ac.id, ac.name, ac.type, ac.description, ac.config, ac.group_id, ac.status, ac.created_at, ac.updated_at,
+			COALESCE(acb.min_severity, ''), COALESCE(acb.effective_start_time, ''), COALESCE(acb.effective_end_time, '')
+		FROM alert_channels ac
+		INNER JOIN alert_channel_bindings acb ON ac.id = acb.channel_id
+		WHERE acb.rule_id = ANY($1) AND ac.status = 1
+	`, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ruleID uuid.UUID
+		var ch models.AlertChannel
+		if err := rows.Scan(&ruleID, &ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
+			&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt, &ch.MinSeverity,
+			&ch.EffectiveStartTime, &ch.EffectiveEndTime); err != nil {
+			return nil, err
+		}
+		out[ruleID] = append(out[ruleID], ch)
+	}
+	return out, rows.Err()
+}
+
+// GetGroupDefaultChannelsByRuleIDs returns, for each of the given rules, the enabled default channel of
+// the rule's business group, if the group has one. Result map: rule_id -> channel ready for sending.
+func (s *AlertChannelBindingService) GetGroupDefaultChannelsByRuleIDs(ctx context.Context, ruleIDs []uuid.UUID) (map[uuid.UUID]models.AlertChannel, error) {
+	out := make(map[uuid.UUID]models.AlertChannel)
+	if len(ruleIDs) == 0 {
+		return out, nil
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT ar.id, ac.id, ac.name, ac.type, ac.description, ac.config, ac.group_id, ac.status, ac.created_at, ac.updated_at
+		FROM alert_rules ar
+		INNER JOIN business_groups bg ON bg.id = ar.group_id
+		INNER JOIN alert_channels ac ON ac.id = bg.default_channel_id
+		WHERE ar.id = ANY($1) AND ac.status = 1
+	`, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ruleID uuid.UUID
+		var ch models.AlertChannel
+		if err := rows.Scan(&ruleID, &ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
+			&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out[ruleID] = ch
+	}
+	return out, rows.Err()
+}
+
+// getEnabledChannel returns the channel with the given id, or nil if it does not exist or is disabled.
+func (s *AlertChannelBindingService) getEnabledChannel(ctx context.Context, id uuid.UUID) (*models.AlertChannel, error) {
+	var ch models.AlertChannel
+	err := s.db.QueryRow(ctx, `
+		SELECT id, name, type, description, config, group_id, status, created_at, updated_at
+		FROM alert_channels WHERE id = $1 AND status = 1
+	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Description, &ch.Config,
+		&ch.GroupID, &ch.Status, &ch.CreatedAt, &ch.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
 func (s *AlertChannelBindingService) SendToBoundChannels(ctx context.Context, ruleID uuid.UUID, alert *AlertPayload) error {
 	channels, err := s.GetByRuleID(ctx, ruleID)
 	if err != nil {
 		return err
 	}
 
+	return sendToChannels(ctx, channels, alert, nil, nil)
+}
+
+// activeChannels returns the channels whose binding is active for the alert now (min_severity and effective window).
+func activeChannels(channels []models.AlertChannel, alert *AlertPayload) []models.AlertChannel {
+	now := time.Now()
+	var active []models.AlertChannel
 	for _, channel := range channels {
+		if bindingActive(channel, alert.Severity, now) {
+			active = append(active, channel)
+		}
+	}
+	return active
+}
+
+// ErrAllChannelsFailed wraps the send error when every channel the alert was sent to failed, so nobody
+// was notified (as opposed to a partial failure).
+var ErrAllChannelsFailed = errors.New("all notification channels failed")
+
+// sendToChannels delivers the alert to each channel whose binding is active for it (min_severity and
+// effective window). Failures are logged and do not stop the others; they are returned joined, wrapped
+// in ErrAllChannelsFailed when no channel succeeded.
+// Each send is recorded in logs for alertID (see recordDelivery). In dry-run mode it only logs what
+// would be sent.
+// Channels with max_per_minute set drop alerts over their rate: the drop is logged with
+// ErrChannelRateLimited but is not a failure, and the dropped alerts are later sent as one summary.
+func sendToChannels(ctx context.Context, channels []models.AlertChannel, alert *AlertPayload,
+	logs *repository.NotificationLogRepository, alertID *uuid.UUID) error {
+	_, err := deliverToChannels(ctx, channels, alert, logs, alertID)
+	return err
+}
+
+// deliverToChannels is sendToChannels that also returns the IDs of the channels done with the alert:
+// those it was sent to and those that dropped it for their rate limit. A caller retrying a partial
+// failure passes only the other channels, so nobody is paged twice.
+func deliverToChannels(ctx context.Context, channels []models.AlertChannel, alert *AlertPayload,
+	logs *repository.NotificationLogRepository, alertID *uuid.UUID) ([]uuid.UUID, error) {
+	if NotificationsDryRun() {
+		dryRunChannels(channels, alert)
+		return nil, nil
+	}
+	var errs []error
+	var delivered []uuid.UUID
+	sent := 0
+	for _, channel := range activeChannels(channels, alert) {
 		var config map[string]interface{}
 		json.Unmarshal([]byte(channel.Config), &config)
 
-		switch channel.Type {
-		case "lark":
-			_ = sendLarkAlert(ctx, config, alert)
-		case "telegram":
-			_ = sendTelegramAlert(ctx, config, alert)
-		case "webhook":
-			_ = sendWebhookAlert(ctx, config, alert)
+		max := channelMaxPerMinute(config)
+		if max > 0 && !channelRateLimits.allow(channel, max, alert, time.Now()) {
+			recordDelivery(ctx, logs, alertID, channel, alert, &deliveryTrace{},
+				fmt.Errorf("%w: max_per_minute %d", ErrChannelRateLimited, max))
+			delivered = append(delivered, channel.ID)
+			continue
+		}
+		sent++
+		if err := sendChannelAlert(ctx, channel, alert, logs, alertID); err != nil {
+			log.Printf("send alert to channel %s (%s): %v", channel.Name, channel.Type, err)
+			errs = append(errs, fmt.Errorf("channel %s: %w", channel.Name, err))
+		} else {
+			delivered = append(delivered, channel.ID)
+		}
+		if max > 0 {
+			sendRateLimitSummary(ctx, channel.ID, logs)
 		}
 	}
+	if len(errs) > 0 && len(errs) == sent {
+		return delivered, fmt.Errorf("%w: %w", ErrAllChannelsFailed, errors.Join(errs...))
+	}
+	return delivered, errors.Join(errs...)
+}
 
-	return nil
+// sendChannelAlert sends the alert to one channel with the sender of its type and records the send.
+func sendChannelAlert(ctx context.Context, channel models.AlertChannel, alert *AlertPayload,
+	logs *repository.NotificationLogRepository, alertID *uuid.UUID) error {
+	var config map[string]interface{}
+	json.Unmarshal([]byte(channel.Config), &config)
+
+	sendCtx, cancel := context.WithTimeout(ctx, channelSendTimeout)
+	defer cancel()
+	sendCtx, trace := withDeliveryTrace(sendCtx)
+	var err error
+	switch channel.Type {
+	case "lark":
+		err = sendLarkAlert(sendCtx, config, alert)
+	case "telegram":
+		err = sendTelegramAlert(sendCtx, config, alert)
+	case "webhook":
+		err = sendWebhookAlert(sendCtx, config, alert)
+	case "email":
+		err = sendEmailAlert(sendCtx, config, alert)
+	case "dingtalk":
+		err = sendDingTalkAlert(sendCtx, config, alert)
+	case "federation":
+		err = sendFederationAlert(sendCtx, config, alert)
+	}
+	recordDelivery(ctx, logs, alertID, channel, alert, trace, err)
+	return err
 }
 
 func sendLarkAlert(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
@@ -135,13 +380,16 @@ func sendLarkAlert(ctx context.Context, config map[string]interface{}, alert *Al
 	if !ok {
 		return nil
 	}
-	payload := buildLarkCardPayload(alert)
+	payload := buildLarkPayload(larkFormat(config, "", larkFormatCard), alert)
 	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, _ := http.DefaultClient.Do(req)
+	resp, err := postChannelJSON(ctx, "lark", webhookURL, body)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
 	return nil
 }
 
@@ -172,9 +420,18 @@ func sendTelegramAlert(ctx context.Context, config map[string]interface{}, alert
 			text = fmt.Sprintf("✅ *告警恢复*\n\n*告警编号*: %s\n*规则*: %s\n*级别*: %s\n*状态*: %s\n*恢复时间*: %s\n*持续时长*: %s",
 				alertNoStr, alert.RuleName, alert.Severity, alert.Status,
 				alert.EndedAt.Format("2006-01-02 15:04:05"), dur.String())
+			if alert.Summary != "" {
+				text += "\n*恢复序列*:\n" + alert.Summary
+			}
 		} else {
 			text = fmt.Sprintf("🚨 *告警通知*\n\n*告警编号*: %s\n*规则*: %s\n*级别*: %s\n*状态*: %s",
 				alertNoStr, alert.RuleName, alert.Severity, alert.Status)
+			if alert.Value != "" {
+				text += "\n*当前值*: " + alert.Value
+			}
+			if alert.Summary != "" {
+				text += "\n*触发序列*:\n" + alert.Summary
+			}
 		}
 	}
 
@@ -190,11 +447,14 @@ func sendTelegramAlert(ctx context.Context, config map[string]interface{}, alert
 	}
 
 	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, _ := http.DefaultClient.Do(req)
+	resp, err := postChannelJSON(ctx, "telegram", url, body)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
 	return nil
 }
 
@@ -206,42 +466,21 @@ func sendWebhookAlert(ctx context.Context, config map[string]interface{}, alert
 	}
 
 	var body []byte
-	if isLarkWebhookURL(webhookURL) {
-		var content string
-		if alert.RenderedContent != "" {
-			if alert.Status == "resolved" {
-				content = "**告警恢复**\n\n" + alert.RenderedContent
-			} else {
-				content = "**告警通知**\n\n" + alert.RenderedContent
-			}
-		} else {
-			alertNoStr := alert.AlertNo
-			if alertNoStr == "" {
-				alertNoStr = "-"
-			}
-			if alert.Status == "resolved" && alert.EndedAt != nil {
-				dur := alert.EndedAt.Sub(alert.StartedAt).Round(time.Second)
-				content = fmt.Sprintf("**告警恢复**\n\n**告警编号**: %s\n**规则**: %s\n**级别**: %s\n**状态**: %s\n**开始时间**: %s\n**恢复时间**: %s\n**持续时长**: %s",
-					alertNoStr, alert.RuleName, alert.Severity, alert.Status,
-					alert.StartedAt.Format("2006-01-02 15:04:05"),
-					alert.EndedAt.Format("2006-01-02 15:04:05"), dur.String())
-			} else {
-				content = fmt.Sprintf("**告警通知**\n\n**告警编号**: %s\n**规则**: %s\n**级别**: %s\n**状态**: %s\n**时间**: %s",
-					alertNoStr, alert.RuleName, alert.Severity, alert.Status, alert.StartedAt.Format("2006-01-02 15:04:05"))
-			}
-		}
-		payload := map[string]interface{}{
-			"msg_type": "markdown",
-			"content":  map[string]interface{}{"text": content},
-		}
-		body, _ = json.Marshal(payload)
+	if format := larkFormat(config, webhookURL, larkFormatMarkdown); format != "" {
+		body, _ = json.Marshal(buildLarkPayload(format, alert))
 	} else {
-		body, _ = json.Marshal(alert)
+		var err error
+		if body, err = webhookAlertBody(config, alert); err != nil {
+			return err
+		}
+	}
+	resp, err := postChannelJSON(ctx, "webhook", webhookURL, body)
+	if err != nil {
+		return err
 	}
-	req, _ := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, _ := http.DefaultClient.Do(req)
 	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
 	return nil
 }
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"alert-center/internal/repository"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to the Postgres database in ALERT_CENTER_TEST_DATABASE_URL and migrates it.
+// Tests using it are skipped when the variable is unset.
+func testPool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("ALERT_CENTER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("ALERT_CENTER_TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := repository.RunMigrations(&repository.Database{Pool: pool}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pool
+}
+
+// BenchmarkRuleChannelLookup compares loading the channels of 200 rules, two bound channels each, with
+// one query per rule (as SendToRuleChannels does for a single alert) against the batched lookup the
+// worker makes once per run.
+func BenchmarkRuleChannelLookup(b *testing.B) {
+	pool := testPool(b)
+	ctx := context.Background()
+	channels := []uuid.UUID{uuid.New(), uuid.New()}
+	for _, id := range channels {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO alert_channels (id, name, type, config, status, created_at, updated_at)
+			VALUES ($1, 'bench', 'webhook', '{"url": "http://127.0.0.1:1"}', 1, NOW(), NOW())
+		`, id); err != nil {
+			b.Fatalf("seed channel: %v", err)
+		}
+	}
+	ruleIDs := make([]uuid.UUID, 200)
+	for i := range ruleIDs {
+		ruleIDs[i] = uuid.New()
+		for _, ch := range channels {
+			if _, err := pool.Exec(ctx, `
+				INSERT INTO alert_channel_bindings (id, rule_id, channel_id, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW())
+			`, uuid.New(), ruleIDs[i], ch); err != nil {
+				b.Fatalf("seed binding: %v", err)
+			}
+		}
+	}
+	b.Cleanup(func() {
+		pool.Exec(ctx, `DELETE FROM alert_channel_bindings WHERE rule_id = ANY($1)`, ruleIDs)
+		pool.Exec(ctx, `DELETE FROM alert_channels WHERE id = ANY($1)`, channels)
+	})
+	sender := NewNotificationSender(pool)
+
+	b.Run("per-rule", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, id := range ruleIDs {
+				if _, err := sender.LoadRuleChannels(ctx, []uuid.UUID{id}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			loaded, err := sender.LoadRuleChannels(ctx, ruleIDs)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(loaded[ruleIDs[0]]) != len(channels) {
+				b.Fatalf("rule has %d channels, want %d", len(loaded[ruleIDs[0]]), len(channels))
+			}
+		}
+	})
+}
@@ -3,9 +3,9 @@ package services
 import (
 	"alert-center/internal/models"
 	"alert-center/internal/repository"
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,21 +13,36 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type AlertChannelService struct {
 	repo *repository.AlertChannelRepository
+	logs *repository.NotificationLogRepository
 }
 
 func NewAlertChannelService(repo *repository.AlertChannelRepository) *AlertChannelService {
 	return &AlertChannelService{repo: repo}
 }
 
+// WithNotificationLog records each Send in the notification log.
+func (s *AlertChannelService) WithNotificationLog(logs *repository.NotificationLogRepository) *AlertChannelService {
+	s.logs = logs
+	return s
+}
+
 func (s *AlertChannelService) Create(ctx context.Context, req *CreateChannelRequest) (*models.AlertChannel, error) {
+	if err := validateChannelFormat(req.Config); err != nil {
+		return nil, err
+	}
+	if err := validateFieldMapping(req.Config); err != nil {
+		return nil, err
+	}
 	config, _ := json.Marshal(req.Config)
 
 	channel := &models.AlertChannel{
 		Name:        req.Name,
+		Slug:        req.Slug,
 		Type:        req.Type,
 		Description: req.Description,
 		Config:      string(config),
@@ -55,17 +70,18 @@ func (s *AlertChannelService) List(ctx context.Context, req *ListChannelRequest)
 	return s.repo.List(ctx, req.Page, req.PageSize, req.Type, status)
 }
 
+// GetByID returns the channel with the given id, or ErrChannelNotFound when there is none.
 func (s *AlertChannelService) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertChannel, error) {
-	channels, _, err := s.repo.List(ctx, 1, 1, "", 1)
-	if err != nil {
-		return nil, err
-	}
-	for _, ch := range channels {
-		if ch.ID == id {
-			return &ch, nil
-		}
+	channel, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, id)
 	}
-	return nil, fmt.Errorf("channel not found")
+	return channel, err
+}
+
+// GetBySlug returns the channel with the given slug.
+func (s *AlertChannelService) GetBySlug(ctx context.Context, slug string) (*models.AlertChannel, error) {
+	return s.repo.GetBySlug(ctx, slug)
 }
 
 func (s *AlertChannelService) Update(ctx context.Context, id uuid.UUID, req *UpdateChannelRequest) (*models.AlertChannel, error) {
@@ -77,6 +93,9 @@ func (s *AlertChannelService) Update(ctx context.Context, id uuid.UUID, req *Upd
 	if req.Name != nil {
 		channel.Name = *req.Name
 	}
+	if req.Slug != nil {
+		channel.Slug = *req.Slug
+	}
 	if req.Type != nil {
 		channel.Type = *req.Type
 	}
@@ -84,6 +103,12 @@ func (s *AlertChannelService) Update(ctx context.Context, id uuid.UUID, req *Upd
 		channel.Description = *req.Description
 	}
 	if req.Config != nil {
+		if err := validateChannelFormat(*req.Config); err != nil {
+			return nil, err
+		}
+		if err := validateFieldMapping(*req.Config); err != nil {
+			return nil, err
+		}
 		config, _ := json.Marshal(req.Config)
 		channel.Config = string(config)
 	}
@@ -97,16 +122,123 @@ func (s *AlertChannelService) Update(ctx context.Context, id uuid.UUID, req *Upd
 	return channel, nil
 }
 
+// ErrChannelNotFound is returned when looking up or deleting a channel id that does not exist.
+var ErrChannelNotFound = errors.New("channel not found")
+
+// Delete soft-deletes the channel (status = 0) and unbinds it from all rules.
 func (s *AlertChannelService) Delete(ctx context.Context, id uuid.UUID) error {
-	// TODO: Implement soft delete
-	return nil
+	err := s.repo.SoftDelete(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrChannelNotFound, id)
+	}
+	return err
+}
+
+// Export returns the enabled channels, of channelType when set, in the format Import accepts. Unless
+// includeSecrets is set, the config keys marked secret in the type's schema (tokens, passwords, robot
+// webhook URLs) are left out.
+func (s *AlertChannelService) Export(ctx context.Context, channelType string, includeSecrets bool) ([]CreateChannelRequest, error) {
+	channels, err := listAll(func(page, pageSize int) ([]models.AlertChannel, int, error) {
+		return s.repo.List(ctx, page, pageSize, channelType, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CreateChannelRequest, 0, len(channels))
+	for _, ch := range channels {
+		out = append(out, CreateChannelRequest{
+			Name:        ch.Name,
+			Slug:        ch.Slug,
+			Type:        ch.Type,
+			Description: ch.Description,
+			Config:      exportedChannelConfig(ch, includeSecrets),
+			GroupID:     ch.GroupID,
+		})
+	}
+	return out, nil
+}
+
+// exportedChannelConfig decodes the channel's config for export, leaving out the keys marked secret in
+// the type's schema unless includeSecrets is set.
+func exportedChannelConfig(ch models.AlertChannel, includeSecrets bool) map[string]interface{} {
+	var config map[string]interface{}
+	json.Unmarshal([]byte(ch.Config), &config)
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	if !includeSecrets {
+		for _, key := range channelSecretFields(ch.Type) {
+			delete(config, key)
+		}
+	}
+	return config
+}
+
+// Import creates a channel from an exported one or, with upsert, updates the existing channel with the
+// same slug. Secret keys missing from the config, as in an export without secrets, keep the existing
+// channel's values, so channels can be round-tripped between environments that hold different
+// credentials. The resulting config must pass ValidateChannelConfig. It reports whether an existing
+// channel was updated.
+func (s *AlertChannelService) Import(ctx context.Context, req *CreateChannelRequest, upsert bool) (bool, error) {
+	config := make(map[string]interface{}, len(req.Config))
+	for k, v := range req.Config {
+		config[k] = v
+	}
+
+	var existing *models.AlertChannel
+	if upsert && req.Slug != "" {
+		ch, err := s.repo.GetBySlug(ctx, req.Slug)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return false, err
+		}
+		existing = ch
+	}
+	keepChannelSecrets(config, req.Type, existing)
+	if errs := ValidateChannelConfig(req.Type, config); len(errs) > 0 {
+		return false, invalidChannelConfig(errs)
+	}
+
+	if existing == nil {
+		create := *req
+		create.Config = config
+		_, err := s.Create(ctx, &create)
+		return false, err
+	}
+	_, err := s.Update(ctx, existing.ID, &UpdateChannelRequest{
+		Name:        &req.Name,
+		Type:        &req.Type,
+		Description: &req.Description,
+		Config:      &config,
+		GroupID:     req.GroupID,
+	})
+	return true, err
+}
+
+// keepChannelSecrets copies into config the secret keys it lacks from existing, the channel it is about
+// to overwrite, when that channel has the same type. Configs exported without secrets can so be
+// imported over a channel without wiping its credentials.
+func keepChannelSecrets(config map[string]interface{}, channelType string, existing *models.AlertChannel) {
+	if existing == nil || existing.Type != channelType {
+		return
+	}
+	var current map[string]interface{}
+	json.Unmarshal([]byte(existing.Config), &current)
+	for _, key := range channelSecretFields(channelType) {
+		if _, ok := config[key]; !ok && current[key] != nil {
+			config[key] = current[key]
+		}
+	}
 }
 
 // SendTestWithConfig sends a test notification using the given type and config (for testing before save).
+// A config failing ValidateChannelConfig is rejected without sending.
 func (s *AlertChannelService) SendTestWithConfig(ctx context.Context, channelType string, config map[string]interface{}) error {
 	if config == nil {
 		config = make(map[string]interface{})
 	}
+	if errs := ValidateChannelConfig(channelType, config); len(errs) > 0 {
+		return invalidChannelConfig(errs)
+	}
 	testPayload := &AlertPayload{
 		AlertNo:     "AL-TEST",
 		RuleID:      uuid.Nil,
@@ -117,6 +249,10 @@ func (s *AlertChannelService) SendTestWithConfig(ctx context.Context, channelTyp
 		Labels:      "{}",
 		StartedAt:   time.Now(),
 	}
+	if NotificationsDryRun() {
+		logDryRun(models.AlertChannel{Name: "test-config", Type: channelType}, testPayload)
+		return nil
+	}
 	switch channelType {
 	case "lark":
 		return s.sendLark(ctx, config, testPayload)
@@ -124,6 +260,12 @@ func (s *AlertChannelService) SendTestWithConfig(ctx context.Context, channelTyp
 		return s.sendTelegram(ctx, config, testPayload)
 	case "webhook":
 		return s.sendWebhook(ctx, config, testPayload)
+	case "email":
+		return s.sendEmail(ctx, config, testPayload)
+	case "dingtalk":
+		return s.sendDingTalk(ctx, config, testPayload)
+	case "federation":
+		return sendFederationTest(ctx, config)
 	default:
 		return fmt.Errorf("unsupported channel type: %s", channelType)
 	}
@@ -131,20 +273,10 @@ func (s *AlertChannelService) SendTestWithConfig(ctx context.Context, channelTyp
 
 // SendTest sends a test notification to the channel for connectivity verification.
 func (s *AlertChannelService) SendTest(ctx context.Context, channelID uuid.UUID) error {
-	channels, _, err := s.repo.List(ctx, 1, 100, "", -1)
+	channel, err := s.GetByID(ctx, channelID)
 	if err != nil {
 		return err
 	}
-	var channel *models.AlertChannel
-	for i := range channels {
-		if channels[i].ID == channelID {
-			channel = &channels[i]
-			break
-		}
-	}
-	if channel == nil {
-		return fmt.Errorf("channel not found")
-	}
 	var config map[string]interface{}
 	if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
 		return fmt.Errorf("invalid channel config")
@@ -153,43 +285,39 @@ func (s *AlertChannelService) SendTest(ctx context.Context, channelID uuid.UUID)
 }
 
 func (s *AlertChannelService) Send(ctx context.Context, channelID uuid.UUID, alert *AlertPayload) error {
-	channels, _, err := s.repo.List(ctx, 1, 1, "", 1)
+	ch, err := s.GetByID(ctx, channelID)
 	if err != nil {
 		return err
 	}
+	channel := *ch
 
-	var channel models.AlertChannel
-	for _, ch := range channels {
-		if ch.ID == channelID {
-			channel = ch
-			break
-		}
+	if NotificationsDryRun() {
+		logDryRun(channel, alert)
+		return nil
 	}
 
 	var config map[string]interface{}
 	json.Unmarshal([]byte(channel.Config), &config)
 
+	sendCtx, trace := withDeliveryTrace(ctx)
 	switch channel.Type {
 	case "lark":
-		return s.sendLark(ctx, config, alert)
+		err = s.sendLark(sendCtx, config, alert)
 	case "telegram":
-		return s.sendTelegram(ctx, config, alert)
+		err = s.sendTelegram(sendCtx, config, alert)
 	case "webhook":
-		return s.sendWebhook(ctx, config, alert)
+		err = s.sendWebhook(sendCtx, config, alert)
+	case "email":
+		err = s.sendEmail(sendCtx, config, alert)
+	case "dingtalk":
+		err = s.sendDingTalk(sendCtx, config, alert)
+	case "federation":
+		err = sendFederationAlert(sendCtx, config, alert)
 	default:
 		return fmt.Errorf("unsupported channel type: %s", channel.Type)
 	}
-}
-
-func larkCardHeaderTemplate(severity string) string {
-	switch severity {
-	case "critical":
-		return "red"
-	case "warning":
-		return "orange"
-	default:
-		return "blue"
-	}
+	recordDelivery(ctx, s.logs, nil, channel, alert, trace, err)
+	return err
 }
 
 func buildLarkCardPayload(alert *AlertPayload) map[string]interface{} {
@@ -206,7 +334,7 @@ func buildLarkCardPayload(alert *AlertPayload) map[string]interface{} {
 					"wide_screen_mode": true,
 				},
 				"header": map[string]interface{}{
-					"template": larkCardHeaderTemplate(alert.Severity),
+					"template": SeverityColor(alert.Severity),
 					"title": map[string]interface{}{
 						"content": headerTitle,
 						"tag":    "plain_text",
@@ -262,21 +390,45 @@ func buildLarkCardPayload(alert *AlertPayload) map[string]interface{} {
 				"tag":    "lark_md",
 			},
 		},
-		{
+	}
+	if alert.Value != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "div",
+			"text": map[string]interface{}{
+				"content": "**当前值**\n" + alert.Value,
+				"tag":    "lark_md",
+			},
+		})
+	}
+	if alert.Summary != "" {
+		heading := "**触发序列**\n"
+		if alert.Status == "resolved" {
+			heading = "**恢复序列**\n"
+		}
+		elements = append(elements, map[string]interface{}{
+			"tag": "div",
+			"text": map[string]interface{}{
+				"content": heading + alert.Summary,
+				"tag":    "lark_md",
+			},
+		})
+	}
+	elements = append(elements,
+		map[string]interface{}{
 			"tag": "div",
 			"text": map[string]interface{}{
 				"content": "**开始时间**\n" + timeStr,
 				"tag":    "lark_md",
 			},
 		},
-		{
+		map[string]interface{}{
 			"tag": "div",
 			"text": map[string]interface{}{
 				"content": "**描述**\n" + desc,
 				"tag":    "lark_md",
 			},
 		},
-	}
+	)
 	if alert.Status == "resolved" && alert.EndedAt != nil {
 		headerTitle = "告警恢复"
 		endStr := alert.EndedAt.Format("2006-01-02 15:04:05")
@@ -305,7 +457,7 @@ func buildLarkCardPayload(alert *AlertPayload) map[string]interface{} {
 				"wide_screen_mode": true,
 			},
 			"header": map[string]interface{}{
-				"template": larkCardHeaderTemplate(alert.Severity),
+				"template": SeverityColor(alert.Severity),
 				"title": map[string]interface{}{
 					"content": headerTitle,
 					"tag":    "plain_text",
@@ -322,16 +474,10 @@ func (s *AlertChannelService) sendLark(ctx context.Context, config map[string]in
 		return fmt.Errorf("lark webhook_url not configured")
 	}
 
-	payload := buildLarkCardPayload(alert)
+	payload := buildLarkPayload(larkFormat(config, "", larkFormatCard), alert)
 	body, _ := json.Marshal(payload)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := postChannelJSON(ctx, "lark", webhookURL, body)
 	if err != nil {
 		return err
 	}
@@ -400,13 +546,7 @@ func (s *AlertChannelService) sendTelegram(ctx context.Context, config map[strin
 
 	body, _ := json.Marshal(payload)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := postChannelJSON(ctx, "telegram", url, body)
 	if err != nil {
 		return err
 	}
@@ -428,20 +568,18 @@ func (s *AlertChannelService) sendWebhook(ctx context.Context, config map[string
 	}
 
 	var body []byte
-	if isLarkWebhookURL(webhookURL) {
-		payload := buildLarkCardPayload(alert)
+	format := larkFormat(config, webhookURL, larkFormatCard)
+	if format != "" {
+		payload := buildLarkPayload(format, alert)
 		body, _ = json.Marshal(payload)
 	} else {
-		body, _ = json.Marshal(alert)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
-	if err != nil {
-		return err
+		var err error
+		if body, err = webhookAlertBody(config, alert); err != nil {
+			return err
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := postChannelJSON(ctx, "webhook", webhookURL, body)
 	if err != nil {
 		return err
 	}
@@ -452,7 +590,7 @@ func (s *AlertChannelService) sendWebhook(ctx context.Context, config map[string
 		return fmt.Errorf("webhook failed (HTTP %d): %s", resp.StatusCode, string(respBody))
 	}
 	// Lark webhook returns 200 with body {"code":0} on success, or {"code":19002,"msg":"..."} on failure
-	if isLarkWebhookURL(webhookURL) {
+	if format != "" {
 		var larkResp struct {
 			Code int    `json:"code"`
 			Msg  string `json:"msg"`
@@ -464,13 +602,17 @@ func (s *AlertChannelService) sendWebhook(ctx context.Context, config map[string
 	return nil
 }
 
-// isLarkWebhookURL returns true if the URL is a Lark/Feishu robot webhook (which requires msg_type in body).
-func isLarkWebhookURL(url string) bool {
-	return strings.Contains(url, "larksuite.com") && strings.Contains(url, "open-apis/bot/v2/hook")
+func (s *AlertChannelService) sendEmail(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
+	return sendEmailAlert(ctx, config, alert)
+}
+
+func (s *AlertChannelService) sendDingTalk(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
+	return sendDingTalkAlert(ctx, config, alert)
 }
 
 type CreateChannelRequest struct {
 	Name        string             `json:"name" binding:"required"`
+	Slug        string             `json:"slug"` // optional, unique across environments
 	Type        string             `json:"type" binding:"required"`
 	Description string             `json:"description"`
 	Config      map[string]interface{} `json:"config" binding:"required"`
@@ -486,6 +628,7 @@ type ListChannelRequest struct {
 
 type UpdateChannelRequest struct {
 	Name        *string            `json:"name"`
+	Slug        *string            `json:"slug"`
 	Type        *string            `json:"type"`
 	Description *string            `json:"description"`
 	Config      *map[string]interface{} `json:"config"`
@@ -500,6 +643,10 @@ type AlertPayload struct {
 	Status          string     `json:"status"`                    // firing, resolved
 	Description     string     `json:"description"`
 	Labels          string     `json:"labels"`
+	Value           string     `json:"value,omitempty"`            // firing value formatted per the rule's value_format
+	Summary         string     `json:"summary,omitempty"`          // aggregate-mode rules: firing series count and top offenders, one per line; grouped recoveries: recovered series
+	RecoveredCount  int        `json:"recovered_count,omitempty"`  // grouped recoveries: number of recovered series
+	Fingerprints    []string   `json:"fingerprints,omitempty"`     // grouped recoveries: fingerprints of the recovered series
 	StartedAt       time.Time  `json:"started_at"`
 	EndedAt         *time.Time `json:"ended_at,omitempty"`
 	RenderedContent string     `json:"rendered_content,omitempty"` // when rule has template_id, content rendered from template
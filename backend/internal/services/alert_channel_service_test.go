@@ -0,0 +1,57 @@
+package services
+
+import (
+	"alert-center/internal/repository"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestChannelGetByIDPastTheFirst(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+
+	s := NewAlertChannelService(repository.NewAlertChannelRepository(&repository.Database{Pool: pool}))
+	var created []uuid.UUID
+	t.Cleanup(func() { pool.Exec(ctx, `DELETE FROM alert_channels WHERE id = ANY($1)`, created) })
+	for _, req := range []CreateChannelRequest{
+		{Name: "lark ops", Type: "lark", Config: map[string]interface{}{"webhook_url": "https://open.feishu.cn/open-apis/bot/v2/hook/0a1b2c3d"}},
+		{Name: "webhook ops", Type: "webhook", Config: map[string]interface{}{"url": srv.URL + "/alerts"}},
+		{Name: "telegram ops", Type: "telegram", Config: map[string]interface{}{"bot_token": "123:abc", "chat_id": "-100"}},
+	} {
+		ch, err := s.Create(ctx, &req)
+		if err != nil {
+			t.Fatalf("create %s: %v", req.Type, err)
+		}
+		created = append(created, ch.ID)
+	}
+
+	for i, want := range []string{"lark", "webhook", "telegram"} {
+		ch, err := s.GetByID(ctx, created[i])
+		if err != nil {
+			t.Fatalf("GetByID of the %s channel: %v", want, err)
+		}
+		if ch.ID != created[i] || ch.Type != want {
+			t.Errorf("GetByID(%s) = %s channel %s, want %s", created[i], ch.Type, ch.ID, want)
+		}
+	}
+
+	if err := s.SendTest(ctx, created[1]); err != nil {
+		t.Fatalf("SendTest of the second channel: %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("webhook called %d times, want 1", n)
+	}
+
+	if _, err := s.GetByID(ctx, uuid.New()); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("unknown id: want ErrChannelNotFound, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"alert-center/internal/models"
@@ -17,10 +18,15 @@ import (
 
 type AlertCorrelationService struct {
 	db *pgxpool.Pool
+
+	groupsMu sync.Mutex
+	// knownGroups maps, per similarity threshold, each alert of the groups GroupSimilarAlerts last
+	// returned to its group, so a later call reports the same group under the same id.
+	knownGroups map[float64]map[uuid.UUID]correlationGroupRef
 }
 
 func NewAlertCorrelationService(db *pgxpool.Pool) *AlertCorrelationService {
-	return &AlertCorrelationService{db: db}
+	return &AlertCorrelationService{db: db, knownGroups: make(map[float64]map[uuid.UUID]correlationGroupRef)}
 }
 
 type CorrelatedAlert struct {
@@ -46,16 +52,18 @@ func (s *AlertCorrelationService) AnalyzeCorrelations(ctx context.Context, alert
 	if err != nil {
 		return nil, err
 	}
+	return s.correlate(alert, relatedAlerts, timeWindow), nil
+}
 
+// correlate scores the alerts that started within timeWindow of alert against it.
+func (s *AlertCorrelationService) correlate(alert *models.AlertHistory, relatedAlerts []*models.AlertHistory, timeWindow time.Duration) *CorrelatedAlert {
 	if len(relatedAlerts) == 0 {
-		var labelsMap map[string]string
-		json.Unmarshal([]byte(alert.Labels), &labelsMap)
 		return &CorrelatedAlert{
 			RootCause:     alert,
 			RelatedAlerts: []*models.AlertHistory{},
-			CommonLabels:  labelsMap,
+			CommonLabels:  alert.LabelMap(),
 			TimeWindow:    timeWindow,
-		}, nil
+		}
 	}
 
 	rootCause := s.identifyRootCause(alert, relatedAlerts)
@@ -68,7 +76,7 @@ func (s *AlertCorrelationService) AnalyzeCorrelations(ctx context.Context, alert
 		CorrelationScore: correlationScore,
 		CommonLabels:     commonLabels,
 		TimeWindow:       timeWindow,
-	}, nil
+	}
 }
 
 func (s *AlertCorrelationService) getAlertByID(ctx context.Context, id uuid.UUID) (*models.AlertHistory, error) {
@@ -123,7 +131,7 @@ func (s *AlertCorrelationService) identifyRootCause(alert *models.AlertHistory,
 	for _, relatedAlert := range related {
 		scores[relatedAlert.ID] = 0
 
-		similarity := s.calculateLabelSimilarity(alert.Labels, relatedAlert.Labels)
+		similarity := labelSimilarity(alert.LabelMap(), relatedAlert.LabelMap())
 		timeDistance := math.Abs(float64(alert.StartedAt.Sub(relatedAlert.StartedAt).Milliseconds()))
 		timeScore := 1.0 / (1.0 + timeDistance/60000)
 
@@ -151,11 +159,8 @@ func (s *AlertCorrelationService) identifyRootCause(alert *models.AlertHistory,
 	return rootCause
 }
 
-func (s *AlertCorrelationService) calculateLabelSimilarity(labels1, labels2 string) float64 {
-	var m1, m2 map[string]string
-	json.Unmarshal([]byte(labels1), &m1)
-	json.Unmarshal([]byte(labels2), &m2)
-
+// labelSimilarity is the Jaccard similarity of two label sets (matching key and value).
+func labelSimilarity(m1, m2 map[string]string) float64 {
 	if m1 == nil || m2 == nil {
 		return 0
 	}
@@ -179,16 +184,12 @@ func (s *AlertCorrelationService) findCommonLabels(alert *models.AlertHistory, r
 	allLabels := make(map[string]map[string]int)
 	totalCount := len(related) + 1
 
-	var m1 map[string]string
-	json.Unmarshal([]byte(alert.Labels), &m1)
-	for k, v := range m1 {
+	for k, v := range alert.LabelMap() {
 		allLabels[k] = map[string]int{v: 1}
 	}
 
 	for _, a := range related {
-		var m2 map[string]string
-		json.Unmarshal([]byte(a.Labels), &m2)
-		for k, v := range m2 {
+		for k, v := range a.LabelMap() {
 			if existing, ok := allLabels[k]; ok {
 				existing[v]++
 			} else {
@@ -216,7 +217,7 @@ func (s *AlertCorrelationService) calculateCorrelationScore(alert *models.AlertH
 
 	var totalSimilarity float64
 	for _, a := range related {
-		similarity := s.calculateLabelSimilarity(alert.Labels, a.Labels)
+		similarity := labelSimilarity(alert.LabelMap(), a.LabelMap())
 		timeDistance := math.Abs(float64(alert.StartedAt.Sub(a.StartedAt).Milliseconds()))
 		timeScore := 1.0 / (1.0 + timeDistance/300000)
 		totalSimilarity += similarity*0.6 + timeScore*0.4
@@ -225,11 +226,64 @@ func (s *AlertCorrelationService) calculateCorrelationScore(alert *models.AlertH
 	return totalSimilarity / float64(len(related))
 }
 
+// SuppressionMatchers analyzes the correlations of alertID and returns silence matchers covering every
+// correlated alert except the root cause. Each matcher is the CommonLabels the alert carries plus the labels
+// that set it apart from the root cause, so a silence built from them leaves the root cause notifying.
+// Alerts whose labels cannot be told apart from the root cause are returned in skipped.
+func (s *AlertCorrelationService) SuppressionMatchers(ctx context.Context, alertID uuid.UUID, timeWindow time.Duration) (*CorrelatedAlert, []map[string]string, []*models.AlertHistory, error) {
+	alert, err := s.getAlertByID(ctx, alertID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	result, err := s.AnalyzeCorrelations(ctx, alertID, timeWindow)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	rootLabels := result.RootCause.LabelMap()
+	candidates := append([]*models.AlertHistory{alert}, result.RelatedAlerts...)
+
+	var matchers []map[string]string
+	var skipped []*models.AlertHistory
+	seen := make(map[string]bool)
+	for _, a := range candidates {
+		if a.ID == result.RootCause.ID {
+			continue
+		}
+		labels := a.LabelMap()
+
+		matcher := make(map[string]string)
+		distinct := false
+		for k, v := range labels {
+			if rv, ok := rootLabels[k]; !ok || rv != v {
+				matcher[k] = v
+				distinct = true
+			} else if cv, ok := result.CommonLabels[k]; ok && cv == v {
+				matcher[k] = v
+			}
+		}
+		if !distinct {
+			skipped = append(skipped, a)
+			continue
+		}
+
+		key, _ := json.Marshal(matcher)
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		matchers = append(matchers, matcher)
+	}
+
+	return result, matchers, skipped, nil
+}
+
 func (s *AlertCorrelationService) FindPatterns(ctx context.Context, timeRange time.Duration, minOccurrences int) ([]AlertPattern, error) {
 	startTime := time.Now().Add(-timeRange)
 
 	rows, err := s.db.Query(ctx, `
-		SELECT labels, COUNT(*) as count, array_agg(id) as ids
+		SELECT labels, COUNT(*) as count, array_agg(id) as ids, MIN(started_at), MAX(started_at)
 		FROM alert_history
 		WHERE started_at >= $1
 		GROUP BY labels
@@ -244,13 +298,20 @@ func (s *AlertCorrelationService) FindPatterns(ctx context.Context, timeRange ti
 	var patterns []AlertPattern
 	for rows.Next() {
 		var p AlertPattern
+		var labels []byte
 		var ids []uuid.UUID
-		if err := rows.Scan(&p.CommonLabels, &p.OccurrenceCount, &ids); err != nil {
+		if err := rows.Scan(&labels, &p.OccurrenceCount, &ids, &p.FirstSeen, &p.LastSeen); err != nil {
 			return nil, err
 		}
+		if p.CommonLabels = models.DecodeLabels(labels); p.CommonLabels == nil {
+			p.CommonLabels = map[string]string{}
+		}
 		p.AlertIDs = ids
 		patterns = append(patterns, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return patterns, nil
 }
@@ -263,13 +324,32 @@ type AlertPattern struct {
 	LastSeen        time.Time         `json:"last_seen"`
 }
 
-func (s *AlertCorrelationService) GroupSimilarAlerts(ctx context.Context, timeRange time.Duration, similarityThreshold float64) ([][]*models.AlertHistory, error) {
+// CorrelationGroup is a group of similar firing alerts. A group keeps its ID and FirstSeenAt across
+// GroupSimilarAlerts calls for as long as it still holds one of the alerts it had on the previous call,
+// so polling clients can tell a group they have already shown from a new one.
+type CorrelationGroup struct {
+	ID          uuid.UUID              `json:"id"`
+	FirstSeenAt time.Time              `json:"first_seen_at"`
+	Alerts      []*models.AlertHistory `json:"alerts"`
+}
+
+type correlationGroupRef struct {
+	id        uuid.UUID
+	firstSeen time.Time
+}
+
+// maxTrackedThresholds bounds how many distinct thresholds knownGroups keeps groups for.
+const maxTrackedThresholds = 16
+
+func (s *AlertCorrelationService) GroupSimilarAlerts(ctx context.Context, timeRange time.Duration, similarityThreshold float64) ([]CorrelationGroup, error) {
 	startTime := time.Now().Add(-timeRange)
 
+	// Ordered so that repeated calls over the same alerts group them the same way.
 	rows, err := s.db.Query(ctx, `
 		SELECT id, COALESCE(alert_no, ''), rule_id, fingerprint, severity, status, started_at, ended_at, labels, annotations, payload, created_at
 		FROM alert_history
 		WHERE started_at >= $1 AND status = 'firing'
+		ORDER BY started_at, id
 	`, startTime)
 	if err != nil {
 		return nil, err
@@ -285,54 +365,301 @@ func (s *AlertCorrelationService) GroupSimilarAlerts(ctx context.Context, timeRa
 		}
 		allAlerts = append(allAlerts, &a)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	groups := s.groupBySimilarity(allAlerts, similarityThreshold)
 
-	return groups, nil
+	return s.dedupeGroups(similarityThreshold, groups, time.Now()), nil
 }
 
+// dedupeGroups gives each group the id of the group returned by the previous call at the same threshold
+// that shared an alert with it, the earliest seen when several did. Each previous group is claimed by
+// one group at most (the first when it split); the others and groups sharing no alert get a new id.
+func (s *AlertCorrelationService) dedupeGroups(threshold float64, groups [][]*models.AlertHistory, now time.Time) []CorrelationGroup {
+	s.groupsMu.Lock()
+	defer s.groupsMu.Unlock()
+
+	previous := s.knownGroups[threshold]
+	if previous == nil && len(s.knownGroups) >= maxTrackedThresholds {
+		s.knownGroups = make(map[float64]map[uuid.UUID]correlationGroupRef)
+	}
+	current := make(map[uuid.UUID]correlationGroupRef)
+	claimed := make(map[uuid.UUID]bool)
+	out := make([]CorrelationGroup, 0, len(groups))
+	for _, alerts := range groups {
+		var match *correlationGroupRef
+		for _, a := range alerts {
+			ref, ok := previous[a.ID]
+			if ok && !claimed[ref.id] && (match == nil || ref.firstSeen.Before(match.firstSeen)) {
+				match = &ref
+			}
+		}
+		ref := correlationGroupRef{id: uuid.New(), firstSeen: now}
+		if match != nil {
+			ref = *match
+			claimed[ref.id] = true
+		}
+		for _, a := range alerts {
+			current[a.ID] = ref
+		}
+		out = append(out, CorrelationGroup{ID: ref.id, FirstSeenAt: ref.firstSeen, Alerts: alerts})
+	}
+	s.knownGroups[threshold] = current
+	return out
+}
+
+// groupBySimilarity groups each alert with every later, ungrouped alert whose label similarity is at least threshold.
+// Labels are parsed and interned once per alert (see labelTokens), so a comparison is a merge of two short sorted
+// slices rather than map lookups, and pairs whose label counts alone keep them below threshold are skipped.
+// With a positive threshold the alerts are first split into buckets that cannot reach it with each other (see
+// similarityBuckets) and only alerts of the same bucket are compared, so comparisons are bounded by the sum of
+// the squared bucket sizes. Groups are identical to those of a pairwise comparison of all alerts.
 func (s *AlertCorrelationService) groupBySimilarity(alerts []*models.AlertHistory, threshold float64) [][]*models.AlertHistory {
-	visited := make(map[int]bool)
+	tokens := labelTokens(alerts)
+	buckets := [][]int{make([]int, len(alerts))}
+	if threshold > 0 {
+		buckets = similarityBuckets(tokens, threshold)
+	} else {
+		for i := range alerts {
+			buckets[0][i] = i
+		}
+	}
+
+	visited := make([]bool, len(alerts))
+	var seeds []int
 	var groups [][]*models.AlertHistory
 
-	for i := 0; i < len(alerts); i++ {
-		if visited[i] {
-			continue
+	for _, bucket := range buckets {
+		for bi, i := range bucket {
+			if visited[i] {
+				continue
+			}
+
+			var group []*models.AlertHistory
+			group = append(group, alerts[i])
+			visited[i] = true
+
+			for _, j := range bucket[bi+1:] {
+				if visited[j] {
+					continue
+				}
+
+				if tokenSimilarity(tokens[i], tokens[j], threshold) >= threshold {
+					group = append(group, alerts[j])
+					visited[j] = true
+				}
+			}
+
+			if len(group) > 1 {
+				sort.Slice(group, func(a, b int) bool {
+					return group[a].StartedAt.Before(group[b].StartedAt)
+				})
+				seeds = append(seeds, i)
+				groups = append(groups, group)
+			}
 		}
+	}
 
-		var group []*models.AlertHistory
-		group = append(group, alerts[i])
-		visited[i] = true
+	// Return the groups in the order of their first alert in the input, as a single pass would.
+	order := make([]int, len(groups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return seeds[order[a]] < seeds[order[b]] })
+	sorted := make([][]*models.AlertHistory, len(groups))
+	for i, g := range order {
+		sorted[i] = groups[g]
+	}
+	return sorted
+}
 
-		for j := i + 1; j < len(alerts); j++ {
-			if visited[j] {
-				continue
+// similarityBuckets splits the alerts into buckets such that any two alerts with a similarity of at least
+// threshold (positive) are in the same bucket; alerts in no pair of that kind are left out. Each bucket
+// lists its alerts in input order. It uses prefix filtering: with label pairs ordered rarest first, two
+// label sets can only reach a Jaccard similarity of t if their first |x| - ceil(t*|x|) + 1 pairs overlap,
+// so alerts sharing a prefix pair are joined into one bucket. Empty label sets share a bucket (they are
+// similar to each other); unparsable labels are similar to nothing.
+func similarityBuckets(tokens [][]int32, threshold float64) [][]int {
+	freq := make(map[int32]int)
+	for _, t := range tokens {
+		for _, id := range t {
+			freq[id]++
+		}
+	}
+
+	parent := make([]int, len(tokens))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[int32]int) // first alert holding each prefix pair
+	firstEmpty := -1
+	joined := make([]bool, len(tokens))
+	prefix := make([]int32, 0, 16)
+	for i, t := range tokens {
+		if t == nil {
+			continue
+		}
+		if len(t) == 0 {
+			if firstEmpty >= 0 {
+				parent[find(i)] = find(firstEmpty)
+				joined[i], joined[firstEmpty] = true, true
+			} else {
+				firstEmpty = i
+			}
+			continue
+		}
+		prefix = append(prefix[:0], t...)
+		sort.Slice(prefix, func(a, b int) bool {
+			if freq[prefix[a]] != freq[prefix[b]] {
+				return freq[prefix[a]] < freq[prefix[b]]
 			}
+			return prefix[a] < prefix[b]
+		})
+		n := len(prefix) - int(math.Ceil(threshold*float64(len(prefix))-1e-9)) + 1
+		if n > len(prefix) {
+			n = len(prefix)
+		}
+		for _, id := range prefix[:max(n, 0)] {
+			if j, ok := owner[id]; ok {
+				parent[find(i)] = find(j)
+				joined[i], joined[j] = true, true
+			} else {
+				owner[id] = i
+			}
+		}
+	}
 
-			similarity := s.calculateLabelSimilarity(alerts[i].Labels, alerts[j].Labels)
-			if similarity >= threshold {
-				group = append(group, alerts[j])
-				visited[j] = true
+	index := make(map[int]int)
+	var buckets [][]int
+	for i := range tokens {
+		if !joined[i] {
+			continue
+		}
+		root := find(i)
+		b, ok := index[root]
+		if !ok {
+			b = len(buckets)
+			index[root] = b
+			buckets = append(buckets, nil)
+		}
+		buckets[b] = append(buckets[b], i)
+	}
+	return buckets
+}
+
+// labelTokens returns each alert's label pairs as sorted ids, one id per distinct key and value. Alerts
+// whose labels do not parse get nil, which like a nil LabelMap is similar to nothing.
+func labelTokens(alerts []*models.AlertHistory) [][]int32 {
+	ids := make(map[string]int32)
+	tokens := make([][]int32, len(alerts))
+	for i, a := range alerts {
+		m := a.LabelMap()
+		if m == nil {
+			continue
+		}
+		t := make([]int32, 0, len(m))
+		for k, v := range m {
+			key := k + "\x00" + v
+			id, ok := ids[key]
+			if !ok {
+				id = int32(len(ids))
+				ids[key] = id
 			}
+			t = append(t, id)
 		}
+		sort.Slice(t, func(a, b int) bool { return t[a] < t[b] })
+		tokens[i] = t
+	}
+	return tokens
+}
+
+// tokenSimilarity is labelSimilarity over labelTokens. With a positive threshold it returns 0 without
+// merging when the smaller set over the larger one, the best Jaccard similarity their sizes allow, is
+// below threshold.
+func tokenSimilarity(a, b []int32, threshold float64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	small, large := len(a), len(b)
+	if small > large {
+		small, large = large, small
+	}
+	if large == 0 {
+		return 1
+	}
+	if threshold > 0 && float64(small)/float64(large) < threshold {
+		return 0
+	}
 
-		if len(group) > 1 {
-			sort.Slice(group, func(a, b int) bool {
-				return group[a].StartedAt.Before(group[b].StartedAt)
-			})
-			groups = append(groups, group)
+	var common int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
 		}
 	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
 
-	return groups
+// minPredictionOccurrences is how many past alerts of a rule PredictFutureAlerts needs.
+const minPredictionOccurrences = 10
+
+// maxPredictions bounds how many future alerts PredictFutureAlerts returns.
+const maxPredictions = 10
+
+// AlertPrediction is the forecast of a rule's next alerts. Status is "ok", or "insufficient_data" when
+// the rule has fewer than minPredictionOccurrences alerts, in which case Predictions is empty.
+type AlertPrediction struct {
+	Status           string           `json:"status"`
+	Occurrences      int              `json:"occurrences"`
+	MeanIntervalSecs float64          `json:"mean_interval_secs,omitempty"`
+	StdDevSecs       float64          `json:"stddev_secs,omitempty"`
+	Seasonality      string           `json:"seasonality,omitempty"` // daily or weekly, when detected
+	PeakHour         *int             `json:"peak_hour,omitempty"`
+	PeakWeekday      string           `json:"peak_weekday,omitempty"`
+	Predictions      []PredictedAlert `json:"predictions"`
 }
 
-func (s *AlertCorrelationService) PredictFutureAlerts(ctx context.Context, ruleID uuid.UUID, timeWindow time.Duration) ([]time.Time, error) {
+// PredictedAlert is one expected alert: PredictedAt within [Earliest, Latest], with a confidence in
+// [0, 1]. Basis is "interval" (mean interval since the last alert), "daily" or "weekly" (seasonality).
+type PredictedAlert struct {
+	PredictedAt time.Time `json:"predicted_at"`
+	Earliest    time.Time `json:"earliest"`
+	Latest      time.Time `json:"latest"`
+	Confidence  float64   `json:"confidence"`
+	Basis       string    `json:"basis"`
+}
+
+// PredictFutureAlerts forecasts the alerts of a rule in the next horizon from its last 200 alert start
+// times. When at least half of them fall in the same hour of the week (over two weeks or more) or the
+// same hour of day (over two days or more) the rule is seasonal and alerts are predicted at that hour;
+// otherwise they are projected at the mean interval from the last alert. The confidence band is one
+// standard deviation of the intervals (of the start times within the hour when seasonal), widening
+// with each step ahead. Confidence drops with every expected alert that did not come, so a rule that
+// stopped alerting is not predicted with confidence. At least one prediction is returned even if it
+// falls after the horizon.
+func (s *AlertCorrelationService) PredictFutureAlerts(ctx context.Context, ruleID uuid.UUID, horizon time.Duration) (*AlertPrediction, error) {
 	rows, err := s.db.Query(ctx, `
 		SELECT started_at FROM alert_history
-		WHERE rule_id = $1 AND status = 'firing'
+		WHERE rule_id = $1
 		ORDER BY started_at DESC
-		LIMIT 100
+		LIMIT 200
 	`, ruleID)
 	if err != nil {
 		return nil, err
@@ -342,46 +669,147 @@ func (s *AlertCorrelationService) PredictFutureAlerts(ctx context.Context, ruleI
 	var occurrences []time.Time
 	for rows.Next() {
 		var t time.Time
-		rows.Scan(&t)
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
 		occurrences = append(occurrences, t)
 	}
-
-	if len(occurrences) < 10 {
-		return []time.Time{}, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	intervals := make([]time.Duration, 0, len(occurrences)-1)
-	for i := 0; i < len(occurrences)-1; i++ {
-		intervals = append(intervals, occurrences[i].Sub(occurrences[i+1]))
-	}
+	return predictAlerts(occurrences, time.Now(), horizon), nil
+}
 
-	var avgInterval time.Duration
-	for _, d := range intervals {
-		avgInterval += d
+// predictAlerts implements PredictFutureAlerts over occurrences ordered newest first.
+func predictAlerts(occurrences []time.Time, now time.Time, horizon time.Duration) *AlertPrediction {
+	result := &AlertPrediction{Status: "ok", Occurrences: len(occurrences), Predictions: []PredictedAlert{}}
+	if len(occurrences) < minPredictionOccurrences {
+		result.Status = "insufficient_data"
+		return result
 	}
-	avgInterval /= time.Duration(len(intervals))
 
-	var variance time.Duration
-	for _, d := range intervals {
-		diff := d - avgInterval
-		variance += diff * diff
+	var mean float64
+	for i := 0; i < len(occurrences)-1; i++ {
+		mean += occurrences[i].Sub(occurrences[i+1]).Seconds()
 	}
-	variance /= time.Duration(len(intervals))
-	_ = time.Duration(math.Sqrt(float64(variance)))
-
-	lastOccurrence := occurrences[0]
-	nextOccurrences := make([]time.Time, 0, 3)
-	currentTime := time.Now()
+	mean /= float64(len(occurrences) - 1)
+	var variance float64
+	for i := 0; i < len(occurrences)-1; i++ {
+		d := occurrences[i].Sub(occurrences[i+1]).Seconds() - mean
+		variance += d * d
+	}
+	variance /= float64(len(occurrences) - 1)
+	result.MeanIntervalSecs, result.StdDevSecs = mean, math.Sqrt(variance)
+
+	// Seasonality: bucket by hour of day and by hour of week, in the server's time zone.
+	var byHour [24][]time.Time
+	var byWeekHour [7 * 24][]time.Time
+	for _, t := range occurrences {
+		t = t.In(now.Location())
+		byHour[t.Hour()] = append(byHour[t.Hour()], t)
+		wh := int(t.Weekday())*24 + t.Hour()
+		byWeekHour[wh] = append(byWeekHour[wh], t)
+	}
+	span := occurrences[0].Sub(occurrences[len(occurrences)-1])
+	half := (len(occurrences) + 1) / 2
+	if wh := largestBucket(byWeekHour[:]); len(byWeekHour[wh]) >= half && span >= 14*24*time.Hour {
+		hour := wh % 24
+		result.Seasonality, result.PeakHour, result.PeakWeekday = "weekly", &hour, time.Weekday(wh/24).String()
+		result.Predictions = seasonalPredictions(byWeekHour[wh], len(occurrences), now, horizon, 7*24*time.Hour, "weekly")
+		return result
+	}
+	if h := largestBucket(byHour[:]); len(byHour[h]) >= half && span >= 2*24*time.Hour {
+		hour := h
+		result.Seasonality, result.PeakHour = "daily", &hour
+		result.Predictions = seasonalPredictions(byHour[h], len(occurrences), now, horizon, 24*time.Hour, "daily")
+		return result
+	}
+
+	if mean <= 0 {
+		return result
+	}
+	interval := time.Duration(mean * float64(time.Second))
+	cv := result.StdDevSecs / mean
+	// Skip the steps already past, so an overdue rule is predicted from now on with a wider band.
+	skipped := 0
+	if now.After(occurrences[0]) {
+		skipped = int(now.Sub(occurrences[0]) / interval)
+	}
+	next := occurrences[0].Add(interval * time.Duration(skipped))
+	for step := skipped + 1; len(result.Predictions) < maxPredictions; step++ {
+		next = next.Add(interval)
+		if !next.After(now) {
+			continue
+		}
+		if len(result.Predictions) > 0 && next.Sub(now) > horizon {
+			break
+		}
+		// Errors of consecutive intervals add up, so the band grows with the square root of the steps.
+		band := time.Duration(result.StdDevSecs * math.Sqrt(float64(step)) * float64(time.Second))
+		result.Predictions = append(result.Predictions, PredictedAlert{
+			PredictedAt: next,
+			Earliest:    next.Add(-band),
+			Latest:      next.Add(band),
+			Confidence:  1 / (1 + cv*math.Sqrt(float64(step))) / float64(1+skipped),
+			Basis:       "interval",
+		})
+	}
+	return result
+}
 
-	for i := 1; i <= 3; i++ {
-		nextTime := lastOccurrence.Add(avgInterval * time.Duration(i))
-		if nextTime.After(currentTime) {
-			nextOccurrences = append(nextOccurrences, nextTime)
+// largestBucket returns the index of the bucket with the most occurrences (the first on ties).
+func largestBucket(buckets [][]time.Time) int {
+	best := 0
+	for i := range buckets {
+		if len(buckets[i]) > len(buckets[best]) {
+			best = i
 		}
 	}
+	return best
+}
 
-	log.Printf("Predicted next occurrences for rule %s: %v", ruleID, nextOccurrences)
-	return nextOccurrences, nil
+// seasonalPredictions predicts alerts every period at the mean offset of bucket's occurrences into
+// their hour, starting from the latest of them. Confidence is the share of all occurrences in bucket,
+// divided by one plus the periods since then without an alert.
+func seasonalPredictions(bucket []time.Time, total int, now time.Time, horizon, period time.Duration, basis string) []PredictedAlert {
+	var mean float64
+	for _, t := range bucket {
+		mean += float64(t.Sub(t.Truncate(time.Hour)))
+	}
+	mean /= float64(len(bucket))
+	var variance float64
+	for _, t := range bucket {
+		d := float64(t.Sub(t.Truncate(time.Hour))) - mean
+		variance += d * d
+	}
+	band := time.Duration(math.Sqrt(variance / float64(len(bucket))))
+	confidence := float64(len(bucket)) / float64(total)
+
+	// bucket is newest first; step whole periods from its latest hour (date arithmetic keeps the
+	// wall-clock hour across DST changes).
+	latest := bucket[0].Truncate(time.Hour)
+	days := int(period / (24 * time.Hour))
+	var predictions []PredictedAlert
+	missed := 0
+	for next := latest.AddDate(0, 0, days); len(predictions) < maxPredictions; next = next.AddDate(0, 0, days) {
+		at := next.Add(time.Duration(mean))
+		if !at.After(now) {
+			missed++
+			continue
+		}
+		if len(predictions) > 0 && at.Sub(now) > horizon {
+			break
+		}
+		predictions = append(predictions, PredictedAlert{
+			PredictedAt: at,
+			Earliest:    at.Add(-band),
+			Latest:      at.Add(band),
+			Confidence:  confidence / float64(1+missed),
+			Basis:       basis,
+		})
+	}
+	return predictions
 }
 
 type TimelineEvent struct {
@@ -390,11 +818,14 @@ type TimelineEvent struct {
 	RuleName  string    `json:"rule_name"`
 	Severity  string    `json:"severity"`
 	Status    string    `json:"status"`
-	EventType string    `json:"event_type"` // firing, resolved, escalated
+	EventType string    `json:"event_type"` // firing, resolved, escalated, acked
+	Detail    string    `json:"detail,omitempty"`
 	Labels    string    `json:"labels"`
 	RootCause bool      `json:"root_cause,omitempty"`
 }
 
+// GenerateTimeline returns the incident narrative for a fingerprint: firing and resolved events from
+// alert_history merged with severity escalations, user escalations and acknowledgements, ordered by time.
 func (s *AlertCorrelationService) GenerateTimeline(ctx context.Context, fingerprint string, timeRange time.Duration) ([]TimelineEvent, error) {
 	startTime := time.Now().Add(-timeRange)
 
@@ -411,21 +842,106 @@ func (s *AlertCorrelationService) GenerateTimeline(ctx context.Context, fingerpr
 	defer rows.Close()
 
 	var events []TimelineEvent
+	alerts := make(map[uuid.UUID]TimelineEvent)
+	var alertIDs []uuid.UUID
 	for rows.Next() {
 		var e TimelineEvent
 		var resolvedAt *time.Time
 		if err := rows.Scan(&e.AlertID, &e.Timestamp, &resolvedAt, &e.Severity, &e.Status, &e.Labels, &e.RuleName); err != nil {
 			return nil, err
 		}
+		alerts[e.AlertID] = e
+		alertIDs = append(alertIDs, e.AlertID)
 
-		if e.Status == "firing" {
-			e.EventType = "firing"
-		} else {
+		e.EventType = "firing"
+		events = append(events, e)
+		if resolvedAt != nil {
+			e.Timestamp = *resolvedAt
 			e.EventType = "resolved"
+			events = append(events, e)
 		}
+	}
+	rows.Close()
+	if len(alertIDs) == 0 {
+		return events, nil
+	}
 
+	// newEvent derives a non-history event from the alert it belongs to.
+	newEvent := func(alertID uuid.UUID, at time.Time, eventType, detail string) TimelineEvent {
+		e := alerts[alertID]
+		e.Timestamp = at
+		e.EventType = eventType
+		e.Detail = detail
+		return e
+	}
+
+	escRows, err := s.db.Query(ctx, `
+		SELECT alert_id, COALESCE(notified_at, created_at), COALESCE(from_severity, ''), COALESCE(to_severity, '')
+		FROM alert_escalation_logs WHERE alert_id = ANY($1)
+	`, alertIDs)
+	if err != nil {
+		return nil, err
+	}
+	for escRows.Next() {
+		var alertID uuid.UUID
+		var at time.Time
+		var from, to string
+		if err := escRows.Scan(&alertID, &at, &from, &to); err != nil {
+			escRows.Close()
+			return nil, err
+		}
+		e := newEvent(alertID, at, "escalated", fmt.Sprintf("severity %s -> %s", from, to))
+		if to != "" {
+			e.Severity = to
+		}
 		events = append(events, e)
 	}
+	escRows.Close()
+
+	userRows, err := s.db.Query(ctx, `
+		SELECT alert_id, created_at, from_username, to_username, COALESCE(reason, '')
+		FROM user_escalations WHERE alert_id = ANY($1)
+	`, alertIDs)
+	if err != nil {
+		return nil, err
+	}
+	for userRows.Next() {
+		var alertID uuid.UUID
+		var at time.Time
+		var from, to, reason string
+		if err := userRows.Scan(&alertID, &at, &from, &to, &reason); err != nil {
+			userRows.Close()
+			return nil, err
+		}
+		detail := fmt.Sprintf("%s -> %s", from, to)
+		if reason != "" {
+			detail += ": " + reason
+		}
+		events = append(events, newEvent(alertID, at, "escalated", detail))
+	}
+	userRows.Close()
+
+	ackRows, err := s.db.Query(ctx, `
+		SELECT alert_id, first_acked_at FROM alert_slas
+		WHERE alert_id = ANY($1) AND first_acked_at IS NOT NULL
+	`, alertIDs)
+	if err != nil {
+		return nil, err
+	}
+	for ackRows.Next() {
+		var alertID uuid.UUID
+		var at time.Time
+		if err := ackRows.Scan(&alertID, &at); err != nil {
+			ackRows.Close()
+			return nil, err
+		}
+		events = append(events, newEvent(alertID, at, "acked", ""))
+	}
+	ackRows.Close()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
 
 	return events, nil
 }
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// pairwiseGroupBySimilarity is the plain pairwise grouping groupBySimilarity must agree with.
+func pairwiseGroupBySimilarity(alerts []*models.AlertHistory, threshold float64) [][]*models.AlertHistory {
+	visited := make([]bool, len(alerts))
+	var groups [][]*models.AlertHistory
+	for i := range alerts {
+		if visited[i] {
+			continue
+		}
+		group := []*models.AlertHistory{alerts[i]}
+		visited[i] = true
+		for j := i + 1; j < len(alerts); j++ {
+			if !visited[j] && labelSimilarity(alerts[i].LabelMap(), alerts[j].LabelMap()) >= threshold {
+				group = append(group, alerts[j])
+				visited[j] = true
+			}
+		}
+		if len(group) > 1 {
+			sort.Slice(group, func(a, b int) bool { return group[a].StartedAt.Before(group[b].StartedAt) })
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// correlationAlerts returns n firing alerts over a node fleet: every alert carries job="node", so
+// all of them share a label pair, and alerts for the same instance and alertname are near-duplicates.
+func correlationAlerts(n, instances int, seed int64) []*models.AlertHistory {
+	rng := rand.New(rand.NewSource(seed))
+	names := []string{"NodeDown", "HighCPU", "HighMemory", "DiskFull", "HighLoad"}
+	severities := []string{"critical", "warning", "info"}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := make([]*models.AlertHistory, n)
+	for i := range alerts {
+		labels := map[string]string{
+			"job":       "node",
+			"alertname": names[rng.Intn(len(names))],
+			"instance":  fmt.Sprintf("10.0.%d.%d:9100", rng.Intn(instances)/250, rng.Intn(instances)%250),
+			"severity":  severities[rng.Intn(len(severities))],
+		}
+		if rng.Intn(3) == 0 {
+			labels["mountpoint"] = fmt.Sprintf("/data%d", rng.Intn(4))
+		}
+		raw, _ := json.Marshal(labels)
+		alerts[i] = &models.AlertHistory{ID: uuid.New(), Labels: string(raw), Status: "firing", StartedAt: start.Add(time.Duration(i) * time.Second)}
+	}
+	return alerts
+}
+
+func groupIDs(groups [][]*models.AlertHistory) [][]uuid.UUID {
+	out := make([][]uuid.UUID, len(groups))
+	for i, g := range groups {
+		for _, a := range g {
+			out[i] = append(out[i], a.ID)
+		}
+	}
+	return out
+}
+
+func TestGroupBySimilarityMatchesPairwise(t *testing.T) {
+	s := NewAlertCorrelationService(nil)
+	for seed := int64(1); seed <= 20; seed++ {
+		alerts := correlationAlerts(150, 40, seed)
+		// Edge cases: unparsable labels are similar to nothing, empty label sets to each other.
+		alerts = append(alerts,
+			&models.AlertHistory{ID: uuid.New(), Labels: "not json"},
+			&models.AlertHistory{ID: uuid.New(), Labels: "not json"},
+			&models.AlertHistory{ID: uuid.New(), Labels: "{}"},
+			&models.AlertHistory{ID: uuid.New(), Labels: "{}"},
+		)
+		for _, threshold := range []float64{0, 0.2, 0.5, 0.6, 0.75, 0.8, 1} {
+			want := groupIDs(pairwiseGroupBySimilarity(alerts, threshold))
+			got := groupIDs(s.groupBySimilarity(alerts, threshold))
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("seed %d threshold %v: got %d groups, want %d groups as pairwise comparison", seed, threshold, len(got), len(want))
+			}
+		}
+	}
+}
+
+func BenchmarkGroupBySimilarity(b *testing.B) {
+	alerts := correlationAlerts(5000, 2000, 1)
+	s := NewAlertCorrelationService(nil)
+	for _, threshold := range []float64{0.5, 0.8} {
+		b.Run(fmt.Sprintf("pairwise/threshold=%v", threshold), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pairwiseGroupBySimilarity(alerts, threshold)
+			}
+		})
+		b.Run(fmt.Sprintf("grouped/threshold=%v", threshold), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.groupBySimilarity(alerts, threshold)
+			}
+		})
+	}
+}
+
+// BenchmarkAnalyzeCorrelations scores an alert against 2000 related ones. Each iteration gets freshly
+// loaded alerts, as each AnalyzeCorrelations call does.
+func BenchmarkAnalyzeCorrelations(b *testing.B) {
+	loaded := correlationAlerts(2001, 500, 1)
+	s := NewAlertCorrelationService(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		alerts := make([]*models.AlertHistory, len(loaded))
+		for j, a := range loaded {
+			alerts[j] = &models.AlertHistory{ID: a.ID, Labels: a.Labels, Status: a.Status, StartedAt: a.StartedAt}
+		}
+		b.StartTimer()
+		s.correlate(alerts[0], alerts[1:], time.Hour)
+	}
+}
+
+func TestDedupeGroupsKeepsIDsAcrossCalls(t *testing.T) {
+	s := NewAlertCorrelationService(nil)
+	a, b, c, d := &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := s.dedupeGroups(0.7, [][]*models.AlertHistory{{a, b}}, t0)
+	// The group gained c; a new group of d appeared.
+	second := s.dedupeGroups(0.7, [][]*models.AlertHistory{{a, b, c}, {d, c}}, t0.Add(time.Minute))
+	if second[0].ID != first[0].ID || !second[0].FirstSeenAt.Equal(t0) {
+		t.Errorf("grown group: got %s first seen %v, want %s first seen %v", second[0].ID, second[0].FirstSeenAt, first[0].ID, t0)
+	}
+	if second[1].ID == first[0].ID || !second[1].FirstSeenAt.Equal(t0.Add(time.Minute)) {
+		t.Errorf("new group reused id %s or first seen %v", second[1].ID, second[1].FirstSeenAt)
+	}
+
+	// a resolved; the group is still reported under its id through b and c.
+	third := s.dedupeGroups(0.7, [][]*models.AlertHistory{{b, c}}, t0.Add(2*time.Minute))
+	if third[0].ID != first[0].ID {
+		t.Errorf("group lost its id after a member resolved")
+	}
+
+	// Other thresholds group independently.
+	other := s.dedupeGroups(0.9, [][]*models.AlertHistory{{b, c}}, t0.Add(2*time.Minute))
+	if other[0].ID == first[0].ID {
+		t.Errorf("group id shared across thresholds")
+	}
+}
+
+func TestDedupeGroupsSplitClaimsOnce(t *testing.T) {
+	s := NewAlertCorrelationService(nil)
+	a, b, c, d := &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}, &models.AlertHistory{ID: uuid.New()}
+	now := time.Now()
+
+	first := s.dedupeGroups(0.7, [][]*models.AlertHistory{{a, b, c, d}}, now)
+	split := s.dedupeGroups(0.7, [][]*models.AlertHistory{{a, b}, {c, d}}, now.Add(time.Minute))
+	if split[0].ID != first[0].ID {
+		t.Errorf("first half of a split group: got a new id")
+	}
+	if split[1].ID == first[0].ID {
+		t.Errorf("both halves of a split group share id %s", first[0].ID)
+	}
+}
+
+func TestFindPatternsOnSeededHistory(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	// A label unique to this run keeps rows of other runs out of the pattern.
+	run := uuid.NewString()
+	labels := fmt.Sprintf(`{"run": %q, "port": 8080, "canary": true, "zone": null}`, run)
+	now := time.Now().UTC().Truncate(time.Second)
+	first, last := now.Add(-3*time.Hour), now.Add(-time.Hour)
+	for _, at := range []time.Time{last, first, now.Add(-2 * time.Hour)} {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO alert_history (id, rule_id, fingerprint, severity, status, started_at, labels, created_at)
+			VALUES ($1, $2, $3, 'warning', 'firing', $4, $5, $4)
+		`, uuid.New(), uuid.New(), run, at, labels); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	patterns, err := NewAlertCorrelationService(pool).FindPatterns(ctx, 4*time.Hour, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range patterns {
+		if p.CommonLabels["run"] != run {
+			continue
+		}
+		if p.OccurrenceCount != 3 || len(p.AlertIDs) != 3 {
+			t.Errorf("occurrences = %d with %d ids, want 3", p.OccurrenceCount, len(p.AlertIDs))
+		}
+		if !p.FirstSeen.Equal(first) || !p.LastSeen.Equal(last) {
+			t.Errorf("seen %v - %v, want %v - %v", p.FirstSeen, p.LastSeen, first, last)
+		}
+		want := map[string]string{"run": run, "port": "8080", "canary": "true", "zone": ""}
+		if fmt.Sprint(p.CommonLabels) != fmt.Sprint(want) {
+			t.Errorf("labels = %v, want %v", p.CommonLabels, want)
+		}
+		return
+	}
+	t.Fatalf("no pattern for the seeded alerts in %d patterns", len(patterns))
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"alert-center/internal/repository"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DigestConfig is the digest section of the config: when to send and how.
+type DigestConfig struct {
+	Hour       int      // local hour of day (0-23) the digest is sent
+	Recipients []string // receive every group's digest, in addition to the group manager
+	SMTPHost   string
+	SMTPPort   int // defaults to 465
+	Username   string
+	Password   string
+	From       string
+}
+
+// AlertDigestService emails each active business group a daily summary of its last 24 hours: alert
+// counts by severity, top firing rules and SLA compliance. A digest is claimed in alert_digests
+// before sending, so several API replicas or a restart send each group's digest once per day.
+type AlertDigestService struct {
+	db      *pgxpool.Pool
+	stats   *AlertStatisticsService
+	slaRepo *repository.AlertSLARepository
+	cfg     DigestConfig
+}
+
+func NewAlertDigestService(db *pgxpool.Pool, stats *AlertStatisticsService, slaRepo *repository.AlertSLARepository, cfg DigestConfig) *AlertDigestService {
+	if cfg.SMTPPort == 0 {
+		cfg.SMTPPort = 465
+	}
+	if cfg.Hour < 0 || cfg.Hour > 23 {
+		cfg.Hour = 8
+	}
+	return &AlertDigestService{db: db, stats: stats, slaRepo: slaRepo, cfg: cfg}
+}
+
+// Run sends the digests every day at the configured hour until ctx is cancelled. Started after that
+// hour, it first sends the digests not yet sent today.
+func (s *AlertDigestService) Run(ctx context.Context) {
+	now := time.Now()
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.cfg.Hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		if err := s.SendAll(ctx, now); err != nil {
+			log.Printf("AlertDigestService: %v", err)
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := s.SendAll(ctx, time.Now()); err != nil {
+				log.Printf("AlertDigestService: %v", err)
+			}
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+}
+
+type digestGroup struct {
+	id           uuid.UUID
+	name         string
+	managerEmail string
+}
+
+// SendAll sends the digest of the 24 hours before now to every active business group not yet sent one
+// today. Groups without alerts in the window or without recipients are skipped.
+func (s *AlertDigestService) SendAll(ctx context.Context, now time.Time) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT g.id, g.name, COALESCE(u.email, '')
+		FROM business_groups g
+		LEFT JOIN users u ON u.id = g.manager_id AND u.status = 1
+		WHERE g.status = 1
+		ORDER BY g.name
+	`)
+	if err != nil {
+		return fmt.Errorf("list business groups: %w", err)
+	}
+	var groups []digestGroup
+	for rows.Next() {
+		var g digestGroup
+		if err := rows.Scan(&g.id, &g.name, &g.managerEmail); err != nil {
+			rows.Close()
+			return err
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		if err := s.sendGroup(ctx, g, now); err != nil {
+			log.Printf("AlertDigestService: digest of group %s (%s): %v", g.name, g.id, err)
+		}
+	}
+	return nil
+}
+
+func (s *AlertDigestService) sendGroup(ctx context.Context, g digestGroup, now time.Time) error {
+	to := append([]string{}, s.cfg.Recipients...)
+	if g.managerEmail != "" {
+		to = append(to, g.managerEmail)
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	start := now.Add(-24 * time.Hour)
+	stats, err := s.stats.GetStatistics(ctx, StatisticsFilter{StartTime: &start, EndTime: &now, GroupID: g.id.String()})
+	if err != nil {
+		return fmt.Errorf("statistics: %w", err)
+	}
+	if stats.TotalAlerts == 0 {
+		return nil
+	}
+	sla, err := s.slaRepo.Report(ctx, start, now, now, Severities(), &g.id)
+	if err != nil {
+		return fmt.Errorf("sla report: %w", err)
+	}
+
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO alert_digests (group_id, digest_date, sent_at) VALUES ($1, $2, $3)
+		ON CONFLICT (group_id, digest_date) DO NOTHING
+	`, g.id, now.Format("2006-01-02"), now)
+	if err != nil {
+		return fmt.Errorf("claim digest: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil // already sent today
+	}
+
+	cfg := &emailConfig{
+		host:     s.cfg.SMTPHost,
+		port:     s.cfg.SMTPPort,
+		username: s.cfg.Username,
+		password: s.cfg.Password,
+		from:     s.cfg.From,
+		to:       to,
+	}
+	subject := fmt.Sprintf("[告警日报] %s %s", g.name, now.Format("2006-01-02"))
+	if err := sendEmail(ctx, cfg, subject, buildDigestHTML(g.name, start, now, stats, sla)); err != nil {
+		// Release the claim so a restart later today retries the group.
+		s.db.Exec(ctx, `DELETE FROM alert_digests WHERE group_id = $1 AND digest_date = $2`, g.id, now.Format("2006-01-02"))
+		return fmt.Errorf("send: %w", err)
+	}
+	return nil
+}
+
+// buildDigestHTML lays out a group's digest: totals, counts by severity, top firing rules and SLA
+// compliance by severity.
+func buildDigestHTML(groupName string, start, end time.Time, stats *AlertStatistics, sla *repository.SLAReport) string {
+	var b strings.Builder
+	td := `style="padding:4px 12px;border-bottom:1px solid #eee"`
+	row := func(cells ...string) {
+		b.WriteString("<tr>")
+		for _, c := range cells {
+			fmt.Fprintf(&b, "<td %s>%s</td>", td, html.EscapeString(c))
+		}
+		b.WriteString("</tr>")
+	}
+
+	fmt.Fprintf(&b, "<h3>%s 告警日报</h3>", html.EscapeString(groupName))
+	fmt.Fprintf(&b, "<p>%s ~ %s</p>", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "<p>告警总数 <b>%d</b>，仍在告警 <b>%d</b>，已恢复 <b>%d</b></p>",
+		stats.TotalAlerts, stats.FiringAlerts, stats.ResolvedAlerts)
+
+	b.WriteString(`<h4>按级别</h4><table style="border-collapse:collapse">`)
+	for _, sev := range stats.BySeverity {
+		row(sev.Severity, fmt.Sprintf("%d", sev.Count))
+	}
+	b.WriteString("</table>")
+
+	if len(stats.TopFiringRules) > 0 {
+		b.WriteString(`<h4>告警最多的规则</h4><table style="border-collapse:collapse">`)
+		for _, r := range stats.TopFiringRules {
+			row(r.RuleName, fmt.Sprintf("%d", r.AlertCount))
+		}
+		b.WriteString("</table>")
+	}
+
+	b.WriteString(`<h4>SLA 达标率</h4><table style="border-collapse:collapse">`)
+	row("", "响应", "解决", "超时")
+	row("总计", fmt.Sprintf("%.1f%%", sla.ResponseComplianceRate), fmt.Sprintf("%.1f%%", sla.ResolutionComplianceRate),
+		fmt.Sprintf("%d", sla.BreachedCount))
+	for _, bucket := range sla.BySeverity {
+		if bucket.TotalAlerts == 0 {
+			continue
+		}
+		row(bucket.Severity, fmt.Sprintf("%.1f%%", bucket.ResponseComplianceRate), fmt.Sprintf("%.1f%%", bucket.ResolutionComplianceRate),
+			fmt.Sprintf("%d", bucket.BreachedCount))
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
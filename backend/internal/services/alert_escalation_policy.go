@@ -0,0 +1,150 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dueEscalation is a firing, unacknowledged alert whose escalation policy is due to notify.
+type dueEscalation struct {
+	escalationID  uuid.UUID
+	name          string
+	escalateTo    string
+	channelID     uuid.UUID
+	waitMinutes   int
+	repeatCount   int
+	alertID       uuid.UUID
+	alertNo       string
+	ruleID        uuid.UUID
+	ruleName      string
+	description   string
+	severity      string
+	labels        string
+	startedAt     time.Time
+	notifications int // escalation notifications already sent for this alert
+}
+
+// escalateUnacknowledged applies the enabled alert_escalations policies: an alert of the policy's rule
+// and severity still firing and not acknowledged (neither alert_history.acked_at nor
+// alert_slas.first_acked_at set) wait_minutes after it started is sent to the policy's channel, then
+// again every repeat_minutes, repeat_count more times. Each send is recorded in alert_escalation_logs;
+// a failed send is retried on the next cycle.
+func (w *AlertNotificationWorker) escalateUnacknowledged(ctx context.Context) (int, error) {
+	now := time.Now()
+	rows, err := w.db.Query(ctx, `
+		SELECT e.id, e.name, COALESCE(e.escalate_to, ''), e.channel_id, COALESCE(e.wait_minutes, 0), COALESCE(e.repeat_count, 0),
+			h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(r.name, ''), COALESCE(r.description, ''),
+			COALESCE(h.severity, ''), COALESCE(h.labels::text, '{}'), h.started_at, COUNT(l.id)
+		FROM alert_escalations e
+		JOIN alert_history h ON h.rule_id = e.rule_id AND h.status = 'firing'
+			AND (COALESCE(e.severity, '') = '' OR h.severity = e.severity)
+		LEFT JOIN alert_rules r ON r.id = h.rule_id
+		LEFT JOIN alert_slas s ON s.alert_id = h.id
+		LEFT JOIN alert_escalation_logs l ON l.escalation_id = e.id AND l.alert_id = h.id
+		WHERE e.status = 1 AND e.channel_id IS NOT NULL
+			AND s.first_acked_at IS NULL AND h.acked_at IS NULL
+			AND h.started_at <= $1::timestamp - make_interval(mins => COALESCE(e.wait_minutes, 0))
+		GROUP BY e.id, h.id, r.name, r.description
+		HAVING COUNT(l.id) = 0
+			OR (COUNT(l.id) <= COALESCE(e.repeat_count, 0)
+				AND MAX(COALESCE(l.notified_at, l.created_at)) <= $1::timestamp - make_interval(mins => COALESCE(e.repeat_minutes, 30)))
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	var due []dueEscalation
+	for rows.Next() {
+		var d dueEscalation
+		if err := rows.Scan(&d.escalationID, &d.name, &d.escalateTo, &d.channelID, &d.waitMinutes, &d.repeatCount,
+			&d.alertID, &d.alertNo, &d.ruleID, &d.ruleName, &d.description,
+			&d.severity, &d.labels, &d.startedAt, &d.notifications); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	binding := &AlertChannelBindingService{db: w.db}
+	channels := make(map[uuid.UUID]*models.AlertChannel)
+	escalated := 0
+	var errs []error
+	for _, d := range due {
+		channel, ok := channels[d.channelID]
+		if !ok {
+			if channel, err = binding.getEnabledChannel(ctx, d.channelID); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			channels[d.channelID] = channel
+		}
+		if channel == nil {
+			log.Printf("AlertNotificationWorker: escalation %q channel %s is missing or disabled; %s not escalated", d.name, d.channelID, d.alertNo)
+			continue
+		}
+		if err := w.sendEscalation(ctx, d, *channel, now); err != nil {
+			errs = append(errs, fmt.Errorf("escalation %q of %s: %w", d.name, d.alertNo, err))
+			continue
+		}
+		escalated++
+	}
+	return escalated, errors.Join(errs...)
+}
+
+// sendEscalation notifies the escalation channel of the alert and records the escalation.
+func (w *AlertNotificationWorker) sendEscalation(ctx context.Context, d dueEscalation, channel models.AlertChannel, now time.Time) error {
+	severity := d.escalateTo
+	if severity == "" {
+		severity = d.severity
+	}
+	description := fmt.Sprintf("【告警升级】%d 分钟未确认，升级通知（第 %d/%d 次）", d.waitMinutes, d.notifications+1, d.repeatCount+1)
+	if d.description != "" {
+		description += "\n" + d.description
+	}
+	payload := &AlertPayload{
+		AlertNo:     d.alertNo,
+		RuleID:      d.ruleID,
+		RuleName:    d.ruleName,
+		Severity:    severity,
+		Status:      "firing",
+		Description: description,
+		Labels:      d.labels,
+		StartedAt:   d.startedAt,
+	}
+
+	if NotificationsDryRun() {
+		log.Printf("[dry-run] would escalate %s (%s) to channel %s (%s)", d.alertNo, d.name, channel.Name, channel.Type)
+	} else if err := w.sender.SendToChannels(ctx, []models.AlertChannel{channel}, payload, d.alertID); err != nil {
+		return err
+	}
+
+	if _, err := w.db.Exec(ctx, `
+		INSERT INTO alert_escalation_logs (id, escalation_id, alert_id, from_severity, to_severity, channel_id, notified_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`, uuid.New(), d.escalationID, d.alertID, d.severity, severity, d.channelID, now); err != nil {
+		return err
+	}
+	log.Printf("AlertNotificationWorker: escalated %s via %q to channel %s (%d/%d)", d.alertNo, d.name, channel.Name, d.notifications+1, d.repeatCount+1)
+
+	w.stateWebhook.Emit(AlertStateEvent{
+		Event:     AlertEventEscalated,
+		AlertID:   d.alertID,
+		AlertNo:   d.alertNo,
+		RuleID:    d.ruleID,
+		RuleName:  d.ruleName,
+		Severity:  severity,
+		OldState:  "firing",
+		NewState:  AlertEventEscalated,
+		Timestamp: now,
+	})
+	return nil
+}
@@ -10,13 +10,20 @@ import (
 )
 
 type AlertEscalationService struct {
-	db *pgxpool.Pool
+	db           *pgxpool.Pool
+	stateWebhook *StateWebhook
 }
 
 func NewAlertEscalationMgmtService(db *pgxpool.Pool) *AlertEscalationService {
 	return &AlertEscalationService{db: db}
 }
 
+// WithStateWebhook emits an escalated event to hook for every escalation created. hook may be nil.
+func (s *AlertEscalationService) WithStateWebhook(hook *StateWebhook) *AlertEscalationService {
+	s.stateWebhook = hook
+	return s
+}
+
 type AlertEscalation struct {
 	ID           uuid.UUID  `json:"id"`
 	AlertID      uuid.UUID  `json:"alert_id"`
@@ -57,9 +64,32 @@ func (s *AlertEscalationService) CreateEscalation(ctx context.Context, fromUserI
 		return nil, err
 	}
 	log.Printf("Alert %s escalated from %s to %s", esc.AlertID, esc.FromUsername, esc.ToUsername)
+	s.emitEscalated(ctx, esc)
 	return esc, nil
 }
 
+// emitEscalated sends the escalation to the state-change webhook, with the alert's current status as old state.
+func (s *AlertEscalationService) emitEscalated(ctx context.Context, esc *AlertEscalation) {
+	if s.stateWebhook == nil {
+		return
+	}
+	event := AlertStateEvent{
+		Event:     AlertEventEscalated,
+		AlertID:   esc.AlertID,
+		NewState:  AlertEventEscalated,
+		Timestamp: esc.CreatedAt,
+	}
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(h.alert_no, ''), h.rule_id, COALESCE(r.name, ''), h.severity, h.status
+		FROM alert_history h LEFT JOIN alert_rules r ON r.id = h.rule_id
+		WHERE h.id = $1
+	`, esc.AlertID).Scan(&event.AlertNo, &event.RuleID, &event.RuleName, &event.Severity, &event.OldState)
+	if err != nil {
+		log.Printf("AlertEscalationService: load alert %s for state webhook: %v", esc.AlertID, err)
+	}
+	s.stateWebhook.Emit(event)
+}
+
 func (s *AlertEscalationService) GetAlertEscalations(ctx context.Context, alertID uuid.UUID) ([]AlertEscalation, error) {
 	rows, err := s.db.Query(ctx, `
 		SELECT id, alert_id, from_user_id, from_username, to_user_id, to_username, reason, status, created_at, resolved_at
@@ -3,7 +3,12 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,9 +38,19 @@ func (e *AlertEvaluator) RegisterDataSource(ds models.DataSource) {
 
 	switch ds.Type {
 	case "prometheus":
-		e.promClients[ds.ID.String()] = NewPrometheusClient(ds.Endpoint)
+		client, err := NewPrometheusClientWithConfig(ds.Endpoint, ds.Config)
+		if err != nil {
+			log.Printf("AlertEvaluator: register data source %s: %v", ds.ID, err)
+			return
+		}
+		e.promClients[ds.ID.String()] = client
 	case "victoria-metrics":
-		e.vmClients[ds.ID.String()] = NewVictoriaMetricsClient(ds.Endpoint)
+		client, err := NewVictoriaMetricsClientWithConfig(ds.Endpoint, ds.Config)
+		if err != nil {
+			log.Printf("AlertEvaluator: register data source %s: %v", ds.ID, err)
+			return
+		}
+		e.vmClients[ds.ID.String()] = client
 	}
 }
 
@@ -66,6 +81,13 @@ func (e *AlertEvaluator) parseAnnotations(annotations string) map[string]string
 }
 
 func (e *AlertEvaluator) EvaluateRule(ctx context.Context, rule models.AlertRule, ds models.DataSource) ([]models.FiringAlert, error) {
+	firing, _, err := e.EvaluateRuleSeries(ctx, rule, ds)
+	return firing, err
+}
+
+// EvaluateRuleSeries is EvaluateRule that also returns the number of series the query returned,
+// firing or not, so a rule whose query returns nothing can be told apart from a healthy one.
+func (e *AlertEvaluator) EvaluateRuleSeries(ctx context.Context, rule models.AlertRule, ds models.DataSource) ([]models.FiringAlert, int, error) {
 	var firing []models.FiringAlert
 
 	var client *PrometheusClient
@@ -74,39 +96,352 @@ func (e *AlertEvaluator) EvaluateRule(ctx context.Context, rule models.AlertRule
 	e.mu.RUnlock()
 
 	if client == nil {
-		client = NewPrometheusClient(ds.Endpoint)
+		var err error
+		if client, err = NewPrometheusClientWithConfig(ds.Endpoint, ds.Config); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	matches, series, err := e.matchingSeries(ctx, client, rule)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, result := range matches {
+		labels := e.mergeLabels(rule.Labels, result.Metric)
+		annotations := e.parseAnnotations(rule.Annotations)
+		for k, v := range annotations {
+			annotations[k] = expandAlertTemplate(v, result.Metric, result.Value.Value)
+		}
+		annotations = addThresholdAnnotations(annotations, rule, result.Value.Value)
+		firing = append(firing, models.FiringAlert{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			Severity:    alertSeverity(rule, labels, annotations),
+			Fingerprint: models.GenerateFingerprint(labels),
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    time.Now(),
+			Value:       result.Value.Value,
+			Status:      "firing",
+		})
+	}
+
+	if rule.NotifyMode == models.NotifyModeAggregate && len(firing) > 0 {
+		return []models.FiringAlert{e.aggregateFiring(rule, firing)}, series, nil
+	}
+	return firing, series, nil
+}
+
+// matchingSeries returns the series for which the rule fires, each with the value to report. Instant rules
+// run an instant query and fire on values satisfying the rule threshold (positive values by default). Range rules query the last range_duration at
+// range_step and fire when the samples reduced by range_condition satisfy it; the reduced value is reported.
+// It also returns the number of series the query returned.
+func (e *AlertEvaluator) matchingSeries(ctx context.Context, client *PrometheusClient, rule models.AlertRule) ([]models.QueryResult, int, error) {
+	var matches []models.QueryResult
+	if rule.EvaluationMode != models.EvaluationModeRange {
+		results, err := client.Query(ctx, rule.Expression, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, result := range results {
+			if e.checkThreshold(result.Value.Value, rule) {
+				matches = append(matches, result)
+			}
+		}
+		return matches, len(results), nil
+	}
+
+	cond, err := ParseRangeCondition(rule.RangeCondition)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, end, step := rangeWindow(rule.RangeDuration, rule.RangeStep)
+	results, err := client.QueryRange(ctx, rule.Expression, start, end, step)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, series := range results {
+		if v, ok := cond.Evaluate(series.Values); ok {
+			matches = append(matches, models.QueryResult{Metric: series.Metric, Value: models.Sample{Timestamp: end, Value: v}})
+		}
+	}
+	return matches, len(results), nil
+}
+
+// TopOffendersAnnotation and FiringSeriesAnnotation are set on the single alert of an aggregate-mode rule.
+const (
+	TopOffendersAnnotation = "top_offenders"
+	FiringSeriesAnnotation = "firing_series"
+)
+
+// ValueAnnotation and ThresholdAnnotation are set on alerts of rules with a comparison_operator: the
+// breaching value and the comparison it breached.
+const (
+	ValueAnnotation     = "value"
+	ThresholdAnnotation = "threshold"
+)
+
+// aggregateFiring collapses the firing series of an aggregate-mode rule into one alert. Its labels are the
+// rule labels only, so the fingerprint stays stable while the set of offending series changes; the value is
+// the highest one and the severity the most severe. The top-N series by value are listed in the
+// top_offenders annotation.
+func (e *AlertEvaluator) aggregateFiring(rule models.AlertRule, firing []models.FiringAlert) models.FiringAlert {
+	sort.SliceStable(firing, func(i, j int) bool { return firing[i].Value > firing[j].Value })
+	top := firing[0]
+
+	severity := top.Severity
+	for _, fa := range firing[1:] {
+		if severityRank(fa.Severity) > severityRank(severity) {
+			severity = fa.Severity
+		}
+	}
+
+	topN := rule.AggregateTopN
+	if topN <= 0 {
+		topN = 10
+	}
+	labels := e.mergeLabels(rule.Labels, nil)
+	var lines []string
+	for i, fa := range firing {
+		if i == topN {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(firing)-topN))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", FormatValue(fa.Value, rule.ValueFormat), formatSeriesLabels(fa.Labels, labels)))
+	}
+
+	annotations := e.parseAnnotations(rule.Annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		annotations[k] = expandAlertTemplate(v, nil, top.Value)
+	}
+	annotations = addThresholdAnnotations(annotations, rule, top.Value)
+	annotations[FiringSeriesAnnotation] = strconv.Itoa(len(firing))
+	annotations[TopOffendersAnnotation] = strings.Join(lines, "\n")
+
+	return models.FiringAlert{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		Severity:    severity,
+		Fingerprint: models.GenerateFingerprint(labels),
+		Labels:      labels,
+		Annotations: annotations,
+		StartsAt:    top.StartsAt,
+		Value:       top.Value,
+		Status:      "firing",
+	}
+}
+
+// formatSeriesLabels renders series labels as {k="v", ...} with sorted keys, leaving out the labels
+// that are the same in common (the rule labels shared by every series).
+func formatSeriesLabels(labels, common map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if cv, ok := common[k]; ok && cv == v {
+			continue
+		}
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
 
-	results, err := client.Query(ctx, rule.Expression, "")
+// BacktestAlert is one alert a rule would have raised during a backtest window.
+type BacktestAlert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	PendingAt   time.Time         `json:"pending_at"`
+	FiredAt     time.Time         `json:"fired_at"`
+	ResolvedAt  *time.Time        `json:"resolved_at"` // nil if still firing at the end of the window
+	PeakValue   float64           `json:"peak_value"`
+}
+
+// BacktestResult summarizes a rule backtest over a historical window.
+type BacktestResult struct {
+	RuleID uuid.UUID       `json:"rule_id"`
+	Start  time.Time       `json:"start"`
+	End    time.Time       `json:"end"`
+	Step   string          `json:"step"`
+	Series int             `json:"series"`
+	Alerts []BacktestAlert `json:"alerts"`
+}
+
+// Backtest runs the rule's expression as a range query over [start, end] and replays the worker's firing
+// logic (threshold plus for_duration) on each series to report when the rule would have fired. Range-mode
+// rules are replayed by applying range_condition to the samples of the range_duration ending at each step.
+// It has no side effects: no history, SLA records or notifications are created.
+// A series missing for more than one and a half steps counts as resolved.
+func (e *AlertEvaluator) Backtest(ctx context.Context, rule models.AlertRule, ds models.DataSource, start, end time.Time, step time.Duration) (*BacktestResult, error) {
+	stepStr := strconv.FormatFloat(step.Seconds(), 'f', -1, 64)
+	var results []models.QueryResult
+	var err error
+	switch ds.Type {
+	case "victoria-metrics":
+		var client *VictoriaMetricsClient
+		if client, err = NewVictoriaMetricsClientWithConfig(ds.Endpoint, ds.Config); err == nil {
+			results, err = client.QueryRange(ctx, rule.Expression, start, end, stepStr)
+		}
+	default:
+		var client *PrometheusClient
+		if client, err = NewPrometheusClientWithConfig(ds.Endpoint, ds.Config); err == nil {
+			results, err = client.QueryRange(ctx, rule.Expression, start, end, stepStr)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	for _, result := range results {
-		if e.checkThreshold(result.Value.Value, rule) {
-			labels := e.mergeLabels(rule.Labels, result.Metric)
-			annotations := e.parseAnnotations(rule.Annotations)
-			firing = append(firing, models.FiringAlert{
-				RuleID:      rule.ID,
-				RuleName:    rule.Name,
-				Severity:    rule.Severity,
-				Fingerprint: models.GenerateFingerprint(labels),
-				Labels:      labels,
-				Annotations: annotations,
-				StartsAt:    time.Now(),
-				Value:       result.Value.Value,
-				Status:      "firing",
-			})
+	var cond *RangeCondition
+	var window time.Duration
+	if rule.EvaluationMode == models.EvaluationModeRange {
+		if cond, err = ParseRangeCondition(rule.RangeCondition); err != nil {
+			return nil, err
 		}
+		rangeStart, rangeEnd, _ := rangeWindow(rule.RangeDuration, rule.RangeStep)
+		window = rangeEnd.Sub(rangeStart)
 	}
 
-	return firing, nil
+	out := &BacktestResult{RuleID: rule.ID, Start: start, End: end, Step: stepStr, Series: len(results), Alerts: []BacktestAlert{}}
+	forDuration := time.Duration(rule.ForDuration) * time.Second
+	maxGap := step + step/2
+
+	for _, series := range results {
+		labels := e.mergeLabels(rule.Labels, series.Metric)
+		fingerprint := models.GenerateFingerprint(labels)
+
+		var active *BacktestAlert
+		var fired bool
+		var prev time.Time
+		closeActive := func(at time.Time) {
+			if active != nil && fired {
+				t := at
+				active.ResolvedAt = &t
+				out.Alerts = append(out.Alerts, *active)
+			}
+			active, fired = nil, false
+		}
+
+		lo := 0
+		for i, sample := range series.Values {
+			if active != nil && sample.Timestamp.Sub(prev) > maxGap {
+				closeActive(prev.Add(step))
+			}
+			prev = sample.Timestamp
+			value, ok := sample.Value, false
+			if cond != nil {
+				for series.Values[lo].Timestamp.Before(sample.Timestamp.Add(-window)) {
+					lo++
+				}
+				value, ok = cond.Evaluate(series.Values[lo : i+1])
+			} else {
+				ok = e.checkThreshold(value, rule)
+			}
+			if !ok {
+				closeActive(sample.Timestamp)
+				continue
+			}
+			if active == nil {
+				active = &BacktestAlert{Fingerprint: fingerprint, Labels: labels, PendingAt: sample.Timestamp, PeakValue: value}
+			}
+			if value > active.PeakValue {
+				active.PeakValue = value
+			}
+			if !fired && sample.Timestamp.Sub(active.PendingAt) >= forDuration {
+				active.FiredAt = sample.Timestamp
+				fired = true
+			}
+		}
+		if active != nil && fired {
+			out.Alerts = append(out.Alerts, *active)
+		}
+	}
+
+	return out, nil
 }
 
+// alertSeverity returns the severity for one firing series: the value of the rule's severity_label
+// (looked up in the series labels, then annotations) when it is a known severity, otherwise the rule's severity.
+func alertSeverity(rule models.AlertRule, labels, annotations map[string]string) string {
+	if rule.SeverityLabel == "" {
+		return rule.Severity
+	}
+	v, ok := labels[rule.SeverityLabel]
+	if !ok {
+		v = annotations[rule.SeverityLabel]
+	}
+	if sev, err := NormalizeSeverity(v); err == nil {
+		return sev
+	}
+	return rule.Severity
+}
+
+// comparisonOperators are the rule comparison_operator values and their comparisons of a sample value
+// against the rule threshold.
+var comparisonOperators = map[string]struct {
+	symbol  string
+	compare func(v, threshold float64) bool
+}{
+	"gt":  {">", func(v, t float64) bool { return v > t }},
+	"gte": {">=", func(v, t float64) bool { return v >= t }},
+	"lt":  {"<", func(v, t float64) bool { return v < t }},
+	"lte": {"<=", func(v, t float64) bool { return v <= t }},
+	"eq":  {"==", func(v, t float64) bool { return v == t }},
+	"neq": {"!=", func(v, t float64) bool { return v != t }},
+}
+
+// ValidateComparison checks a rule's comparison_operator and threshold: no operator keeps the default
+// (value > 0), and an operator needs a threshold.
+func ValidateComparison(operator string, threshold *float64) error {
+	if operator == "" {
+		return nil
+	}
+	if _, ok := comparisonOperators[operator]; !ok {
+		return fmt.Errorf("%w: comparison_operator must be one of gt, gte, lt, lte, eq, neq", ErrInvalidComparison)
+	}
+	if threshold == nil {
+		return fmt.Errorf("%w: threshold is required with comparison_operator", ErrInvalidComparison)
+	}
+	return nil
+}
+
+// checkThreshold reports whether a sample value fires the rule: it is compared against the rule threshold
+// with the rule's comparison_operator, or must be positive when the rule has none.
 func (e *AlertEvaluator) checkThreshold(value float64, rule models.AlertRule) bool {
+	if op, ok := comparisonOperators[rule.ComparisonOperator]; ok && rule.Threshold != nil {
+		return op.compare(value, *rule.Threshold)
+	}
 	return value > 0
 }
 
+// addThresholdAnnotations sets the value and threshold annotations of an alert of a rule with a
+// comparison_operator, e.g. value "92.5" and threshold "> 90", unless the rule defines them itself.
+func addThresholdAnnotations(annotations map[string]string, rule models.AlertRule, value float64) map[string]string {
+	op, ok := comparisonOperators[rule.ComparisonOperator]
+	if !ok || rule.Threshold == nil || rule.EvaluationMode == models.EvaluationModeRange {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if _, set := annotations[ValueAnnotation]; !set {
+		annotations[ValueAnnotation] = FormatValue(value, rule.ValueFormat)
+	}
+	if _, set := annotations[ThresholdAnnotation]; !set {
+		annotations[ThresholdAnnotation] = op.symbol + " " + FormatValue(*rule.Threshold, rule.ValueFormat)
+	}
+	return annotations
+}
+
+// mergeLabels returns the rule labels, with $labels templates expanded against the series, overridden by the
+// series labels. Labels make up the fingerprint, so "{{ $value }}" is not expanded in them: a label changing
+// with every evaluation would start a new alert each time.
 func (e *AlertEvaluator) mergeLabels(ruleLabels string, metricLabels map[string]string) map[string]string {
 	result := make(map[string]string)
 
@@ -116,7 +451,7 @@ func (e *AlertEvaluator) mergeLabels(ruleLabels string, metricLabels map[string]
 	}
 	if ruleL != nil {
 		for k, v := range ruleL {
-			result[k] = v
+			result[k] = expandTemplateVars(v, metricLabels, nil)
 		}
 	}
 
@@ -127,6 +462,36 @@ func (e *AlertEvaluator) mergeLabels(ruleLabels string, metricLabels map[string]
 	return result
 }
 
+// alertTemplateVar matches the placeholders expandAlertTemplate substitutes, "{{ $labels.<name> }}" and
+// "{{ $value }}", with or without spaces inside the braces.
+var alertTemplateVar = regexp.MustCompile(`\{\{\s*\$(?:value|labels\.([a-zA-Z_][a-zA-Z0-9_]*))\s*\}\}`)
+
+// expandAlertTemplate interpolates the firing series' labels and value into a rule annotation, e.g.
+// "High CPU on {{ $labels.instance }}" or "{{ $value }} errors/s", as Prometheus does. Only these two
+// placeholders are recognized, so rule text never runs template logic: a label the series lacks renders
+// empty, and any other "{{ ... }}" is left as written.
+func expandAlertTemplate(text string, labels map[string]string, value float64) string {
+	return expandTemplateVars(text, labels, &value)
+}
+
+// expandTemplateVars substitutes the placeholders of alertTemplateVar; with a nil value "{{ $value }}" is
+// left as written.
+func expandTemplateVars(text string, labels map[string]string, value *float64) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return alertTemplateVar.ReplaceAllStringFunc(text, func(m string) string {
+		name := alertTemplateVar.FindStringSubmatch(m)[1]
+		if name != "" {
+			return labels[name]
+		}
+		if value == nil {
+			return m
+		}
+		return strconv.FormatFloat(*value, 'g', -1, 64)
+	})
+}
+
 func (e *AlertEvaluator) EvaluateAllRules(ctx context.Context, rules []models.AlertRule, ds models.DataSource) ([]models.FiringAlert, error) {
 	var allFiring []models.FiringAlert
 
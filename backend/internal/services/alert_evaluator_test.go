@@ -0,0 +1,83 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// prometheusServer answers instant queries with a single series whose value is next().
+func prometheusServer(t *testing.T, metric string, next func() float64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":%s,"value":[1700000000,"%g"]}]}}`, metric, next())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEvaluateRuleKeepsFingerprintAcrossValues(t *testing.T) {
+	var value atomic.Int64
+	srv := prometheusServer(t, `{"instance":"db-1:9100","cluster":"east"}`, func() float64 { return float64(value.Add(1)) })
+	rule := models.AlertRule{
+		ID:          uuid.New(),
+		Name:        "replication lag",
+		Expression:  "pg_replication_lag_seconds",
+		Severity:    "warning",
+		Labels:      `{"team": "db-{{ $labels.cluster }}", "reading": "{{ $value }}"}`,
+		Annotations: `{"summary": "lag is {{ $value }}s"}`,
+	}
+	ds := models.DataSource{ID: uuid.New(), Endpoint: srv.URL}
+	e := NewAlertEvaluator(0)
+
+	first, err := e.EvaluateRule(context.Background(), rule, ds)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("first evaluation: %v, %d alerts", err, len(first))
+	}
+	second, err := e.EvaluateRule(context.Background(), rule, ds)
+	if err != nil || len(second) != 1 {
+		t.Fatalf("second evaluation: %v, %d alerts", err, len(second))
+	}
+
+	if first[0].Fingerprint != second[0].Fingerprint {
+		t.Errorf("fingerprint changed with the value: %v vs %v", first[0].Labels, second[0].Labels)
+	}
+	if got := first[0].Labels["team"]; got != "db-east" {
+		t.Errorf("team label = %q, want db-east", got)
+	}
+	if got := first[0].Labels["reading"]; got != "{{ $value }}" {
+		t.Errorf("reading label = %q, want $value kept as written", got)
+	}
+	if got := second[0].Annotations["summary"]; got != "lag is 2s" {
+		t.Errorf("summary annotation = %q, want lag is 2s", got)
+	}
+}
+
+func TestExpandAlertTemplate(t *testing.T) {
+	labels := map[string]string{"instance": "db-1:9100", "job": "node"}
+	for _, tc := range []struct {
+		name, text, want string
+	}{
+		{"plain text", "disk almost full", "disk almost full"},
+		{"label", "High CPU on {{ $labels.instance }}", "High CPU on db-1:9100"},
+		{"no spaces", "{{$labels.job}}/{{$value}}", "node/0.93"},
+		{"value", "{{ $value }} errors/s", "0.93 errors/s"},
+		{"missing label", "zone {{ $labels.zone }}!", "zone !"},
+		{"other placeholder kept", "{{ .Labels.instance }} {{ $externalURL }}", "{{ .Labels.instance }} {{ $externalURL }}"},
+		{"no template logic", `{{ if $labels.job }}x{{ end }}`, `{{ if $labels.job }}x{{ end }}`},
+	} {
+		if got := expandAlertTemplate(tc.text, labels, 0.93); got != tc.want {
+			t.Errorf("%s: expandAlertTemplate(%q) = %q, want %q", tc.name, tc.text, got, tc.want)
+		}
+	}
+
+	if got := expandAlertTemplate("{{ $labels.instance }} at {{ $value }}", nil, 12); got != " at 12" {
+		t.Errorf("without series labels: got %q, want %q", got, " at 12")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"sort"
 	"strconv"
@@ -14,9 +15,19 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// aggregateSummary returns the firing series count and top offenders of an aggregate-mode alert, or "".
+func aggregateSummary(fa models.FiringAlert) string {
+	top := fa.Annotations[TopOffendersAnnotation]
+	if top == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s series firing\n%s", fa.Annotations[FiringSeriesAnnotation], top)
+}
+
 // formatMapToKeyValueLines parses jsonStr as a JSON object and returns markdown-style lines "**key**: value" per entry (keys sorted for stable output). Auto-adapts to any Prometheus labels/annotations.
 func formatMapToKeyValueLines(jsonStr string) string {
 	if jsonStr == "" || jsonStr == "{}" {
@@ -60,6 +71,8 @@ type pendingKey struct {
 type pendingState struct {
 	firstSeenAt time.Time
 	notified    bool
+	missed      int // consecutive runs a notified series has been absent, for resolve_confirmations
+	silencedBy  uuid.UUID // silence suppressing the series before it was notified, to log it once
 }
 
 // AlertNotificationWorker evaluates alert rules periodically and sends notifications.
@@ -68,18 +81,28 @@ type AlertNotificationWorker struct {
 	ruleRepo       *repository.AlertRuleRepository
 	historyRepo    *repository.AlertHistoryRepository
 	evaluator      *AlertEvaluator
+	breaker        *DataSourceBreaker
 	sender         *NotificationSender
 	templateSvc    *AlertTemplateService
 	silenceSvc     *AlertSilenceService
 	slaSvc         *SLAService
 	slaBreachSvc   *SLABreachService
+	outbox         *NotificationOutbox
+	status         *WorkerStatus
+	stateWebhook   *StateWebhook
 	broadcaster    Broadcaster
 	checkInterval  time.Duration
+	evalJitter     time.Duration
+	batchSize      int // rules loaded and evaluated at a time
+	maxRules       int // safety cap on rules evaluated per cycle
+	warnedRules    int // rule count last warned about exceeding batchSize
 	pendingMu      sync.Mutex
 	pending        map[pendingKey]pendingState
+	outboxOnce     sync.Once
 }
 
 // NewAlertNotificationWorker returns a new AlertNotificationWorker.
+// broadcaster may be nil (e.g. the standalone worker), in which case real-time notifications are skipped.
 func NewAlertNotificationWorker(
 	db *pgxpool.Pool,
 	ruleRepo *repository.AlertRuleRepository,
@@ -98,21 +121,99 @@ func NewAlertNotificationWorker(
 		ruleRepo:      ruleRepo,
 		historyRepo:   historyRepo,
 		evaluator:     evaluator,
+		breaker:       NewDataSourceBreaker(db, 0, 0),
 		sender:        sender,
 		templateSvc:   templateSvc,
 		silenceSvc:    silenceSvc,
 		slaSvc:        slaSvc,
 		slaBreachSvc:  slaBreachSvc,
+		outbox:        NewNotificationOutbox(db, sender),
+		status:        NewWorkerStatus(),
 		broadcaster:   broadcaster,
 		checkInterval: checkInterval,
+		batchSize:     defaultRuleBatchSize,
+		maxRules:      defaultMaxRules,
 		pending:       make(map[pendingKey]pendingState),
 	}
 }
 
+// WithStatus sets the status the worker records each evaluation cycle into, so it can be shared
+// with the API's health endpoint.
+func (w *AlertNotificationWorker) WithStatus(status *WorkerStatus) *AlertNotificationWorker {
+	w.status = status
+	return w
+}
+
+// WithStateWebhook emits a created/resolved event to hook for every alert the worker records. hook may be nil.
+func (w *AlertNotificationWorker) WithStateWebhook(hook *StateWebhook) *AlertNotificationWorker {
+	w.stateWebhook = hook
+	return w
+}
+
+// WithEvalJitter spreads rule evaluations over [0, jitter) after each tick instead of running them all
+// at once, so data sources do not see a query spike every cycle. The jitter is capped at the check interval.
+func (w *AlertNotificationWorker) WithEvalJitter(jitter time.Duration) *AlertNotificationWorker {
+	if jitter > w.checkInterval {
+		jitter = w.checkInterval
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
+	w.evalJitter = jitter
+	return w
+}
+
+// Rule batching defaults, overridden by worker.batch_size and worker.max_rules.
+const (
+	defaultRuleBatchSize = 500
+	defaultMaxRules      = 50000
+)
+
+// WithRuleBatch sets how many enabled rules each cycle loads and evaluates at a time (batchSize) and the
+// most rules it evaluates per cycle (maxRules), keeping the worker's memory bounded as rules grow.
+// Rules past the cap are not evaluated that cycle and their alerts are left as they are. Non-positive
+// values keep the defaults of 500 and 50000.
+func (w *AlertNotificationWorker) WithRuleBatch(batchSize, maxRules int) *AlertNotificationWorker {
+	if batchSize <= 0 {
+		batchSize = defaultRuleBatchSize
+	}
+	if maxRules <= 0 {
+		maxRules = defaultMaxRules
+	}
+	if batchSize > maxRules {
+		batchSize = maxRules
+	}
+	w.batchSize, w.maxRules = batchSize, maxRules
+	return w
+}
+
+// WithDataSourceBreaker sets when a failing data source's circuit opens (failureThreshold consecutive
+// failures) and how long its rules are skipped before a probe (cooldown). Non-positive values keep the
+// defaults of 5 failures and 1m.
+func (w *AlertNotificationWorker) WithDataSourceBreaker(failureThreshold int, cooldown time.Duration) *AlertNotificationWorker {
+	w.breaker = NewDataSourceBreaker(w.db, failureThreshold, cooldown)
+	return w
+}
+
+// evalOffset returns the rule's delay within the jitter window. It is derived from the rule ID so each
+// rule keeps a stable slot (and a steady evaluation interval) while rules are spread across the window.
+func (w *AlertNotificationWorker) evalOffset(ruleID uuid.UUID) time.Duration {
+	if w.evalJitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(ruleID[:])
+	return time.Duration(h.Sum64() % uint64(w.evalJitter))
+}
+
 // inEffectiveWindow returns true if t (server local) is within the rule's daily effective window.
 func inEffectiveWindow(rule models.AlertRule, t time.Time) bool {
-	start := rule.EffectiveStartTime
-	end := rule.EffectiveEndTime
+	return inDailyWindow(rule.EffectiveStartTime, rule.EffectiveEndTime, t)
+}
+
+// inDailyWindow returns true if t's time of day is within start-end (HH:MM, inclusive). Empty start/end
+// default to 00:00/23:59; a window with start after end spans midnight.
+func inDailyWindow(start, end string, t time.Time) bool {
 	if start == "" {
 		start = "00:00"
 	}
@@ -179,8 +280,181 @@ func inExclusionWindow(rule models.AlertRule, t time.Time) bool {
 	return false
 }
 
-// Start runs the worker loop until ctx is cancelled.
+// recordWithNotification runs record and enqueues payload for the alert ID it returns in a single
+// transaction, so an alert is never recorded without its notification. The outbox is woken on commit.
+// Alerts matching an active silence (only resolves can, firing alerts are checked before recording), or recorded with notify false (a resolve of a rule with
+// notify_on_resolve off), are recorded without a notification.
+func (w *AlertNotificationWorker) recordWithNotification(ctx context.Context, groupID uuid.UUID, payload *AlertPayload, notify bool, record func(tx pgx.Tx) (uuid.UUID, error)) error {
+	var silence *models.AlertSilence
+	if notify {
+		var labels map[string]string
+		json.Unmarshal([]byte(payload.Labels), &labels)
+		silence = w.matchingSilence(ctx, groupID, labels)
+		notify = silence == nil
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	alertID, err := record(tx)
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := w.outbox.Enqueue(ctx, tx, alertID, payload); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if notify {
+		w.outbox.Notify()
+	} else if silence != nil {
+		log.Printf("AlertNotificationWorker: %s %s is silenced by %q (%s); notification suppressed",
+			payload.AlertNo, payload.Status, silence.Name, silence.ID)
+	}
+
+	event := AlertStateEvent{
+		Event:    AlertEventCreated,
+		AlertID:  alertID,
+		AlertNo:  payload.AlertNo,
+		RuleID:   payload.RuleID,
+		RuleName: payload.RuleName,
+		Severity: payload.Severity,
+		NewState: payload.Status,
+	}
+	if payload.Status == "resolved" {
+		event.Event = AlertEventResolved
+		event.OldState = "firing"
+		if payload.EndedAt != nil {
+			event.Timestamp = *payload.EndedAt
+		}
+	} else {
+		event.Timestamp = payload.StartedAt
+	}
+	w.stateWebhook.Emit(event)
+	return nil
+}
+
+// recentOccurrenceWindow is the window of the recentCount template variable.
+const recentOccurrenceWindow = 24 * time.Hour
+
+// occurrenceContext returns the recentCount and lastResolved template values of a fingerprint: the
+// number of its alerts started in the last 24h plus unrecorded, and when it last resolved ("-" if never).
+func (w *AlertNotificationWorker) occurrenceContext(ctx context.Context, ruleID uuid.UUID, fingerprint string, unrecorded int) (int, string) {
+	count, lastResolved, err := w.historyRepo.RecentOccurrences(ctx, ruleID, fingerprint, time.Now().Add(-recentOccurrenceWindow))
+	if err != nil {
+		log.Printf("AlertNotificationWorker: recent occurrences of %s/%s: %v", ruleID, fingerprint, err)
+	}
+	last := "-"
+	if lastResolved != nil {
+		last = lastResolved.Format("2006-01-02 15:04:05")
+	}
+	return count + unrecorded, last
+}
+
+// matchingSilence returns the active silence matching the labels of an alert of a rule in groupID, or
+// nil. Lookup errors are logged and treated as not silenced, so a database hiccup never swallows a
+// notification.
+func (w *AlertNotificationWorker) matchingSilence(ctx context.Context, groupID uuid.UUID, labels map[string]string) *models.AlertSilence {
+	if w.silenceSvc == nil {
+		return nil
+	}
+	silence, err := w.silenceSvc.MatchingSilence(ctx, groupID, labels)
+	if err != nil {
+		log.Printf("AlertNotificationWorker: check silences: %v", err)
+		return nil
+	}
+	return silence
+}
+
+const (
+	workerRestartMinBackoff = time.Second
+	workerRestartMaxBackoff = time.Minute
+	// workerHealthyRun is how long Start must run before the restart backoff resets.
+	workerHealthyRun = 5 * time.Minute
+)
+
+// Run runs Start under supervision until ctx is cancelled: if Start returns or panics for any reason
+// other than cancellation, it is restarted with exponential backoff and the restart is logged and
+// counted in the worker status.
+func (w *AlertNotificationWorker) Run(ctx context.Context) {
+	backoff := workerRestartMinBackoff
+	for {
+		started := time.Now()
+		err := w.startRecovered(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("worker stopped unexpectedly")
+		}
+		if time.Since(started) >= workerHealthyRun {
+			backoff = workerRestartMinBackoff
+		}
+		log.Printf("AlertNotificationWorker: %v; restarting in %v", err, backoff)
+		w.status.recordRestart(err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > workerRestartMaxBackoff {
+			backoff = workerRestartMaxBackoff
+		}
+	}
+}
+
+// startRecovered calls Start, converting a panic into an error.
+func (w *AlertNotificationWorker) startRecovered(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("worker panic: %v", r)
+		}
+	}()
+	return w.Start(ctx)
+}
+
+// restorePending seeds pending with the alerts recorded as firing, marked notified, so a series still
+// firing after a process restart continues its firing period instead of being recorded and notified
+// again, and resolves as usual when it stops. Series already pending are kept as they are.
+func (w *AlertNotificationWorker) restorePending(ctx context.Context) error {
+	firing, err := w.historyRepo.ListFiring(ctx)
+	if err != nil {
+		return err
+	}
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	restored := 0
+	for _, f := range firing {
+		key := pendingKey{ruleID: f.RuleID, fingerprint: f.Fingerprint}
+		if _, ok := w.pending[key]; ok {
+			continue
+		}
+		w.pending[key] = pendingState{firstSeenAt: f.StartedAt, notified: true}
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("AlertNotificationWorker: restored %d firing alerts from alert_history", restored)
+	}
+	return nil
+}
+
+// Start runs the worker loop until ctx is cancelled. Notifications are delivered by the outbox dispatcher,
+// which is started once and keeps running across restarts of the loop. Before the first cycle the
+// pending state is restored from the firing alerts in alert_history.
 func (w *AlertNotificationWorker) Start(ctx context.Context) error {
+	w.outboxOnce.Do(func() { go w.outbox.Run(ctx) })
+	if err := w.restorePending(ctx); err != nil {
+		log.Printf("AlertNotificationWorker: restore firing alerts: %v", err)
+	}
+	w.status.started(w.checkInterval)
 	ticker := time.NewTicker(w.checkInterval)
 	defer ticker.Stop()
 	for {
@@ -188,44 +462,147 @@ func (w *AlertNotificationWorker) Start(ctx context.Context) error {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if err := w.runOnce(ctx); err != nil {
+			start := time.Now()
+			var stats workerRunStats
+			if err := w.runOnce(ctx, &stats); err != nil {
 				log.Printf("AlertNotificationWorker runOnce: %v", err)
+				stats.fail(err)
+			}
+			if _, err := w.escalateUnacknowledged(ctx); err != nil {
+				log.Printf("AlertNotificationWorker: escalate unacknowledged alerts: %v", err)
+			}
+			stats.openDataSources = w.breaker.OpenEndpoints()
+			if elapsed := time.Since(start); elapsed > w.checkInterval {
+				log.Printf("AlertNotificationWorker: evaluation cycle took %s, longer than the check interval %s; cycles are falling behind",
+					elapsed.Round(time.Millisecond), w.checkInterval)
+			}
+			if stats.rulesSkipped > 0 {
+				log.Printf("AlertNotificationWorker: skipped %d rules on open data sources %v", stats.rulesSkipped, stats.openDataSources)
 			}
+			w.pendingMu.Lock()
+			pendingAlerts := len(w.pending)
+			w.pendingMu.Unlock()
+			pendingNotifications, err := w.outbox.PendingCount(ctx)
+			if err != nil {
+				log.Printf("AlertNotificationWorker: count pending notifications: %v", err)
+			}
+			elapsed := time.Since(start)
+			w.status.recordRun(start, elapsed, stats, pendingAlerts, pendingNotifications)
+			observeCycle(elapsed, stats)
 		}
 	}
 }
 
-func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
-	// List enabled rules (status "1"); use a large page size to evaluate all.
-	rules, _, err := w.ruleRepo.List(ctx, 1, 5000, nil, "", "1")
-	if err != nil {
-		return err
+// anyAllowed reports whether the circuit of at least one of sources is closed.
+func (w *AlertNotificationWorker) anyAllowed(sources []evalSource) bool {
+	for _, src := range sources {
+		if w.breaker.Allow(src.ds.Endpoint) {
+			return true
+		}
 	}
-	if len(rules) == 0 {
-		return nil
+	return false
+}
+
+// evaluateWithFailover evaluates rule against each of sources in order, skipping those with an open
+// circuit, until one responds, and returns its result and the source that served it. Each failure
+// feeds the source's circuit and evalErrors; the error of the last source tried is returned when all
+// fail.
+func (w *AlertNotificationWorker) evaluateWithFailover(ctx context.Context, rule models.AlertRule, sources []evalSource,
+	evalErrors *evalErrorLog) ([]models.FiringAlert, int, *evalSource, error) {
+	var lastErr error
+	for i := range sources {
+		src := &sources[i]
+		if !w.breaker.Allow(src.ds.Endpoint) {
+			continue
+		}
+		firing, series, err := w.evaluator.EvaluateRuleSeries(ctx, rule, src.ds)
+		if err == nil {
+			w.breaker.Success(ctx, src.ds.Endpoint)
+			return firing, series, src, nil
+		}
+		if ctx.Err() != nil {
+			return nil, 0, nil, ctx.Err()
+		}
+		evalErrors.add(src.ds.Endpoint, rule.ID, err)
+		w.breaker.Failure(ctx, src.ds.Endpoint, err)
+		lastErr = err
 	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no data source available")
+	}
+	return nil, 0, nil, lastErr
+}
 
-	// Build minimal data source from rule (evaluator uses Endpoint and creates client on demand).
-	seenThisRun := make(map[pendingKey]struct{})
-	ruleByID := make(map[uuid.UUID]models.AlertRule)
+// evaluateBatch evaluates a batch of rules, recording new firing alerts and the series seen this run.
+// With eval jitter each rule waits for its slot after runStart, the start of the cycle, so batches share
+// one jitter window. It returns an error only when ctx is cancelled.
+func (w *AlertNotificationWorker) evaluateBatch(ctx context.Context, runStart time.Time, rules []models.AlertRule, sources *evalSources,
+	seenThisRun map[pendingKey]struct{}, unevaluated map[uuid.UUID]struct{}, evalErrors *evalErrorLog, stats *workerRunStats) error {
+	candidates := make(map[uuid.UUID][]evalSource, len(rules))
 	for _, rule := range rules {
-		ruleByID[rule.ID] = rule
+		if c := sources.forRule(rule); len(c) > 0 {
+			candidates[rule.ID] = c
+		}
+	}
+	w.status.evalQueued(len(candidates))
+
+	// The outcome of every evaluated rule is stored once the batch is done.
+	var evals []repository.RuleEvaluation
+	defer func() {
+		if err := w.ruleRepo.RecordEvaluations(context.WithoutCancel(ctx), evals); err != nil {
+			log.Printf("AlertNotificationWorker: record rule evaluations: %v", err)
+		}
+	}()
+
+	// With eval jitter, evaluate rules in offset order and wait for each rule's slot.
+	if w.evalJitter > 0 {
+		sort.SliceStable(rules, func(i, j int) bool {
+			return w.evalOffset(rules[i].ID) < w.evalOffset(rules[j].ID)
+		})
 	}
 
 	for _, rule := range rules {
-		if rule.DataSourceURL == "" {
+		ruleSources, ok := candidates[rule.ID]
+		if !ok {
 			continue
 		}
-		ds := models.DataSource{
-			ID:       uuid.New(),
-			Type:     rule.DataSourceType,
-			Endpoint: rule.DataSourceURL,
+		if wait := time.Until(runStart.Add(w.evalOffset(rule.ID))); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		if !w.anyAllowed(ruleSources) {
+			w.status.evalDequeued()
+			unevaluated[rule.ID] = struct{}{}
+			stats.rulesSkipped++
+			continue
 		}
-		firingList, err := w.evaluator.EvaluateRule(ctx, rule, ds)
+		done := w.status.evalStarted()
+		evalStart := time.Now()
+		firingList, series, served, err := w.evaluateWithFailover(ctx, rule, ruleSources, evalErrors)
+		done()
+		stats.evalDurations = append(stats.evalDurations, time.Since(evalStart))
 		if err != nil {
-			log.Printf("AlertNotificationWorker: evaluate rule %s: %v", rule.ID, err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			evals = append(evals, repository.RuleEvaluation{RuleID: rule.ID, At: evalStart, Status: models.RuleEvalError, Error: evalErrorMessage(err)})
+			unevaluated[rule.ID] = struct{}{}
+			stats.fail(err)
 			continue
 		}
+		stats.rulesEvaluated++
+		evalStatus := models.RuleEvalOK
+		if series == 0 {
+			evalStatus = models.RuleEvalNoData
+		}
+		eval := repository.RuleEvaluation{RuleID: rule.ID, At: evalStart, Status: evalStatus}
+		if served.registered {
+			eval.DataSourceID = &served.ds.ID
+		}
+		evals = append(evals, eval)
 
 		now := time.Now()
 		for _, fa := range firingList {
@@ -241,6 +618,10 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 			if !exists {
 				state = pendingState{firstSeenAt: time.Now(), notified: false}
 				w.pending[key] = state
+			} else if state.missed > 0 {
+				// Seen again before recovery was confirmed: still the same firing period.
+				state.missed = 0
+				w.pending[key] = state
 			}
 			w.pendingMu.Unlock()
 
@@ -253,6 +634,20 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 				continue
 			}
 
+			// A silenced series is neither recorded nor notified but stays pending, unnotified, so it is
+			// recorded and notified if it still fires when the silence ends, and dropped if it resolves first.
+			if silence := w.matchingSilence(ctx, rule.GroupID, fa.Labels); silence != nil {
+				if state.silencedBy != silence.ID {
+					log.Printf("AlertNotificationWorker: rule %s (%s) series %s is silenced by %q (%s) until %s; not recorded or notified",
+						rule.Name, rule.ID, fa.Fingerprint, silence.Name, silence.ID, silence.EndTime.Format(time.RFC3339))
+					w.pendingMu.Lock()
+					state.silencedBy = silence.ID
+					w.pending[key] = state
+					w.pendingMu.Unlock()
+				}
+				continue
+			}
+
 			// Mark as notified so we do not send again until this firing period ends.
 			w.pendingMu.Lock()
 			w.pending[key] = pendingState{firstSeenAt: state.firstSeenAt, notified: true}
@@ -269,32 +664,11 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 				annotationsJSON = string(b)
 			}
 
-			history := &models.AlertHistory{
-				RuleID:      rule.ID,
-				Fingerprint: fa.Fingerprint,
-				Severity:    rule.Severity,
-				Status:      "firing",
-				StartedAt:   fa.StartsAt,
-				Labels:      labelsJSON,
-				Annotations: annotationsJSON,
-			}
-			if err := w.historyRepo.Create(ctx, history); err != nil {
-				log.Printf("AlertNotificationWorker: create alert_history: %v", err)
-				continue
-			}
-
-			// Create SLA record for this alert if config exists.
-			if w.slaSvc != nil {
-				if err := w.slaSvc.CreateAlertSLA(ctx, history.ID, rule.ID, rule.Severity, history.StartedAt); err != nil {
-					log.Printf("AlertNotificationWorker: create alert_sla: %v", err)
-				}
-			}
-
 			var renderedContent string
 			if rule.TemplateID != nil && w.templateSvc != nil {
 				data := map[string]interface{}{
 					"ruleName":          rule.Name,
-					"severity":          rule.Severity,
+					"severity":          fa.Severity,
 					"status":            "firing",
 					"startTime":         fa.StartsAt.Format("2006-01-02 15:04:05"),
 					"duration":          "0",
@@ -302,33 +676,67 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 					"annotations":       annotationsJSON,
 					"labelsFormatted":   formatMapToKeyValueLines(labelsJSON),
 					"annotationsFormatted": formatMapToKeyValueLines(annotationsJSON),
+					"value":             FormatValue(fa.Value, rule.ValueFormat),
+					"rawValue":          fa.Value,
+					"summary":           aggregateSummary(fa),
 				}
+				// This alert is not recorded yet, so count it on top of the previous occurrences.
+				data["recentCount"], data["lastResolved"] = w.occurrenceContext(ctx, rule.ID, fa.Fingerprint, 1)
 				if r, err := w.templateSvc.Render(ctx, *rule.TemplateID, data); err == nil {
 					renderedContent = r
 				} else {
 					log.Printf("AlertNotificationWorker: render template %s: %v", rule.TemplateID, err)
 				}
 			}
+			history := &models.AlertHistory{
+				RuleID:      rule.ID,
+				Fingerprint: fa.Fingerprint,
+				Severity:    fa.Severity,
+				Status:      "firing",
+				StartedAt:   fa.StartsAt,
+				Labels:      labelsJSON,
+				Annotations: annotationsJSON,
+			}
 			payload := &AlertPayload{
-				AlertNo:         history.AlertNo,
 				RuleID:          rule.ID,
 				RuleName:        rule.Name,
-				Severity:        rule.Severity,
+				Severity:        fa.Severity,
 				Status:          "firing",
 				Description:     rule.Description,
 				Labels:         labelsJSON,
+				Value:           FormatValue(fa.Value, rule.ValueFormat),
+				Summary:         aggregateSummary(fa),
 				StartedAt:       fa.StartsAt,
 				RenderedContent: renderedContent,
 			}
-			if err := w.sender.SendToRuleChannels(ctx, rule.ID, payload); err != nil {
-				log.Printf("AlertNotificationWorker: send to channels for rule %s: %v", rule.ID, err)
+			// History and its notification are committed together; the outbox delivers it.
+			err := w.recordWithNotification(ctx, rule.GroupID, payload, true, func(tx pgx.Tx) (uuid.UUID, error) {
+				if err := w.historyRepo.CreateTx(ctx, tx, history); err != nil {
+					return uuid.Nil, err
+				}
+				payload.AlertNo = history.AlertNo
+				return history.ID, nil
+			})
+			if err != nil {
+				log.Printf("AlertNotificationWorker: create alert_history: %v", err)
+				stats.fail(err)
+				continue
+			}
+
+			// Create SLA record for this alert if config exists.
+			if w.slaSvc != nil {
+				if err := w.slaSvc.CreateAlertSLA(ctx, history.ID, rule.ID, fa.Severity, history.StartedAt); err != nil {
+					log.Printf("AlertNotificationWorker: create alert_sla: %v", err)
+				}
 			}
+
 			if w.broadcaster != nil {
 				w.broadcaster.SendAlertNotification(&AlertNotification{
 					AlertID:   history.ID.String(),
 					RuleID:    rule.ID.String(),
 					RuleName:  rule.Name,
-					Severity:  rule.Severity,
+					GroupID:   rule.GroupID.String(),
+					Severity:  fa.Severity,
 					Status:    "firing",
 					Labels:    fa.Labels,
 					Timestamp: time.Now(),
@@ -336,19 +744,137 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 			}
 		}
 	}
+	return nil
+}
+
+// runOnce evaluates the enabled rules in batches of batchSize, up to maxRules, then resolves alerts
+// that stopped firing. Only rules with pending alerts are kept across batches, for recovery.
+func (w *AlertNotificationWorker) runOnce(ctx context.Context, stats *workerRunStats) error {
+	// Rules reference their data sources by ID or by type and URL; use the registered data source when
+	// there is one so its auth/TLS config applies, else a minimal one built from the rule.
+	sources, err := loadEvalSources(ctx, w.db)
+	if err != nil {
+		log.Printf("AlertNotificationWorker: load data sources: %v", err)
+	}
+	seenThisRun := make(map[pendingKey]struct{})
+	// Rules skipped by an open circuit or failing to evaluate: their state is unknown, so absent series
+	// must not count toward recovery.
+	unevaluated := make(map[uuid.UUID]struct{})
+	var evalErrors evalErrorLog
+	defer evalErrors.flush()
 
-	// Detect recovery: keys that were notified (firing) but are no longer in seenThisRun.
+	w.pendingMu.Lock()
+	pendingRules := make(map[uuid.UUID]struct{})
+	for key := range w.pending {
+		pendingRules[key.ruleID] = struct{}{}
+	}
+	w.pendingMu.Unlock()
+	ruleByID := make(map[uuid.UUID]models.AlertRule)
+
+	runStart := time.Now()
+	total, capped := 0, false
+	for after := uuid.Nil; ; {
+		limit := w.batchSize
+		if rest := w.maxRules - total; rest < limit {
+			limit = rest
+		}
+		rules, err := w.ruleRepo.ListEnabledAfter(ctx, after, limit)
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			break
+		}
+		after = rules[len(rules)-1].ID
+		total += len(rules)
+		for _, rule := range rules {
+			if _, ok := pendingRules[rule.ID]; ok {
+				ruleByID[rule.ID] = rule
+			}
+		}
+		if err := w.evaluateBatch(ctx, runStart, rules, sources, seenThisRun, unevaluated, &evalErrors, stats); err != nil {
+			return err
+		}
+		if len(rules) < limit {
+			break
+		}
+		if total >= w.maxRules {
+			more, err := w.ruleRepo.ListEnabledAfter(ctx, after, 1)
+			if err != nil {
+				return err
+			}
+			capped = len(more) > 0
+			break
+		}
+	}
+	if capped {
+		log.Printf("AlertNotificationWorker: more than %d enabled rules (worker.max_rules); rules past the cap were not evaluated this cycle", w.maxRules)
+	}
+	if total > w.batchSize && total != w.warnedRules {
+		log.Printf("AlertNotificationWorker: %d enabled rules exceed the batch size %d (worker.batch_size); evaluating in batches", total, w.batchSize)
+		w.warnedRules = total
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// Detect recovery: keys that were notified (firing) but have been absent from seenThisRun for
+	// the rule's resolve_confirmations consecutive runs. Keys still awaiting confirmation are kept.
 	now := time.Now()
 	w.pendingMu.Lock()
 	var recovered []pendingKey
+	awaiting := make(map[pendingKey]struct{})
 	for key, state := range w.pending {
-		if _, seen := seenThisRun[key]; !seen && state.notified {
+		if _, seen := seenThisRun[key]; seen {
+			continue
+		}
+		if _, ok := ruleByID[key.ruleID]; !ok && capped {
+			// Possibly past the rule cap and not evaluated: keep it until a cycle evaluates its rule.
+			awaiting[key] = struct{}{}
+			continue
+		}
+		if !state.notified {
+			continue
+		}
+		if _, unknown := unevaluated[key.ruleID]; unknown {
+			// Keep it, with its missed count, so the series continues its firing period once the rule
+			// is evaluated again instead of being recorded and notified as new.
+			awaiting[key] = struct{}{}
+			continue
+		}
+		state.missed++
+		confirmations := 1
+		if rule, ok := ruleByID[key.ruleID]; ok && rule.ResolveConfirmations > 1 {
+			confirmations = rule.ResolveConfirmations
+		}
+		if state.missed >= confirmations {
 			recovered = append(recovered, key)
+			continue
 		}
+		w.pending[key] = state
+		awaiting[key] = struct{}{}
 	}
 	w.pendingMu.Unlock()
 
+	// Rules with group_recovery get one notification for all their series recovered this cycle.
+	grouped := make(map[uuid.UUID][]pendingKey)
+	var single []pendingKey
 	for _, key := range recovered {
+		if rule, ok := ruleByID[key.ruleID]; ok && rule.GroupRecovery {
+			grouped[key.ruleID] = append(grouped[key.ruleID], key)
+			continue
+		}
+		single = append(single, key)
+	}
+	for ruleID, keys := range grouped {
+		if len(keys) == 1 {
+			single = append(single, keys[0])
+			continue
+		}
+		w.resolveGroup(ctx, ruleByID[ruleID], keys, now, stats)
+	}
+
+	for _, key := range single {
 		rule, ok := ruleByID[key.ruleID]
 		if !ok {
 			continue
@@ -358,21 +884,12 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 			log.Printf("AlertNotificationWorker: get latest firing for recovery %s/%s: %v", key.ruleID, key.fingerprint, err)
 			continue
 		}
-		if err := w.historyRepo.MarkResolvedByRuleAndFingerprint(ctx, key.ruleID, key.fingerprint, now); err != nil {
-			log.Printf("AlertNotificationWorker: mark resolved %s/%s: %v", key.ruleID, key.fingerprint, err)
-			continue
-		}
-		if w.slaSvc != nil {
-			if err := w.slaSvc.MarkResolved(ctx, hist.ID, now); err != nil {
-				log.Printf("AlertNotificationWorker: mark alert_sla resolved %s: %v", hist.ID, err)
-			}
-		}
 		dur := now.Sub(hist.StartedAt).Round(time.Second)
 		var renderedContent string
-		if rule.TemplateID != nil && w.templateSvc != nil {
+		if rule.NotifyOnResolve && rule.TemplateID != nil && w.templateSvc != nil {
 			data := map[string]interface{}{
 				"ruleName":            rule.Name,
-				"severity":            rule.Severity,
+				"severity":            hist.Severity,
 				"status":              "resolved",
 				"startTime":           hist.StartedAt.Format("2006-01-02 15:04:05"),
 				"duration":            dur.String(),
@@ -382,6 +899,7 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 				"labelsFormatted":     formatMapToKeyValueLines(hist.Labels),
 				"annotationsFormatted": formatMapToKeyValueLines(hist.Annotations),
 			}
+			data["recentCount"], data["lastResolved"] = w.occurrenceContext(ctx, rule.ID, key.fingerprint, 0)
 			if r, err := w.templateSvc.Render(ctx, *rule.TemplateID, data); err == nil {
 				renderedContent = r
 			} else {
@@ -392,7 +910,7 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 			AlertNo:         hist.AlertNo,
 			RuleID:          rule.ID,
 			RuleName:        rule.Name,
-			Severity:        rule.Severity,
+			Severity:        hist.Severity,
 			Status:          "resolved",
 			Description:     rule.Description,
 			Labels:          hist.Labels,
@@ -400,15 +918,27 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 			EndedAt:         &now,
 			RenderedContent: renderedContent,
 		}
-		if err := w.sender.SendToRuleChannels(ctx, rule.ID, payload); err != nil {
-			log.Printf("AlertNotificationWorker: send recovery to channels for rule %s: %v", rule.ID, err)
+		// Rules with notify_on_resolve off still record the recovery, without a notification.
+		err = w.recordWithNotification(ctx, rule.GroupID, payload, rule.NotifyOnResolve, func(tx pgx.Tx) (uuid.UUID, error) {
+			return hist.ID, w.historyRepo.MarkResolvedByRuleAndFingerprintTx(ctx, tx, key.ruleID, key.fingerprint, now)
+		})
+		if err != nil {
+			log.Printf("AlertNotificationWorker: mark resolved %s/%s: %v", key.ruleID, key.fingerprint, err)
+			stats.fail(err)
+			continue
+		}
+		if w.slaSvc != nil {
+			if err := w.slaSvc.MarkResolved(ctx, hist.ID, now); err != nil {
+				log.Printf("AlertNotificationWorker: mark alert_sla resolved %s: %v", hist.ID, err)
+			}
 		}
 		if w.broadcaster != nil {
 			w.broadcaster.SendAlertNotification(&AlertNotification{
 				AlertID:   hist.ID.String(),
 				RuleID:    rule.ID.String(),
 				RuleName:  rule.Name,
-				Severity:  rule.Severity,
+				GroupID:   rule.GroupID.String(),
+				Severity:  hist.Severity,
 				Status:    "resolved",
 				Labels:    nil,
 				Timestamp: time.Now(),
@@ -416,11 +946,14 @@ func (w *AlertNotificationWorker) runOnce(ctx context.Context) error {
 		}
 	}
 
-	// Remove from pending any (ruleID, fingerprint) that is no longer firing this run (resolved).
+	// Remove from pending any (ruleID, fingerprint) that is no longer firing this run (resolved),
+	// except notified ones still awaiting recovery confirmation or whose rule was not evaluated.
 	w.pendingMu.Lock()
 	for key := range w.pending {
 		if _, seen := seenThisRun[key]; !seen {
-			delete(w.pending, key)
+			if _, wait := awaiting[key]; !wait {
+				delete(w.pending, key)
+			}
 		}
 	}
 	w.pendingMu.Unlock()
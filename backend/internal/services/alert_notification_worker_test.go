@@ -0,0 +1,160 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// workerFixture is a rule querying a fake Prometheus, with a webhook channel bound to it.
+type workerFixture struct {
+	pool   *pgxpool.Pool
+	ruleID uuid.UUID
+	down   atomic.Bool  // the fake Prometheus answers 400, so evaluating the rule fails
+	hits   atomic.Int32 // webhook calls
+}
+
+// newWorkerFixture creates a rule whose one series is always firing while the fake Prometheus is up,
+// and removes everything it recorded when the test ends.
+func newWorkerFixture(t *testing.T) *workerFixture {
+	t.Helper()
+	f := &workerFixture{pool: testPool(t)}
+	ctx := context.Background()
+
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.down.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"instance":"db-1:9100"},"value":[1700000000,"1"]}]}}`)
+	}))
+	t.Cleanup(prom.Close)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { f.hits.Add(1) }))
+	t.Cleanup(hook.Close)
+
+	rule := &models.AlertRule{
+		Name:           "worker test " + uuid.NewString(),
+		Expression:     "up == 0",
+		Severity:       "critical",
+		Labels:         "{}",
+		Annotations:    "{}",
+		GroupID:        uuid.New(),
+		DataSourceType: "prometheus",
+		DataSourceURL:  prom.URL,
+		Status:         1,
+	}
+	if err := repository.NewAlertRuleRepository(&repository.Database{Pool: f.pool}).Create(ctx, rule); err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	f.ruleID = rule.ID
+	channelID := uuid.New()
+	if _, err := f.pool.Exec(ctx, `
+		INSERT INTO alert_channels (id, name, type, config, status, created_at, updated_at)
+		VALUES ($1, 'worker test', 'webhook', $2, 1, NOW(), NOW())
+	`, channelID, fmt.Sprintf(`{"url": %q}`, hook.URL)); err != nil {
+		t.Fatalf("create channel: %v", err)
+	}
+	if err := NewAlertChannelBindingService(f.pool).BindChannels(ctx, rule.ID, []uuid.UUID{channelID}); err != nil {
+		t.Fatalf("bind channel: %v", err)
+	}
+	t.Cleanup(func() {
+		f.pool.Exec(ctx, `DELETE FROM notification_logs WHERE alert_id IN (SELECT id FROM alert_history WHERE rule_id = $1)`, rule.ID)
+		f.pool.Exec(ctx, `DELETE FROM pending_notifications WHERE rule_id = $1`, rule.ID)
+		f.pool.Exec(ctx, `DELETE FROM alert_history WHERE rule_id = $1`, rule.ID)
+		f.pool.Exec(ctx, `DELETE FROM alert_channel_bindings WHERE rule_id = $1`, rule.ID)
+		f.pool.Exec(ctx, `DELETE FROM alert_channels WHERE id = $1`, channelID)
+		f.pool.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1`, rule.ID)
+	})
+	return f
+}
+
+// startWorker returns a new worker with its pending state restored, as after a process start.
+func (f *workerFixture) startWorker(t *testing.T) *AlertNotificationWorker {
+	t.Helper()
+	db := &repository.Database{Pool: f.pool}
+	w := NewAlertNotificationWorker(f.pool, repository.NewAlertRuleRepository(db), repository.NewAlertHistoryRepository(db),
+		NewAlertEvaluator(0), NewNotificationSender(f.pool), nil, nil, nil, nil, nil, time.Minute)
+	if err := w.restorePending(context.Background()); err != nil {
+		t.Fatalf("restore pending: %v", err)
+	}
+	return w
+}
+
+// cycle runs one evaluation of w and delivers what it enqueued.
+func (f *workerFixture) cycle(t *testing.T, w *AlertNotificationWorker) {
+	t.Helper()
+	ctx := context.Background()
+	if err := w.runOnce(ctx, &workerRunStats{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := w.outbox.dispatch(ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+}
+
+// expect checks the alerts and notifications recorded for the rule and the webhook calls so far.
+func (f *workerFixture) expect(t *testing.T, step string, alerts, notifications, calls int) {
+	t.Helper()
+	ctx := context.Background()
+	var history, queued int
+	if err := f.pool.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history WHERE rule_id = $1`, f.ruleID).Scan(&history); err != nil {
+		t.Fatalf("count history: %v", err)
+	}
+	if err := f.pool.QueryRow(ctx, `SELECT COUNT(*) FROM pending_notifications WHERE rule_id = $1`, f.ruleID).Scan(&queued); err != nil {
+		t.Fatalf("count notifications: %v", err)
+	}
+	if history != alerts || queued != notifications {
+		t.Errorf("%s: %d alerts and %d notifications recorded, want %d and %d", step, history, queued, alerts, notifications)
+	}
+	if n := int(f.hits.Load()); n != calls {
+		t.Errorf("%s: webhook called %d times, want %d", step, n, calls)
+	}
+}
+
+// TestWorkerRestartSendsNoDuplicateNotification runs a worker until a firing alert is recorded and
+// delivered, then replaces it with a new worker, as a process restart does, while the series keeps
+// firing. The new worker must neither record the alert again nor send it again.
+func TestWorkerRestartSendsNoDuplicateNotification(t *testing.T) {
+	f := newWorkerFixture(t)
+
+	f.cycle(t, f.startWorker(t))
+	f.expect(t, "first worker", 1, 1, 1)
+
+	f.cycle(t, f.startWorker(t))
+	f.expect(t, "restarted worker", 1, 1, 1)
+}
+
+// TestWorkerKeepsFiringAlertOfSkippedRule fails one evaluation of a firing rule. Its alert must neither
+// resolve nor be forgotten, so the next successful evaluation continues it instead of recording and
+// notifying it again.
+func TestWorkerKeepsFiringAlertOfSkippedRule(t *testing.T) {
+	f := newWorkerFixture(t)
+	w := f.startWorker(t)
+
+	f.cycle(t, w)
+	f.expect(t, "firing", 1, 1, 1)
+
+	f.down.Store(true)
+	f.cycle(t, w)
+	f.expect(t, "evaluation failed", 1, 1, 1)
+	var status string
+	if err := f.pool.QueryRow(context.Background(), `SELECT status FROM alert_history WHERE rule_id = $1`, f.ruleID).Scan(&status); err != nil {
+		t.Fatalf("alert status: %v", err)
+	}
+	if status != "firing" {
+		t.Errorf("evaluation failed: alert %s, want firing", status)
+	}
+
+	f.down.Store(false)
+	f.cycle(t, w)
+	f.expect(t, "firing again", 1, 1, 1)
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrPresetNotFound is returned when instantiating a preset id that does not exist.
+	ErrPresetNotFound = errors.New("rule preset not found")
+	// ErrInvalidPresetTarget is returned when the data source to instantiate a preset into is missing or unknown.
+	ErrInvalidPresetTarget = errors.New("invalid preset target")
+)
+
+// defaultRulePresets are the built-in presets seeded into alert_rule_presets. Expressions assume the
+// standard node_exporter and kube-state-metrics metric names. A preset severity outside the configured
+// set must be overridden when instantiating.
+var defaultRulePresets = []models.AlertRulePreset{
+	{
+		ID:          "node-down",
+		Name:        "节点宕机",
+		Category:    "node",
+		Description: "node_exporter 抓取失败，节点可能宕机或网络不可达",
+		Expression:  `up{job=~".*node.*"} == 0`,
+		ForDuration: 120,
+		Severity:    "critical",
+		Annotations: `{"summary":"节点 {{ $labels.instance }} 不可达"}`,
+	},
+	{
+		ID:          "node-high-cpu",
+		Name:        "节点 CPU 使用率过高",
+		Category:    "node",
+		Description: "节点 CPU 使用率持续高于 90%",
+		Expression:  `(1 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[5m]))) * 100 > 90`,
+		ForDuration: 600,
+		Severity:    "warning",
+		Annotations: `{"summary":"节点 {{ $labels.instance }} CPU 使用率 {{ $value }}%"}`,
+	},
+	{
+		ID:          "node-high-memory",
+		Name:        "节点内存使用率过高",
+		Category:    "node",
+		Description: "节点内存使用率持续高于 90%",
+		Expression:  `(1 - node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes) * 100 > 90`,
+		ForDuration: 600,
+		Severity:    "warning",
+		Annotations: `{"summary":"节点 {{ $labels.instance }} 内存使用率 {{ $value }}%"}`,
+	},
+	{
+		ID:          "node-disk-almost-full",
+		Name:        "节点磁盘空间不足",
+		Category:    "node",
+		Description: "文件系统可用空间低于 10%",
+		Expression:  `node_filesystem_avail_bytes{fstype!~"tmpfs|overlay"} / node_filesystem_size_bytes{fstype!~"tmpfs|overlay"} * 100 < 10`,
+		ForDuration: 300,
+		Severity:    "critical",
+		Annotations: `{"summary":"节点 {{ $labels.instance }} 挂载点 {{ $labels.mountpoint }} 可用空间 {{ $value }}%"}`,
+	},
+	{
+		ID:          "pod-crashloop",
+		Name:        "Pod 反复重启",
+		Category:    "kubernetes",
+		Description: "容器处于 CrashLoopBackOff 状态",
+		Expression:  `max by (namespace, pod, container) (kube_pod_container_status_waiting_reason{reason="CrashLoopBackOff"}) == 1`,
+		ForDuration: 300,
+		Severity:    "warning",
+		Annotations: `{"summary":"{{ $labels.namespace }}/{{ $labels.pod }} 容器 {{ $labels.container }} 反复重启"}`,
+	},
+	{
+		ID:          "pod-not-ready",
+		Name:        "Pod 未就绪",
+		Category:    "kubernetes",
+		Description: "Pod 长时间处于非 Running/Succeeded 状态",
+		Expression:  `sum by (namespace, pod) (kube_pod_status_phase{phase=~"Pending|Unknown|Failed"}) > 0`,
+		ForDuration: 900,
+		Severity:    "warning",
+		Annotations: `{"summary":"{{ $labels.namespace }}/{{ $labels.pod }} 未就绪"}`,
+	},
+	{
+		ID:          "deployment-replicas-mismatch",
+		Name:        "Deployment 副本不足",
+		Category:    "kubernetes",
+		Description: "可用副本数低于期望副本数",
+		Expression:  `kube_deployment_spec_replicas != kube_deployment_status_replicas_available`,
+		ForDuration: 900,
+		Severity:    "warning",
+		Annotations: `{"summary":"{{ $labels.namespace }}/{{ $labels.deployment }} 可用副本不足"}`,
+	},
+	{
+		ID:          "target-down",
+		Name:        "抓取目标不可达",
+		Category:    "prometheus",
+		Description: "任意 Prometheus 抓取目标失败",
+		Expression:  `up == 0`,
+		ForDuration: 300,
+		Severity:    "warning",
+		Annotations: `{"summary":"{{ $labels.job }} 目标 {{ $labels.instance }} 不可达"}`,
+	},
+}
+
+// AlertRulePresetService manages the built-in rule preset library and instantiates presets into rules.
+type AlertRulePresetService struct {
+	db          *pgxpool.Pool
+	rules       *AlertRuleService
+	dataSources *DataSourceService
+}
+
+// NewAlertRulePresetService returns a new AlertRulePresetService.
+func NewAlertRulePresetService(db *pgxpool.Pool, rules *AlertRuleService, dataSources *DataSourceService) *AlertRulePresetService {
+	return &AlertRulePresetService{db: db, rules: rules, dataSources: dataSources}
+}
+
+// SeedDefaultPresets inserts the built-in presets, updating existing rows with the same id so that
+// improved expressions ship with upgrades.
+func (s *AlertRulePresetService) SeedDefaultPresets(ctx context.Context) error {
+	now := time.Now()
+	for _, p := range defaultRulePresets {
+		labels := p.Labels
+		if labels == "" {
+			labels = "{}"
+		}
+		_, err := s.db.Exec(ctx, `
+			INSERT INTO alert_rule_presets (id, name, category, description, expression, for_duration, severity, labels, annotations, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+			ON CONFLICT (id) DO UPDATE SET name = $2, category = $3, description = $4, expression = $5,
+				for_duration = $6, severity = $7, labels = $8, annotations = $9, updated_at = $10
+		`, p.ID, p.Name, p.Category, p.Description, p.Expression, p.ForDuration, p.Severity, labels, p.Annotations, now)
+		if err != nil {
+			return fmt.Errorf("seed rule preset %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// List returns all presets ordered by category and name.
+func (s *AlertRulePresetService) List(ctx context.Context) ([]models.AlertRulePreset, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, COALESCE(category, ''), COALESCE(description, ''), expression, COALESCE(for_duration, 0), severity,
+			COALESCE(labels::text, '{}'), COALESCE(annotations::text, '{}'), created_at, updated_at
+		FROM alert_rule_presets
+		ORDER BY category, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	presets := []models.AlertRulePreset{}
+	for rows.Next() {
+		var p models.AlertRulePreset
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category, &p.Description, &p.Expression, &p.ForDuration, &p.Severity,
+			&p.Labels, &p.Annotations, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	return presets, rows.Err()
+}
+
+// GetByID returns the preset with the given id, or ErrPresetNotFound.
+func (s *AlertRulePresetService) GetByID(ctx context.Context, id string) (*models.AlertRulePreset, error) {
+	var p models.AlertRulePreset
+	err := s.db.QueryRow(ctx, `
+		SELECT id, name, COALESCE(category, ''), COALESCE(description, ''), expression, COALESCE(for_duration, 0), severity,
+			COALESCE(labels::text, '{}'), COALESCE(annotations::text, '{}'), created_at, updated_at
+		FROM alert_rule_presets WHERE id = $1
+	`, id).Scan(&p.ID, &p.Name, &p.Category, &p.Description, &p.Expression, &p.ForDuration, &p.Severity,
+		&p.Labels, &p.Annotations, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrPresetNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// InstantiatePresetRequest picks the business group and data source a preset is created in. The data
+// source is either DataSourceID or an explicit DataSourceURL (with optional DataSourceType). Name,
+// Severity and ForDuration override the preset's values when set.
+type InstantiatePresetRequest struct {
+	GroupID        uuid.UUID  `json:"group_id" binding:"required"`
+	DataSourceID   *uuid.UUID `json:"data_source_id"`
+	DataSourceType string     `json:"data_source_type"`
+	DataSourceURL  string     `json:"data_source_url"`
+	Name           string     `json:"name"`
+	Severity       string     `json:"severity"`
+	ForDuration    *int       `json:"for_duration"`
+	Status         *int       `json:"status"` // 0=禁用, 1=启用, default 1
+}
+
+// Instantiate creates a rule from the preset with the given id in req's group and data source.
+func (s *AlertRulePresetService) Instantiate(ctx context.Context, id string, req *InstantiatePresetRequest) (*models.AlertRule, error) {
+	preset, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dsType, dsURL := req.DataSourceType, req.DataSourceURL
+	if req.DataSourceID != nil {
+		ds, err := s.dataSources.GetByID(ctx, *req.DataSourceID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: data source %s not found", ErrInvalidPresetTarget, req.DataSourceID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dsType, dsURL = ds.Type, ds.Endpoint
+	}
+	if dsURL == "" {
+		return nil, fmt.Errorf("%w: data_source_id or data_source_url is required", ErrInvalidPresetTarget)
+	}
+	if dsType == "" {
+		dsType = "prometheus"
+	}
+
+	var labels, annotations map[string]string
+	json.Unmarshal([]byte(preset.Labels), &labels)
+	json.Unmarshal([]byte(preset.Annotations), &annotations)
+
+	create := &CreateAlertRuleRequest{
+		Name:           preset.Name,
+		Description:    preset.Description,
+		Expression:     preset.Expression,
+		ForDuration:    preset.ForDuration,
+		Severity:       preset.Severity,
+		Labels:         labels,
+		Annotations:    annotations,
+		GroupID:        req.GroupID,
+		DataSourceType: dsType,
+		DataSourceURL:  dsURL,
+		Status:         1,
+	}
+	if req.Name != "" {
+		create.Name = req.Name
+	}
+	if req.Severity != "" {
+		create.Severity = req.Severity
+	}
+	if req.ForDuration != nil {
+		create.ForDuration = *req.ForDuration
+	}
+	if req.Status != nil {
+		create.Status = *req.Status
+	}
+	return s.rules.Create(ctx, create)
+}
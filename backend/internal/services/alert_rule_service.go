@@ -5,12 +5,14 @@ import (
 	"alert-center/internal/repository"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // optionalUUID allows distinguishing "key absent" from "key present with null" in JSON for PATCH-style updates.
@@ -46,6 +48,59 @@ func NewAlertRuleService(repo *repository.AlertRuleRepository,
 }
 
 func (s *AlertRuleService) Create(ctx context.Context, req *CreateAlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := ruleFromCreateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// Upsert updates the rule matched by req.Slug, or by req.Name within req.GroupID, and creates a new
+// rule when nothing matches. It reports whether an existing rule was updated. A failed lookup is
+// returned rather than treated as no match, so it cannot create a duplicate.
+func (s *AlertRuleService) Upsert(ctx context.Context, req *CreateAlertRuleRequest) (*models.AlertRule, bool, error) {
+	var existing *models.AlertRule
+	var err error
+	if req.Slug != "" {
+		if existing, err = s.repo.GetBySlug(ctx, req.Slug); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("look up rule by slug: %w", err)
+		}
+	}
+	if existing == nil {
+		if existing, err = s.repo.GetByNameAndGroup(ctx, req.Name, req.GroupID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("look up rule by name: %w", err)
+		}
+	}
+	if existing == nil {
+		rule, err := s.Create(ctx, req)
+		return rule, false, err
+	}
+
+	rule, err := ruleFromCreateRequest(req)
+	if err != nil {
+		return nil, false, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	if rule.Slug == "" {
+		rule.Slug = existing.Slug
+	}
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return nil, false, err
+	}
+	return rule, true, nil
+}
+
+// ruleFromCreateRequest builds a rule model from a create request, applying defaults and normalizing the severity.
+func ruleFromCreateRequest(req *CreateAlertRuleRequest) (*models.AlertRule, error) {
+	severity, err := NormalizeSeverity(req.Severity)
+	if err != nil {
+		return nil, err
+	}
 	labels, _ := json.Marshal(req.Labels)
 	annotations, _ := json.Marshal(req.Annotations)
 
@@ -66,33 +121,74 @@ func (s *AlertRuleService) Create(ctx context.Context, req *CreateAlertRuleReque
 	if evalInterval <= 0 {
 		evalInterval = 60
 	}
+	resolveConfirmations := req.ResolveConfirmations
+	if resolveConfirmations <= 0 {
+		resolveConfirmations = 1
+	}
+	notifyMode := req.NotifyMode
+	if notifyMode == "" {
+		notifyMode = models.NotifyModePerSeries
+	}
+	topN := req.AggregateTopN
+	if topN <= 0 {
+		topN = 10
+	}
 	status := req.Status
 	if status != 0 && status != 1 {
 		status = 1
 	}
+	notifyOnResolve := req.NotifyOnResolve == nil || *req.NotifyOnResolve
+	evalMode := req.EvaluationMode
+	if evalMode == "" {
+		evalMode = models.EvaluationModeInstant
+	}
+	rangeDuration := req.RangeDuration
+	if rangeDuration <= 0 {
+		rangeDuration = 600
+	}
+	rangeStep := req.RangeStep
+	if rangeStep <= 0 {
+		rangeStep = 60
+	}
+	if err := ValidateRangeSettings(evalMode, rangeDuration, rangeStep, req.RangeCondition); err != nil {
+		return nil, err
+	}
+	if err := ValidateComparison(req.ComparisonOperator, req.Threshold); err != nil {
+		return nil, err
+	}
 	rule := &models.AlertRule{
 		Name:                       req.Name,
+		Slug:                       req.Slug,
 		Description:                req.Description,
 		Expression:                 req.Expression,
 		EvaluationIntervalSeconds:  evalInterval,
 		ForDuration:                req.ForDuration,
-		Severity:                   req.Severity,
+		Severity:                   severity,
 		Labels:             string(labels),
 		Annotations:        string(annotations),
 		TemplateID:         req.TemplateID,
 		GroupID:            req.GroupID,
 		DataSourceType:     req.DataSourceType,
 		DataSourceURL:      req.DataSourceURL,
+		DataSourceIDs:      req.DataSourceIDs,
 		Status:             status,
 		EffectiveStartTime: effectiveStart,
 		EffectiveEndTime:   effectiveEnd,
 		ExclusionWindows:   exclJSON,
+		SeverityLabel:      req.SeverityLabel,
+		ResolveConfirmations: resolveConfirmations,
+		ValueFormat:        req.ValueFormat,
+		NotifyMode:         notifyMode,
+		AggregateTopN:      topN,
+		NotifyOnResolve:    notifyOnResolve,
+		EvaluationMode:     evalMode,
+		RangeDuration:      rangeDuration,
+		RangeStep:          rangeStep,
+		RangeCondition:     req.RangeCondition,
+		ComparisonOperator: req.ComparisonOperator,
+		Threshold:          req.Threshold,
+		GroupRecovery:      req.GroupRecovery,
 	}
-
-	if err := s.repo.Create(ctx, rule); err != nil {
-		return nil, err
-	}
-
 	return rule, nil
 }
 
@@ -100,6 +196,43 @@ func (s *AlertRuleService) GetByID(ctx context.Context, id uuid.UUID) (*models.A
 	return s.repo.GetByID(ctx, id)
 }
 
+// RuleStatus is the last evaluation outcome of a rule and how many of its series are firing.
+type RuleStatus struct {
+	RuleID          uuid.UUID  `json:"rule_id"`
+	Enabled         bool       `json:"enabled"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at"`
+	LastEvalStatus  string     `json:"last_eval_status"` // ok, error, no_data; empty if never evaluated
+	LastEvalError   string     `json:"last_eval_error,omitempty"`
+	DataSourceID    *uuid.UUID `json:"last_eval_data_source_id,omitempty"` // data source of the last successful evaluation
+	FiringSeries    int        `json:"firing_series"`
+}
+
+// Status returns the rule's last evaluation outcome, as recorded by the worker, and its firing series.
+func (s *AlertRuleService) Status(ctx context.Context, id uuid.UUID) (*RuleStatus, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	firing, err := s.history.CountFiringByRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleStatus{
+		RuleID:          rule.ID,
+		Enabled:         rule.Status == 1,
+		LastEvaluatedAt: rule.LastEvaluatedAt,
+		LastEvalStatus:  rule.LastEvalStatus,
+		LastEvalError:   rule.LastEvalError,
+		DataSourceID:    rule.LastEvalDataSourceID,
+		FiringSeries:    firing,
+	}, nil
+}
+
+// GetBySlug returns the rule with the given slug.
+func (s *AlertRuleService) GetBySlug(ctx context.Context, slug string) (*models.AlertRule, error) {
+	return s.repo.GetBySlug(ctx, slug)
+}
+
 func (s *AlertRuleService) List(ctx context.Context, req *ListAlertRuleRequest) ([]models.AlertRule, int, error) {
 	var groupID *uuid.UUID
 	if req.GroupID != "" {
@@ -118,6 +251,9 @@ func (s *AlertRuleService) Update(ctx context.Context, id uuid.UUID, req *Update
 	if req.Name != nil {
 		rule.Name = *req.Name
 	}
+	if req.Slug != nil {
+		rule.Slug = *req.Slug
+	}
 	if req.Description != nil {
 		rule.Description = *req.Description
 	}
@@ -135,7 +271,11 @@ func (s *AlertRuleService) Update(ctx context.Context, id uuid.UUID, req *Update
 		rule.ForDuration = *req.ForDuration
 	}
 	if req.Severity != nil {
-		rule.Severity = *req.Severity
+		severity, err := NormalizeSeverity(*req.Severity)
+		if err != nil {
+			return nil, err
+		}
+		rule.Severity = severity
 	}
 	if req.Labels != nil {
 		labels, _ := json.Marshal(req.Labels)
@@ -157,6 +297,9 @@ func (s *AlertRuleService) Update(ctx context.Context, id uuid.UUID, req *Update
 	if req.DataSourceURL != nil {
 		rule.DataSourceURL = *req.DataSourceURL
 	}
+	if req.DataSourceIDs != nil {
+		rule.DataSourceIDs = *req.DataSourceIDs
+	}
 	if req.Status != nil {
 		rule.Status = *req.Status
 	}
@@ -180,6 +323,66 @@ func (s *AlertRuleService) Update(ctx context.Context, id uuid.UUID, req *Update
 		}
 		rule.ExclusionWindows = exclJSON
 	}
+	if req.SeverityLabel != nil {
+		rule.SeverityLabel = *req.SeverityLabel
+	}
+	if req.ResolveConfirmations != nil {
+		rule.ResolveConfirmations = *req.ResolveConfirmations
+		if rule.ResolveConfirmations <= 0 {
+			rule.ResolveConfirmations = 1
+		}
+	}
+	if req.ValueFormat != nil {
+		rule.ValueFormat = *req.ValueFormat
+	}
+	if req.NotifyMode != nil {
+		rule.NotifyMode = *req.NotifyMode
+	}
+	if req.AggregateTopN != nil {
+		rule.AggregateTopN = *req.AggregateTopN
+		if rule.AggregateTopN <= 0 {
+			rule.AggregateTopN = 10
+		}
+	}
+	if req.NotifyOnResolve != nil {
+		rule.NotifyOnResolve = *req.NotifyOnResolve
+	}
+	if req.EvaluationMode != nil {
+		rule.EvaluationMode = *req.EvaluationMode
+		if rule.EvaluationMode == "" {
+			rule.EvaluationMode = models.EvaluationModeInstant
+		}
+	}
+	if req.RangeDuration != nil {
+		rule.RangeDuration = *req.RangeDuration
+		if rule.RangeDuration <= 0 {
+			rule.RangeDuration = 600
+		}
+	}
+	if req.RangeStep != nil {
+		rule.RangeStep = *req.RangeStep
+		if rule.RangeStep <= 0 {
+			rule.RangeStep = 60
+		}
+	}
+	if req.RangeCondition != nil {
+		rule.RangeCondition = *req.RangeCondition
+	}
+	if req.ComparisonOperator != nil {
+		rule.ComparisonOperator = *req.ComparisonOperator
+	}
+	if req.GroupRecovery != nil {
+		rule.GroupRecovery = *req.GroupRecovery
+	}
+	if req.Threshold != nil {
+		rule.Threshold = req.Threshold
+	}
+	if err := ValidateRangeSettings(rule.EvaluationMode, rule.RangeDuration, rule.RangeStep, rule.RangeCondition); err != nil {
+		return nil, err
+	}
+	if err := ValidateComparison(rule.ComparisonOperator, rule.Threshold); err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.Update(ctx, rule); err != nil {
 		return nil, err
@@ -192,6 +395,59 @@ func (s *AlertRuleService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.repo.Delete(ctx, id)
 }
 
+// ErrInvalidComparison is returned for a rule with an unknown comparison_operator or one without a threshold.
+var ErrInvalidComparison = errors.New("invalid rule comparison")
+
+// ErrInvalidBulkMove is returned when a bulk move names no rules or an unknown target group.
+var ErrInvalidBulkMove = errors.New("invalid bulk move")
+
+// BulkMoveRequest moves RuleIDs to TargetGroupID.
+type BulkMoveRequest struct {
+	RuleIDs       []uuid.UUID `json:"rule_ids" binding:"required"`
+	TargetGroupID uuid.UUID   `json:"target_group_id" binding:"required"`
+}
+
+// BulkMoveResult reports a bulk move: Moved rules now belong to the target group, NotFound ids matched no rule.
+type BulkMoveResult struct {
+	Requested int         `json:"requested"`
+	Moved     int         `json:"moved"`
+	NotFound  []uuid.UUID `json:"not_found"`
+}
+
+// BulkMove moves rules to another business group in one transaction.
+func (s *AlertRuleService) BulkMove(ctx context.Context, req *BulkMoveRequest) (*BulkMoveResult, error) {
+	seen := make(map[uuid.UUID]bool, len(req.RuleIDs))
+	ids := make([]uuid.UUID, 0, len(req.RuleIDs))
+	for _, id := range req.RuleIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: rule_ids is empty", ErrInvalidBulkMove)
+	}
+
+	moved, err := s.repo.MoveToGroup(ctx, ids, req.TargetGroupID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: target group %s not found", ErrInvalidBulkMove, req.TargetGroupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkMoveResult{Requested: len(ids), Moved: len(moved), NotFound: []uuid.UUID{}}
+	for _, id := range moved {
+		delete(seen, id)
+	}
+	for _, id := range ids {
+		if seen[id] {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	return result, nil
+}
+
 func (s *AlertRuleService) GetStatistics(ctx context.Context, req *StatisticsRequest) (map[string]interface{}, error) {
 	var startTime, endTime *time.Time
 	if req.StartTime != "" {
@@ -203,7 +459,7 @@ func (s *AlertRuleService) GetStatistics(ctx context.Context, req *StatisticsReq
 		endTime = &t
 	}
 
-	return s.history.GetStatistics(ctx, startTime, endTime, nil)
+	return s.history.GetStatistics(ctx, startTime, endTime, nil, Severities())
 }
 
 // PrometheusService handles Prometheus/VictoriaMetrics integration
@@ -213,9 +469,7 @@ type PrometheusService struct {
 
 func NewPrometheusService() *PrometheusService {
 	return &PrometheusService{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client: newHTTPClient(30 * time.Second),
 	}
 }
 
@@ -246,6 +500,7 @@ func (s *PrometheusService) Query(ctx context.Context, url, query string, queryT
 
 type CreateAlertRuleRequest struct {
 	Name                       string                  `json:"name" binding:"required"`
+	Slug                       string                  `json:"slug"` // optional, unique across environments
 	Description                string                  `json:"description"`
 	Expression                 string                  `json:"expression" binding:"required"`
 	EvaluationIntervalSeconds  int                     `json:"evaluation_interval_seconds"` // 执行频率(秒), default 60
@@ -257,9 +512,23 @@ type CreateAlertRuleRequest struct {
 	GroupID            uuid.UUID               `json:"group_id" binding:"required"`
 	DataSourceType     string                  `json:"data_source_type"`
 	DataSourceURL      string                  `json:"data_source_url"`
+	DataSourceIDs      []uuid.UUID             `json:"data_source_ids"` // tried in order until one responds; data_source_url is the last resort
 	EffectiveStartTime string                  `json:"effective_start_time"` // HH:MM, default 00:00
 	EffectiveEndTime   string                  `json:"effective_end_time"`   // HH:MM, default 23:59
 	ExclusionWindows   []models.ExclusionWindow `json:"exclusion_windows"`
+	SeverityLabel      string                  `json:"severity_label"` // label/annotation overriding severity per alert
+	ResolveConfirmations int                   `json:"resolve_confirmations"` // consecutive missed evaluations before recovery, default 1
+	ValueFormat        string                  `json:"value_format" binding:"omitempty,oneof=percent bytes duration"` // how {{value}} renders in notifications
+	NotifyMode         string                  `json:"notify_mode" binding:"omitempty,oneof=per_series aggregate"` // default per_series
+	AggregateTopN      int                     `json:"aggregate_top_n"` // series listed in an aggregate notification, default 10
+	NotifyOnResolve    *bool                   `json:"notify_on_resolve"` // send a recovery notification, default true
+	GroupRecovery      bool                    `json:"group_recovery"`    // one recovery notification per rule and cycle, default false
+	EvaluationMode     string                  `json:"evaluation_mode" binding:"omitempty,oneof=instant range"` // default instant
+	RangeDuration      int                     `json:"range_duration"`  // range mode: query window in seconds, default 600
+	RangeStep          int                     `json:"range_step"`      // range mode: query step in seconds, default 60
+	RangeCondition     string                  `json:"range_condition"` // range mode: e.g. "change_percent > 50", required
+	ComparisonOperator string                  `json:"comparison_operator" binding:"omitempty,oneof=gt gte lt lte eq neq"` // compare sample values to threshold; empty fires on value > 0
+	Threshold          *float64                `json:"threshold"` // required with comparison_operator
 	Status             int                     `json:"status"` // 0=禁用, 1=启用, default 1
 }
 
@@ -273,6 +542,7 @@ type ListAlertRuleRequest struct {
 
 type UpdateAlertRuleRequest struct {
 	Name                      *string            `json:"name"`
+	Slug                      *string            `json:"slug"`
 	Description               *string            `json:"description"`
 	Expression                *string            `json:"expression"`
 	EvaluationIntervalSeconds *int               `json:"evaluation_interval_seconds"`
@@ -284,10 +554,24 @@ type UpdateAlertRuleRequest struct {
 	GroupID            *uuid.UUID                `json:"group_id"`
 	DataSourceType     *string                   `json:"data_source_type"`
 	DataSourceURL      *string                   `json:"data_source_url"`
+	DataSourceIDs      *[]uuid.UUID              `json:"data_source_ids"` // [] clears it
 	Status             *int                      `json:"status"`
 	EffectiveStartTime *string                   `json:"effective_start_time"`
 	EffectiveEndTime   *string                   `json:"effective_end_time"`
 	ExclusionWindows   *[]models.ExclusionWindow `json:"exclusion_windows"`
+	SeverityLabel      *string                   `json:"severity_label"`
+	ResolveConfirmations *int                    `json:"resolve_confirmations"`
+	ValueFormat        *string                   `json:"value_format"` // "" clears it; unknown formats render raw
+	NotifyMode         *string                   `json:"notify_mode" binding:"omitempty,oneof=per_series aggregate"`
+	AggregateTopN      *int                      `json:"aggregate_top_n"`
+	NotifyOnResolve    *bool                     `json:"notify_on_resolve"`
+	GroupRecovery      *bool                     `json:"group_recovery"`
+	EvaluationMode     *string                   `json:"evaluation_mode" binding:"omitempty,oneof=instant range"`
+	RangeDuration      *int                      `json:"range_duration"`
+	RangeStep          *int                      `json:"range_step"`
+	RangeCondition     *string                   `json:"range_condition"`
+	ComparisonOperator *string                   `json:"comparison_operator" binding:"omitempty,oneof=gt gte lt lte eq neq"` // "" restores value > 0
+	Threshold          *float64                  `json:"threshold"`
 }
 
 type StatisticsRequest struct {
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RuleDiagnostic is the validation outcome for one rule in a batch.
+// Errors block the import of the rule; warnings are informational.
+type RuleDiagnostic struct {
+	Index    int      `json:"index"`
+	Name     string   `json:"name"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// RuleValidator checks rule definitions before they are written: expression syntax,
+// references to groups, templates and data sources, and duplicate names.
+type RuleValidator struct {
+	db *pgxpool.Pool
+}
+
+func NewRuleValidator(db *pgxpool.Pool) *RuleValidator {
+	return &RuleValidator{db: db}
+}
+
+// Validate returns one diagnostic per rule, in the order given. It never writes to the database.
+func (v *RuleValidator) Validate(ctx context.Context, rules []CreateAlertRuleRequest) ([]RuleDiagnostic, error) {
+	diagnostics := make([]RuleDiagnostic, 0, len(rules))
+	seenNames := make(map[string]int)
+	seenSlugs := make(map[string]int)
+
+	for i := range rules {
+		rule := &rules[i]
+		d := RuleDiagnostic{Index: i, Name: rule.Name, Errors: []string{}, Warnings: []string{}}
+
+		if strings.TrimSpace(rule.Name) == "" {
+			d.Errors = append(d.Errors, "name is required")
+		}
+		if _, err := NormalizeSeverity(rule.Severity); err != nil {
+			d.Errors = append(d.Errors, err.Error())
+		}
+		if err := ValidatePromQLSyntax(rule.Expression); err != nil {
+			d.Errors = append(d.Errors, "expression: "+err.Error())
+		}
+		if err := ValidateComparison(rule.ComparisonOperator, rule.Threshold); err != nil {
+			d.Errors = append(d.Errors, err.Error())
+		}
+
+		if rule.GroupID == uuid.Nil {
+			d.Errors = append(d.Errors, "group_id is required")
+		} else {
+			exists, err := v.exists(ctx, `SELECT EXISTS(SELECT 1 FROM business_groups WHERE id = $1)`, rule.GroupID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				d.Errors = append(d.Errors, fmt.Sprintf("business group %s not found", rule.GroupID))
+			}
+		}
+
+		if rule.TemplateID != nil {
+			exists, err := v.exists(ctx, `SELECT EXISTS(SELECT 1 FROM alert_templates WHERE id = $1 AND status = 1)`, *rule.TemplateID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				d.Errors = append(d.Errors, fmt.Sprintf("template %s not found", *rule.TemplateID))
+			}
+		}
+
+		switch rule.DataSourceType {
+		case "", "prometheus", "victoria-metrics":
+		default:
+			d.Errors = append(d.Errors, fmt.Sprintf("unsupported data_source_type %q", rule.DataSourceType))
+		}
+		for _, id := range rule.DataSourceIDs {
+			exists, err := v.exists(ctx, `SELECT EXISTS(SELECT 1 FROM data_sources WHERE id = $1)`, id)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				d.Errors = append(d.Errors, fmt.Sprintf("data source %s not found", id))
+			}
+		}
+		if rule.DataSourceURL == "" {
+			if len(rule.DataSourceIDs) == 0 {
+				d.Warnings = append(d.Warnings, "data_source_url is empty; the rule will not be evaluated")
+			}
+		} else {
+			exists, err := v.exists(ctx, `SELECT EXISTS(SELECT 1 FROM data_sources WHERE endpoint = $1)`, rule.DataSourceURL)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				d.Warnings = append(d.Warnings, fmt.Sprintf("data source %s is not registered", rule.DataSourceURL))
+			}
+		}
+
+		nameKey := rule.GroupID.String() + "/" + rule.Name
+		if j, ok := seenNames[nameKey]; ok {
+			d.Errors = append(d.Errors, fmt.Sprintf("duplicate name %q in the same group (rule %d)", rule.Name, j))
+		} else {
+			seenNames[nameKey] = i
+		}
+		if rule.Slug != "" {
+			if j, ok := seenSlugs[rule.Slug]; ok {
+				d.Errors = append(d.Errors, fmt.Sprintf("duplicate slug %q (rule %d)", rule.Slug, j))
+			} else {
+				seenSlugs[rule.Slug] = i
+			}
+		}
+		if rule.GroupID != uuid.Nil && rule.Name != "" {
+			var existing bool
+			if err := v.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM alert_rules WHERE name = $1 AND group_id = $2)`,
+				rule.Name, rule.GroupID).Scan(&existing); err != nil {
+				return nil, err
+			}
+			if existing {
+				d.Warnings = append(d.Warnings, fmt.Sprintf("a rule named %q already exists in this group; import it with mode=upsert to update it", rule.Name))
+			}
+		}
+
+		d.Valid = len(d.Errors) == 0
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics, nil
+}
+
+func (v *RuleValidator) exists(ctx context.Context, query string, arg interface{}) (bool, error) {
+	var ok bool
+	err := v.db.QueryRow(ctx, query, arg).Scan(&ok)
+	return ok, err
+}
+
+// ValidatePromQLSyntax performs a lightweight structural check of a PromQL expression:
+// it must be non-empty, have balanced brackets, terminated string literals, and must not end with an operator.
+// It does not type-check the expression; the data source remains the final authority.
+func ValidatePromQLSyntax(expr string) error {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return fmt.Errorf("expression is empty")
+	}
+
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []rune
+	var quote rune
+	escaped := false
+	for i, ch := range expr {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\' && quote != '`':
+				escaped = true
+			case ch == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'', '`':
+			quote = ch
+		case '(', '[', '{':
+			stack = append(stack, ch)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != closing[ch] {
+				return fmt.Errorf("unexpected %q at position %d", ch, i)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("unterminated string literal")
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+	if strings.ContainsRune("+-*/%^=<>!,", rune(expr[len(expr)-1])) {
+		return fmt.Errorf("expression ends with operator %q", expr[len(expr)-1])
+	}
+	return nil
+}
@@ -4,10 +4,14 @@ import (
 	"alert-center/internal/models"
 	"context"
 	"encoding/json"
-	"regexp"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,24 +23,65 @@ func NewAlertSilenceService(db *pgxpool.Pool) *AlertSilenceService {
 	return &AlertSilenceService{db: db}
 }
 
+// ErrInvalidSilence is wrapped by the errors Create and Update return for silences that could never match.
+var ErrInvalidSilence = errors.New("invalid silence")
+
+// validateSilenceWindow checks that the silence ends after it starts and, for a new silence, that it has not
+// already ended; either would create a silence that never matches.
+func validateSilenceWindow(start, end time.Time, isNew bool) error {
+	if !end.After(start) {
+		return fmt.Errorf("%w: end_time must be after start_time", ErrInvalidSilence)
+	}
+	if isNew && !end.After(time.Now()) {
+		return fmt.Errorf("%w: end_time is in the past", ErrInvalidSilence)
+	}
+	return nil
+}
+
+// validateSilenceScope checks the matchers and business group of a silence. A group-level silence may
+// have no matchers, muting every alert of the group's rules; otherwise the matchers must compile.
+func (s *AlertSilenceService) validateSilenceScope(ctx context.Context, matchers SilenceMatchers, groupID *uuid.UUID) error {
+	if groupID != nil {
+		var exists bool
+		if err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM business_groups WHERE id = $1)`, *groupID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: business_group_id %s not found", ErrInvalidSilence, *groupID)
+		}
+		if len(matchers.Matchers) == 0 && len(matchers.Sets) == 0 {
+			return nil
+		}
+	}
+	_, err := matchers.compile()
+	return err
+}
+
 func (s *AlertSilenceService) Create(ctx context.Context, req *CreateSilenceRequest, userID uuid.UUID) (*models.AlertSilence, error) {
+	if err := validateSilenceWindow(req.StartTime, req.EndTime, true); err != nil {
+		return nil, err
+	}
+	if err := s.validateSilenceScope(ctx, req.Matchers, req.BusinessGroupID); err != nil {
+		return nil, err
+	}
 	matchers, _ := json.Marshal(req.Matchers)
 
 	silence := &models.AlertSilence{
-		ID:          uuid.New(),
-		Name:        req.Name,
-		Description: req.Description,
-		Matchers:    string(matchers),
-		StartTime:   req.StartTime,
-		EndTime:     req.EndTime,
-		CreatedBy:   userID,
-		Status:      1,
+		ID:              uuid.New(),
+		Name:            req.Name,
+		Description:     req.Description,
+		Matchers:        string(matchers),
+		BusinessGroupID: req.BusinessGroupID,
+		StartTime:       req.StartTime,
+		EndTime:         req.EndTime,
+		CreatedBy:       userID,
+		Status:          1,
 	}
 
 	_, err := s.db.Exec(ctx, `
-		INSERT INTO alert_silences (id, name, description, matchers, start_time, end_time, created_by, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`, silence.ID, silence.Name, silence.Description, silence.Matchers,
+		INSERT INTO alert_silences (id, name, description, matchers, business_group_id, start_time, end_time, created_by, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, silence.ID, silence.Name, silence.Description, silence.Matchers, silence.BusinessGroupID,
 		silence.StartTime, silence.EndTime, silence.CreatedBy, silence.Status, time.Now(), time.Now())
 	if err != nil {
 		return nil, err
@@ -45,11 +90,23 @@ func (s *AlertSilenceService) Create(ctx context.Context, req *CreateSilenceRequ
 	return silence, nil
 }
 
+const silenceColumns = `id, name, description, matchers, business_group_id, start_time, end_time, created_by, status, created_at, updated_at`
+
+func scanSilence(row pgx.Row) (*models.AlertSilence, error) {
+	var silence models.AlertSilence
+	if err := row.Scan(&silence.ID, &silence.Name, &silence.Description, &silence.Matchers, &silence.BusinessGroupID,
+		&silence.StartTime, &silence.EndTime, &silence.CreatedBy,
+		&silence.Status, &silence.CreatedAt, &silence.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
 func (s *AlertSilenceService) List(ctx context.Context, page, pageSize int, status int) ([]models.AlertSilence, int, error) {
 	offset := (page - 1) * pageSize
 
 	rows, err := s.db.Query(ctx, `
-		SELECT id, name, description, matchers, start_time, end_time, created_by, status, created_at, updated_at
+		SELECT `+silenceColumns+`
 		FROM alert_silences
 		WHERE status = $1 OR $2 = -1
 		ORDER BY created_at DESC
@@ -62,13 +119,11 @@ func (s *AlertSilenceService) List(ctx context.Context, page, pageSize int, stat
 
 	var list []models.AlertSilence
 	for rows.Next() {
-		var silence models.AlertSilence
-		if err := rows.Scan(&silence.ID, &silence.Name, &silence.Description, &silence.Matchers,
-			&silence.StartTime, &silence.EndTime, &silence.CreatedBy,
-			&silence.Status, &silence.CreatedAt, &silence.UpdatedAt); err != nil {
+		silence, err := scanSilence(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-		list = append(list, silence)
+		list = append(list, *silence)
 	}
 
 	var total int
@@ -77,60 +132,298 @@ func (s *AlertSilenceService) List(ctx context.Context, page, pageSize int, stat
 	return list, total, nil
 }
 
-func (s *AlertSilenceService) IsSilenced(ctx context.Context, labels map[string]string) (bool, error) {
+// IsSilenced reports whether an active silence matches an alert of the rule with the labels. The rule's
+// business group is looked up for group-level silences; with uuid.Nil, or a rule that does not exist,
+// only silences without a group apply. Silences whose stored matchers no longer parse are skipped.
+func (s *AlertSilenceService) IsSilenced(ctx context.Context, ruleID uuid.UUID, labels map[string]string) (bool, error) {
+	groupID := uuid.Nil
+	if ruleID != uuid.Nil {
+		err := s.db.QueryRow(ctx, `SELECT group_id FROM alert_rules WHERE id = $1`, ruleID).Scan(&groupID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return false, err
+		}
+	}
+	silence, err := s.MatchingSilence(ctx, groupID, labels)
+	return silence != nil, err
+}
+
+// MatchingSilence returns the active silence that matches an alert with the labels of a rule in the
+// business group groupID (uuid.Nil when unknown), the one ending last when several do, or nil when none
+// does. A group-level silence only matches alerts of its group, and without matchers matches all of
+// them. Silences whose stored matchers no longer compile are skipped and logged (see
+// logInvalidSilence).
+func (s *AlertSilenceService) MatchingSilence(ctx context.Context, groupID uuid.UUID, labels map[string]string) (*models.AlertSilence, error) {
 	now := time.Now()
-	
+
 	rows, err := s.db.Query(ctx, `
-		SELECT id, matchers FROM alert_silences
+		SELECT id, name, matchers, business_group_id, start_time, end_time FROM alert_silences
 		WHERE status = 1 AND start_time <= $1 AND end_time >= $1
-	`, now)
+			AND (business_group_id IS NULL OR business_group_id = $2)
+		ORDER BY end_time DESC
+	`, now, groupID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var id string
-		var matchers string
-		rows.Scan(&id, &matchers)
-
-		var silenceMatchers []map[string]string
-		json.Unmarshal([]byte(matchers), &silenceMatchers)
-
-		for _, sm := range silenceMatchers {
-			match := true
-			for key, pattern := range sm {
-				labelValue, exists := labels[key]
-				if !exists {
-					match = false
-					break
-				}
-				
-				if len(pattern) >= 2 && pattern[0:2] == "~" {
-					regexPattern := pattern[2:]
-					re, err := regexp.Compile("^" + regexPattern + "$")
-					if err != nil {
-						match = false
-						break
-					}
-					if !re.MatchString(labelValue) {
-						match = false
-						break
-					}
-				} else {
-					if labelValue != pattern {
-						match = false
-						break
-					}
-				}
+		var silence models.AlertSilence
+		if err := rows.Scan(&silence.ID, &silence.Name, &silence.Matchers, &silence.BusinessGroupID, &silence.StartTime, &silence.EndTime); err != nil {
+			return nil, err
+		}
+		sets, err := compileSilenceMatchers(&silence)
+		if err != nil {
+			logInvalidSilence(&silence, err)
+			continue
+		}
+		if sets == nil || silenceMatches(sets, labels) {
+			return &silence, nil
+		}
+	}
+
+	return nil, rows.Err()
+}
+
+// compileStoredMatchers parses and compiles a silence's stored matchers.
+func compileStoredMatchers(s string) ([][]labelMatcher, error) {
+	m, err := parseSilenceMatchers(s)
+	if err != nil {
+		return nil, err
+	}
+	return m.compile()
+}
+
+// invalidSilencesLogged holds, per silence id, the stored matchers already logged as not compiling, so
+// a broken silence is logged once rather than on every alert it is checked against.
+var invalidSilencesLogged sync.Map
+
+// logInvalidSilence logs a silence whose stored matchers no longer compile, e.g. after matcher
+// validation was tightened. Such a silence matches nothing until it is edited.
+func logInvalidSilence(silence *models.AlertSilence, err error) {
+	if prev, loaded := invalidSilencesLogged.Swap(silence.ID, silence.Matchers); loaded && prev == silence.Matchers {
+		return
+	}
+	log.Printf("AlertSilenceService: silence %s (%s) matches nothing, its matchers do not compile: %v", silence.Name, silence.ID, err)
+}
+
+// compileSilenceMatchers compiles a stored silence's matchers. It returns nil sets, with no error, for a
+// group-level silence without matchers, which matches every alert of its group.
+func compileSilenceMatchers(silence *models.AlertSilence) ([][]labelMatcher, error) {
+	if silence.BusinessGroupID != nil {
+		if m, err := parseSilenceMatchers(silence.Matchers); err == nil && len(m.Matchers) == 0 && len(m.Sets) == 0 {
+			return nil, nil
+		}
+	}
+	return compileStoredMatchers(silence.Matchers)
+}
+
+// silenceAlertCondition returns the alert_history prefilter of a silence: its label condition on
+// labelsColumn (see silenceLabelCondition) and, for a group-level silence, the alert's rule (ruleColumn)
+// being in the group. It yields "" when there is nothing to filter on.
+func silenceAlertCondition(sets [][]labelMatcher, groupID *uuid.UUID, labelsColumn, ruleColumn string) (string, []interface{}) {
+	cond, args := silenceLabelCondition(sets, labelsColumn)
+	if groupID == nil {
+		return cond, args
+	}
+	if cond == "" {
+		cond = "TRUE"
+	}
+	args = append(args, *groupID)
+	return fmt.Sprintf("(%s) AND %s IN (SELECT id FROM alert_rules WHERE group_id = $%d)", cond, ruleColumn, len(args)), args
+}
+
+// ActiveSilenceMatches is an active silence with the currently firing alerts it suppresses.
+type ActiveSilenceMatches struct {
+	Silence models.AlertSilence    `json:"silence"`
+	Count   int                    `json:"count"`
+	Alerts  []*models.AlertHistory `json:"alerts"`
+	Error   string                 `json:"error,omitempty"` // why the stored matchers do not compile; the silence matches nothing
+}
+
+// ActiveMatches runs every active silence against the currently firing alerts and returns, per silence,
+// the alerts it is suppressing right now. Silences matching nothing are included with a zero count.
+func (s *AlertSilenceService) ActiveMatches(ctx context.Context) ([]ActiveSilenceMatches, error) {
+	now := time.Now()
+	rows, err := s.db.Query(ctx, `
+		SELECT `+silenceColumns+`
+		FROM alert_silences
+		WHERE status = 1 AND start_time <= $1 AND end_time >= $1
+		ORDER BY end_time
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	var silences []models.AlertSilence
+	for rows.Next() {
+		silence, err := scanSilence(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		silences = append(silences, *silence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]ActiveSilenceMatches, 0, len(silences))
+	if len(silences) == 0 {
+		return out, nil
+	}
+
+	// An alert matched by several silences is decoded once and shared between their results.
+	seen := make(map[uuid.UUID]*models.AlertHistory)
+	for _, silence := range silences {
+		m := ActiveSilenceMatches{Silence: silence, Alerts: []*models.AlertHistory{}}
+		sets, err := compileSilenceMatchers(&silence)
+		if err != nil {
+			logInvalidSilence(&silence, err)
+			m.Error = err.Error()
+			out = append(out, m)
+			continue
+		}
+		cond, args := silenceAlertCondition(sets, silence.BusinessGroupID, "labels", "rule_id")
+		if cond == "" {
+			out = append(out, m)
+			continue
+		}
+		rows, err := s.db.Query(ctx, `
+			SELECT id, COALESCE(alert_no, ''), rule_id, fingerprint, severity, status, started_at, ended_at, labels, annotations, created_at
+			FROM alert_history WHERE status = 'firing' AND (`+cond+`)
+			ORDER BY started_at DESC
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var a models.AlertHistory
+			if err := rows.Scan(&a.ID, &a.AlertNo, &a.RuleID, &a.Fingerprint, &a.Severity, &a.Status,
+				&a.StartedAt, &a.EndedAt, &a.Labels, &a.Annotations, &a.CreatedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			alert := &a
+			if prev, ok := seen[a.ID]; ok && prev.Labels == a.Labels {
+				alert = prev
+			} else {
+				seen[a.ID] = alert
 			}
-			if match {
-				return true, nil
+			if sets == nil || silenceMatches(sets, alert.LabelMap()) {
+				m.Alerts = append(m.Alerts, alert)
 			}
 		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		m.Count = len(m.Alerts)
+		out = append(out, m)
 	}
+	return out, nil
+}
+
+// PreviewSilenceRequest is a proposed silence to test against the currently firing alerts. The window
+// is optional and only validated and reported; Limit caps the sample (default 20, max 200).
+type PreviewSilenceRequest struct {
+	Matchers        SilenceMatchers `json:"matchers"`
+	BusinessGroupID *uuid.UUID      `json:"business_group_id"`
+	StartTime       *time.Time      `json:"start_time"`
+	EndTime         *time.Time      `json:"end_time"`
+	Limit           int             `json:"limit"`
+}
 
-	return false, nil
+// SilencePreviewAlert is a firing alert a proposed silence would match.
+type SilencePreviewAlert struct {
+	ID        uuid.UUID         `json:"id"`
+	AlertNo   string            `json:"alert_no"`
+	RuleID    uuid.UUID         `json:"rule_id"`
+	RuleName  string            `json:"rule_name"`
+	Severity  string            `json:"severity"`
+	Labels    map[string]string `json:"labels"`
+	StartedAt time.Time         `json:"started_at"`
+}
+
+// SilencePreview is what a proposed silence would suppress right now: the number of matching firing
+// alerts, their counts per severity, and the most recently started of them.
+type SilencePreview struct {
+	Count      int                   `json:"count"`
+	BySeverity map[string]int        `json:"by_severity"`
+	Alerts     []SilencePreviewAlert `json:"alerts"`
+	// ActiveNow reports whether the window includes the current time; a future silence matches
+	// nothing until it starts.
+	ActiveNow bool `json:"active_now"`
+}
+
+const (
+	defaultSilencePreviewLimit = 20
+	maxSilencePreviewLimit     = 200
+)
+
+// Preview validates a proposed silence and runs its matchers against the currently firing alerts, with
+// the same matching as IsSilenced.
+func (s *AlertSilenceService) Preview(ctx context.Context, req *PreviewSilenceRequest) (*SilencePreview, error) {
+	if err := s.validateSilenceScope(ctx, req.Matchers, req.BusinessGroupID); err != nil {
+		return nil, err
+	}
+	var sets [][]labelMatcher
+	if len(req.Matchers.Matchers) > 0 || len(req.Matchers.Sets) > 0 {
+		sets, _ = req.Matchers.compile()
+	}
+	now := time.Now()
+	start, end := now, now
+	if req.StartTime != nil {
+		start = *req.StartTime
+	}
+	if req.EndTime != nil {
+		end = *req.EndTime
+		if err := validateSilenceWindow(start, end, true); err != nil {
+			return nil, err
+		}
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSilencePreviewLimit
+	} else if limit > maxSilencePreviewLimit {
+		limit = maxSilencePreviewLimit
+	}
+
+	preview := &SilencePreview{
+		BySeverity: map[string]int{},
+		Alerts:     []SilencePreviewAlert{},
+		ActiveNow:  !start.After(now) && !end.Before(now),
+	}
+	cond, args := silenceAlertCondition(sets, req.BusinessGroupID, "h.labels", "h.rule_id")
+	rows, err := s.db.Query(ctx, `
+		SELECT h.id, COALESCE(h.alert_no, ''), h.rule_id, COALESCE(r.name, ''), COALESCE(h.severity, ''),
+			COALESCE(h.labels::text, '{}'), h.started_at
+		FROM alert_history h
+		LEFT JOIN alert_rules r ON r.id = h.rule_id
+		WHERE h.status = 'firing' AND (`+cond+`)
+		ORDER BY h.started_at DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a SilencePreviewAlert
+		var labels string
+		if err := rows.Scan(&a.ID, &a.AlertNo, &a.RuleID, &a.RuleName, &a.Severity, &labels, &a.StartedAt); err != nil {
+			return nil, err
+		}
+		a.Labels = models.DecodeLabels([]byte(labels))
+		if sets != nil && !silenceMatches(sets, a.Labels) {
+			continue
+		}
+		preview.Count++
+		preview.BySeverity[a.Severity]++
+		if len(preview.Alerts) < limit {
+			preview.Alerts = append(preview.Alerts, a)
+		}
+	}
+	return preview, rows.Err()
 }
 
 func (s *AlertSilenceService) Update(ctx context.Context, id uuid.UUID, req *UpdateSilenceRequest) (*models.AlertSilence, error) {
@@ -145,9 +438,19 @@ func (s *AlertSilenceService) Update(ctx context.Context, id uuid.UUID, req *Upd
 	if req.Description != nil {
 		silence.Description = *req.Description
 	}
-	if req.Matchers != nil {
-		matchers, _ := json.Marshal(req.Matchers)
-		silence.Matchers = string(matchers)
+	if req.Matchers != nil || req.BusinessGroupID.Set {
+		matchers, _ := parseSilenceMatchers(silence.Matchers)
+		if req.Matchers != nil {
+			matchers = *req.Matchers
+		}
+		if req.BusinessGroupID.Set {
+			silence.BusinessGroupID = req.BusinessGroupID.Value
+		}
+		if err := s.validateSilenceScope(ctx, matchers, silence.BusinessGroupID); err != nil {
+			return nil, err
+		}
+		encoded, _ := json.Marshal(matchers)
+		silence.Matchers = string(encoded)
 	}
 	if req.StartTime != nil {
 		silence.StartTime = *req.StartTime
@@ -155,13 +458,21 @@ func (s *AlertSilenceService) Update(ctx context.Context, id uuid.UUID, req *Upd
 	if req.EndTime != nil {
 		silence.EndTime = *req.EndTime
 	}
+	if req.StartTime != nil || req.EndTime != nil {
+		if err := validateSilenceWindow(silence.StartTime, silence.EndTime, false); err != nil {
+			return nil, err
+		}
+	}
 
 	silence.UpdatedAt = time.Now()
 
-	s.db.Exec(ctx, `
-		UPDATE alert_silences SET name=$1, description=$2, matchers=$3, start_time=$4, end_time=$5, updated_at=$6
-		WHERE id=$7
-	`, silence.Name, silence.Description, silence.Matchers, silence.StartTime, silence.EndTime, silence.UpdatedAt, id)
+	_, err = s.db.Exec(ctx, `
+		UPDATE alert_silences SET name=$1, description=$2, matchers=$3, business_group_id=$4, start_time=$5, end_time=$6, updated_at=$7
+		WHERE id=$8
+	`, silence.Name, silence.Description, silence.Matchers, silence.BusinessGroupID, silence.StartTime, silence.EndTime, silence.UpdatedAt, id)
+	if err != nil {
+		return nil, err
+	}
 
 	return silence, nil
 }
@@ -172,31 +483,26 @@ func (s *AlertSilenceService) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *AlertSilenceService) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertSilence, error) {
-	var silence models.AlertSilence
-	err := s.db.QueryRow(ctx, `
-		SELECT id, name, description, matchers, start_time, end_time, created_by, status, created_at, updated_at
-		FROM alert_silences WHERE id=$1
-	`, id).Scan(&silence.ID, &silence.Name, &silence.Description, &silence.Matchers,
-		&silence.StartTime, &silence.EndTime, &silence.CreatedBy,
-		&silence.Status, &silence.CreatedAt, &silence.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &silence, nil
+	return scanSilence(s.db.QueryRow(ctx, `SELECT `+silenceColumns+` FROM alert_silences WHERE id=$1`, id))
 }
 
+// CreateSilenceRequest creates a silence. Matchers are required unless BusinessGroupID is set, in which
+// case the silence applies to the alerts of the group's rules only.
 type CreateSilenceRequest struct {
-	Name        string            `json:"name" binding:"required"`
-	Description string            `json:"description"`
-	Matchers    []map[string]string `json:"matchers" binding:"required"`
-	StartTime   time.Time        `json:"start_time" binding:"required"`
-	EndTime     time.Time        `json:"end_time" binding:"required"`
+	Name            string          `json:"name" binding:"required"`
+	Description     string          `json:"description"`
+	Matchers        SilenceMatchers `json:"matchers"`
+	BusinessGroupID *uuid.UUID      `json:"business_group_id"`
+	StartTime       time.Time       `json:"start_time" binding:"required"`
+	EndTime         time.Time       `json:"end_time" binding:"required"`
 }
 
 type UpdateSilenceRequest struct {
-	Name        *string            `json:"name"`
-	Description *string            `json:"description"`
-	Matchers    *[]map[string]string `json:"matchers"`
-	StartTime   *time.Time        `json:"start_time"`
-	EndTime     *time.Time        `json:"end_time"`
+	Name        *string          `json:"name"`
+	Description *string          `json:"description"`
+	Matchers    *SilenceMatchers `json:"matchers"`
+	// BusinessGroupID sets the silence's group; null turns it back into a label-only silence.
+	BusinessGroupID optionalUUID `json:"business_group_id"`
+	StartTime       *time.Time   `json:"start_time"`
+	EndTime         *time.Time   `json:"end_time"`
 }
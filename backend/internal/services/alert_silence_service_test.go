@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPreviewSilenceAgainstFiringAlerts(t *testing.T) {
+	pool := testPool(t)
+	run := uuid.NewString()
+	seedFiringAlerts(t, pool,
+		`{"env": "prod", "run": "`+run+`"}`,
+		`{"env": "prod", "run": "`+run+`", "instance": "db-2"}`,
+		`{"env": "dev", "run": "`+run+`", "instance": "db-3"}`)
+	s := NewAlertSilenceService(pool)
+
+	for _, tc := range []struct {
+		name     string
+		matchers []SilenceMatcher
+		want     int
+	}{
+		{"exact", []SilenceMatcher{{Name: "env", Operator: MatchEqual, Value: "prod"}}, 2},
+		{"regex", []SilenceMatcher{{Name: "instance", Operator: MatchRegexp, Value: "db-.*"}}, 2},
+		{"negative", []SilenceMatcher{{Name: "env", Operator: MatchNotEqual, Value: "prod"}}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			matchers := append([]SilenceMatcher{{Name: "run", Operator: MatchEqual, Value: run}}, tc.matchers...)
+			preview, err := s.Preview(context.Background(), &PreviewSilenceRequest{Matchers: SilenceMatchers{Matchers: matchers}})
+			if err != nil {
+				t.Fatalf("Preview: %v", err)
+			}
+			if preview.Count != tc.want || len(preview.Alerts) != tc.want || preview.BySeverity["critical"] != tc.want {
+				t.Errorf("Preview matched %d alerts (%d listed, by severity %v), want %d", preview.Count, len(preview.Alerts), preview.BySeverity, tc.want)
+			}
+			for _, a := range preview.Alerts {
+				if a.RuleName != "seeded rule" || a.Labels["run"] != run {
+					t.Errorf("preview alert %+v", a)
+				}
+			}
+			if !preview.ActiveNow {
+				t.Error("a silence starting now should be active now")
+			}
+		})
+	}
+}
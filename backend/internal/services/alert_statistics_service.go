@@ -1,14 +1,27 @@
 package services
 
 import (
+	"alert-center/internal/repository"
 	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dashboardSummaryTTL is how long a computed dashboard summary is served from memory.
+const dashboardSummaryTTL = 30 * time.Second
+
 type AlertStatisticsService struct {
 	db *pgxpool.Pool
+
+	summaryMu sync.Mutex
+	summary   *DashboardSummary
+	summaryAt time.Time
 }
 
 func NewAlertStatisticsService(db *pgxpool.Pool) *AlertStatisticsService {
@@ -46,6 +59,7 @@ type DailyStats struct {
 	Resolved    int64  `json:"resolved"`
 	Critical    int64  `json:"critical"`
 	Warning    int64  `json:"warning"`
+	BySeverity  map[string]int64 `json:"by_severity"` // every configured severity, plus any legacy values seen
 }
 
 type RuleStats struct {
@@ -54,87 +68,86 @@ type RuleStats struct {
 	AlertCount  int64  `json:"alert_count"`
 }
 
-func (s *AlertStatisticsService) GetStatistics(ctx context.Context, startTime, endTime *time.Time, groupID *string) (*AlertStatistics, error) {
-	stats := &AlertStatistics{}
+// StatisticsFilter scopes GetStatistics. Empty fields are not filtered on. LabelKey alone matches alerts
+// carrying that label; with LabelValue it matches alerts whose labels contain key=value (e.g. env=prod).
+type StatisticsFilter struct {
+	StartTime  *time.Time
+	EndTime    *time.Time
+	GroupID    string
+	LabelKey   string
+	LabelValue string
+}
 
-	// Total alerts
-	var totalQuery string
+// where returns the WHERE clause over alert_history ah (or "") and its args, applying the time range
+// to timeColumn plus any extra conditions.
+func (f StatisticsFilter) where(timeColumn string, extra ...string) (string, []interface{}) {
+	conds := append([]string{}, extra...)
 	var args []interface{}
-	
-	if groupID != nil && *groupID != "" {
-		totalQuery = `
-			SELECT COUNT(*) FROM alert_history ah
-			INNER JOIN alert_rules ar ON ah.rule_id = ar.id
-			WHERE ar.group_id = $1
-		`
-		args = []interface{}{*groupID}
-	} else {
-		totalQuery = `SELECT COUNT(*) FROM alert_history`
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
 	}
-
-	if startTime != nil {
-		totalQuery += " AND ah.started_at >= $2"
-		args = append(args, *startTime)
+	if f.StartTime != nil {
+		conds = append(conds, timeColumn+" >= "+arg(*f.StartTime))
 	}
-	if endTime != nil {
-		totalQuery += " AND ah.started_at <= $3"
-		args = append(args, *endTime)
+	if f.EndTime != nil {
+		conds = append(conds, timeColumn+" <= "+arg(*f.EndTime))
 	}
-
-	s.db.QueryRow(ctx, totalQuery, args...).Scan(&stats.TotalAlerts)
-
-	// Firing alerts
-	firingQuery := `SELECT COUNT(*) FROM alert_history WHERE status = 'firing'`
-	if startTime != nil {
-		firingQuery += " AND started_at >= $1"
-		args = append(args, *startTime)
+	if f.GroupID != "" {
+		conds = append(conds, "ah.rule_id IN (SELECT id FROM alert_rules WHERE group_id = "+arg(f.GroupID)+"::uuid)")
 	}
-	if endTime != nil {
-		firingQuery += " AND started_at <= $2"
-		args = append(args, *endTime)
+	if cond, v := (repository.LabelFilter{Key: f.LabelKey, Value: f.LabelValue}).Condition("ah.labels", len(args)+1); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, v)
 	}
-	s.db.QueryRow(ctx, firingQuery, args...).Scan(&stats.FiringAlerts)
-
-	// Resolved alerts
-	resolvedQuery := `SELECT COUNT(*) FROM alert_history WHERE status = 'resolved'`
-	if startTime != nil {
-		resolvedQuery += " AND ended_at >= $1"
-		args = append(args, *startTime)
+	if len(conds) == 0 {
+		return "", nil
 	}
-	if endTime != nil {
-		resolvedQuery += " AND ended_at <= $2"
-		args = append(args, *endTime)
-	}
-	s.db.QueryRow(ctx, resolvedQuery, args...).Scan(&stats.ResolvedAlerts)
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (s *AlertStatisticsService) GetStatistics(ctx context.Context, filter StatisticsFilter) (*AlertStatistics, error) {
+	stats := &AlertStatistics{}
+
+	// Totals
+	where, args := filter.where("ah.started_at")
+	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history ah`+where, args...).Scan(&stats.TotalAlerts)
+	where, args = filter.where("ah.started_at", "ah.status = 'firing'")
+	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history ah`+where, args...).Scan(&stats.FiringAlerts)
+	where, args = filter.where("ah.ended_at", "ah.status = 'resolved'")
+	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history ah`+where, args...).Scan(&stats.ResolvedAlerts)
 
 	// By severity
+	where, args = filter.where("ah.started_at")
 	severityRows, _ := s.db.Query(ctx, `
-		SELECT severity, COUNT(*) FROM alert_history
-		WHERE ($1::timestamp IS NULL OR started_at >= $1)
-			AND ($2::timestamp IS NULL OR started_at <= $2)
-		GROUP BY severity
-	`, startTime, endTime)
+		SELECT COALESCE(ah.severity, ''), COUNT(*) FROM alert_history ah`+where+`
+		GROUP BY 1
+	`, args...)
 	defer severityRows.Close()
+	severityCounts := make(map[string]int64)
 	for severityRows.Next() {
-		var s SeverityStats
-		severityRows.Scan(&s.Severity, &s.Count)
-		stats.BySeverity = append(stats.BySeverity, s)
-		if s.Severity == "critical" {
-			stats.CriticalAlerts = s.Count
-		} else if s.Severity == "warning" {
-			stats.WarningAlerts = s.Count
-		} else if s.Severity == "info" {
-			stats.InfoAlerts = s.Count
-		}
+		var sev string
+		var count int64
+		severityRows.Scan(&sev, &count)
+		severityCounts[sev] = count
+	}
+	stats.CriticalAlerts = severityCounts["critical"]
+	stats.WarningAlerts = severityCounts["warning"]
+	stats.InfoAlerts = severityCounts["info"]
+	// Configured severities first, most severe first and including zero counts, then unknown values.
+	for _, sev := range Severities() {
+		stats.BySeverity = append(stats.BySeverity, SeverityStats{Severity: sev, Count: severityCounts[sev]})
+		delete(severityCounts, sev)
+	}
+	for sev, count := range severityCounts {
+		stats.BySeverity = append(stats.BySeverity, SeverityStats{Severity: sev, Count: count})
 	}
 
 	// By status
 	statusRows, _ := s.db.Query(ctx, `
-		SELECT status, COUNT(*) FROM alert_history
-		WHERE ($1::timestamp IS NULL OR started_at >= $1)
-			AND ($2::timestamp IS NULL OR started_at <= $2)
-		GROUP BY status
-	`, startTime, endTime)
+		SELECT COALESCE(ah.status, ''), COUNT(*) FROM alert_history ah`+where+`
+		GROUP BY 1
+	`, args...)
 	defer statusRows.Close()
 	for statusRows.Next() {
 		var s StatusStats
@@ -142,47 +155,138 @@ func (s *AlertStatisticsService) GetStatistics(ctx context.Context, startTime, e
 		stats.ByStatus = append(stats.ByStatus, s)
 	}
 
-	// By day (last 7 days)
+	// The daily rollup has no labels, so a label filter reads by-day and top rules from raw history.
+	if filter.LabelKey != "" {
+		s.rawDailyAndTopRules(ctx, filter, stats)
+		return stats, nil
+	}
+
+	// By day (last 7 days): past days from the daily rollup, today from raw history.
 	dayRows, _ := s.db.Query(ctx, `
-		SELECT 
-			DATE(started_at) as date,
-			COUNT(*) as total,
-			COUNT(*) FILTER (WHERE status = 'firing') as firing,
-			COUNT(*) FILTER (WHERE status = 'resolved') as resolved,
-			COUNT(*) FILTER (WHERE severity = 'critical') as critical,
-			COUNT(*) FILTER (WHERE severity = 'warning') as warning
+		SELECT date::text, severity, SUM(total), SUM(firing), SUM(resolved)
+		FROM alert_stats_daily
+		WHERE date >= CURRENT_DATE - 7 AND date < CURRENT_DATE
+		GROUP BY date, severity
+		UNION ALL
+		SELECT
+			DATE(started_at)::text,
+			COALESCE(severity, ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'firing'),
+			COUNT(*) FILTER (WHERE status = 'resolved')
 		FROM alert_history
-		WHERE started_at >= CURRENT_DATE - INTERVAL '7 days'
-		GROUP BY DATE(started_at)
-		ORDER BY date DESC
+		WHERE started_at >= CURRENT_DATE
+		GROUP BY 1, 2
+		ORDER BY 1 DESC
 	`)
 	defer dayRows.Close()
-	for dayRows.Next() {
-		var d DailyStats
-		dayRows.Scan(&d.Date, &d.Total, &d.Firing, &d.Resolved, &d.Critical, &d.Warning)
-		stats.ByDay = append(stats.ByDay, d)
+	stats.ByDay = scanDailyStats(dayRows)
+
+	// Top firing rules: past days from the daily rollup, today from raw history.
+	// Sentinel times stand in for open bounds so PostgreSQL gets typed params (avoids 42P08).
+	rangeStart := time.Time{}
+	if filter.StartTime != nil {
+		rangeStart = *filter.StartTime
+	}
+	rangeEnd := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	if filter.EndTime != nil {
+		rangeEnd = *filter.EndTime
+	}
+	ruleRows, _ := s.db.Query(ctx, `
+		SELECT t.rule_id::text, ar.name, SUM(t.count)::bigint as count
+		FROM (
+			SELECT rule_id, SUM(firing) as count
+			FROM alert_stats_daily
+			WHERE date < CURRENT_DATE AND date >= $1::date AND date <= $2::date
+			GROUP BY rule_id
+			UNION ALL
+			SELECT rule_id, COUNT(*)
+			FROM alert_history
+			WHERE status = 'firing' AND started_at >= CURRENT_DATE
+				AND started_at >= $1 AND started_at <= $2
+			GROUP BY rule_id
+		) t
+		INNER JOIN alert_rules ar ON t.rule_id = ar.id
+		GROUP BY t.rule_id, ar.name
+		HAVING SUM(t.count) > 0
+		ORDER BY count DESC
+		LIMIT 10
+	`, rangeStart, rangeEnd)
+	defer ruleRows.Close()
+	for ruleRows.Next() {
+		var r RuleStats
+		ruleRows.Scan(&r.RuleID, &r.RuleName, &r.AlertCount)
+		stats.TopFiringRules = append(stats.TopFiringRules, r)
 	}
 
-	// Top firing rules
+	return stats, nil
+}
+
+// rawDailyAndTopRules fills ByDay (last 7 days) and TopFiringRules from alert_history with the full filter applied.
+func (s *AlertStatisticsService) rawDailyAndTopRules(ctx context.Context, filter StatisticsFilter, stats *AlertStatistics) {
+	dayFilter := filter
+	dayFilter.StartTime, dayFilter.EndTime = nil, nil
+	where, args := dayFilter.where("ah.started_at", "ah.started_at >= CURRENT_DATE - 7")
+	dayRows, _ := s.db.Query(ctx, `
+		SELECT
+			DATE(ah.started_at)::text,
+			COALESCE(ah.severity, ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE ah.status = 'firing'),
+			COUNT(*) FILTER (WHERE ah.status = 'resolved')
+		FROM alert_history ah`+where+`
+		GROUP BY 1, 2
+		ORDER BY 1 DESC
+	`, args...)
+	defer dayRows.Close()
+	stats.ByDay = scanDailyStats(dayRows)
+
+	where, args = filter.where("ah.started_at", "ah.status = 'firing'")
 	ruleRows, _ := s.db.Query(ctx, `
-		SELECT ah.rule_id, ar.name, COUNT(*) as count
+		SELECT ah.rule_id::text, ar.name, COUNT(*)
 		FROM alert_history ah
-		INNER JOIN alert_rules ar ON ah.rule_id = ar.id
-		WHERE ah.status = 'firing'
-			AND ($1::timestamp IS NULL OR ah.started_at >= $1)
-			AND ($2::timestamp IS NULL OR ah.started_at <= $2)
+		INNER JOIN alert_rules ar ON ah.rule_id = ar.id`+where+`
 		GROUP BY ah.rule_id, ar.name
-		ORDER BY count DESC
+		ORDER BY 3 DESC
 		LIMIT 10
-	`, startTime, endTime)
+	`, args...)
 	defer ruleRows.Close()
 	for ruleRows.Next() {
 		var r RuleStats
 		ruleRows.Scan(&r.RuleID, &r.RuleName, &r.AlertCount)
 		stats.TopFiringRules = append(stats.TopFiringRules, r)
 	}
+}
 
-	return stats, nil
+// scanDailyStats folds (date, severity, total, firing, resolved) rows, ordered by date, into one DailyStats per date.
+func scanDailyStats(rows pgx.Rows) []DailyStats {
+	var days []DailyStats
+	dayIndex := make(map[string]int)
+	for rows.Next() {
+		var date, sev string
+		var total, firing, resolved int64
+		if err := rows.Scan(&date, &sev, &total, &firing, &resolved); err != nil {
+			continue
+		}
+		i, ok := dayIndex[date]
+		if !ok {
+			i = len(days)
+			dayIndex[date] = i
+			d := DailyStats{Date: date, BySeverity: make(map[string]int64)}
+			for _, name := range Severities() {
+				d.BySeverity[name] = 0
+			}
+			days = append(days, d)
+		}
+		d := &days[i]
+		d.Total += total
+		d.Firing += firing
+		d.Resolved += resolved
+		d.BySeverity[sev] += total
+		d.Critical = d.BySeverity["critical"]
+		d.Warning = d.BySeverity["warning"]
+	}
+	return days
 }
 
 type DashboardSummary struct {
@@ -192,17 +296,118 @@ type DashboardSummary struct {
 	EnabledChannels int `json:"enabled_channels"`
 	TodayAlerts    int `json:"today_alerts"`
 	FiringAlerts    int `json:"firing_alerts"`
+	// Time to notify over the last 24h: p50/p95 of the fire-to-delivery latency of successful firing
+	// deliveries, in milliseconds; 0 when nothing was delivered.
+	NotifyLatencyP50Ms int64 `json:"notify_latency_p50_ms"`
+	NotifyLatencyP95Ms int64 `json:"notify_latency_p95_ms"`
 }
 
-func (s *AlertStatisticsService) GetDashboardSummary(ctx context.Context) (*DashboardSummary, error) {
+// GetDashboardSummary returns the dashboard counters, served from a short-lived in-memory cache
+// (dashboardSummaryTTL) so repeated dashboard loads do not re-count alert_history. bypassCache forces a recount.
+func (s *AlertStatisticsService) GetDashboardSummary(ctx context.Context, bypassCache bool) (*DashboardSummary, error) {
+	s.summaryMu.Lock()
+	defer s.summaryMu.Unlock()
+
+	if !bypassCache && s.summary != nil && time.Since(s.summaryAt) < dashboardSummaryTTL {
+		cached := *s.summary
+		return &cached, nil
+	}
+
 	summary := &DashboardSummary{}
 
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_rules`).Scan(&summary.TotalRules)
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_rules WHERE status = 1`).Scan(&summary.EnabledRules)
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_channels`).Scan(&summary.TotalChannels)
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_channels WHERE status = 1`).Scan(&summary.EnabledChannels)
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history WHERE DATE(started_at) = CURRENT_DATE`).Scan(&summary.TodayAlerts)
-	s.db.QueryRow(ctx, `SELECT COUNT(*) FROM alert_history WHERE status = 'firing'`).Scan(&summary.FiringAlerts)
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM alert_rules),
+			(SELECT COUNT(*) FROM alert_rules WHERE status = 1),
+			(SELECT COUNT(*) FROM alert_channels),
+			(SELECT COUNT(*) FROM alert_channels WHERE status = 1),
+			(SELECT COUNT(*) FROM alert_history WHERE started_at >= CURRENT_DATE),
+			(SELECT COUNT(*) FROM alert_history WHERE status = 'firing'),
+			COALESCE(l.p50, 0), COALESCE(l.p95, 0)
+		FROM (
+			SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms)::bigint AS p50,
+				percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms)::bigint AS p95
+			FROM notification_logs
+			WHERE latency_ms IS NOT NULL AND created_at >= NOW() - INTERVAL '24 hours'
+		) l
+	`).Scan(&summary.TotalRules, &summary.EnabledRules, &summary.TotalChannels, &summary.EnabledChannels,
+		&summary.TodayAlerts, &summary.FiringAlerts, &summary.NotifyLatencyP50Ms, &summary.NotifyLatencyP95Ms)
+	if err != nil {
+		return nil, err
+	}
+
+	s.summary = summary
+	s.summaryAt = time.Now()
+	cached := *summary
+	return &cached, nil
+}
+
+// rollupLookbackDays is how many past days the nightly rollup recomputes, so alerts resolved after
+// their start day are reflected in the firing/resolved counts.
+const rollupLookbackDays = 7
+
+// RollupDaily recomputes alert_stats_daily for the days in [from, to) from alert_history.
+func (s *AlertStatisticsService) RollupDaily(ctx context.Context, from, to time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO alert_stats_daily (date, group_id, rule_id, severity, total, firing, resolved, avg_resolve_secs, updated_at)
+		SELECT
+			DATE(ah.started_at),
+			ar.group_id,
+			ah.rule_id,
+			COALESCE(ah.severity, ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE ah.status = 'firing'),
+			COUNT(*) FILTER (WHERE ah.status = 'resolved'),
+			AVG(EXTRACT(EPOCH FROM (ah.ended_at - ah.started_at))) FILTER (WHERE ah.ended_at IS NOT NULL),
+			NOW()
+		FROM alert_history ah
+		LEFT JOIN alert_rules ar ON ah.rule_id = ar.id
+		WHERE ah.started_at >= $1 AND ah.started_at < $2
+		GROUP BY DATE(ah.started_at), ar.group_id, ah.rule_id, COALESCE(ah.severity, '')
+		ON CONFLICT (date, rule_id, severity) DO UPDATE SET
+			group_id = EXCLUDED.group_id,
+			total = EXCLUDED.total,
+			firing = EXCLUDED.firing,
+			resolved = EXCLUDED.resolved,
+			avg_resolve_secs = EXCLUDED.avg_resolve_secs,
+			updated_at = EXCLUDED.updated_at
+	`, from, to)
+	return err
+}
+
+// StartDailyRollup keeps alert_stats_daily up to date until ctx is cancelled. On start it backfills
+// all history when the rollup table is empty (otherwise the last rollupLookbackDays days), then
+// re-runs shortly after every midnight.
+func (s *AlertStatisticsService) StartDailyRollup(ctx context.Context) {
+	run := func(backfill bool) {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		from := today.AddDate(0, 0, -rollupLookbackDays)
+		if backfill {
+			var hasRollup bool
+			var oldest *time.Time
+			s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM alert_stats_daily)`).Scan(&hasRollup)
+			s.db.QueryRow(ctx, `SELECT MIN(started_at) FROM alert_history`).Scan(&oldest)
+			if !hasRollup && oldest != nil && oldest.Before(from) {
+				from = *oldest
+			}
+		}
+		if err := s.RollupDaily(ctx, from, today); err != nil {
+			log.Printf("AlertStatisticsService: daily rollup: %v", err)
+		}
+	}
 
-	return summary, nil
+	run(true)
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 5, 0, 0, now.Location())
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			run(false)
+		}
+	}
 }
@@ -36,6 +36,7 @@ func (s *AlertTemplateService) Create(ctx context.Context, req *CreateTemplateRe
 	template := &models.AlertTemplate{
 		ID:          uuid.New(),
 		Name:        req.Name,
+		Slug:        req.Slug,
 		Description: req.Description,
 		Content:     req.Content,
 		Variables:   variablesJSON,
@@ -45,9 +46,9 @@ func (s *AlertTemplateService) Create(ctx context.Context, req *CreateTemplateRe
 	}
 
 	_, err := s.db.Exec(ctx, `
-		INSERT INTO alert_templates (id, name, description, content, variables, type, group_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
-	`, template.ID, template.Name, template.Description, template.Content, template.Variables,
+		INSERT INTO alert_templates (id, name, slug, description, content, variables, type, group_id, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, NOW(), NOW())
+	`, template.ID, template.Name, template.Slug, template.Description, template.Content, template.Variables,
 		template.Type, template.GroupID, template.Status)
 	if err != nil {
 		return nil, err
@@ -59,9 +60,9 @@ func (s *AlertTemplateService) Create(ctx context.Context, req *CreateTemplateRe
 func (s *AlertTemplateService) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertTemplate, error) {
 	var template models.AlertTemplate
 	err := s.db.QueryRow(ctx, `
-		SELECT id, name, description, content, variables, type, group_id, status, created_at, updated_at
+		SELECT id, name, COALESCE(slug, ''), description, content, variables, type, group_id, status, created_at, updated_at
 		FROM alert_templates WHERE id = $1
-	`, id).Scan(&template.ID, &template.Name, &template.Description, &template.Content,
+	`, id).Scan(&template.ID, &template.Name, &template.Slug, &template.Description, &template.Content,
 		&template.Variables, &template.Type, &template.GroupID, &template.Status,
 		&template.CreatedAt, &template.UpdatedAt)
 	if err != nil {
@@ -70,11 +71,20 @@ func (s *AlertTemplateService) GetByID(ctx context.Context, id uuid.UUID) (*mode
 	return &template, nil
 }
 
+// GetBySlug returns the template with the given slug.
+func (s *AlertTemplateService) GetBySlug(ctx context.Context, slug string) (*models.AlertTemplate, error) {
+	var id uuid.UUID
+	if err := s.db.QueryRow(ctx, `SELECT id FROM alert_templates WHERE slug = $1`, slug).Scan(&id); err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, id)
+}
+
 func (s *AlertTemplateService) List(ctx context.Context, page, pageSize int, templateType string, status int) ([]models.AlertTemplate, int, error) {
 	offset := (page - 1) * pageSize
 
 	rows, err := s.db.Query(ctx, `
-		SELECT id, name, description, content, variables, type, group_id, status, created_at, updated_at
+		SELECT id, name, COALESCE(slug, ''), description, content, variables, type, group_id, status, created_at, updated_at
 		FROM alert_templates
 		WHERE ($1 = '' OR type = $1) AND ($2 = -1 OR status = $2)
 		ORDER BY created_at DESC
@@ -88,7 +98,7 @@ func (s *AlertTemplateService) List(ctx context.Context, page, pageSize int, tem
 	var templates []models.AlertTemplate
 	for rows.Next() {
 		var t models.AlertTemplate
-		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Content,
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.Description, &t.Content,
 			&t.Variables, &t.Type, &t.GroupID, &t.Status, &t.CreatedAt, &t.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
@@ -113,6 +123,9 @@ func (s *AlertTemplateService) Update(ctx context.Context, id uuid.UUID, req *Up
 	if req.Name != nil {
 		template.Name = *req.Name
 	}
+	if req.Slug != nil {
+		template.Slug = *req.Slug
+	}
 	if req.Description != nil {
 		template.Description = *req.Description
 	}
@@ -128,9 +141,9 @@ func (s *AlertTemplateService) Update(ctx context.Context, id uuid.UUID, req *Up
 	}
 
 	_, err = s.db.Exec(ctx, `
-		UPDATE alert_templates SET name=$1, description=$2, content=$3, variables=$4, type=$5, updated_at=NOW()
-		WHERE id=$6
-	`, template.Name, template.Description, template.Content, template.Variables, template.Type, template.ID)
+		UPDATE alert_templates SET name=$1, description=$2, content=$3, variables=$4, type=$5, slug=NULLIF($6, ''), updated_at=NOW()
+		WHERE id=$7
+	`, template.Name, template.Description, template.Content, template.Variables, template.Type, template.Slug, template.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +173,7 @@ func (s *AlertTemplateService) Render(ctx context.Context, templateID uuid.UUID,
 
 type CreateTemplateRequest struct {
 	Name        string                 `json:"name" binding:"required"`
+	Slug        string                 `json:"slug"` // optional, unique across environments
 	Description string                 `json:"description"`
 	Content     string                 `json:"content" binding:"required"`
 	Variables   map[string]string     `json:"variables"`
@@ -169,6 +183,7 @@ type CreateTemplateRequest struct {
 
 type UpdateTemplateRequest struct {
 	Name        *string                `json:"name"`
+	Slug        *string                `json:"slug"`
 	Description *string                `json:"description"`
 	Content     *string                `json:"content"`
 	Variables   *map[string]string     `json:"variables"`
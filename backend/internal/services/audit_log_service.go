@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"alert-center/internal/models"
+	"alert-center/internal/repository"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,11 +20,12 @@ func NewAuditLogService(db *pgxpool.Pool) *AuditLogService {
 	return &AuditLogService{db: db}
 }
 
+// Create writes an audit entry, inside the caller's transaction when ctx carries one (see repository.WithTx).
 func (s *AuditLogService) Create(ctx context.Context, log *models.OperationLog) error {
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
 
-	_, err := s.db.Exec(ctx, `
+	_, err := repository.Conn(ctx, s.db).Exec(ctx, `
 		INSERT INTO operation_logs (id, user_id, action, resource, resource_id, detail, ip, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`, log.ID, log.UserID, log.Action, log.Resource, log.ResourceID, log.Detail, log.IP, log.CreatedAt)
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dingTalkSignedURL appends the timestamp and HMAC-SHA256 signature a DingTalk robot with "加签" security
+// requires: sign = base64(hmac_sha256(secret, timestamp + "\n" + secret)), timestamp in milliseconds.
+func dingTalkSignedURL(webhookURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("dingtalk webhook_url invalid: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// buildDingTalkMarkdown returns the markdown message title and text: the rendered template content when
+// the rule has one, else the same fields as the Lark card.
+func buildDingTalkMarkdown(alert *AlertPayload) (string, string) {
+	title := "告警通知"
+	icon := "🚨"
+	if alert.Status == "resolved" {
+		title = "告警恢复"
+		icon = "✅"
+	}
+	header := fmt.Sprintf("### %s %s\n\n", icon, title)
+	if alert.RenderedContent != "" {
+		return title, header + alert.RenderedContent
+	}
+
+	alertNoStr := alert.AlertNo
+	if alertNoStr == "" {
+		alertNoStr = "-"
+	}
+	desc := alert.Description
+	if desc == "" {
+		desc = "-"
+	}
+	lines := []string{
+		"**告警编号**: " + alertNoStr,
+		"**规则名称**: " + alert.RuleName,
+		"**严重级别**: " + alert.Severity,
+		"**状态**: " + alert.Status,
+	}
+	if alert.Value != "" {
+		lines = append(lines, "**当前值**: "+alert.Value)
+	}
+	if alert.Summary != "" {
+		lines = append(lines, "**触发序列**:\n\n"+alert.Summary)
+	}
+	lines = append(lines, "**开始时间**: "+alert.StartedAt.Format("2006-01-02 15:04:05"))
+	if alert.Status == "resolved" && alert.EndedAt != nil {
+		lines = append(lines,
+			"**恢复时间**: "+alert.EndedAt.Format("2006-01-02 15:04:05"),
+			"**持续时长**: "+alert.EndedAt.Sub(alert.StartedAt).Round(time.Second).String(),
+		)
+	}
+	lines = append(lines, "**描述**: "+desc)
+	return title, header + strings.Join(lines, "\n\n")
+}
+
+// sendDingTalkAlert posts the alert as a markdown message to a DingTalk robot webhook, signing the
+// request when secret is configured.
+func sendDingTalkAlert(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
+	webhookURL, ok := config["webhook_url"].(string)
+	if !ok || webhookURL == "" {
+		return fmt.Errorf("dingtalk webhook_url not configured")
+	}
+	if secret, _ := config["secret"].(string); secret != "" {
+		signed, err := dingTalkSignedURL(webhookURL, secret, time.Now())
+		if err != nil {
+			return err
+		}
+		webhookURL = signed
+	}
+
+	title, text := buildDingTalkMarkdown(alert)
+	body, _ := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": title,
+			"text":  text,
+		},
+	})
+
+	resp, err := postChannelJSON(ctx, "dingtalk", webhookURL, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("dingtalk webhook failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	// DingTalk returns 200 with body {"errcode":0,"errmsg":"ok"} on success, or e.g. {"errcode":310000,"errmsg":"sign not match"} on failure
+	var dingResp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(respBody, &dingResp); err == nil && dingResp.ErrCode != 0 {
+		return fmt.Errorf("dingtalk webhook rejected: %s (errcode %d)", dingResp.ErrMsg, dingResp.ErrCode)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailDialTimeout bounds connecting to the SMTP server and emailTimeout the whole SMTP session
+// when ctx carries no earlier deadline.
+const (
+	emailDialTimeout = 10 * time.Second
+	emailTimeout     = 30 * time.Second
+)
+
+// emailConfig is the parsed config of an email channel.
+type emailConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// parseEmailConfig reads smtp_host, smtp_port, username, password, from and to (comma-separated) from
+// a channel config. smtp_port defaults to 465; username and password may be omitted for relays
+// without auth.
+func parseEmailConfig(config map[string]interface{}) (*emailConfig, error) {
+	cfg := &emailConfig{port: 465}
+	cfg.host, _ = config["smtp_host"].(string)
+	cfg.username, _ = config["username"].(string)
+	cfg.password, _ = config["password"].(string)
+	cfg.from, _ = config["from"].(string)
+	switch v := config["smtp_port"].(type) {
+	case float64:
+		cfg.port = int(v)
+	case string:
+		if v != "" {
+			p, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("email smtp_port %q is not a number", v)
+			}
+			cfg.port = p
+		}
+	}
+	to, _ := config["to"].(string)
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.to = append(cfg.to, addr)
+		}
+	}
+
+	var missing []string
+	if cfg.host == "" {
+		missing = append(missing, "smtp_host")
+	}
+	if cfg.from == "" {
+		missing = append(missing, "from")
+	}
+	if len(cfg.to) == 0 {
+		missing = append(missing, "to")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("email %s not configured", strings.Join(missing, ", "))
+	}
+	return cfg, nil
+}
+
+// emailSubject includes severity and rule name, e.g. "[CRITICAL] 告警通知: CPU usage high".
+func emailSubject(alert *AlertPayload) string {
+	title := "告警通知"
+	if alert.Status == "resolved" {
+		title = "告警恢复"
+	}
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(alert.Severity), title, alert.RuleName)
+}
+
+// buildEmailHTML returns the rendered template content when the rule has one, else the same key/value
+// layout as the Lark card.
+func buildEmailHTML(alert *AlertPayload) string {
+	if alert.RenderedContent != "" {
+		return alert.RenderedContent
+	}
+	alertNoStr := alert.AlertNo
+	if alertNoStr == "" {
+		alertNoStr = "-"
+	}
+	desc := alert.Description
+	if desc == "" {
+		desc = "-"
+	}
+	rows := [][2]string{
+		{"告警编号", alertNoStr},
+		{"规则名称", alert.RuleName},
+		{"严重级别", alert.Severity},
+		{"状态", alert.Status},
+	}
+	if alert.Value != "" {
+		rows = append(rows, [2]string{"当前值", alert.Value})
+	}
+	if alert.Summary != "" {
+		rows = append(rows, [2]string{"触发序列", alert.Summary})
+	}
+	rows = append(rows,
+		[2]string{"开始时间", alert.StartedAt.Format("2006-01-02 15:04:05")},
+		[2]string{"描述", desc},
+	)
+	if alert.Status == "resolved" && alert.EndedAt != nil {
+		rows = append(rows,
+			[2]string{"恢复时间", alert.EndedAt.Format("2006-01-02 15:04:05")},
+			[2]string{"持续时长", alert.EndedAt.Sub(alert.StartedAt).Round(time.Second).String()},
+		)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<table cellpadding="6" style="border-collapse:collapse">`)
+	for _, r := range rows {
+		fmt.Fprintf(&b, `<tr><td style="font-weight:bold;vertical-align:top">%s</td><td style="white-space:pre-wrap">%s</td></tr>`,
+			html.EscapeString(r[0]), html.EscapeString(r[1]))
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// sendEmailAlert sends the alert as an HTML mail over SMTP with TLS: implicit TLS on port 465,
+// STARTTLS (required) on any other port.
+func sendEmailAlert(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
+	cfg, err := parseEmailConfig(config)
+	if err != nil {
+		return err
+	}
+	return sendEmail(ctx, cfg, emailSubject(alert), buildEmailHTML(alert))
+}
+
+// sendEmail sends an HTML mail to cfg.to over SMTP with TLS, as sendEmailAlert does.
+func sendEmail(ctx context.Context, cfg *emailConfig, subject, htmlBody string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.WriteString(htmlBody)
+
+	addr := net.JoinHostPort(cfg.host, strconv.Itoa(cfg.port))
+	dialer := &net.Dialer{Timeout: emailDialTimeout}
+	tlsConfig := &tls.Config{ServerName: cfg.host}
+
+	var conn net.Conn
+	var err error
+	if cfg.port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("email connect %s: %w", addr, err)
+	}
+	deadline := time.Now().Add(emailTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, cfg.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if cfg.port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("email server %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("email starttls: %w", err)
+		}
+	}
+	if cfg.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.username, cfg.password, cfg.host)); err != nil {
+			return fmt.Errorf("email auth: %w", err)
+		}
+	}
+	if err := client.Mail(cfg.from); err != nil {
+		return fmt.Errorf("email MAIL FROM: %w", err)
+	}
+	for _, rcpt := range cfg.to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("email RCPT TO %s: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("email DATA: %w", err)
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("email write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email send: %w", err)
+	}
+	return client.Quit()
+}
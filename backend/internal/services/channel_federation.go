@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FederationAPIKeyHeader carries the API key a federation channel authenticates with.
+const FederationAPIKeyHeader = "X-API-Key"
+
+// FederatedAlert is the body a federation channel POSTs to another instance's
+// /api/v1/federation/alerts. The receiving instance keeps alert_no and labels as sent. Test requests
+// only check the API key and store nothing.
+type FederatedAlert struct {
+	AlertNo     string            `json:"alert_no"`
+	RuleID      uuid.UUID         `json:"rule_id"`
+	RuleName    string            `json:"rule_name"`
+	Severity    string            `json:"severity"`
+	Status      string            `json:"status"` // firing, resolved
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Value       string            `json:"value,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+	EndedAt     *time.Time        `json:"ended_at,omitempty"`
+	Test        bool              `json:"test,omitempty"`
+}
+
+func federatedAlertFromPayload(alert *AlertPayload) FederatedAlert {
+	labels := map[string]string{}
+	json.Unmarshal([]byte(alert.Labels), &labels)
+	return FederatedAlert{
+		AlertNo:     alert.AlertNo,
+		RuleID:      alert.RuleID,
+		RuleName:    alert.RuleName,
+		Severity:    alert.Severity,
+		Status:      alert.Status,
+		Description: alert.Description,
+		Labels:      labels,
+		Value:       alert.Value,
+		Summary:     alert.Summary,
+		StartedAt:   alert.StartedAt,
+		EndedAt:     alert.EndedAt,
+	}
+}
+
+// sendFederationAlert forwards the alert to another alert-center instance's ingestion endpoint.
+func sendFederationAlert(ctx context.Context, config map[string]interface{}, alert *AlertPayload) error {
+	return postFederatedAlert(ctx, config, federatedAlertFromPayload(alert))
+}
+
+// sendFederationTest checks that the receiving instance accepts the channel's API key, without
+// creating an alert there.
+func sendFederationTest(ctx context.Context, config map[string]interface{}) error {
+	return postFederatedAlert(ctx, config, FederatedAlert{Test: true})
+}
+
+func postFederatedAlert(ctx context.Context, config map[string]interface{}, alert FederatedAlert) error {
+	url, _ := config["url"].(string)
+	apiKey, _ := config["api_key"].(string)
+	if url == "" || apiKey == "" {
+		return fmt.Errorf("federation url and api_key must be configured")
+	}
+
+	body, _ := json.Marshal(alert)
+	resp, err := postChannelJSONWithHeader(ctx, "federation", url, body, http.Header{FederationAPIKeyHeader: {apiKey}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("federation endpoint failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidFieldMapping is returned when a webhook channel config sets an invalid "field_mapping".
+var ErrInvalidFieldMapping = errors.New("invalid field_mapping")
+
+// fieldMappingDrop as a field_mapping target leaves the field out of the body.
+const fieldMappingDrop = "-"
+
+// alertPayloadFields are the JSON keys of AlertPayload, the sources a field_mapping can rename.
+var alertPayloadFields = map[string]bool{
+	"alert_no": true, "rule_id": true, "rule_name": true, "severity": true, "status": true, "description": true,
+	"labels": true, "value": true, "summary": true, "started_at": true, "ended_at": true, "rendered_content": true,
+}
+
+// fieldMapping returns a webhook channel's "field_mapping": AlertPayload JSON key to the key the
+// downstream expects, e.g. {"summary": "message", "severity": "priority"}. A dotted target nests the
+// field ("labels": "details.labels") and "-" drops it. Unmapped fields keep their names.
+func fieldMapping(config map[string]interface{}) (map[string]string, error) {
+	raw, ok := config["field_mapping"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: must be an object of field names", ErrInvalidFieldMapping)
+	}
+	mapping := make(map[string]string, len(obj))
+	for from, v := range obj {
+		to, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: target of %q must be a string", ErrInvalidFieldMapping, from)
+		}
+		mapping[from] = strings.TrimSpace(to)
+	}
+	return mapping, nil
+}
+
+// validateFieldMapping checks the optional "field_mapping" of a channel config: sources must be
+// AlertPayload fields, and targets non-empty, distinct, and not nested under another target.
+func validateFieldMapping(config map[string]interface{}) error {
+	mapping, err := fieldMapping(config)
+	if err != nil || len(mapping) == 0 {
+		return err
+	}
+	targets := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		if !alertPayloadFields[from] {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidFieldMapping, from)
+		}
+		if to == fieldMappingDrop {
+			continue
+		}
+		for _, part := range strings.Split(to, ".") {
+			if part == "" {
+				return fmt.Errorf("%w: invalid target %q for %q", ErrInvalidFieldMapping, to, from)
+			}
+		}
+		if other, dup := targets[to]; dup {
+			return fmt.Errorf("%w: %q and %q both map to %q", ErrInvalidFieldMapping, other, from, to)
+		}
+		targets[to] = from
+	}
+	for to, from := range targets {
+		for prefix := to; strings.Contains(prefix, "."); {
+			prefix = prefix[:strings.LastIndex(prefix, ".")]
+			if other, ok := targets[prefix]; ok {
+				return fmt.Errorf("%w: %q maps to %q, inside the target of %q", ErrInvalidFieldMapping, from, to, other)
+			}
+		}
+	}
+	return nil
+}
+
+// mapAlertFields renders the alert as a JSON object with its keys renamed per mapping. Mapped fields
+// take precedence over unmapped fields of the same name.
+func mapAlertFields(alert *AlertPayload, mapping map[string]string) ([]byte, error) {
+	raw, err := json.Marshal(alert)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	var mapped []string
+	for k, v := range fields {
+		if _, ok := mapping[k]; ok {
+			mapped = append(mapped, k)
+			continue
+		}
+		out[k] = v
+	}
+	sort.Strings(mapped)
+	for _, k := range mapped {
+		if to := mapping[k]; to != fieldMappingDrop {
+			setFieldPath(out, strings.Split(to, "."), fields[k])
+		}
+	}
+	return json.Marshal(out)
+}
+
+// setFieldPath sets obj[path[0]][path[1]]... = v, creating (or replacing non-object values with)
+// nested objects along the way.
+func setFieldPath(obj map[string]interface{}, path []string, v interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			obj[key] = next
+		}
+		obj = next
+	}
+	obj[path[len(path)-1]] = v
+}
+
+// webhookAlertBody is the body of a plain (non-Lark) webhook: the alert JSON, with the channel's
+// field_mapping applied when set.
+func webhookAlertBody(config map[string]interface{}, alert *AlertPayload) ([]byte, error) {
+	mapping, err := fieldMapping(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapping) == 0 {
+		return json.Marshal(alert)
+	}
+	return mapAlertFields(alert, mapping)
+}
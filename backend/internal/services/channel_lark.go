@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Lark message formats a lark or webhook channel can force with config "format".
+const (
+	larkFormatCard     = "card"
+	larkFormatText     = "text"
+	larkFormatMarkdown = "markdown"
+)
+
+// ErrInvalidChannelFormat is returned when a channel config sets an unknown "format".
+var ErrInvalidChannelFormat = errors.New("invalid channel format: must be card, text or markdown")
+
+// validateChannelFormat checks the optional "format" of a channel config.
+func validateChannelFormat(config map[string]interface{}) error {
+	v, ok := config["format"]
+	if !ok || v == nil || v == "" {
+		return nil
+	}
+	switch v {
+	case larkFormatCard, larkFormatText, larkFormatMarkdown:
+		return nil
+	}
+	return ErrInvalidChannelFormat
+}
+
+// larkFormat returns the Lark message format for a channel: the config "format" when set, else def for a
+// lark channel or a webhook whose URL is a Lark robot, else "" (a webhook posting the alert JSON as is).
+// Setting "format" on a webhook channel sends Lark payloads to URLs that are not detected, such as proxies.
+func larkFormat(config map[string]interface{}, webhookURL, def string) string {
+	if f, _ := config["format"].(string); f != "" {
+		return f
+	}
+	if webhookURL == "" || isLarkWebhookURL(webhookURL) {
+		return def
+	}
+	return ""
+}
+
+// larkHookPath is the custom robot webhook path, the same on open.larksuite.com, open.feishu.cn and
+// self-hosted or proxied Lark deployments.
+const larkHookPath = "/open-apis/bot/v2/hook/"
+
+// isLarkWebhookURL returns true if the URL is a Lark/Feishu robot webhook (which requires msg_type in body),
+// detected by its path so Feishu (feishu.cn), Lark (larksuite.com) and other hosts are all matched.
+func isLarkWebhookURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.HasPrefix(u.Path, larkHookPath) && len(u.Path) > len(larkHookPath)
+}
+
+// buildLarkPayload returns the Lark robot message body for the format; unknown formats fall back to a card.
+func buildLarkPayload(format string, alert *AlertPayload) map[string]interface{} {
+	switch format {
+	case larkFormatMarkdown:
+		return map[string]interface{}{
+			"msg_type": "markdown",
+			"content":  map[string]interface{}{"text": buildLarkMarkdown(alert)},
+		}
+	case larkFormatText:
+		return map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]interface{}{"text": strings.ReplaceAll(buildLarkMarkdown(alert), "**", "")},
+		}
+	}
+	return buildLarkCardPayload(alert)
+}
+
+// buildLarkMarkdown returns the alert as Lark markdown: the rendered template content when the rule has
+// one, else the alert fields.
+func buildLarkMarkdown(alert *AlertPayload) string {
+	if alert.RenderedContent != "" {
+		if alert.Status == "resolved" {
+			return "**告警恢复**\n\n" + alert.RenderedContent
+		}
+		return "**告警通知**\n\n" + alert.RenderedContent
+	}
+	alertNoStr := alert.AlertNo
+	if alertNoStr == "" {
+		alertNoStr = "-"
+	}
+	if alert.Status == "resolved" && alert.EndedAt != nil {
+		dur := alert.EndedAt.Sub(alert.StartedAt).Round(time.Second)
+		return fmt.Sprintf("**告警恢复**\n\n**告警编号**: %s\n**规则**: %s\n**级别**: %s\n**状态**: %s\n**开始时间**: %s\n**恢复时间**: %s\n**持续时长**: %s",
+			alertNoStr, alert.RuleName, alert.Severity, alert.Status,
+			alert.StartedAt.Format("2006-01-02 15:04:05"),
+			alert.EndedAt.Format("2006-01-02 15:04:05"), dur.String())
+	}
+	content := fmt.Sprintf("**告警通知**\n\n**告警编号**: %s\n**规则**: %s\n**级别**: %s\n**状态**: %s\n**时间**: %s",
+		alertNoStr, alert.RuleName, alert.Severity, alert.Status, alert.StartedAt.Format("2006-01-02 15:04:05"))
+	if alert.Value != "" {
+		content += "\n**当前值**: " + alert.Value
+	}
+	if alert.Summary != "" {
+		content += "\n**触发序列**:\n" + alert.Summary
+	}
+	return content
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsLarkWebhookURL(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		want bool
+	}{
+		{"https://open.feishu.cn/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"https://feishu.cn/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"https://open.larksuite.com/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"https://larksuite.com/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"http://lark-proxy.internal:8080/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"  https://open.feishu.cn/open-apis/bot/v2/hook/0a1b2c3d  ", true},
+		{"https://open.feishu.cn/open-apis/bot/v2/hook/", false},
+		{"https://open.feishu.cn/open-apis/im/v1/messages", false},
+		{"https://hooks.example.com/alerts", false},
+		{"/open-apis/bot/v2/hook/0a1b2c3d", false},
+		{"://bad", false},
+	} {
+		if got := isLarkWebhookURL(tc.url); got != tc.want {
+			t.Errorf("isLarkWebhookURL(%q) = %v, want %v", tc.url, got, tc.want)
+		}
+	}
+}
+
+// A webhook channel pointed at a Feishu or Lark robot, on any host, is sent a robot message with
+// msg_type; other webhooks get the alert JSON.
+func TestWebhookSendsRobotMessageToLarkHooks(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+	alert := &AlertPayload{RuleName: "disk full", Severity: "critical", Status: "firing", Labels: "{}"}
+
+	for _, tc := range []struct {
+		path    string
+		msgType bool
+	}{
+		{"/open-apis/bot/v2/hook/0a1b2c3d", true},
+		{"/alerts", false},
+	} {
+		if err := sendWebhookAlert(context.Background(), map[string]interface{}{"url": srv.URL + tc.path}, alert); err != nil {
+			t.Fatalf("%s: %v", tc.path, err)
+		}
+		if _, ok := body["msg_type"]; ok != tc.msgType {
+			t.Errorf("%s: msg_type present = %v, want %v (body %v)", tc.path, ok, tc.msgType, body)
+		}
+	}
+}
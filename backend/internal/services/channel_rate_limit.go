@@ -0,0 +1,170 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrChannelRateLimited is recorded in the notification log for alerts dropped because their channel
+// exceeded its max_per_minute.
+var ErrChannelRateLimited = errors.New("channel rate limited")
+
+// channelRateLimits is the process-wide limiter of channels with max_per_minute set. Limits are per
+// process: an API and a worker process sending to the same channel each get the full rate.
+var channelRateLimits = &channelRateLimiter{buckets: make(map[uuid.UUID]*channelBucket)}
+
+// channelRateLimiter is a token bucket per channel ID, safe for concurrent senders. Alerts sent while a
+// bucket is empty are dropped and counted, to be reported later in a single summary notification.
+type channelRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[uuid.UUID]*channelBucket
+}
+
+type channelBucket struct {
+	channel    models.AlertChannel // last seen config, used to flush the summary
+	max        int
+	tokens     float64
+	updated    time.Time
+	suppressed int
+	firstAt    time.Time
+	severity   string
+	rules      map[string]int // suppressed alerts by rule name
+}
+
+// channelMaxPerMinute reads max_per_minute from a channel config; 0 (unset or invalid) means unlimited.
+func channelMaxPerMinute(config map[string]interface{}) int {
+	switch v := config["max_per_minute"].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+// refill adds the tokens earned since the last update; a bucket holds at most max tokens, so a
+// channel can burst max alerts after being quiet for a minute.
+func (b *channelBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updated).Minutes() * float64(b.max)
+	if b.tokens > float64(b.max) {
+		b.tokens = float64(b.max)
+	}
+	b.updated = now
+}
+
+// allow takes a token for sending alert to channel. When the bucket is empty the alert is counted as
+// suppressed and false is returned.
+func (l *channelRateLimiter) allow(channel models.AlertChannel, max int, alert *AlertPayload, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[channel.ID]
+	if !ok {
+		b = &channelBucket{tokens: float64(max), updated: now}
+		l.buckets[channel.ID] = b
+	}
+	b.channel, b.max = channel, max
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	if b.suppressed == 0 {
+		b.firstAt, b.severity, b.rules = now, alert.Severity, make(map[string]int)
+	} else if severityRank(alert.Severity) > severityRank(b.severity) {
+		b.severity = alert.Severity
+	}
+	b.suppressed++
+	b.rules[alert.RuleName]++
+	return false
+}
+
+// takeSummary returns the summary of the alerts suppressed on the channel, taking a token for it, or
+// nil when nothing was suppressed or the bucket is still empty.
+func (l *channelRateLimiter) takeSummary(channelID uuid.UUID, now time.Time) (*AlertPayload, models.AlertChannel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[channelID]
+	if !ok || b.suppressed == 0 {
+		return nil, models.AlertChannel{}
+	}
+	b.refill(now)
+	if b.tokens < 1 {
+		return nil, models.AlertChannel{}
+	}
+	b.tokens--
+	payload := b.summary()
+	b.suppressed, b.rules = 0, nil
+	return payload, b.channel
+}
+
+// pending returns the IDs of channels with suppressed alerts not yet summarized.
+func (l *channelRateLimiter) pending() []uuid.UUID {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ids []uuid.UUID
+	for id, b := range l.buckets {
+		if b.suppressed > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// summary builds the "N additional alerts suppressed" notification: the highest suppressed severity
+// and one line per rule with its suppressed count, most suppressed first.
+func (b *channelBucket) summary() *AlertPayload {
+	names := make([]string, 0, len(b.rules))
+	for name := range b.rules {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if b.rules[names[i]] != b.rules[names[j]] {
+			return b.rules[names[i]] > b.rules[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s ×%d", name, b.rules[name])
+	}
+	return &AlertPayload{
+		RuleName:    fmt.Sprintf("%d additional alerts suppressed", b.suppressed),
+		Severity:    b.severity,
+		Status:      "firing",
+		Description: fmt.Sprintf("channel %s exceeded max_per_minute %d", b.channel.Name, b.max),
+		Labels:      "{}",
+		Summary:     strings.Join(lines, "\n"),
+		StartedAt:   b.firstAt,
+	}
+}
+
+// sendRateLimitSummary sends the pending suppression summary of channel, if a token is available.
+func sendRateLimitSummary(ctx context.Context, channelID uuid.UUID, logs *repository.NotificationLogRepository) {
+	payload, channel := channelRateLimits.takeSummary(channelID, time.Now())
+	if payload == nil {
+		return
+	}
+	if err := sendChannelAlert(ctx, channel, payload, logs, nil); err != nil {
+		log.Printf("send rate limit summary to channel %s (%s): %v", channel.Name, channel.Type, err)
+	}
+}
+
+// FlushRateLimitSummaries sends the suppression summaries of channels whose bucket has refilled, so
+// alerts dropped at the end of a burst are reported without waiting for the channel's next alert.
+func FlushRateLimitSummaries(ctx context.Context, logs *repository.NotificationLogRepository) {
+	for _, id := range channelRateLimits.pending() {
+		sendRateLimitSummary(ctx, id, logs)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Channel send retry settings, from notifications.max_retries and notifications.retry_base_ms.
+var (
+	channelMaxRetries atomic.Int64
+	channelRetryBase  atomic.Int64 // nanoseconds
+)
+
+func init() {
+	SetChannelRetry(3, time.Second)
+}
+
+// SetChannelRetry sets how often a failed channel POST is retried and the base backoff delay, which
+// doubles per retry (1s, 2s, 4s, ... with up to 50% jitter). maxRetries 0 disables retries; a
+// non-positive base keeps 1s.
+func SetChannelRetry(maxRetries int, base time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if base <= 0 {
+		base = time.Second
+	}
+	channelMaxRetries.Store(int64(maxRetries))
+	channelRetryBase.Store(int64(base))
+}
+
+// retryableStatus reports whether a channel response is worth retrying: 5xx and 429, never other 4xx.
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+type deliveryTraceKey struct{}
+
+// deliveryTrace collects what postChannelJSON observed for one channel send, for the notification log.
+type deliveryTrace struct {
+	httpStatus int // status of the last response, 0 when none was received
+	attempts   int
+}
+
+// withDeliveryTrace returns a context whose channel POSTs are recorded in the returned trace.
+func withDeliveryTrace(ctx context.Context) (context.Context, *deliveryTrace) {
+	trace := &deliveryTrace{}
+	return context.WithValue(ctx, deliveryTraceKey{}, trace), trace
+}
+
+// postChannelJSON POSTs body as JSON to a channel endpoint, retrying connection errors and 5xx/429
+// responses with jittered exponential backoff. It returns the last response for the caller to check
+// like a single request; the response body of retried attempts is discarded.
+func postChannelJSON(ctx context.Context, channelType, url string, body []byte) (*http.Response, error) {
+	return postChannelJSONWithHeader(ctx, channelType, url, body, nil)
+}
+
+// postChannelJSONWithHeader is postChannelJSON with extra request headers, e.g. an API key.
+func postChannelJSONWithHeader(ctx context.Context, channelType, url string, body []byte, header http.Header) (*http.Response, error) {
+	maxRetries := int(channelMaxRetries.Load())
+	delay := time.Duration(channelRetryBase.Load())
+	trace, _ := ctx.Value(deliveryTraceKey{}).(*deliveryTrace)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := channelHTTPClient.Do(req)
+		if trace != nil {
+			trace.attempts = attempt + 1
+			trace.httpStatus = 0
+			if resp != nil {
+				trace.httpStatus = resp.StatusCode
+			}
+		}
+		var failure string
+		switch {
+		case err != nil:
+			failure = err.Error()
+		case retryableStatus(resp.StatusCode):
+			failure = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		default:
+			if attempt > 0 {
+				log.Printf("channel %s: send succeeded after %d retries", channelType, attempt)
+			}
+			return resp, nil
+		}
+
+		if attempt >= maxRetries || ctx.Err() != nil {
+			if attempt > 0 {
+				log.Printf("channel %s: send failed after %d retries: %s", channelType, attempt, failure)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+		log.Printf("channel %s: attempt %d/%d failed (%s), retrying in %s", channelType, attempt+1, maxRetries+1, failure, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfigField describes one key of a channel or data source config object.
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // string, number, boolean, object
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"` // credential; UIs should mask it
+	Description string `json:"description"`
+}
+
+// ChannelTypeSchema describes a supported channel type and the config fields its sender reads.
+type ChannelTypeSchema struct {
+	Type   string        `json:"type"`
+	Name   string        `json:"name"`
+	Fields []ConfigField `json:"fields"`
+}
+
+// rateLimitField is accepted by every channel type (see channelRateLimiter).
+var rateLimitField = ConfigField{Name: "max_per_minute", Type: "number", Description: "每分钟最多发送条数，超出的告警被丢弃并汇总为一条“N additional alerts suppressed”通知；0 或留空不限"}
+
+// channelTypeSchemas lists the channel types with a sender implementation (see sendToChannels and
+// AlertChannelService.Send). Keep it in sync when adding a type or a config key.
+var channelTypeSchemas = []ChannelTypeSchema{
+	{
+		Type: "lark",
+		Name: "飞书",
+		Fields: []ConfigField{
+			{Name: "webhook_url", Type: "string", Required: true, Secret: true, Description: "飞书机器人 Webhook URL"},
+			rateLimitField,
+		},
+	},
+	{
+		Type: "dingtalk",
+		Name: "钉钉",
+		Fields: []ConfigField{
+			{Name: "webhook_url", Type: "string", Required: true, Secret: true, Description: "钉钉机器人 Webhook URL"},
+			{Name: "secret", Type: "string", Secret: true, Description: "加签密钥（SEC 开头），未开启加签可留空"},
+			rateLimitField,
+		},
+	},
+	{
+		Type: "telegram",
+		Name: "Telegram",
+		Fields: []ConfigField{
+			{Name: "bot_token", Type: "string", Required: true, Secret: true, Description: "Telegram Bot Token"},
+			{Name: "chat_id", Type: "string", Required: true, Description: "Telegram Chat ID"},
+			{Name: "api_base", Type: "string", Description: "Bot API 地址，默认 https://api.telegram.org"},
+			rateLimitField,
+		},
+	},
+	{
+		Type: "email",
+		Name: "邮件",
+		Fields: []ConfigField{
+			{Name: "smtp_host", Type: "string", Required: true, Description: "SMTP 服务器地址"},
+			{Name: "smtp_port", Type: "number", Description: "SMTP 端口，默认 465（隐式 TLS），其他端口使用 STARTTLS"},
+			{Name: "username", Type: "string", Description: "SMTP 用户名"},
+			{Name: "password", Type: "string", Secret: true, Description: "SMTP 密码"},
+			{Name: "from", Type: "string", Required: true, Description: "发件人地址"},
+			{Name: "to", Type: "string", Required: true, Description: "收件人地址，多个以逗号分隔"},
+			rateLimitField,
+		},
+	},
+	{
+		Type: "webhook",
+		Name: "Webhook",
+		Fields: []ConfigField{
+			{Name: "url", Type: "string", Required: true, Description: "通用 Webhook 地址；飞书机器人地址将按飞书卡片格式推送"},
+			{Name: "field_mapping", Type: "object", Description: "字段映射，如 {\"summary\": \"message\", \"severity\": \"priority\"}；目标含 . 时嵌套，\"-\" 表示去掉该字段"},
+			rateLimitField,
+		},
+	},
+	{
+		Type: "federation",
+		Name: "告警联邦",
+		Fields: []ConfigField{
+			{Name: "url", Type: "string", Required: true, Description: "中心 alert-center 的接收地址，如 https://central.example.com/api/v1/federation/alerts"},
+			{Name: "api_key", Type: "string", Required: true, Secret: true, Description: "中心实例 federation.sources 中为本实例配置的 API Key"},
+			rateLimitField,
+		},
+	},
+}
+
+// SupportedChannelTypes returns the supported channel types and their config schemas.
+func SupportedChannelTypes() []ChannelTypeSchema {
+	return channelTypeSchemas
+}
+
+// channelTypeSchema returns the schema for channelType, or nil when the type is unknown.
+func channelTypeSchema(channelType string) *ChannelTypeSchema {
+	for i := range channelTypeSchemas {
+		if channelTypeSchemas[i].Type == channelType {
+			return &channelTypeSchemas[i]
+		}
+	}
+	return nil
+}
+
+// ErrInvalidChannelConfig is wrapped by the errors SendTestWithConfig returns for configs that fail
+// ValidateChannelConfig.
+var ErrInvalidChannelConfig = errors.New("invalid channel config")
+
+// ChannelConfigError is a problem with one key of a channel config ("type" for an unknown type).
+type ChannelConfigError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateChannelConfig statically checks config against the schema of channelType, without sending
+// anything: required keys must be set, keys must have their schema type (a number may also be given as
+// a numeric string), and the optional format and field_mapping must be valid. It returns one error per
+// offending key, in schema order, or nil when the config is valid.
+func ValidateChannelConfig(channelType string, config map[string]interface{}) []ChannelConfigError {
+	schema := channelTypeSchema(channelType)
+	if schema == nil {
+		types := make([]string, len(channelTypeSchemas))
+		for i, t := range channelTypeSchemas {
+			types[i] = t.Type
+		}
+		return []ChannelConfigError{{Field: "type", Message: "must be one of " + strings.Join(types, ", ")}}
+	}
+	var errs []ChannelConfigError
+	for _, f := range schema.Fields {
+		v, ok := config[f.Name]
+		if !ok || v == nil || v == "" {
+			if f.Required {
+				errs = append(errs, ChannelConfigError{Field: f.Name, Message: "is required"})
+			}
+			continue
+		}
+		if !configValueHasType(v, f.Type) {
+			errs = append(errs, ChannelConfigError{Field: f.Name, Message: "must be a " + f.Type})
+		}
+	}
+	if channelMaxPerMinute(config) < 0 {
+		errs = append(errs, ChannelConfigError{Field: rateLimitField.Name, Message: "must not be negative"})
+	}
+	if err := validateChannelFormat(config); err != nil {
+		errs = append(errs, ChannelConfigError{Field: "format", Message: "must be card, text or markdown"})
+	}
+	if err := validateFieldMapping(config); err != nil {
+		errs = append(errs, ChannelConfigError{Field: "field_mapping", Message: strings.TrimPrefix(err.Error(), ErrInvalidFieldMapping.Error()+": ")})
+	}
+	return errs
+}
+
+// invalidChannelConfig returns the error for a config that failed ValidateChannelConfig, naming its
+// first problem.
+func invalidChannelConfig(errs []ChannelConfigError) error {
+	return fmt.Errorf("%w: %s %s", ErrInvalidChannelConfig, errs[0].Field, errs[0].Message)
+}
+
+// channelSecretFields returns the config keys of channelType marked secret in its schema.
+func channelSecretFields(channelType string) []string {
+	schema := channelTypeSchema(channelType)
+	if schema == nil {
+		return nil
+	}
+	var keys []string
+	for _, f := range schema.Fields {
+		if f.Secret {
+			keys = append(keys, f.Name)
+		}
+	}
+	return keys
+}
+
+func configValueHasType(v interface{}, fieldType string) bool {
+	switch fieldType {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		switch n := v.(type) {
+		case float64:
+			return true
+		case string:
+			_, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			return err == nil
+		}
+		return false
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
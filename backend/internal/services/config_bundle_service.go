@@ -0,0 +1,711 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConfigBundleVersion is the format version written into exported bundles.
+const ConfigBundleVersion = 1
+
+// ConfigBundle is a portable snapshot of templates, channels, rules, bindings and silences.
+// Cross-references use slugs (falling back to names) instead of UUIDs so a bundle can be imported
+// into another environment.
+type ConfigBundle struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Templates  []BundleTemplate `json:"templates"`
+	Channels   []BundleChannel  `json:"channels"`
+	Rules      []BundleRule     `json:"rules"`
+	Bindings   []BundleBinding  `json:"bindings"`
+	Silences   []BundleSilence  `json:"silences"`
+}
+
+type BundleTemplate struct {
+	Name        string            `json:"name"`
+	Slug        string            `json:"slug,omitempty"`
+	Description string            `json:"description"`
+	Content     string            `json:"content"`
+	Variables   map[string]string `json:"variables"`
+	Type        string            `json:"type"`
+}
+
+type BundleChannel struct {
+	Name        string                 `json:"name"`
+	Slug        string                 `json:"slug,omitempty"`
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Config      map[string]interface{} `json:"config"`
+	Group       string                 `json:"group,omitempty"` // business group slug or name
+}
+
+type BundleRule struct {
+	Name                      string                   `json:"name"`
+	Slug                      string                   `json:"slug,omitempty"`
+	Description               string                   `json:"description"`
+	Expression                string                   `json:"expression"`
+	EvaluationIntervalSeconds int                      `json:"evaluation_interval_seconds"`
+	ForDuration               int                      `json:"for_duration"`
+	Severity                  string                   `json:"severity"`
+	Labels                    map[string]string        `json:"labels"`
+	Annotations               map[string]string        `json:"annotations"`
+	Template                  string                   `json:"template,omitempty"` // template slug or name
+	Group                     string                   `json:"group"`              // business group slug or name
+	DataSourceType            string                   `json:"data_source_type"`
+	DataSourceURL             string                   `json:"data_source_url"`
+	EffectiveStartTime        string                   `json:"effective_start_time"`
+	EffectiveEndTime          string                   `json:"effective_end_time"`
+	ExclusionWindows          []models.ExclusionWindow `json:"exclusion_windows"`
+	SeverityLabel             string                   `json:"severity_label,omitempty"`
+	ResolveConfirmations      int                      `json:"resolve_confirmations,omitempty"`
+	ValueFormat               string                   `json:"value_format,omitempty"`
+	NotifyMode                string                   `json:"notify_mode,omitempty"`
+	AggregateTopN             int                      `json:"aggregate_top_n,omitempty"`
+	NotifyOnResolve           *bool                    `json:"notify_on_resolve,omitempty"` // absent means true
+	GroupRecovery             bool                     `json:"group_recovery,omitempty"`
+	EvaluationMode            string                   `json:"evaluation_mode,omitempty"` // absent means instant
+	RangeDuration             int                      `json:"range_duration,omitempty"`
+	RangeStep                 int                      `json:"range_step,omitempty"`
+	RangeCondition            string                   `json:"range_condition,omitempty"`
+	ComparisonOperator        string                   `json:"comparison_operator,omitempty"` // absent fires on value > 0
+	Threshold                 *float64                 `json:"threshold,omitempty"`
+	Status                    int                      `json:"status"`
+}
+
+// BundleBinding binds a rule (by slug, or group + name) to channels (by slug or name).
+// Options maps a channel reference to its binding options; channels not listed get every alert at any time.
+type BundleBinding struct {
+	Rule     string                          `json:"rule"`
+	Group    string                          `json:"group"`
+	Channels []string                        `json:"channels"`
+	Options  map[string]BundleBindingOptions `json:"options,omitempty"`
+}
+
+// BundleBindingOptions are the per-channel routing options of a binding.
+type BundleBindingOptions struct {
+	MinSeverity        string `json:"min_severity,omitempty"`
+	EffectiveStartTime string `json:"effective_start_time,omitempty"`
+	EffectiveEndTime   string `json:"effective_end_time,omitempty"`
+}
+
+type BundleSilence struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Matchers    SilenceMatchers `json:"matchers"`
+	Group       string          `json:"group,omitempty"` // business group slug or name of a group-level silence
+	StartTime   time.Time       `json:"start_time"`
+	EndTime     time.Time       `json:"end_time"`
+}
+
+// BundleSectionResult counts the outcome of importing one section of a bundle.
+type BundleSectionResult struct {
+	Success int `json:"success"`
+	Updated int `json:"updated"` // subset of Success that updated an existing entity matched by slug
+	Failed  int `json:"failed"`
+}
+
+// BundleImportResult is the per-section outcome of ImportBundle.
+type BundleImportResult struct {
+	Templates BundleSectionResult `json:"templates"`
+	Channels  BundleSectionResult `json:"channels"`
+	Rules     BundleSectionResult `json:"rules"`
+	Bindings  BundleSectionResult `json:"bindings"`
+	Silences  BundleSectionResult `json:"silences"`
+	Errors    []string            `json:"errors"`
+}
+
+// ConfigBundleService exports and imports the full alerting configuration as one bundle.
+type ConfigBundleService struct {
+	groupRepo       *repository.BusinessGroupRepository
+	ruleService     *AlertRuleService
+	channelService  *AlertChannelService
+	templateService *AlertTemplateService
+	bindingService  *AlertChannelBindingService
+	silenceService  *AlertSilenceService
+}
+
+// NewConfigBundleService returns a new ConfigBundleService.
+func NewConfigBundleService(
+	groupRepo *repository.BusinessGroupRepository,
+	ruleService *AlertRuleService,
+	channelService *AlertChannelService,
+	templateService *AlertTemplateService,
+	bindingService *AlertChannelBindingService,
+	silenceService *AlertSilenceService,
+) *ConfigBundleService {
+	return &ConfigBundleService{
+		groupRepo:       groupRepo,
+		ruleService:     ruleService,
+		channelService:  channelService,
+		templateService: templateService,
+		bindingService:  bindingService,
+		silenceService:  silenceService,
+	}
+}
+
+// ruleRef is the key used to reference a rule by name inside a bundle; rule names are only unique per group.
+func ruleRef(group, name string) string {
+	return group + "/" + name
+}
+
+// bundleRef returns how an entity is referenced inside a bundle: its slug when set, otherwise its name.
+func bundleRef(slug, name string) string {
+	if slug != "" {
+		return slug
+	}
+	return name
+}
+
+// refIndex resolves bundle references to IDs, preferring slugs over names.
+type refIndex struct {
+	bySlug map[string]uuid.UUID
+	byName map[string]uuid.UUID
+}
+
+func newRefIndex() *refIndex {
+	return &refIndex{bySlug: make(map[string]uuid.UUID), byName: make(map[string]uuid.UUID)}
+}
+
+func (x *refIndex) add(slug, name string, id uuid.UUID) {
+	if slug != "" {
+		x.bySlug[slug] = id
+	}
+	x.byName[name] = id
+}
+
+func (x *refIndex) resolve(ref string) (uuid.UUID, bool) {
+	if id, ok := x.bySlug[ref]; ok {
+		return id, true
+	}
+	id, ok := x.byName[ref]
+	return id, ok
+}
+
+// bundlePageSize is how many rows Export and ImportBundle read per list query.
+const bundlePageSize = 1000
+
+// listAll calls list page by page until it has returned every row.
+func listAll[T any](list func(page, pageSize int) ([]T, int, error)) ([]T, error) {
+	var all []T
+	for page := 1; ; page++ {
+		items, total, err := list(page, bundlePageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < bundlePageSize || len(all) >= total {
+			return all, nil
+		}
+	}
+}
+
+func (s *ConfigBundleService) listGroups(ctx context.Context) ([]models.BusinessGroup, error) {
+	return listAll(func(page, pageSize int) ([]models.BusinessGroup, int, error) {
+		return s.groupRepo.List(ctx, page, pageSize, -1)
+	})
+}
+
+func (s *ConfigBundleService) listTemplates(ctx context.Context) ([]models.AlertTemplate, error) {
+	return listAll(func(page, pageSize int) ([]models.AlertTemplate, int, error) {
+		return s.templateService.List(ctx, page, pageSize, "", 1)
+	})
+}
+
+func (s *ConfigBundleService) listChannels(ctx context.Context) ([]models.AlertChannel, error) {
+	return listAll(func(page, pageSize int) ([]models.AlertChannel, int, error) {
+		return s.channelService.List(ctx, &ListChannelRequest{Page: page, PageSize: pageSize})
+	})
+}
+
+// Export builds a bundle of the current configuration. Unless includeSecrets is set, channel config keys
+// marked secret in the type's schema are left out, as in AlertChannelService.Export.
+func (s *ConfigBundleService) Export(ctx context.Context, includeSecrets bool) (*ConfigBundle, error) {
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[uuid.UUID]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = bundleRef(g.Slug, g.Name)
+	}
+
+	bundle := &ConfigBundle{
+		Version:    ConfigBundleVersion,
+		ExportedAt: time.Now(),
+		Templates:  []BundleTemplate{},
+		Channels:   []BundleChannel{},
+		Rules:      []BundleRule{},
+		Bindings:   []BundleBinding{},
+		Silences:   []BundleSilence{},
+	}
+
+	templates, err := s.listTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	templateNames := make(map[uuid.UUID]string, len(templates))
+	for _, t := range templates {
+		templateNames[t.ID] = bundleRef(t.Slug, t.Name)
+		var variables map[string]string
+		json.Unmarshal([]byte(t.Variables), &variables)
+		bundle.Templates = append(bundle.Templates, BundleTemplate{
+			Name:        t.Name,
+			Slug:        t.Slug,
+			Description: t.Description,
+			Content:     t.Content,
+			Variables:   variables,
+			Type:        t.Type,
+		})
+	}
+
+	channels, err := s.listChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	channelRefs := make(map[uuid.UUID]string, len(channels))
+	for _, ch := range channels {
+		channelRefs[ch.ID] = bundleRef(ch.Slug, ch.Name)
+		bc := BundleChannel{
+			Name:        ch.Name,
+			Slug:        ch.Slug,
+			Type:        ch.Type,
+			Description: ch.Description,
+			Config:      exportedChannelConfig(ch, includeSecrets),
+		}
+		if ch.GroupID != nil {
+			bc.Group = groupNames[*ch.GroupID]
+		}
+		bundle.Channels = append(bundle.Channels, bc)
+	}
+
+	rules, err := listAll(func(page, pageSize int) ([]models.AlertRule, int, error) {
+		return s.ruleService.List(ctx, &ListAlertRuleRequest{Page: page, PageSize: pageSize})
+	})
+	if err != nil {
+		return nil, err
+	}
+	ruleIDs := make([]uuid.UUID, 0, len(rules))
+	for _, r := range rules {
+		ruleIDs = append(ruleIDs, r.ID)
+		var labels, annotations map[string]string
+		json.Unmarshal([]byte(r.Labels), &labels)
+		json.Unmarshal([]byte(r.Annotations), &annotations)
+		var windows []models.ExclusionWindow
+		json.Unmarshal([]byte(r.ExclusionWindows), &windows)
+		br := BundleRule{
+			Name:                      r.Name,
+			Slug:                      r.Slug,
+			Description:               r.Description,
+			Expression:                r.Expression,
+			EvaluationIntervalSeconds: r.EvaluationIntervalSeconds,
+			ForDuration:               r.ForDuration,
+			Severity:                  r.Severity,
+			Labels:                    labels,
+			Annotations:               annotations,
+			Group:                     groupNames[r.GroupID],
+			DataSourceType:            r.DataSourceType,
+			DataSourceURL:             r.DataSourceURL,
+			EffectiveStartTime:        r.EffectiveStartTime,
+			EffectiveEndTime:          r.EffectiveEndTime,
+			ExclusionWindows:          windows,
+			SeverityLabel:             r.SeverityLabel,
+			ResolveConfirmations:      r.ResolveConfirmations,
+			ValueFormat:               r.ValueFormat,
+			NotifyMode:                r.NotifyMode,
+			AggregateTopN:             r.AggregateTopN,
+			GroupRecovery:             r.GroupRecovery,
+			ComparisonOperator:        r.ComparisonOperator,
+			Threshold:                 r.Threshold,
+			Status:                    r.Status,
+		}
+		if !r.NotifyOnResolve {
+			off := false
+			br.NotifyOnResolve = &off
+		}
+		if r.EvaluationMode == models.EvaluationModeRange {
+			br.EvaluationMode = r.EvaluationMode
+			br.RangeDuration = r.RangeDuration
+			br.RangeStep = r.RangeStep
+			br.RangeCondition = r.RangeCondition
+		}
+		if r.TemplateID != nil {
+			br.Template = templateNames[*r.TemplateID]
+		}
+		bundle.Rules = append(bundle.Rules, br)
+	}
+
+	channelsByRule, err := s.bindingService.GetChannelsByRuleIDs(ctx, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		bound := channelsByRule[r.ID]
+		if len(bound) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(bound))
+		var options map[string]BundleBindingOptions
+		for _, ch := range bound {
+			ref := channelRefs[ch.ID]
+			names = append(names, ref)
+			opt := BundleBindingOptions{
+				MinSeverity:        ch.MinSeverity,
+				EffectiveStartTime: ch.EffectiveStartTime,
+				EffectiveEndTime:   ch.EffectiveEndTime,
+			}
+			if opt != (BundleBindingOptions{}) {
+				if options == nil {
+					options = make(map[string]BundleBindingOptions)
+				}
+				options[ref] = opt
+			}
+		}
+		bundle.Bindings = append(bundle.Bindings, BundleBinding{
+			Rule:     bundleRef(r.Slug, r.Name),
+			Group:    groupNames[r.GroupID],
+			Channels: names,
+			Options:  options,
+		})
+	}
+
+	silences, err := listAll(func(page, pageSize int) ([]models.AlertSilence, int, error) {
+		return s.silenceService.List(ctx, page, pageSize, -1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, sl := range silences {
+		matchers, _ := parseSilenceMatchers(sl.Matchers)
+		bs := BundleSilence{
+			Name:        sl.Name,
+			Description: sl.Description,
+			Matchers:    matchers,
+			StartTime:   sl.StartTime,
+			EndTime:     sl.EndTime,
+		}
+		if sl.BusinessGroupID != nil {
+			bs.Group = groupNames[*sl.BusinessGroupID]
+		}
+		bundle.Silences = append(bundle.Silences, bs)
+	}
+
+	return bundle, nil
+}
+
+// ImportBundle applies the entities in the bundle in dependency order (templates, channels, rules,
+// bindings, silences). Templates, channels and rules that carry a slug are upserted: an existing entity
+// with the same slug is updated instead of duplicated. References are resolved against entities applied
+// by this import first, then against existing ones. A failing entity is reported and skipped; the rest
+// of the bundle is still applied.
+func (s *ConfigBundleService) ImportBundle(ctx context.Context, bundle *ConfigBundle, userID uuid.UUID) (*BundleImportResult, error) {
+	result := &BundleImportResult{Errors: []string{}}
+
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs := newRefIndex()
+	for _, g := range groups {
+		groupIDs.add(g.Slug, g.Name, g.ID)
+	}
+
+	templateIDs := newRefIndex()
+	existingTemplates, err := s.listTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range existingTemplates {
+		templateIDs.add(t.Slug, t.Name, t.ID)
+	}
+	for i, bt := range bundle.Templates {
+		id, updated, err := s.upsertTemplate(ctx, &bt)
+		if err != nil {
+			result.Templates.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Template %d (%s): %v", i, bt.Name, err))
+			continue
+		}
+		templateIDs.add(bt.Slug, bt.Name, id)
+		result.Templates.Success++
+		if updated {
+			result.Templates.Updated++
+		}
+	}
+
+	channelIDs := newRefIndex()
+	existingChannels, err := s.listChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range existingChannels {
+		channelIDs.add(ch.Slug, ch.Name, ch.ID)
+	}
+	for i, bc := range bundle.Channels {
+		var groupID *uuid.UUID
+		if bc.Group != "" {
+			gid, ok := groupIDs.resolve(bc.Group)
+			if !ok {
+				result.Channels.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("Channel %d (%s): business group %q not found", i, bc.Name, bc.Group))
+				continue
+			}
+			groupID = &gid
+		}
+		id, updated, err := s.upsertChannel(ctx, &bc, groupID)
+		if err != nil {
+			result.Channels.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Channel %d (%s): %v", i, bc.Name, err))
+			continue
+		}
+		channelIDs.add(bc.Slug, bc.Name, id)
+		result.Channels.Success++
+		if updated {
+			result.Channels.Updated++
+		}
+	}
+
+	ruleIDs := newRefIndex()
+	for i, br := range bundle.Rules {
+		gid, ok := groupIDs.resolve(br.Group)
+		if !ok {
+			result.Rules.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Rule %d (%s): business group %q not found", i, br.Name, br.Group))
+			continue
+		}
+		var templateID *uuid.UUID
+		if br.Template != "" {
+			tid, ok := templateIDs.resolve(br.Template)
+			if !ok {
+				result.Rules.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("Rule %d (%s): template %q not found", i, br.Name, br.Template))
+				continue
+			}
+			templateID = &tid
+		}
+		id, updated, err := s.upsertRule(ctx, &br, gid, templateID)
+		if err != nil {
+			result.Rules.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Rule %d (%s): %v", i, br.Name, err))
+			continue
+		}
+		ruleIDs.add(br.Slug, ruleRef(br.Group, br.Name), id)
+		result.Rules.Success++
+		if updated {
+			result.Rules.Updated++
+		}
+	}
+
+	for i, bb := range bundle.Bindings {
+		rid, ok := ruleIDs.resolve(bb.Rule)
+		if !ok {
+			rid, ok = ruleIDs.resolve(ruleRef(bb.Group, bb.Rule))
+		}
+		if !ok {
+			result.Bindings.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Binding %d: rule %q in group %q was not imported", i, bb.Rule, bb.Group))
+			continue
+		}
+		bindings := make([]ChannelBindingRequest, 0, len(bb.Channels))
+		var missing string
+		for _, ref := range bb.Channels {
+			cid, ok := channelIDs.resolve(ref)
+			if !ok {
+				missing = ref
+				break
+			}
+			opt := bb.Options[ref]
+			bindings = append(bindings, ChannelBindingRequest{
+				ChannelID:          cid,
+				MinSeverity:        opt.MinSeverity,
+				EffectiveStartTime: opt.EffectiveStartTime,
+				EffectiveEndTime:   opt.EffectiveEndTime,
+			})
+		}
+		if missing != "" {
+			result.Bindings.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Binding %d (%s): channel %q not found", i, bb.Rule, missing))
+			continue
+		}
+		if err := s.bindingService.SetBindings(ctx, rid, bindings); err != nil {
+			result.Bindings.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Binding %d (%s): %v", i, bb.Rule, err))
+			continue
+		}
+		result.Bindings.Success++
+	}
+
+	for i, bs := range bundle.Silences {
+		var groupID *uuid.UUID
+		if bs.Group != "" {
+			gid, ok := groupIDs.resolve(bs.Group)
+			if !ok {
+				result.Silences.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("Silence %d (%s): business group %q not found", i, bs.Name, bs.Group))
+				continue
+			}
+			groupID = &gid
+		}
+		_, err := s.silenceService.Create(ctx, &CreateSilenceRequest{
+			Name:            bs.Name,
+			Description:     bs.Description,
+			Matchers:        bs.Matchers,
+			BusinessGroupID: groupID,
+			StartTime:       bs.StartTime,
+			EndTime:         bs.EndTime,
+		}, userID)
+		if err != nil {
+			result.Silences.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Silence %d (%s): %v", i, bs.Name, err))
+			continue
+		}
+		result.Silences.Success++
+	}
+
+	return result, nil
+}
+
+// upsertTemplate updates the template matching bt.Slug, or creates a new one. It reports whether an existing template was updated.
+func (s *ConfigBundleService) upsertTemplate(ctx context.Context, bt *BundleTemplate) (uuid.UUID, bool, error) {
+	if bt.Slug != "" {
+		if existing, err := s.templateService.GetBySlug(ctx, bt.Slug); err == nil {
+			variables := bt.Variables
+			_, err := s.templateService.Update(ctx, existing.ID, &UpdateTemplateRequest{
+				Name:        &bt.Name,
+				Description: &bt.Description,
+				Content:     &bt.Content,
+				Variables:   &variables,
+				Type:        &bt.Type,
+			})
+			return existing.ID, true, err
+		}
+	}
+	t, err := s.templateService.Create(ctx, &CreateTemplateRequest{
+		Name:        bt.Name,
+		Slug:        bt.Slug,
+		Description: bt.Description,
+		Content:     bt.Content,
+		Variables:   bt.Variables,
+		Type:        bt.Type,
+	})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return t.ID, false, nil
+}
+
+// upsertChannel updates the channel matching bc.Slug, or creates a new one. It reports whether an existing channel was updated.
+// Secret keys missing from the bundle, as in an export without secrets, keep the existing channel's values.
+func (s *ConfigBundleService) upsertChannel(ctx context.Context, bc *BundleChannel, groupID *uuid.UUID) (uuid.UUID, bool, error) {
+	if bc.Slug != "" {
+		if existing, err := s.channelService.GetBySlug(ctx, bc.Slug); err == nil {
+			config := make(map[string]interface{}, len(bc.Config))
+			for k, v := range bc.Config {
+				config[k] = v
+			}
+			keepChannelSecrets(config, bc.Type, existing)
+			_, err := s.channelService.Update(ctx, existing.ID, &UpdateChannelRequest{
+				Name:        &bc.Name,
+				Type:        &bc.Type,
+				Description: &bc.Description,
+				Config:      &config,
+				GroupID:     groupID,
+			})
+			return existing.ID, true, err
+		}
+	}
+	ch, err := s.channelService.Create(ctx, &CreateChannelRequest{
+		Name:        bc.Name,
+		Slug:        bc.Slug,
+		Type:        bc.Type,
+		Description: bc.Description,
+		Config:      bc.Config,
+		GroupID:     groupID,
+	})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return ch.ID, false, nil
+}
+
+// upsertRule updates the rule matching br.Slug, or creates a new one. It reports whether an existing rule was updated.
+func (s *ConfigBundleService) upsertRule(ctx context.Context, br *BundleRule, groupID uuid.UUID, templateID *uuid.UUID) (uuid.UUID, bool, error) {
+	notifyOnResolve := br.NotifyOnResolve == nil || *br.NotifyOnResolve
+	if br.Slug != "" {
+		if existing, err := s.ruleService.GetBySlug(ctx, br.Slug); err == nil {
+			labels, annotations, windows := br.Labels, br.Annotations, br.ExclusionWindows
+			_, err := s.ruleService.Update(ctx, existing.ID, &UpdateAlertRuleRequest{
+				Name:                      &br.Name,
+				Description:               &br.Description,
+				Expression:                &br.Expression,
+				EvaluationIntervalSeconds: &br.EvaluationIntervalSeconds,
+				ForDuration:               &br.ForDuration,
+				Severity:                  &br.Severity,
+				Labels:                    &labels,
+				Annotations:               &annotations,
+				TemplateID:                optionalUUID{Value: templateID, Set: true},
+				GroupID:                   &groupID,
+				DataSourceType:            &br.DataSourceType,
+				DataSourceURL:             &br.DataSourceURL,
+				Status:                    &br.Status,
+				EffectiveStartTime:        &br.EffectiveStartTime,
+				EffectiveEndTime:          &br.EffectiveEndTime,
+				ExclusionWindows:          &windows,
+				SeverityLabel:             &br.SeverityLabel,
+				ResolveConfirmations:      &br.ResolveConfirmations,
+				ValueFormat:               &br.ValueFormat,
+				NotifyMode:                &br.NotifyMode,
+				AggregateTopN:             &br.AggregateTopN,
+				NotifyOnResolve:           &notifyOnResolve,
+				GroupRecovery:             &br.GroupRecovery,
+				EvaluationMode:            &br.EvaluationMode,
+				RangeDuration:             &br.RangeDuration,
+				RangeStep:                 &br.RangeStep,
+				RangeCondition:            &br.RangeCondition,
+				ComparisonOperator:        &br.ComparisonOperator,
+				Threshold:                 br.Threshold,
+			})
+			return existing.ID, true, err
+		}
+	}
+	rule, err := s.ruleService.Create(ctx, &CreateAlertRuleRequest{
+		Name:                      br.Name,
+		Slug:                      br.Slug,
+		Description:               br.Description,
+		Expression:                br.Expression,
+		EvaluationIntervalSeconds: br.EvaluationIntervalSeconds,
+		ForDuration:               br.ForDuration,
+		Severity:                  br.Severity,
+		Labels:                    br.Labels,
+		Annotations:               br.Annotations,
+		TemplateID:                templateID,
+		GroupID:                   groupID,
+		DataSourceType:            br.DataSourceType,
+		DataSourceURL:             br.DataSourceURL,
+		EffectiveStartTime:        br.EffectiveStartTime,
+		EffectiveEndTime:          br.EffectiveEndTime,
+		ExclusionWindows:          br.ExclusionWindows,
+		SeverityLabel:             br.SeverityLabel,
+		ResolveConfirmations:      br.ResolveConfirmations,
+		ValueFormat:               br.ValueFormat,
+		NotifyMode:                br.NotifyMode,
+		AggregateTopN:             br.AggregateTopN,
+		NotifyOnResolve:           &notifyOnResolve,
+		GroupRecovery:             br.GroupRecovery,
+		EvaluationMode:            br.EvaluationMode,
+		RangeDuration:             br.RangeDuration,
+		RangeStep:                 br.RangeStep,
+		RangeCondition:            br.RangeCondition,
+		ComparisonOperator:        br.ComparisonOperator,
+		Threshold:                 br.Threshold,
+		Status:                    br.Status,
+	})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return rule.ID, false, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestListAllReadsEveryPage(t *testing.T) {
+	total := 2*bundlePageSize + 7
+	var calls int
+	got, err := listAll(func(page, pageSize int) ([]int, int, error) {
+		calls++
+		start := (page - 1) * pageSize
+		end := min(start+pageSize, total)
+		var items []int
+		for i := start; i < end; i++ {
+			items = append(items, i)
+		}
+		return items, total, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != total {
+		t.Fatalf("got %d rows, want %d", len(got), total)
+	}
+	if calls != 3 {
+		t.Errorf("%d list calls, want 3", calls)
+	}
+}
+
+func TestListAllStopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := listAll(func(page, pageSize int) ([]int, int, error) {
+		if page == 2 {
+			return nil, 0, boom
+		}
+		return make([]int, pageSize), 3 * pageSize, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want the page 2 error rather than a partial result", err)
+	}
+}
+
+func TestExportedChannelConfigStripsSecrets(t *testing.T) {
+	ch := models.AlertChannel{Type: "dingtalk", Config: `{"webhook_url": "https://oapi.dingtalk.com/robot/send?access_token=x", "secret": "SECabc", "max_per_minute": 5}`}
+
+	config := exportedChannelConfig(ch, false)
+	for _, key := range []string{"webhook_url", "secret"} {
+		if _, ok := config[key]; ok {
+			t.Errorf("secret %q exported", key)
+		}
+	}
+	if config["max_per_minute"] != float64(5) {
+		t.Errorf("max_per_minute = %v, want 5", config["max_per_minute"])
+	}
+
+	config = exportedChannelConfig(ch, true)
+	if config["secret"] != "SECabc" {
+		t.Errorf("include_secrets: secret = %v", config["secret"])
+	}
+}
+
+func TestKeepChannelSecrets(t *testing.T) {
+	existing := &models.AlertChannel{Type: "telegram", Config: `{"bot_token": "123:abc", "chat_id": "1"}`}
+
+	config := map[string]interface{}{"chat_id": "2"}
+	keepChannelSecrets(config, "telegram", existing)
+	if config["bot_token"] != "123:abc" || config["chat_id"] != "2" {
+		t.Errorf("got %v, want the existing bot_token and the imported chat_id", config)
+	}
+
+	config = map[string]interface{}{"bot_token": "456:def"}
+	keepChannelSecrets(config, "telegram", existing)
+	if config["bot_token"] != "456:def" {
+		t.Errorf("imported secret overwritten: %v", config["bot_token"])
+	}
+
+	config = map[string]interface{}{}
+	keepChannelSecrets(config, "lark", existing)
+	if len(config) != 0 {
+		t.Errorf("secrets copied across channel types: %v", config)
+	}
+}
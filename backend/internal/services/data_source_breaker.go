@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Circuit breaker states of a data source.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// breakerState is the circuit of one data source endpoint.
+type breakerState struct {
+	state     string
+	failures  int // consecutive unavailability failures while closed
+	openUntil time.Time
+	probing   bool // a half-open probe is in flight
+}
+
+// DataSourceBreaker is a per-data-source circuit breaker for rule evaluation, keyed by endpoint. After
+// failureThreshold consecutive unavailability failures (see dataSourceUnavailable) the circuit opens and
+// rules on that source are skipped for cooldown; then a single evaluation is let through as a half-open
+// probe, which closes the circuit on success or re-opens it on failure. Opening and closing update the
+// data source's health_status.
+type DataSourceBreaker struct {
+	db               *pgxpool.Pool
+	failureThreshold int
+	cooldown         time.Duration
+	mu               sync.Mutex
+	circuits         map[string]*breakerState
+}
+
+// NewDataSourceBreaker returns a breaker that opens after failureThreshold consecutive failures for
+// cooldown. A non-positive threshold defaults to 5 and a non-positive cooldown to 1m. db may be nil,
+// in which case health_status is not updated.
+func NewDataSourceBreaker(db *pgxpool.Pool, failureThreshold int, cooldown time.Duration) *DataSourceBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &DataSourceBreaker{
+		db:               db,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		circuits:         make(map[string]*breakerState),
+	}
+}
+
+// Allow reports whether a rule on endpoint may be evaluated now. When the cooldown of an open circuit
+// has elapsed it moves to half-open and admits exactly one probe until Success or Failure is reported.
+func (b *DataSourceBreaker) Allow(endpoint string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		return true
+	}
+	switch c.state {
+	case breakerOpen:
+		if time.Now().Before(c.openUntil) {
+			return false
+		}
+		c.state = breakerHalfOpen
+		c.probing = true
+		log.Printf("DataSourceBreaker: %s half-open, probing", endpoint)
+		return true
+	case breakerHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	}
+	return true
+}
+
+// Success records a successful evaluation against endpoint, closing its circuit.
+func (b *DataSourceBreaker) Success(ctx context.Context, endpoint string) {
+	b.mu.Lock()
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		b.mu.Unlock()
+		return
+	}
+	recovered := c.state != breakerClosed
+	delete(b.circuits, endpoint)
+	b.mu.Unlock()
+
+	if recovered {
+		log.Printf("DataSourceBreaker: %s recovered, circuit closed", endpoint)
+		b.setHealth(ctx, endpoint, "healthy", nil)
+	}
+}
+
+// Failure records a failed evaluation against endpoint. Errors that do not mean the source is
+// unavailable (e.g. a bad expression) are ignored, except that they end a half-open probe without
+// deciding it.
+func (b *DataSourceBreaker) Failure(ctx context.Context, endpoint string, err error) {
+	b.mu.Lock()
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		c = &breakerState{state: breakerClosed}
+		b.circuits[endpoint] = c
+	}
+	if !dataSourceUnavailable(err) {
+		c.probing = false
+		if c.state == breakerClosed && c.failures == 0 {
+			delete(b.circuits, endpoint)
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	opened := false
+	switch c.state {
+	case breakerHalfOpen:
+		opened = true
+	case breakerClosed:
+		c.failures++
+		opened = c.failures >= b.failureThreshold
+	}
+	if opened {
+		c.state = breakerOpen
+		c.probing = false
+		c.openUntil = time.Now().Add(b.cooldown)
+	}
+	failures := c.failures
+	b.mu.Unlock()
+
+	if opened {
+		log.Printf("DataSourceBreaker: %s circuit open for %s after %d consecutive failures: %v", endpoint, b.cooldown, failures, err)
+		b.setHealth(ctx, endpoint, "unhealthy", err)
+	}
+}
+
+// OpenEndpoints returns the endpoints whose circuit is not closed, sorted.
+func (b *DataSourceBreaker) OpenEndpoints() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var open []string
+	for endpoint, c := range b.circuits {
+		if c.state != breakerClosed {
+			open = append(open, endpoint)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// setHealth updates health_status of the data sources registered with endpoint and records the change
+// in their health history.
+func (b *DataSourceBreaker) setHealth(ctx context.Context, endpoint, status string, err error) {
+	if b.db == nil {
+		return
+	}
+	var errMsg string
+	if err != nil {
+		errMsg = evalErrorMessage(err)
+	}
+	setEndpointHealth(ctx, b.db, endpoint, status, healthCheckBreaker, errMsg)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Health check sources recorded in data_source_health_checks.
+const (
+	healthCheckManual  = "manual"
+	healthCheckBreaker = "breaker"
+)
+
+// recordHealthCheck appends a result to the data source's health history, so flapping stays visible
+// after health_status is overwritten.
+func recordHealthCheck(ctx context.Context, db *pgxpool.Pool, check *models.DataSourceHealthCheck) error {
+	check.ID = uuid.New()
+	if check.CheckedAt.IsZero() {
+		check.CheckedAt = time.Now()
+	}
+	_, err := db.Exec(ctx, `
+		INSERT INTO data_source_health_checks (id, data_source_id, status, source, latency_ms, error, checked_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`, check.ID, check.DataSourceID, check.Status, check.Source, check.LatencyMs, check.Error, check.CheckedAt)
+	return err
+}
+
+// setEndpointHealth updates health_status of the data sources registered with endpoint and records the
+// change in their health history.
+func setEndpointHealth(ctx context.Context, db *pgxpool.Pool, endpoint, status, source, errMsg string) {
+	rows, err := db.Query(ctx, `
+		UPDATE data_sources SET health_status = $1, last_check_at = NOW(), updated_at = NOW() WHERE endpoint = $2
+		RETURNING id
+	`, status, endpoint)
+	if err != nil {
+		log.Printf("data source health: update %s: %v", endpoint, err)
+		return
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	for _, id := range ids {
+		check := &models.DataSourceHealthCheck{DataSourceID: id, Status: status, Source: source, Error: errMsg}
+		if err := recordHealthCheck(ctx, db, check); err != nil {
+			log.Printf("data source health: record %s: %v", endpoint, err)
+		}
+	}
+}
+
+// HealthHistory returns the data source's most recent health checks, newest first.
+func (s *DataSourceService) HealthHistory(ctx context.Context, id uuid.UUID, limit int) ([]models.DataSourceHealthCheck, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT id, data_source_id, status, source, latency_ms, COALESCE(error, ''), checked_at
+		FROM data_source_health_checks
+		WHERE data_source_id = $1
+		ORDER BY checked_at DESC
+		LIMIT $2
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.DataSourceHealthCheck{}
+	for rows.Next() {
+		var hc models.DataSourceHealthCheck
+		if err := rows.Scan(&hc.ID, &hc.DataSourceID, &hc.Status, &hc.Source, &hc.LatencyMs, &hc.Error, &hc.CheckedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, hc)
+	}
+	return list, rows.Err()
+}
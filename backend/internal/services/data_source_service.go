@@ -4,7 +4,6 @@ import (
 	"alert-center/internal/models"
 	"context"
 	"encoding/json"
-	"net/http"
 	"strings"
 	"time"
 
@@ -22,6 +21,9 @@ func NewDataSourceService(db *pgxpool.Pool) *DataSourceService {
 
 func (s *DataSourceService) Create(ctx context.Context, req *CreateDataSourceRequest) (*models.DataSource, error) {
 	config, _ := json.Marshal(req.Config)
+	if err := ValidateDataSourceConfig(string(config)); err != nil {
+		return nil, err
+	}
 
 	ds := &models.DataSource{
 		ID:          uuid.New(),
@@ -94,58 +96,138 @@ func (s *DataSourceService) GetByID(ctx context.Context, id uuid.UUID) (*models.
 	return &ds, nil
 }
 
-func (s *DataSourceService) HealthCheck(ctx context.Context, id uuid.UUID) error {
+// HealthCheck probes the data source's health-check path, stores the result in health_status and
+// appends it to the health history.
+func (s *DataSourceService) HealthCheck(ctx context.Context, id uuid.UUID) (*models.DataSourceHealthCheck, error) {
 	var ds models.DataSource
 	err := s.db.QueryRow(ctx, `
 		SELECT id, name, type, endpoint, config FROM data_sources WHERE id = $1
 	`, id).Scan(&ds.ID, &ds.Name, &ds.Type, &ds.Endpoint, &ds.Config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var healthy bool
-	switch ds.Type {
-	case "prometheus":
-		healthy = checkPrometheusHealth(ctx, ds.Endpoint)
-	case "victoria-metrics":
-		healthy = checkVictoriaMetricsHealth(ctx, ds.Endpoint)
-	default:
-		healthy = true
+	start := time.Now()
+	var checkErr error
+	if schema := dataSourceTypeSchema(ds.Type); schema != nil {
+		checkErr = checkEndpointHealth(ctx, ds, schema.HealthCheckPath)
 	}
-
-	healthStatus := "healthy"
-	if !healthy {
-		healthStatus = "unhealthy"
+	check := &models.DataSourceHealthCheck{
+		DataSourceID: id,
+		Status:       "healthy",
+		Source:       healthCheckManual,
+		LatencyMs:    time.Since(start).Milliseconds(),
+		CheckedAt:    time.Now(),
+	}
+	if checkErr != nil {
+		check.Status = "unhealthy"
+		check.Error = evalErrorMessage(checkErr)
 	}
 
-	now := time.Now()
-	s.db.Exec(ctx, `
+	if _, err := s.db.Exec(ctx, `
 		UPDATE data_sources SET health_status=$1, last_check_at=$2, updated_at=$2 WHERE id=$3
-	`, healthStatus, now, id)
+	`, check.Status, check.CheckedAt, id); err != nil {
+		return nil, err
+	}
+	if err := recordHealthCheck(ctx, s.db, check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
 
-	return nil
+// dataSourceKey identifies a data source by type and endpoint, the way rules reference it. An empty
+// type is prometheus.
+func dataSourceKey(dsType, endpoint string) string {
+	if dsType == "" {
+		dsType = "prometheus"
+	}
+	return dsType + " " + strings.TrimSuffix(endpoint, "/")
 }
 
-func checkPrometheusHealth(ctx context.Context, endpoint string) bool {
-	client := &http.Client{Timeout: 5 * time.Second}
-	url := strings.TrimSuffix(endpoint, "/") + "/-/healthy"
-	resp, err := client.Get(url)
+// evalSources are the enabled data sources, by ID and by dataSourceKey, so rules that only carry a
+// type and URL can be evaluated with the data source's auth config too.
+type evalSources struct {
+	byID  map[uuid.UUID]models.DataSource
+	byKey map[string]models.DataSource
+}
+
+// loadEvalSources returns the enabled data sources.
+func loadEvalSources(ctx context.Context, db *pgxpool.Pool) (*evalSources, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, name, type, endpoint, COALESCE(config::text, '') FROM data_sources WHERE status = 1
+	`)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == 200
+	defer rows.Close()
+	sources := &evalSources{byID: make(map[uuid.UUID]models.DataSource), byKey: make(map[string]models.DataSource)}
+	for rows.Next() {
+		var ds models.DataSource
+		if err := rows.Scan(&ds.ID, &ds.Name, &ds.Type, &ds.Endpoint, &ds.Config); err != nil {
+			return nil, err
+		}
+		sources.byID[ds.ID] = ds
+		sources.byKey[dataSourceKey(ds.Type, ds.Endpoint)] = ds
+	}
+	return sources, rows.Err()
+}
+
+// evalSource is a data source a rule can be evaluated against; registered is false for a rule URL
+// with no data source behind it.
+type evalSource struct {
+	ds         models.DataSource
+	registered bool
 }
 
-func checkVictoriaMetricsHealth(ctx context.Context, endpoint string) bool {
-	client := &http.Client{Timeout: 5 * time.Second}
-	url := strings.TrimSuffix(endpoint, "/") + "/health"
-	resp, err := client.Get(url)
+// forRule returns the data sources to evaluate rule against, in failover order: its data_source_ids
+// that are enabled, then its data_source_url unless one of those already has that endpoint. s may
+// be nil when the data sources could not be loaded.
+func (s *evalSources) forRule(rule models.AlertRule) []evalSource {
+	var out []evalSource
+	seen := make(map[string]bool)
+	for _, id := range rule.DataSourceIDs {
+		if s == nil {
+			break
+		}
+		ds, ok := s.byID[id]
+		if !ok {
+			continue
+		}
+		key := dataSourceKey(ds.Type, ds.Endpoint)
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, evalSource{ds: ds, registered: true})
+		}
+	}
+	if rule.DataSourceURL == "" {
+		return out
+	}
+	key := dataSourceKey(rule.DataSourceType, rule.DataSourceURL)
+	if seen[key] {
+		return out
+	}
+	if s != nil {
+		if ds, ok := s.byKey[key]; ok {
+			return append(out, evalSource{ds: ds, registered: true})
+		}
+	}
+	return append(out, evalSource{ds: models.DataSource{
+		ID:       uuid.New(),
+		Type:     rule.DataSourceType,
+		Endpoint: rule.DataSourceURL,
+	}})
+}
+
+// checkEndpointHealth checks that GET endpoint+path, with the data source's auth config, returns 200
+// within 5s.
+func checkEndpointHealth(ctx context.Context, ds models.DataSource, path string) error {
+	client, err := NewPrometheusClientWithConfig(ds.Endpoint, ds.Config)
 	if err != nil {
-		return false
+		return err
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == 200
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return client.Probe(ctx, path)
 }
 
 func (s *DataSourceService) Update(ctx context.Context, id uuid.UUID, req *UpdateDataSourceRequest) (*models.DataSource, error) {
@@ -170,6 +252,9 @@ func (s *DataSourceService) Update(ctx context.Context, id uuid.UUID, req *Updat
 	}
 	if req.Config != nil {
 		config, _ := json.Marshal(req.Config)
+		if err := ValidateDataSourceConfig(string(config)); err != nil {
+			return nil, err
+		}
 		ds.Config = string(config)
 	}
 	if req.Status != nil {
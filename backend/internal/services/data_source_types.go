@@ -0,0 +1,58 @@
+package services
+
+// DataSourceTypeSchema describes a supported data source type: the fields a data source of this type
+// needs, the auth fields it requires, and the path HealthCheck probes on its endpoint.
+type DataSourceTypeSchema struct {
+	Type            string        `json:"type"`
+	Name            string        `json:"name"`
+	HealthCheckPath string        `json:"health_check_path"`
+	Fields          []ConfigField `json:"fields"`
+	AuthFields      []ConfigField `json:"auth_fields"` // config keys for authenticated endpoints; dotted names are nested objects
+}
+
+// dataSourceAuthFields are the auth/TLS config keys every data source type honors (see
+// NewPrometheusClientWithConfig). A config sets basic_auth or bearer_token, not both.
+var dataSourceAuthFields = []ConfigField{
+	{Name: "basic_auth.username", Type: "string", Description: "Basic Auth 用户名"},
+	{Name: "basic_auth.password", Type: "string", Secret: true, Description: "Basic Auth 密码"},
+	{Name: "bearer_token", Type: "string", Secret: true, Description: "Bearer Token，不能与 Basic Auth 同时配置"},
+	{Name: "insecure_skip_verify", Type: "boolean", Description: "跳过 TLS 证书校验（自签名证书）"},
+}
+
+// dataSourceTypeSchemas lists the data source types the evaluator can query. Add new types (Loki,
+// Elasticsearch, ...) here so they show up in GET /data-sources/types and get a health check.
+var dataSourceTypeSchemas = []DataSourceTypeSchema{
+	{
+		Type:            "prometheus",
+		Name:            "Prometheus",
+		HealthCheckPath: "/-/healthy",
+		Fields: []ConfigField{
+			{Name: "endpoint", Type: "string", Required: true, Description: "Prometheus 地址，如 http://prometheus:9090"},
+		},
+		AuthFields: dataSourceAuthFields,
+	},
+	{
+		Type:            "victoria-metrics",
+		Name:            "VictoriaMetrics",
+		HealthCheckPath: "/health",
+		Fields: []ConfigField{
+			{Name: "endpoint", Type: "string", Required: true, Description: "VictoriaMetrics 地址，如 http://victoriametrics:8428"},
+		},
+		AuthFields: dataSourceAuthFields,
+	},
+}
+
+// SupportedDataSourceTypes returns the supported data source types and their schemas.
+func SupportedDataSourceTypes() []DataSourceTypeSchema {
+	return dataSourceTypeSchemas
+}
+
+// dataSourceTypeSchema returns the schema for dsType, or nil when the type is unknown.
+func dataSourceTypeSchema(dsType string) *DataSourceTypeSchema {
+	for i := range dataSourceTypeSchemas {
+		if dataSourceTypeSchemas[i].Type == dsType {
+			return &dataSourceTypeSchemas[i]
+		}
+	}
+	return nil
+}
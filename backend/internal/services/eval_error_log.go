@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// evalErrorKey groups evaluation errors by data source and error message.
+type evalErrorKey struct {
+	source string
+	msg    string
+}
+
+// evalErrorLog aggregates the rule evaluation errors of one worker cycle, so a data source outage logs
+// one line per source and error instead of one per rule.
+type evalErrorLog struct {
+	rules map[evalErrorKey][]uuid.UUID
+	order []evalErrorKey
+}
+
+// add records that ruleID failed to evaluate against source.
+func (l *evalErrorLog) add(source string, ruleID uuid.UUID, err error) {
+	if l.rules == nil {
+		l.rules = make(map[evalErrorKey][]uuid.UUID)
+	}
+	key := evalErrorKey{source: source, msg: evalErrorMessage(err)}
+	if _, ok := l.rules[key]; !ok {
+		l.order = append(l.order, key)
+	}
+	l.rules[key] = append(l.rules[key], ruleID)
+}
+
+// flush logs each group once, naming the rule when only one failed, and resets the log.
+func (l *evalErrorLog) flush() {
+	for _, key := range l.order {
+		ids := l.rules[key]
+		if len(ids) == 1 {
+			log.Printf("AlertNotificationWorker: rule %s failed to evaluate against %s: %s", ids[0], key.source, key.msg)
+		} else {
+			log.Printf("AlertNotificationWorker: %d rules failed to evaluate against %s: %s", len(ids), key.source, key.msg)
+		}
+	}
+	l.rules, l.order = nil, nil
+}
+
+// evalErrorMessage returns err's message without the request URL of a transport error, which embeds
+// the rule's query and would make every rule's error distinct.
+func evalErrorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FederationSource is an instance allowed to forward alerts here, from federation.sources. Its alerts
+// are recorded under proxy rules in GroupID.
+type FederationSource struct {
+	Name    string
+	APIKey  string
+	GroupID uuid.UUID
+}
+
+var (
+	// ErrInvalidFederatedAlert is wrapped by the errors Ingest returns for malformed alerts.
+	ErrInvalidFederatedAlert = errors.New("invalid federated alert")
+	// ErrFederationConflict is returned when a forwarded alert's rule ID or alert_no already belongs to a
+	// local rule or to an alert of another rule.
+	ErrFederationConflict = errors.New("federated alert conflicts with an existing rule or alert")
+)
+
+// federationRuleType is the data_source_type of the disabled proxy rules forwarded alerts are recorded
+// under. Proxy rules reuse the forwarding instance's rule ID and have no data source, so the worker never
+// evaluates them.
+const federationRuleType = "federation"
+
+// FederationService records alerts forwarded by other alert-center instances' federation channels, so a
+// central instance sees the alerts of all regions without access to their data sources.
+type FederationService struct {
+	db          *pgxpool.Pool
+	sources     []FederationSource
+	slaSvc      *SLAService
+	broadcaster Broadcaster
+}
+
+func NewFederationService(db *pgxpool.Pool, sources []FederationSource) *FederationService {
+	return &FederationService{db: db, sources: sources}
+}
+
+// WithSLA tracks SLAs of forwarded alerts like local ones.
+func (s *FederationService) WithSLA(slaSvc *SLAService) *FederationService {
+	s.slaSvc = slaSvc
+	return s
+}
+
+// WithBroadcaster pushes forwarded alerts to WebSocket clients.
+func (s *FederationService) WithBroadcaster(b Broadcaster) *FederationService {
+	s.broadcaster = b
+	return s
+}
+
+// Authenticate returns the source whose API key is apiKey.
+func (s *FederationService) Authenticate(apiKey string) (*FederationSource, bool) {
+	if apiKey == "" {
+		return nil, false
+	}
+	for i := range s.sources {
+		if subtle.ConstantTimeCompare([]byte(s.sources[i].APIKey), []byte(apiKey)) == 1 {
+			return &s.sources[i], true
+		}
+	}
+	return nil, false
+}
+
+func validateFederatedAlert(a *FederatedAlert) error {
+	switch {
+	case a.AlertNo == "" || len(a.AlertNo) > 32:
+		return fmt.Errorf("%w: alert_no is required and at most 32 characters", ErrInvalidFederatedAlert)
+	case a.RuleID == uuid.Nil:
+		return fmt.Errorf("%w: rule_id is required", ErrInvalidFederatedAlert)
+	case a.Status != "firing" && a.Status != "resolved":
+		return fmt.Errorf("%w: status must be firing or resolved", ErrInvalidFederatedAlert)
+	case strings.TrimSpace(a.Severity) == "":
+		return fmt.Errorf("%w: severity is required", ErrInvalidFederatedAlert)
+	case a.StartedAt.IsZero():
+		return fmt.Errorf("%w: started_at is required", ErrInvalidFederatedAlert)
+	}
+	return nil
+}
+
+// Ingest records a forwarded alert from source: a firing alert is created with its alert_no and labels,
+// a resolved one resolves it (or is created resolved when its firing was never received). Repeated
+// deliveries are idempotent. The alert's rule is recorded as a disabled proxy rule named
+// "[source] rule name" in the source's business group.
+func (s *FederationService) Ingest(ctx context.Context, source *FederationSource, a *FederatedAlert) (*models.AlertHistory, error) {
+	if err := validateFederatedAlert(a); err != nil {
+		return nil, err
+	}
+	a.Severity = strings.ToLower(strings.TrimSpace(a.Severity))
+	if a.Labels == nil {
+		a.Labels = map[string]string{}
+	}
+	labels, _ := json.Marshal(a.Labels)
+	payload, _ := json.Marshal(a)
+	now := time.Now()
+
+	h := &models.AlertHistory{
+		AlertNo:     a.AlertNo,
+		RuleID:      a.RuleID,
+		Fingerprint: models.GenerateFingerprint(a.Labels),
+		Severity:    a.Severity,
+		Status:      a.Status,
+		StartedAt:   a.StartedAt,
+		Labels:      string(labels),
+		Annotations: "{}",
+		Payload:     string(payload),
+	}
+	if a.Status == "resolved" {
+		endedAt := now
+		if a.EndedAt != nil {
+			endedAt = *a.EndedAt
+		}
+		h.EndedAt = &endedAt
+	}
+
+	changed := false
+	err := repository.WithTx(ctx, s.db, func(ctx context.Context) error {
+		q := repository.Conn(ctx, s.db)
+		name := []rune(fmt.Sprintf("[%s] %s", source.Name, a.RuleName))
+		if len(name) > 128 {
+			name = name[:128]
+		}
+		var ruleID uuid.UUID
+		err := q.QueryRow(ctx, `
+			INSERT INTO alert_rules (id, name, description, expression, severity, labels, annotations, group_id,
+				data_source_type, data_source_url, status, created_at, updated_at)
+			VALUES ($1, $2, $3, '', $4, '{}', '{}', $5, $6, '', 0, $7, $7)
+			ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description,
+				severity = EXCLUDED.severity, updated_at = EXCLUDED.updated_at
+			WHERE alert_rules.data_source_type = $6
+			RETURNING id
+		`, a.RuleID, string(name), a.Description, a.Severity, source.GroupID, federationRuleType, now).Scan(&ruleID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrFederationConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		var existingRule uuid.UUID
+		var existingStatus string
+		err = q.QueryRow(ctx, `
+			SELECT id, rule_id, COALESCE(status, ''), created_at FROM alert_history WHERE alert_no = $1 FOR UPDATE
+		`, a.AlertNo).Scan(&h.ID, &existingRule, &existingStatus, &h.CreatedAt)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			h.ID = uuid.New()
+			h.CreatedAt = now
+			changed = true
+			_, err = q.Exec(ctx, `
+				INSERT INTO alert_history (id, alert_no, rule_id, fingerprint, severity, status, started_at, ended_at,
+					labels, annotations, payload, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			`, h.ID, h.AlertNo, h.RuleID, h.Fingerprint, h.Severity, h.Status, h.StartedAt, h.EndedAt,
+				h.Labels, h.Annotations, h.Payload, h.CreatedAt)
+			return err
+		case err != nil:
+			return err
+		case existingRule != a.RuleID:
+			return ErrFederationConflict
+		case a.Status == "resolved" && existingStatus == "firing":
+			changed = true
+			_, err = q.Exec(ctx, `
+				UPDATE alert_history SET status = 'resolved', ended_at = $1 WHERE id = $2
+			`, h.EndedAt, h.ID)
+			return err
+		}
+		// Already recorded in this state, or a late firing for a resolved alert: nothing to do.
+		h.Status = existingStatus
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return h, nil
+	}
+
+	if s.slaSvc != nil {
+		if h.Status == "firing" {
+			if err := s.slaSvc.CreateAlertSLA(ctx, h.ID, h.RuleID, h.Severity, h.StartedAt); err != nil {
+				log.Printf("Federation: create alert_sla for %s: %v", h.AlertNo, err)
+			}
+		} else if err := s.slaSvc.MarkResolved(ctx, h.ID, *h.EndedAt); err != nil {
+			log.Printf("Federation: mark alert_sla resolved for %s: %v", h.AlertNo, err)
+		}
+	}
+	if s.broadcaster != nil {
+		s.broadcaster.SendAlertNotification(&AlertNotification{
+			AlertID:   h.ID.String(),
+			RuleID:    h.RuleID.String(),
+			RuleName:  fmt.Sprintf("[%s] %s", source.Name, a.RuleName),
+			GroupID:   source.GroupID.String(),
+			Severity:  h.Severity,
+			Status:    h.Status,
+			Labels:    a.Labels,
+			Timestamp: now,
+		})
+	}
+	return h, nil
+}
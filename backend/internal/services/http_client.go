@@ -0,0 +1,68 @@
+package services
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// configuredProxy is the proxy set via http.proxy_url; nil means use HTTP_PROXY/HTTPS_PROXY/NO_PROXY.
+var configuredProxy atomic.Pointer[url.URL]
+
+// outboundTransport is shared by all outbound clients (channel senders, data source clients)
+// so they honor the same proxy settings and reuse connections.
+var outboundTransport = func() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = outboundProxy
+	return t
+}()
+
+// insecureOutboundTransport is outboundTransport without TLS certificate verification, for data sources
+// configured with insecure_skip_verify.
+var insecureOutboundTransport = func() *http.Transport {
+	t := outboundTransport.Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return t
+}()
+
+func outboundProxy(req *http.Request) (*url.URL, error) {
+	if u := configuredProxy.Load(); u != nil {
+		return u, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// SetHTTPProxy sets the proxy used for all outbound requests. An empty proxyURL restores
+// the default of reading HTTP_PROXY/HTTPS_PROXY/NO_PROXY from the environment.
+func SetHTTPProxy(proxyURL string) error {
+	if proxyURL == "" {
+		configuredProxy.Store(nil)
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy url %q", proxyURL)
+	}
+	configuredProxy.Store(u)
+	return nil
+}
+
+// channelRequestTimeout bounds one request of a channel sender; channelSendTimeout bounds a whole send
+// to one channel, retries included. Channels are sent to one after another by a single dispatcher, so
+// without them a channel endpoint that never answers would hold up every other notification.
+const (
+	channelRequestTimeout = 10 * time.Second
+	channelSendTimeout    = 45 * time.Second
+)
+
+// channelHTTPClient is used by the notification channel senders.
+var channelHTTPClient = newHTTPClient(channelRequestTimeout)
+
+// newHTTPClient returns a client for outbound requests using the shared proxy-aware transport.
+// A zero timeout means no timeout.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: outboundTransport, Timeout: timeout}
+}
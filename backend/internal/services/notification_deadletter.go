@@ -0,0 +1,257 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when retrying a dead-lettered notification that does not exist.
+	ErrDeadLetterNotFound = errors.New("dead-lettered notification not found")
+	// ErrDeadLetterDelivered is returned when retrying a dead-lettered notification already delivered.
+	ErrDeadLetterDelivered = errors.New("dead-lettered notification already delivered")
+)
+
+const deadLetterBatchSize = 100
+
+// Dead-letter sweep settings, from notifications.deadletter_interval and notifications.deadletter_max_age.
+var (
+	deadLetterInterval atomic.Int64 // nanoseconds
+	deadLetterMaxAge   atomic.Int64 // nanoseconds
+)
+
+func init() {
+	SetDeadLetterSweep(5*time.Minute, 24*time.Hour)
+}
+
+// SetDeadLetterSweep sets how often dead-lettered notifications are retried and how long after being
+// dead-lettered they are given up as expired. Non-positive values keep 5m and 24h.
+func SetDeadLetterSweep(interval, maxAge time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	deadLetterInterval.Store(int64(interval))
+	deadLetterMaxAge.Store(int64(maxAge))
+}
+
+// NotificationDeadLetter keeps notifications the outbox gave up on while every channel was failing (e.g.
+// an outage of all chat providers), so critical pages survive outages longer than the outbox retries.
+// Run retries them every sweep interval until delivered or older than the max age; Retry sends one now.
+// A retry counts as delivered when at least one channel succeeds.
+type NotificationDeadLetter struct {
+	db     *pgxpool.Pool
+	sender *NotificationSender
+}
+
+// NewNotificationDeadLetter returns a new NotificationDeadLetter.
+func NewNotificationDeadLetter(db *pgxpool.Pool, sender *NotificationSender) *NotificationDeadLetter {
+	return &NotificationDeadLetter{db: db, sender: sender}
+}
+
+// Add dead-letters the notification of alertID; cause is the last send error.
+func (d *NotificationDeadLetter) Add(ctx context.Context, alertID uuid.UUID, payload *AlertPayload, cause error) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(ctx, `
+		INSERT INTO notification_deadletter (id, alert_id, rule_id, payload, status, attempts, last_error, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, 'pending', 0, $5, $6, NOW())
+	`, uuid.New(), alertID, payload.RuleID, string(body), cause.Error(), time.Now().Add(time.Duration(deadLetterInterval.Load())))
+	return err
+}
+
+// Run retries due dead-lettered notifications every sweep interval until ctx is cancelled.
+func (d *NotificationDeadLetter) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(deadLetterInterval.Load()))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.sweep(ctx); err != nil {
+				log.Printf("NotificationDeadLetter: sweep: %v", err)
+			}
+		}
+	}
+}
+
+// deadLetterColumns selects a notification_deadletter row for scanDeadLetter.
+const deadLetterColumns = `id, alert_id, rule_id, COALESCE(payload->>'alert_no', ''), COALESCE(payload->>'rule_name', ''),
+	COALESCE(payload->>'severity', ''), COALESCE(payload->>'status', ''), payload::text, status, attempts,
+	COALESCE(last_error, ''), next_attempt_at, delivered_at, created_at`
+
+func scanDeadLetter(row pgx.Row) (models.NotificationDeadLetter, error) {
+	var dl models.NotificationDeadLetter
+	err := row.Scan(&dl.ID, &dl.AlertID, &dl.RuleID, &dl.AlertNo, &dl.RuleName, &dl.Severity, &dl.AlertStatus,
+		&dl.Payload, &dl.Status, &dl.Attempts, &dl.LastError, &dl.NextAttemptAt, &dl.DeliveredAt, &dl.CreatedAt)
+	return dl, err
+}
+
+// sweep expires notifications older than the max age, then claims and retries the due ones.
+func (d *NotificationDeadLetter) sweep(ctx context.Context) error {
+	if NotificationsDryRun() {
+		return nil
+	}
+	maxAge := time.Duration(deadLetterMaxAge.Load())
+	tag, err := d.db.Exec(ctx, `
+		UPDATE notification_deadletter SET status = 'expired' WHERE status = 'pending' AND created_at < $1
+	`, time.Now().Add(-maxAge))
+	if err != nil {
+		return err
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("NotificationDeadLetter: %d notifications expired after %s undelivered", n, maxAge)
+	}
+
+	rows, err := d.db.Query(ctx, `
+		UPDATE notification_deadletter SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM notification_deadletter
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deadLetterColumns,
+		time.Now().Add(time.Duration(deadLetterInterval.Load())), deadLetterBatchSize)
+	if err != nil {
+		return err
+	}
+	var due []models.NotificationDeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, dl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range due {
+		if err := d.deliver(ctx, &due[i]); err != nil {
+			log.Printf("NotificationDeadLetter: retry %s (%s): %v", due[i].ID, due[i].AlertNo, err)
+		}
+	}
+	return nil
+}
+
+// deliver sends a claimed dead-lettered notification to its rule's channels and records the outcome.
+// It returns the send error when no channel succeeded.
+func (d *NotificationDeadLetter) deliver(ctx context.Context, dl *models.NotificationDeadLetter) error {
+	var payload AlertPayload
+	if err := json.Unmarshal([]byte(dl.Payload), &payload); err != nil {
+		return err
+	}
+	channels, err := d.sender.LoadRuleChannels(ctx, []uuid.UUID{dl.RuleID})
+	if err != nil {
+		return err
+	}
+
+	sendErr := d.sender.SendToChannels(ctx, channels[dl.RuleID], &payload, dl.AlertID)
+	if errors.Is(sendErr, ErrAllChannelsFailed) || len(channels[dl.RuleID]) == 0 {
+		if sendErr == nil {
+			sendErr = fmt.Errorf("rule %s has no channels", dl.RuleID)
+		}
+		dl.LastError = sendErr.Error()
+		if _, err := d.db.Exec(ctx, `UPDATE notification_deadletter SET last_error = $1 WHERE id = $2`, dl.LastError, dl.ID); err != nil {
+			log.Printf("NotificationDeadLetter: record failure %s: %v", dl.ID, err)
+		}
+		return sendErr
+	}
+
+	// Delivered to at least one channel; a partial failure is kept in last_error.
+	now := time.Now()
+	dl.Status, dl.DeliveredAt, dl.LastError = "delivered", &now, ""
+	if sendErr != nil {
+		dl.LastError = sendErr.Error()
+	}
+	if _, err := d.db.Exec(ctx, `
+		UPDATE notification_deadletter SET status = 'delivered', delivered_at = $1, last_error = NULLIF($2, '') WHERE id = $3
+	`, now, dl.LastError, dl.ID); err != nil {
+		log.Printf("NotificationDeadLetter: mark delivered %s: %v", dl.ID, err)
+	}
+	log.Printf("NotificationDeadLetter: delivered %s (%s) after %d retries", dl.ID, dl.AlertNo, dl.Attempts)
+	return nil
+}
+
+// Retry sends the dead-lettered notification now, including an expired one, and returns it with the
+// outcome. A failed send is reported in LastError, not as an error.
+func (d *NotificationDeadLetter) Retry(ctx context.Context, id uuid.UUID) (*models.NotificationDeadLetter, error) {
+	dl, err := scanDeadLetter(d.db.QueryRow(ctx, `
+		UPDATE notification_deadletter SET status = 'pending', attempts = attempts + 1, next_attempt_at = $1
+		WHERE id = $2 AND status <> 'delivered'
+		RETURNING `+deadLetterColumns,
+		time.Now().Add(time.Duration(deadLetterInterval.Load())), id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err := d.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM notification_deadletter WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrDeadLetterDelivered
+		}
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if NotificationsDryRun() {
+		return &dl, nil
+	}
+	d.deliver(ctx, &dl)
+	return &dl, nil
+}
+
+// List returns dead-lettered notifications, newest first, optionally filtered by status.
+func (d *NotificationDeadLetter) List(ctx context.Context, status string, page, pageSize int) ([]models.NotificationDeadLetter, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	var total int
+	if err := d.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notification_deadletter WHERE ($1 = '' OR status = $1)
+	`, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := d.db.Query(ctx, `
+		SELECT `+deadLetterColumns+` FROM notification_deadletter
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	list := []models.NotificationDeadLetter{}
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, dl)
+	}
+	return list, total, rows.Err()
+}
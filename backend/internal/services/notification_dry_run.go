@@ -0,0 +1,45 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// notificationsDryRun is set from notifications.dry_run. When on, channel senders (worker deliveries and
+// channel test endpoints) log what they would send instead of calling the channel, so a staging copy
+// can run against prod-like channel config without paging anyone.
+var notificationsDryRun atomic.Bool
+
+// SetNotificationsDryRun turns dry-run mode on or off for all channel senders.
+func SetNotificationsDryRun(on bool) {
+	notificationsDryRun.Store(on)
+}
+
+// NotificationsDryRun reports whether dry-run mode is on.
+func NotificationsDryRun() bool {
+	return notificationsDryRun.Load()
+}
+
+// logDryRun logs the notification a channel would have received and returns a short description
+// ("name (type)") for the delivery log.
+func logDryRun(channel models.AlertChannel, alert *AlertPayload) string {
+	target := fmt.Sprintf("%s (%s)", channel.Name, channel.Type)
+	log.Printf("[dry-run] would send %s %s [%s] %s to channel %s", alert.AlertNo, alert.Status, alert.Severity, alert.RuleName, target)
+	return target
+}
+
+// dryRunChannels logs the notification for every channel whose binding is active for it and returns
+// the comma-separated targets, or "none" when no channel would have received it.
+func dryRunChannels(channels []models.AlertChannel, alert *AlertPayload) string {
+	var targets []string
+	for _, channel := range activeChannels(channels, alert) {
+		targets = append(targets, logDryRun(channel, alert))
+	}
+	if len(targets) == 0 {
+		return "none"
+	}
+	return strings.Join(targets, ", ")
+}
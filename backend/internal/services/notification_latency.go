@@ -0,0 +1,89 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// notificationLatencyBuckets are the upper bounds, in seconds, of the notification latency histogram.
+var notificationLatencyBuckets = []float64{1, 2, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// notificationLatencyWindow is how many recent latencies the p50/p95 are computed from.
+const notificationLatencyWindow = 1024
+
+// NotificationLatency keeps the recent times from an alert firing (its started_at) to a successful
+// delivery to a channel, the "time to notify" of our SLOs, for their p50/p95. It counts deliveries made
+// by this process; every latency also goes to the alert_center_notification_latency_seconds histogram.
+type NotificationLatency struct {
+	mu     sync.Mutex
+	recent []float64 // ring of the last notificationLatencyWindow latencies, in seconds
+	next   int
+}
+
+// NotificationLatencyPercentiles are the p50 and p95 of the recent notification latencies, in milliseconds.
+type NotificationLatencyPercentiles struct {
+	P50   int64 `json:"p50"`
+	P95   int64 `json:"p95"`
+	Count int   `json:"count"` // latencies the percentiles are computed from
+}
+
+var notificationLatency = &NotificationLatency{}
+
+var (
+	notificationLatencySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "alert_center_notification_latency_seconds",
+		Help:    "Time from an alert firing to its successful delivery to a channel.",
+		Buckets: notificationLatencyBuckets,
+	})
+	notificationLatencyP50 = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alert_center_notification_latency_p50_seconds",
+		Help: "p50 of the last 1024 notification latencies.",
+	}, func() float64 { return float64(notificationLatency.Percentiles().P50) / 1000 })
+	notificationLatencyP95 = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alert_center_notification_latency_p95_seconds",
+		Help: "p95 of the last 1024 notification latencies.",
+	}, func() float64 { return float64(notificationLatency.Percentiles().P95) / 1000 })
+)
+
+// NotificationLatencyMetrics returns the process-wide recent notification latencies.
+func NotificationLatencyMetrics() *NotificationLatency {
+	return notificationLatency
+}
+
+// Observe records one delivery latency.
+func (l *NotificationLatency) Observe(d time.Duration) {
+	secs := d.Seconds()
+	if secs < 0 {
+		secs = 0
+	}
+	notificationLatencySeconds.Observe(secs)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.recent) < notificationLatencyWindow {
+		l.recent = append(l.recent, secs)
+	} else {
+		l.recent[l.next] = secs
+	}
+	l.next = (l.next + 1) % notificationLatencyWindow
+}
+
+// Percentiles returns the p50 and p95 of the recent latencies.
+func (l *NotificationLatency) Percentiles() NotificationLatencyPercentiles {
+	l.mu.Lock()
+	sorted := append([]float64(nil), l.recent...)
+	l.mu.Unlock()
+	sort.Float64s(sorted)
+
+	p := NotificationLatencyPercentiles{Count: len(sorted)}
+	if len(sorted) == 0 {
+		return p
+	}
+	at := func(q float64) int64 {
+		return int64(sorted[int(q*float64(len(sorted)-1))] * 1000)
+	}
+	p.P50, p.P95 = at(0.50), at(0.95)
+	return p
+}
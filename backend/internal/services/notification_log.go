@@ -0,0 +1,57 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordDelivery writes the outcome of one channel send to notification_logs. alertID is the
+// alert_history id, nil for sends not tied to a recorded alert. A nil repo disables recording, and a
+// failed insert is only logged so auditing never affects delivery. A successful firing delivery of a
+// recorded alert also records its latency from started_at in the log and the latency histogram. Every
+// send is counted per channel type in the process metrics.
+func recordDelivery(ctx context.Context, logs *repository.NotificationLogRepository, alertID *uuid.UUID,
+	channel models.AlertChannel, alert *AlertPayload, trace *deliveryTrace, sendErr error) {
+	observeDelivery(channel.Type, sendErr)
+	var latencyMs *int64
+	if sendErr == nil && alertID != nil && alert.Status == "firing" && !alert.StartedAt.IsZero() {
+		latency := time.Since(alert.StartedAt)
+		notificationLatency.Observe(latency)
+		ms := latency.Milliseconds()
+		latencyMs = &ms
+	}
+	if logs == nil {
+		return
+	}
+	entry := &models.NotificationLog{
+		AlertID:     alertID,
+		AlertNo:     alert.AlertNo,
+		ChannelID:   channel.ID,
+		ChannelName: channel.Name,
+		ChannelType: channel.Type,
+		AlertStatus: alert.Status,
+		Success:     sendErr == nil,
+		HTTPStatus:  trace.httpStatus,
+		Attempts:    trace.attempts,
+		LatencyMs:   latencyMs,
+	}
+	if alert.RuleID != uuid.Nil {
+		ruleID := alert.RuleID
+		entry.RuleID = &ruleID
+	}
+	if entry.Attempts == 0 {
+		// Channels not sent over HTTP (email) make a single attempt.
+		entry.Attempts = 1
+	}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	}
+	if err := logs.Create(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("record notification log for channel %s: %v", channel.Name, err)
+	}
+}
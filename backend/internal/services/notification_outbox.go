@@ -0,0 +1,244 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	outboxBatchSize    = 100
+	outboxMaxAttempts  = 10
+	// outboxLease is how long a claimed notification stays invisible to other dispatchers; if the
+	// process dies mid-delivery the notification becomes due again after the lease.
+	outboxLease = 5 * time.Minute
+)
+
+// NotificationOutbox implements a transactional outbox for alert notifications. The worker enqueues a
+// pending_notifications row in the same transaction that records the alert, and Run delivers due rows
+// to the rule's channels, retrying with backoff. Channels a notification was delivered to are recorded
+// in delivered_channels, so a retry after a partial failure only sends to the channels that failed.
+// Delivery is at-least-once: a channel can be sent to again if the process dies after sending but
+// before recording it.
+// Notifications it gives up on while every channel is failing are moved to the dead-letter queue.
+type NotificationOutbox struct {
+	db         *pgxpool.Pool
+	sender     *NotificationSender
+	deadLetter *NotificationDeadLetter
+	wake       chan struct{}
+}
+
+// NewNotificationOutbox returns a new NotificationOutbox.
+func NewNotificationOutbox(db *pgxpool.Pool, sender *NotificationSender) *NotificationOutbox {
+	return &NotificationOutbox{db: db, sender: sender, deadLetter: NewNotificationDeadLetter(db, sender), wake: make(chan struct{}, 1)}
+}
+
+// Enqueue records the notification within tx; it is delivered after tx commits.
+func (o *NotificationOutbox) Enqueue(ctx context.Context, tx pgx.Tx, alertID uuid.UUID, payload *AlertPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO pending_notifications (id, alert_id, rule_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, 'pending', 0, NOW(), NOW())
+	`, uuid.New(), alertID, payload.RuleID, string(body))
+	return err
+}
+
+// PendingCount returns the number of notifications not yet delivered.
+func (o *NotificationOutbox) PendingCount(ctx context.Context) (int, error) {
+	var n int
+	err := o.db.QueryRow(ctx, `SELECT COUNT(*) FROM pending_notifications WHERE status = 'pending'`).Scan(&n)
+	return n, err
+}
+
+// Notify wakes the dispatcher so newly committed notifications go out without waiting for the next poll.
+func (o *NotificationOutbox) Notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers pending notifications, and retries dead-lettered ones in the background, until ctx is cancelled.
+// Each round also sends the suppression summaries of rate-limited channels whose rate allows it again.
+func (o *NotificationOutbox) Run(ctx context.Context) {
+	go o.deadLetter.Run(ctx)
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := o.dispatch(ctx)
+			if err != nil {
+				log.Printf("NotificationOutbox: dispatch: %v", err)
+				break
+			}
+			if n < outboxBatchSize {
+				break
+			}
+		}
+		FlushRateLimitSummaries(ctx, o.sender.logs)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+type outboxEntry struct {
+	id        uuid.UUID
+	alertID   uuid.UUID
+	ruleID    uuid.UUID
+	payload   AlertPayload
+	attempts  int
+	delivered []uuid.UUID // channels already sent to by earlier attempts
+}
+
+// dispatch claims a batch of due notifications and delivers them. It returns the number claimed.
+func (o *NotificationOutbox) dispatch(ctx context.Context) (int, error) {
+	rows, err := o.db.Query(ctx, `
+		UPDATE pending_notifications SET attempts = attempts + 1, next_attempt_at = NOW() + $1::interval
+		WHERE id IN (
+			SELECT id FROM pending_notifications
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, alert_id, rule_id, payload::text, attempts, delivered_channels
+	`, fmt.Sprintf("%d seconds", int(outboxLease.Seconds())), outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var entries []outboxEntry
+	for rows.Next() {
+		var e outboxEntry
+		var body string
+		if err := rows.Scan(&e.id, &e.alertID, &e.ruleID, &body, &e.attempts, &e.delivered); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal([]byte(body), &e.payload); err != nil {
+			log.Printf("NotificationOutbox: decode %s: %v", e.id, err)
+			o.markFailed(ctx, e, err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	ruleIDs := make([]uuid.UUID, 0, len(entries))
+	for _, e := range entries {
+		ruleIDs = append(ruleIDs, e.ruleID)
+	}
+	channels, err := o.sender.LoadRuleChannels(ctx, ruleIDs)
+	if err != nil {
+		// Leave the batch claimed; it becomes due again when the lease expires.
+		return len(entries), fmt.Errorf("load rule channels: %w", err)
+	}
+
+	for _, e := range entries {
+		if NotificationsDryRun() {
+			o.markDryRun(ctx, e, dryRunChannels(channels[e.ruleID], &e.payload))
+			continue
+		}
+		delivered, err := o.sender.DeliverToChannels(ctx, undelivered(channels[e.ruleID], e.delivered), &e.payload, e.alertID)
+		e.delivered = append(e.delivered, delivered...)
+		if err != nil {
+			o.retryOrFail(ctx, e, err)
+			continue
+		}
+		if _, err := o.db.Exec(ctx, `
+			UPDATE pending_notifications SET status = 'sent', sent_at = NOW(), last_error = NULL, delivered_channels = COALESCE($1::uuid[], '{}') WHERE id = $2
+		`, e.delivered, e.id); err != nil {
+			log.Printf("NotificationOutbox: mark sent %s: %v", e.id, err)
+		}
+	}
+	return len(entries), nil
+}
+
+// undelivered returns the channels not in delivered.
+func undelivered(channels []models.AlertChannel, delivered []uuid.UUID) []models.AlertChannel {
+	if len(delivered) == 0 {
+		return channels
+	}
+	done := make(map[uuid.UUID]bool, len(delivered))
+	for _, id := range delivered {
+		done[id] = true
+	}
+	var rest []models.AlertChannel
+	for _, ch := range channels {
+		if !done[ch.ID] {
+			rest = append(rest, ch)
+		}
+	}
+	return rest
+}
+
+// retryOrFail records the channels delivered so far and schedules the next attempt with exponential
+// backoff, or gives up after outboxMaxAttempts. A notification given up on without having reached any
+// channel is dead-lettered rather than dropped.
+func (o *NotificationOutbox) retryOrFail(ctx context.Context, e outboxEntry, sendErr error) {
+	if e.attempts >= outboxMaxAttempts {
+		log.Printf("NotificationOutbox: giving up on %s (%s) after %d attempts: %v", e.id, e.payload.AlertNo, e.attempts, sendErr)
+		if errors.Is(sendErr, ErrAllChannelsFailed) && len(e.delivered) == 0 {
+			if err := o.deadLetter.Add(ctx, e.alertID, &e.payload, sendErr); err != nil {
+				log.Printf("NotificationOutbox: dead-letter %s: %v", e.id, err)
+			} else {
+				if _, err := o.db.Exec(ctx, `
+					UPDATE pending_notifications SET status = 'dead_letter', last_error = $1 WHERE id = $2
+				`, sendErr.Error(), e.id); err != nil {
+					log.Printf("NotificationOutbox: mark dead-lettered %s: %v", e.id, err)
+				}
+				return
+			}
+		}
+		o.markFailed(ctx, e, sendErr)
+		return
+	}
+	backoff := outboxPollInterval << uint(e.attempts)
+	if backoff > 10*time.Minute {
+		backoff = 10 * time.Minute
+	}
+	if _, err := o.db.Exec(ctx, `
+		UPDATE pending_notifications SET next_attempt_at = $1, last_error = $2, delivered_channels = COALESCE($3::uuid[], '{}') WHERE id = $4
+	`, time.Now().Add(backoff), sendErr.Error(), e.delivered, e.id); err != nil {
+		log.Printf("NotificationOutbox: schedule retry %s: %v", e.id, err)
+	}
+}
+
+// markDryRun records in the delivery log that the notification was not sent because of dry-run mode,
+// with the channels it would have gone to.
+func (o *NotificationOutbox) markDryRun(ctx context.Context, e outboxEntry, targets string) {
+	if _, err := o.db.Exec(ctx, `
+		UPDATE pending_notifications SET status = 'dry_run', sent_at = NOW(), last_error = NULL, dry_run_channels = $1 WHERE id = $2
+	`, targets, e.id); err != nil {
+		log.Printf("NotificationOutbox: mark dry run %s: %v", e.id, err)
+	}
+}
+
+func (o *NotificationOutbox) markFailed(ctx context.Context, e outboxEntry, cause error) {
+	if _, err := o.db.Exec(ctx, `
+		UPDATE pending_notifications SET status = 'failed', last_error = $1, delivered_channels = COALESCE($2::uuid[], '{}') WHERE id = $3
+	`, cause.Error(), e.delivered, e.id); err != nil {
+		log.Printf("NotificationOutbox: mark failed %s: %v", e.id, err)
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookChannel returns a webhook channel posting to url.
+func webhookChannel(name, url string) models.AlertChannel {
+	return models.AlertChannel{ID: uuid.New(), Name: name, Type: "webhook", Config: fmt.Sprintf(`{"url": %q}`, url)}
+}
+
+func TestOutboxRetrySkipsDeliveredChannels(t *testing.T) {
+	SetChannelRetry(0, time.Millisecond)
+	defer SetChannelRetry(3, time.Second)
+
+	var okHits, flakyHits atomic.Int32
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { okHits.Add(1) }))
+	defer ok.Close()
+	var flakyDown atomic.Bool
+	flakyDown.Store(true)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flakyHits.Add(1)
+		if flakyDown.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer flaky.Close()
+
+	channels := []models.AlertChannel{webhookChannel("ok", ok.URL), webhookChannel("flaky", flaky.URL)}
+	alert := &AlertPayload{RuleName: "disk full", Severity: "critical", Status: "firing", Labels: "{}"}
+	var delivered []uuid.UUID
+
+	// First attempt: one channel fails, the outbox keeps the delivered one.
+	got, err := deliverToChannels(context.Background(), undelivered(channels, delivered), alert, nil, nil)
+	if err == nil || errors.Is(err, ErrAllChannelsFailed) {
+		t.Fatalf("first attempt: want a partial failure, got %v", err)
+	}
+	delivered = append(delivered, got...)
+	if len(delivered) != 1 || delivered[0] != channels[0].ID {
+		t.Fatalf("first attempt: delivered %v, want only %s", delivered, channels[0].ID)
+	}
+
+	// Retry: only the failed channel is sent to, and it fails again on its own.
+	got, err = deliverToChannels(context.Background(), undelivered(channels, delivered), alert, nil, nil)
+	if !errors.Is(err, ErrAllChannelsFailed) {
+		t.Fatalf("retry: want ErrAllChannelsFailed for the remaining channel, got %v", err)
+	}
+	delivered = append(delivered, got...)
+
+	// The channel recovers; the last retry completes the notification.
+	flakyDown.Store(false)
+	got, err = deliverToChannels(context.Background(), undelivered(channels, delivered), alert, nil, nil)
+	if err != nil {
+		t.Fatalf("last retry: %v", err)
+	}
+	delivered = append(delivered, got...)
+
+	if n := okHits.Load(); n != 1 {
+		t.Errorf("healthy channel paged %d times, want 1", n)
+	}
+	if n := flakyHits.Load(); n != 3 {
+		t.Errorf("flaky channel called %d times, want 3", n)
+	}
+	if rest := undelivered(channels, delivered); len(rest) != 0 {
+		t.Errorf("channels left after delivery: %v", rest)
+	}
+}
@@ -1,15 +1,22 @@
 package services
 
 import (
+	"alert-center/internal/models"
+	"alert-center/internal/repository"
 	"context"
+	"log"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // NotificationSender sends alert notifications to bound channels.
+// A rule with no bound channels falls back to its business group's default channel,
+// then to the global default channel (see WithDefaultChannel).
 type NotificationSender struct {
-	db *pgxpool.Pool
+	db               *pgxpool.Pool
+	defaultChannelID uuid.UUID
+	logs             *repository.NotificationLogRepository
 }
 
 // NewNotificationSender returns a new NotificationSender.
@@ -17,8 +24,91 @@ func NewNotificationSender(db *pgxpool.Pool) *NotificationSender {
 	return &NotificationSender{db: db}
 }
 
+// WithDefaultChannel sets the global fallback channel (config channels.default_channel_id) used for rules
+// that have no bound channels and whose group has no default channel. An empty id disables it.
+func (s *NotificationSender) WithDefaultChannel(id string) *NotificationSender {
+	if id == "" {
+		return s
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("NotificationSender: ignoring invalid default channel id %q: %v", id, err)
+		return s
+	}
+	s.defaultChannelID = parsed
+	return s
+}
+
+// WithNotificationLog records every channel send in the notification log.
+func (s *NotificationSender) WithNotificationLog(logs *repository.NotificationLogRepository) *NotificationSender {
+	s.logs = logs
+	return s
+}
+
 // SendToRuleChannels sends the alert payload to all channels bound to the rule.
 func (s *NotificationSender) SendToRuleChannels(ctx context.Context, ruleID uuid.UUID, payload *AlertPayload) error {
+	channels, err := s.LoadRuleChannels(ctx, []uuid.UUID{ruleID})
+	if err != nil {
+		return err
+	}
+	return sendToChannels(ctx, channels[ruleID], payload, s.logs, nil)
+}
+
+// LoadRuleChannels returns the channels bound to each of the given rules in a single query,
+// so a caller sending many alerts can avoid one binding lookup per alert.
+// Rules without bindings get their fallback channel instead; rules with nowhere to send are logged.
+func (s *NotificationSender) LoadRuleChannels(ctx context.Context, ruleIDs []uuid.UUID) (map[uuid.UUID][]models.AlertChannel, error) {
 	binding := &AlertChannelBindingService{db: s.db}
-	return binding.SendToBoundChannels(ctx, ruleID, payload)
+	channels, err := binding.GetBoundChannelsByRuleIDs(ctx, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var unbound []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ruleIDs {
+		if len(channels[id]) == 0 && !seen[id] {
+			seen[id] = true
+			unbound = append(unbound, id)
+		}
+	}
+	if len(unbound) == 0 {
+		return channels, nil
+	}
+
+	groupDefaults, err := binding.GetGroupDefaultChannelsByRuleIDs(ctx, unbound)
+	if err != nil {
+		return nil, err
+	}
+	var global *models.AlertChannel
+	if s.defaultChannelID != uuid.Nil {
+		if global, err = binding.getEnabledChannel(ctx, s.defaultChannelID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range unbound {
+		if ch, ok := groupDefaults[id]; ok {
+			log.Printf("NotificationSender: rule %s has no bound channels, using group default channel %s", id, ch.Name)
+			channels[id] = []models.AlertChannel{ch}
+		} else if global != nil {
+			log.Printf("NotificationSender: rule %s has no bound channels, using default channel %s", id, global.Name)
+			channels[id] = []models.AlertChannel{*global}
+		} else {
+			log.Printf("NotificationSender: rule %s has no bound channels and no default channel; notification dropped", id)
+		}
+	}
+	return channels, nil
+}
+
+// SendToChannels sends the alert payload to channels previously returned by LoadRuleChannels, logging
+// each send against alertID. It returns the joined errors of channels that failed.
+func (s *NotificationSender) SendToChannels(ctx context.Context, channels []models.AlertChannel, payload *AlertPayload, alertID uuid.UUID) error {
+	return sendToChannels(ctx, channels, payload, s.logs, &alertID)
+}
+
+// DeliverToChannels is SendToChannels that also returns the IDs of the channels done with the alert
+// (see deliverToChannels), for callers that retry only the channels that failed.
+func (s *NotificationSender) DeliverToChannels(ctx context.Context, channels []models.AlertChannel, payload *AlertPayload, alertID uuid.UUID) ([]uuid.UUID, error) {
+	return deliverToChannels(ctx, channels, payload, s.logs, &alertID)
 }
@@ -1,6 +1,10 @@
 package services
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Broadcaster delivers real-time notifications (e.g. WebSocket).
 // Implemented by handlers.WebSocketHandler to avoid package cycles.
@@ -8,12 +12,14 @@ type Broadcaster interface {
 	SendAlertNotification(notification *AlertNotification)
 	SendSLABreachNotification(notification *SLABreachNotification)
 	SendTicketNotification(notification *TicketNotification)
+	SendAlertAckNotification(notification *AlertAckNotification)
 }
 
 type AlertNotification struct {
 	AlertID   string            `json:"alert_id"`
 	RuleID    string            `json:"rule_id"`
 	RuleName  string            `json:"rule_name"`
+	GroupID   string            `json:"group_id,omitempty"` // business group of the rule, when known
 	Severity  string            `json:"severity"`
 	Status    string            `json:"status"`
 	Labels    map[string]string `json:"labels"`
@@ -25,6 +31,7 @@ type SLABreachNotification struct {
 	AlertID   string    `json:"alert_id"`
 	Severity  string    `json:"severity"`
 	BreachType string   `json:"breach_type"`
+	GroupID   string    `json:"group_id,omitempty"` // business group of the alert's rule, when known
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -33,5 +40,26 @@ type TicketNotification struct {
 	Title     string    `json:"title"`
 	Status    string    `json:"status"`
 	Action    string    `json:"action"`
+	GroupID   string    `json:"group_id,omitempty"` // business group of the ticket's rule, when known
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// AlertAckNotification tells dashboards an alert was acknowledged.
+type AlertAckNotification struct {
+	AlertID     string    `json:"alert_id"`
+	AlertNo     string    `json:"alert_no"`
+	RuleID      string    `json:"rule_id"`
+	GroupID     string    `json:"group_id,omitempty"` // business group of the rule, when known
+	Severity    string    `json:"severity"`
+	AckedBy     string    `json:"acked_by"`
+	AckedByName string    `json:"acked_by_name"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// groupIDString returns the ID of a business group for a notification's GroupID, "" when unknown.
+func groupIDString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
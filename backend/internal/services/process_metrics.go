@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// workerCycleBuckets are the upper bounds, in seconds, of the evaluation cycle duration histogram.
+var workerCycleBuckets = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300}
+
+// The work of the alert worker and the channel deliveries of this process, so teams can alert on the
+// alerting system itself going silent. main registers them with MetricsCollectors.
+var (
+	workerCycles = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alert_center_worker_cycles_total",
+		Help: "Evaluation cycles the alert worker completed.",
+	})
+	workerCycleErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alert_center_worker_cycle_errors_total",
+		Help: "Evaluation cycles with at least one error.",
+	})
+	workerRulesEvaluated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alert_center_worker_rules_evaluated_total",
+		Help: "Rule evaluations made by the alert worker.",
+	})
+	workerRulesSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alert_center_worker_rules_skipped_total",
+		Help: "Rule evaluations skipped because the data source circuit was open.",
+	})
+	workerCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "alert_center_worker_cycle_duration_seconds",
+		Help:    "Duration of the alert worker's evaluation cycles.",
+		Buckets: workerCycleBuckets,
+	})
+	notificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alert_center_notifications_total",
+		Help: "Channel sends by channel type and result (sent, failed or rate_limited).",
+	}, []string{"channel_type", "result"})
+)
+
+// MetricsCollectors returns the collectors of the worker, channel delivery and notification latency
+// metrics of this package.
+func MetricsCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		workerCycles, workerCycleErrors, workerRulesEvaluated, workerRulesSkipped, workerCycleDuration,
+		notificationsTotal, notificationLatencySeconds, notificationLatencyP50, notificationLatencyP95,
+	}
+}
+
+// observeCycle records one completed evaluation cycle.
+func observeCycle(d time.Duration, stats workerRunStats) {
+	workerCycles.Inc()
+	if stats.errors > 0 {
+		workerCycleErrors.Inc()
+	}
+	workerRulesEvaluated.Add(float64(stats.rulesEvaluated))
+	workerRulesSkipped.Add(float64(stats.rulesSkipped))
+	workerCycleDuration.Observe(d.Seconds())
+}
+
+// observeDelivery records one channel send of the given type.
+func observeDelivery(channelType string, err error) {
+	result := "sent"
+	if errors.Is(err, ErrChannelRateLimited) {
+		result = "rate_limited"
+	} else if err != nil {
+		result = "failed"
+	}
+	notificationsTotal.WithLabelValues(channelType, result).Inc()
+}
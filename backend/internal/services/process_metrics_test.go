@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsCollectorsGather(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(MetricsCollectors()...)
+	status := NewWorkerStatus()
+	status.started(time.Minute)
+	reg.MustRegister(status)
+
+	observeDelivery("lark", nil)
+	observeDelivery("lark", errors.New("502"))
+	observeDelivery("lark", ErrChannelRateLimited)
+	observeCycle(1500*time.Millisecond, workerRunStats{rulesEvaluated: 3, rulesSkipped: 1})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	sends := map[string]float64{}
+	for _, f := range families {
+		got[f.GetName()] = true
+		if f.GetName() != "alert_center_notifications_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var channelType, result string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "channel_type":
+					channelType = l.GetValue()
+				case "result":
+					result = l.GetValue()
+				}
+			}
+			if channelType == "lark" {
+				sends[result] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	for _, name := range []string{
+		"alert_center_worker_cycles_total", "alert_center_worker_rules_evaluated_total",
+		"alert_center_worker_cycle_duration_seconds", "alert_center_notifications_total",
+		"alert_center_notification_latency_p95_seconds", "alert_center_worker_last_run_age_seconds",
+	} {
+		if !got[name] {
+			t.Errorf("%s not gathered", name)
+		}
+	}
+	for _, result := range []string{"sent", "failed", "rate_limited"} {
+		if sends[result] < 1 {
+			t.Errorf("lark %s sends = %v, want at least 1", result, sends[result])
+		}
+	}
+}
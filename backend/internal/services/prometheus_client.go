@@ -3,12 +3,17 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"alert-center/internal/models"
@@ -29,8 +34,11 @@ type PrometheusQueryResult struct {
 }
 
 type PrometheusClient struct {
-	client  *http.Client
-	baseURL string
+	client   *http.Client
+	baseURL  string
+	username string // basic auth, when set
+	password string
+	bearer   string
 }
 
 func NewPrometheusClient(endpoint string) *PrometheusClient {
@@ -38,13 +46,76 @@ func NewPrometheusClient(endpoint string) *PrometheusClient {
 		endpoint = "http://" + endpoint
 	}
 	return &PrometheusClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  newHTTPClient(30 * time.Second),
 		baseURL: strings.TrimSuffix(endpoint, "/"),
 	}
 }
 
+// dataSourceAuthConfig is the auth/TLS part of a data source config:
+// {"basic_auth": {"username": "...", "password": "..."}, "bearer_token": "...", "insecure_skip_verify": true}.
+type dataSourceAuthConfig struct {
+	BasicAuth *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"basic_auth"`
+	BearerToken        string `json:"bearer_token"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// ErrInvalidDataSourceConfig is wrapped by the errors returned for a data source config that is not a
+// JSON object or that sets both basic_auth and bearer_token.
+var ErrInvalidDataSourceConfig = errors.New("invalid data source config")
+
+// parseDataSourceAuthConfig decodes the auth/TLS part of a data source config. Only one of basic_auth
+// and bearer_token may be set, as a request carries a single Authorization header.
+func parseDataSourceAuthConfig(config string) (dataSourceAuthConfig, error) {
+	var cfg dataSourceAuthConfig
+	if config == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		return cfg, fmt.Errorf("%w: %v", ErrInvalidDataSourceConfig, err)
+	}
+	if cfg.BasicAuth != nil && cfg.BasicAuth.Username != "" && cfg.BearerToken != "" {
+		return cfg, fmt.Errorf("%w: set either basic_auth or bearer_token, not both", ErrInvalidDataSourceConfig)
+	}
+	return cfg, nil
+}
+
+// ValidateDataSourceConfig checks a data source config before it is saved.
+func ValidateDataSourceConfig(config string) error {
+	_, err := parseDataSourceAuthConfig(config)
+	return err
+}
+
+// NewPrometheusClientWithConfig returns a client for endpoint that applies the basic_auth, bearer_token
+// and insecure_skip_verify settings of a data source's config JSON. An empty config behaves like
+// NewPrometheusClient.
+func NewPrometheusClientWithConfig(endpoint, config string) (*PrometheusClient, error) {
+	c := NewPrometheusClient(endpoint)
+	cfg, err := parseDataSourceAuthConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BasicAuth != nil && cfg.BasicAuth.Username != "" {
+		c.username, c.password = cfg.BasicAuth.Username, cfg.BasicAuth.Password
+	}
+	c.bearer = cfg.BearerToken
+	if cfg.InsecureSkipVerify {
+		c.client = &http.Client{Transport: insecureOutboundTransport, Timeout: c.client.Timeout}
+	}
+	return c, nil
+}
+
+// authorize sets the configured credentials on req.
+func (c *PrometheusClient) authorize(req *http.Request) {
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	} else if c.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearer)
+	}
+}
+
 func (c *PrometheusClient) Query(ctx context.Context, query string, time string) ([]models.QueryResult, error) {
 	params := url.Values{}
 	params.Set("query", query)
@@ -75,16 +146,83 @@ func (c *PrometheusClient) QueryRange(ctx context.Context, query string, start,
 	return c.parseResults(resp)
 }
 
+// Data source query retry settings, from data_sources.max_attempts and data_sources.retry_base_ms.
+// Kept separate from the channel send retries (SetChannelRetry).
+var (
+	dataSourceMaxAttempts atomic.Int64
+	dataSourceRetryBase   atomic.Int64 // nanoseconds
+)
+
+func init() {
+	SetDataSourceRetry(3, 500*time.Millisecond)
+}
+
+// SetDataSourceRetry sets the attempts per data source query (1 disables retries) and the base backoff,
+// which doubles per retry with up to 50% jitter. Non-positive values keep 1 attempt / 500ms.
+func SetDataSourceRetry(maxAttempts int, base time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	dataSourceMaxAttempts.Store(int64(maxAttempts))
+	dataSourceRetryBase.Store(int64(base))
+}
+
+// errRetryable marks a query failure worth retrying: a 5xx response or a timeout.
+type errRetryable struct{ err error }
+
+func (e errRetryable) Error() string { return e.err.Error() }
+func (e errRetryable) Unwrap() error { return e.err }
+
+// dataSourceUnavailable reports whether a query error means the data source itself is failing (5xx,
+// timeout, connection error) rather than the query being bad (4xx, unparsable result).
+func dataSourceUnavailable(err error) bool {
+	var retryable errRetryable
+	var urlErr *url.Error
+	return errors.As(err, &retryable) || errors.As(err, &urlErr)
+}
+
+// doRequest queries path, retrying 5xx responses and timeouts with jittered exponential backoff so
+// an overloaded backend is not hammered; 4xx and other errors fail fast.
 func (c *PrometheusClient) doRequest(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	maxAttempts := int(dataSourceMaxAttempts.Load())
+	delay := time.Duration(dataSourceRetryBase.Load())
+	for attempt := 1; ; attempt++ {
+		body, err := c.doRequestOnce(ctx, path, params)
+		var retryable errRetryable
+		if err == nil || !errors.As(err, &retryable) || attempt >= maxAttempts || ctx.Err() != nil {
+			return body, err
+		}
+
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+		log.Printf("data source %s%s: attempt %d/%d failed (%v), retrying in %s", c.baseURL, path, attempt, maxAttempts, err, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func (c *PrometheusClient) doRequestOnce(ctx context.Context, path string, params url.Values) ([]byte, error) {
 	url := fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	c.authorize(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query prometheus: %w", err)
+		err = fmt.Errorf("failed to query prometheus: %w", err)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+			return nil, errRetryable{err}
+		}
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -94,7 +232,11 @@ func (c *PrometheusClient) doRequest(ctx context.Context, path string, params ur
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned status %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("prometheus returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode >= 500 {
+			return nil, errRetryable{err}
+		}
+		return nil, err
 	}
 
 	return body, nil
@@ -161,6 +303,24 @@ func parseFloat64(v interface{}) float64 {
 	return 0
 }
 
+// Probe checks that GET path on the endpoint, with the configured credentials, returns 200.
+func (c *PrometheusClient) Probe(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	c.authorize(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (c *PrometheusClient) HealthCheck(ctx context.Context) error {
 	params := url.Values{}
 	params.Set("query", "up")
@@ -179,6 +339,15 @@ func NewVictoriaMetricsClient(endpoint string) *VictoriaMetricsClient {
 	}
 }
 
+// NewVictoriaMetricsClientWithConfig is NewPrometheusClientWithConfig for VictoriaMetrics.
+func NewVictoriaMetricsClientWithConfig(endpoint, config string) (*VictoriaMetricsClient, error) {
+	prom, err := NewPrometheusClientWithConfig(endpoint, config)
+	if err != nil {
+		return nil, err
+	}
+	return &VictoriaMetricsClient{prom: prom}, nil
+}
+
 func (c *VictoriaMetricsClient) Query(ctx context.Context, query string, time string) ([]models.QueryResult, error) {
 	return c.prom.Query(ctx, query, time)
 }
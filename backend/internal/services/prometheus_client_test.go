@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrometheusClientSendsConfiguredAuth(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name, config, wantAuth string
+		wantUser, wantPass     string
+	}{
+		{name: "basic auth", config: `{"basic_auth": {"username": "grafana", "password": "s3cret"}}`, wantUser: "grafana", wantPass: "s3cret"},
+		{name: "bearer token", config: `{"bearer_token": "tok-123"}`, wantAuth: "Bearer tok-123"},
+		{name: "no auth", config: ``},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			c, err := NewPrometheusClientWithConfig(srv.URL, tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Query(context.Background(), "up", ""); err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				t.Fatal("no request reached the server")
+			}
+			user, pass, ok := got.BasicAuth()
+			if tc.wantUser != "" {
+				if !ok || user != tc.wantUser || pass != tc.wantPass {
+					t.Errorf("basic auth = %q/%q (%v), want %q/%q", user, pass, ok, tc.wantUser, tc.wantPass)
+				}
+				return
+			}
+			if auth := got.Header.Get("Authorization"); auth != tc.wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, tc.wantAuth)
+			}
+		})
+	}
+}
+
+func TestPrometheusClientRejectsBasicAuthWithBearerToken(t *testing.T) {
+	config := `{"basic_auth": {"username": "grafana", "password": "s3cret"}, "bearer_token": "tok-123"}`
+	if _, err := NewPrometheusClientWithConfig("http://prometheus:9090", config); !errors.Is(err, ErrInvalidDataSourceConfig) {
+		t.Errorf("NewPrometheusClientWithConfig: want ErrInvalidDataSourceConfig, got %v", err)
+	}
+	if err := ValidateDataSourceConfig(config); !errors.Is(err, ErrInvalidDataSourceConfig) {
+		t.Errorf("ValidateDataSourceConfig: want ErrInvalidDataSourceConfig, got %v", err)
+	}
+	if err := ValidateDataSourceConfig(`{"bearer_token": "tok-123", "insecure_skip_verify": true}`); err != nil {
+		t.Errorf("bearer token alone: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidPrometheusRules is wrapped by the errors ParsePrometheusRules returns for documents that
+// are not a Prometheus rule file.
+var ErrInvalidPrometheusRules = errors.New("invalid prometheus rule file")
+
+// PrometheusRuleFile is a Prometheus rule file: a list of rule groups under `groups:`.
+type PrometheusRuleFile struct {
+	Groups []PrometheusRuleGroup `yaml:"groups"`
+}
+
+type PrometheusRuleGroup struct {
+	Name     string           `yaml:"name"`
+	Interval string           `yaml:"interval,omitempty"`
+	Rules    []PrometheusRule `yaml:"rules"`
+}
+
+// PrometheusRule is an alerting rule of a Prometheus rule group. Recording rules (`record:`) are
+// decoded only to be reported as unsupported.
+type PrometheusRule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// PrometheusRuleImport is one rule of a parsed rule file: either the create request it maps to, or
+// the reason it cannot be imported.
+type PrometheusRuleImport struct {
+	Group string
+	Name  string
+	Rule  *CreateAlertRuleRequest
+	Err   error
+}
+
+// ParsePrometheusRules maps the alerting rules of a Prometheus rule file onto create requests in the
+// business group groupID. `alert` becomes the name, `expr` the expression, `for` the for duration and
+// the group's `interval` the evaluation interval. The `severity` label sets the rule severity, or
+// defaultSeverity when it is missing, and is kept in the labels like Prometheus does. The returned
+// slice has one entry per rule, in file order.
+func ParsePrometheusRules(data []byte, groupID uuid.UUID, defaultSeverity string) ([]PrometheusRuleImport, error) {
+	var file PrometheusRuleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrometheusRules, err)
+	}
+	if len(file.Groups) == 0 {
+		return nil, fmt.Errorf("%w: no groups", ErrInvalidPrometheusRules)
+	}
+
+	var out []PrometheusRuleImport
+	for _, g := range file.Groups {
+		interval := 0
+		if g.Interval != "" {
+			secs, err := parsePrometheusDuration(g.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("%w: group %q: interval: %v", ErrInvalidPrometheusRules, g.Name, err)
+			}
+			interval = secs
+		}
+		for _, r := range g.Rules {
+			imp := PrometheusRuleImport{Group: g.Name, Name: r.Alert}
+			if r.Record != "" {
+				imp.Name = r.Record
+				imp.Err = fmt.Errorf("recording rules are not supported")
+				out = append(out, imp)
+				continue
+			}
+			imp.Rule, imp.Err = prometheusRuleRequest(r, groupID, interval, defaultSeverity)
+			out = append(out, imp)
+		}
+	}
+	return out, nil
+}
+
+func prometheusRuleRequest(r PrometheusRule, groupID uuid.UUID, interval int, defaultSeverity string) (*CreateAlertRuleRequest, error) {
+	if strings.TrimSpace(r.Alert) == "" {
+		return nil, fmt.Errorf("alert is required")
+	}
+	if strings.TrimSpace(r.Expr) == "" {
+		return nil, fmt.Errorf("expr is required")
+	}
+	forDuration := 0
+	if r.For != "" {
+		secs, err := parsePrometheusDuration(r.For)
+		if err != nil {
+			return nil, fmt.Errorf("for: %v", err)
+		}
+		forDuration = secs
+	}
+	severity := r.Labels["severity"]
+	if severity == "" {
+		severity = defaultSeverity
+	}
+	severity, err := NormalizeSeverity(severity)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateAlertRuleRequest{
+		Name:                      r.Alert,
+		Description:               r.Annotations["summary"],
+		Expression:                strings.TrimSpace(r.Expr),
+		EvaluationIntervalSeconds: interval,
+		ForDuration:               forDuration,
+		Severity:                  severity,
+		Labels:                    r.Labels,
+		Annotations:               r.Annotations,
+		GroupID:                   groupID,
+		DataSourceType:            "prometheus",
+	}, nil
+}
+
+// MarshalPrometheusRules writes rules as a Prometheus rule file. Rules are grouped by business group,
+// named by groupNames (falling back to the group ID), and within a group by evaluation interval, which
+// becomes the group's `interval`. The rule severity is written as the `severity` label.
+func MarshalPrometheusRules(rules []models.AlertRule, groupNames map[uuid.UUID]string) ([]byte, error) {
+	type groupKey struct {
+		groupID  uuid.UUID
+		interval int
+	}
+	var keys []groupKey
+	byKey := make(map[groupKey][]PrometheusRule)
+	intervals := make(map[uuid.UUID]int)
+	for _, rule := range rules {
+		var labels, annotations map[string]string
+		json.Unmarshal([]byte(rule.Labels), &labels)
+		json.Unmarshal([]byte(rule.Annotations), &annotations)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels["severity"] = rule.Severity
+
+		pr := PrometheusRule{
+			Alert:       rule.Name,
+			Expr:        rule.Expression,
+			Labels:      labels,
+			Annotations: annotations,
+		}
+		if rule.ForDuration > 0 {
+			pr.For = formatPrometheusDuration(rule.ForDuration)
+		}
+		key := groupKey{groupID: rule.GroupID, interval: rule.EvaluationIntervalSeconds}
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+			intervals[rule.GroupID]++
+		}
+		byKey[key] = append(byKey[key], pr)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		ni, nj := prometheusGroupName(keys[i].groupID, groupNames), prometheusGroupName(keys[j].groupID, groupNames)
+		if ni != nj {
+			return ni < nj
+		}
+		return keys[i].interval < keys[j].interval
+	})
+
+	file := PrometheusRuleFile{Groups: []PrometheusRuleGroup{}}
+	for _, key := range keys {
+		g := PrometheusRuleGroup{Name: prometheusGroupName(key.groupID, groupNames), Rules: byKey[key]}
+		if key.interval > 0 {
+			g.Interval = formatPrometheusDuration(key.interval)
+			// Prometheus requires unique group names within a file.
+			if intervals[key.groupID] > 1 {
+				g.Name += "-" + g.Interval
+			}
+		}
+		file.Groups = append(file.Groups, g)
+	}
+	return yaml.Marshal(file)
+}
+
+func prometheusGroupName(groupID uuid.UUID, names map[uuid.UUID]string) string {
+	if name := names[groupID]; name != "" {
+		return name
+	}
+	return groupID.String()
+}
+
+var prometheusDurationUnits = []struct {
+	unit string
+	secs int
+}{
+	{"y", 365 * 86400}, {"w", 7 * 86400}, {"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1},
+}
+
+// parsePrometheusDuration parses a Prometheus duration such as "5m", "1h30m" or "2d" into seconds.
+// Units must appear in descending order, as Prometheus requires; milliseconds are truncated.
+func parsePrometheusDuration(s string) (int, error) {
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if rest == "0" {
+		return 0, nil
+	}
+	total, next := 0, 0
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		rest = rest[i:]
+		if strings.HasPrefix(rest, "ms") {
+			// Sub-second parts are dropped; rules are evaluated at second granularity.
+			total += n / 1000
+			rest = rest[2:]
+			next = len(prometheusDurationUnits)
+			continue
+		}
+		found := false
+		for j := next; j < len(prometheusDurationUnits); j++ {
+			u := prometheusDurationUnits[j]
+			if strings.HasPrefix(rest, u.unit) {
+				total += n * u.secs
+				rest = rest[len(u.unit):]
+				next = j + 1
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	return total, nil
+}
+
+// formatPrometheusDuration formats seconds as a Prometheus duration such as "1h30m".
+func formatPrometheusDuration(secs int) string {
+	if secs <= 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	for _, u := range prometheusDurationUnits {
+		if secs >= u.secs {
+			b.WriteString(strconv.Itoa(secs / u.secs))
+			b.WriteString(u.unit)
+			secs %= u.secs
+		}
+	}
+	return b.String()
+}
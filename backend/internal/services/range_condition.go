@@ -0,0 +1,128 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRangeCondition is returned for a range-mode rule whose range settings or condition are invalid.
+var ErrInvalidRangeCondition = errors.New("invalid range condition")
+
+// Functions a range condition reduces a series' samples with.
+var rangeFunctions = map[string]func(values []models.Sample) float64{
+	// change_percent is the relative change from the first to the last sample, in percent.
+	"change_percent": func(v []models.Sample) float64 {
+		first, last := v[0].Value, v[len(v)-1].Value
+		if first == 0 {
+			return 0
+		}
+		return (last - first) / first * 100
+	},
+	"change": func(v []models.Sample) float64 { return v[len(v)-1].Value - v[0].Value },
+	"last":   func(v []models.Sample) float64 { return v[len(v)-1].Value },
+	"min": func(v []models.Sample) float64 {
+		m := v[0].Value
+		for _, s := range v[1:] {
+			if s.Value < m {
+				m = s.Value
+			}
+		}
+		return m
+	},
+	"max": func(v []models.Sample) float64 {
+		m := v[0].Value
+		for _, s := range v[1:] {
+			if s.Value > m {
+				m = s.Value
+			}
+		}
+		return m
+	},
+	"avg": func(v []models.Sample) float64 {
+		var sum float64
+		for _, s := range v {
+			sum += s.Value
+		}
+		return sum / float64(len(v))
+	},
+}
+
+var rangeOperators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+}
+
+// RangeCondition is a parsed range_condition "<function> <operator> <threshold>", e.g. "change_percent > 50".
+// Functions: change_percent, change, min, max, avg, last; operators: >, >=, <, <=.
+type RangeCondition struct {
+	Function  string
+	Operator  string
+	Threshold float64
+}
+
+// ParseRangeCondition parses a range_condition, returning an error wrapping ErrInvalidRangeCondition.
+func ParseRangeCondition(s string) (*RangeCondition, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("%w %q (expected \"<function> <operator> <threshold>\", e.g. \"change_percent > 50\")", ErrInvalidRangeCondition, s)
+	}
+	if _, ok := rangeFunctions[fields[0]]; !ok {
+		return nil, fmt.Errorf("%w: unknown function %q (must be one of change_percent, change, min, max, avg, last)", ErrInvalidRangeCondition, fields[0])
+	}
+	if _, ok := rangeOperators[fields[1]]; !ok {
+		return nil, fmt.Errorf("%w: unknown operator %q (must be one of >, >=, <, <=)", ErrInvalidRangeCondition, fields[1])
+	}
+	threshold, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid threshold %q", ErrInvalidRangeCondition, fields[2])
+	}
+	return &RangeCondition{Function: fields[0], Operator: fields[1], Threshold: threshold}, nil
+}
+
+// Evaluate reduces the samples with the condition's function and reports whether the result satisfies
+// the comparison. A series without samples never matches.
+func (c *RangeCondition) Evaluate(values []models.Sample) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	v := rangeFunctions[c.Function](values)
+	return v, rangeOperators[c.Operator](v, c.Threshold)
+}
+
+// ValidateRangeSettings checks the range settings of a rule in range mode; instant rules need none.
+func ValidateRangeSettings(mode string, duration, step int, condition string) error {
+	switch mode {
+	case "", models.EvaluationModeInstant:
+		return nil
+	case models.EvaluationModeRange:
+	default:
+		return fmt.Errorf("%w: evaluation_mode must be instant or range", ErrInvalidRangeCondition)
+	}
+	if duration < 0 || step < 0 {
+		return fmt.Errorf("%w: range_duration and range_step must be positive", ErrInvalidRangeCondition)
+	}
+	if duration > 0 && step > duration {
+		return fmt.Errorf("%w: range_step must not exceed range_duration", ErrInvalidRangeCondition)
+	}
+	_, err := ParseRangeCondition(condition)
+	return err
+}
+
+// rangeWindow returns the [start, end] window and step of a range-mode query ending now, applying the
+// defaults of 600s duration and 60s step.
+func rangeWindow(durationSecs, stepSecs int) (time.Time, time.Time, string) {
+	if durationSecs <= 0 {
+		durationSecs = 600
+	}
+	if stepSecs <= 0 {
+		stepSecs = 60
+	}
+	end := time.Now()
+	return end.Add(-time.Duration(durationSecs) * time.Second), end, strconv.Itoa(stepSecs)
+}
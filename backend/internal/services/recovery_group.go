@@ -0,0 +1,201 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// recoveredSeries is one series of a grouped recovery with the firing alert it resolves.
+type recoveredSeries struct {
+	key      pendingKey
+	hist     *models.AlertHistory
+	labels   map[string]string
+	silenced *models.AlertSilence
+}
+
+// resolveGroup resolves the series of a group_recovery rule that recovered in the same cycle. Every
+// alert is marked resolved as usual, but the rule gets a single recovery notification listing the
+// recovered fingerprints, their count and the labels they share, committed in the same transaction
+// as the resolves. Series matching an active silence are resolved without being listed.
+func (w *AlertNotificationWorker) resolveGroup(ctx context.Context, rule models.AlertRule, keys []pendingKey, now time.Time, stats *workerRunStats) {
+	var series []recoveredSeries
+	for _, key := range keys {
+		hist, err := w.historyRepo.GetLatestFiringByRuleAndFingerprint(ctx, key.ruleID, key.fingerprint)
+		if err != nil || hist == nil {
+			log.Printf("AlertNotificationWorker: get latest firing for recovery %s/%s: %v", key.ruleID, key.fingerprint, err)
+			continue
+		}
+		s := recoveredSeries{key: key, hist: hist}
+		json.Unmarshal([]byte(hist.Labels), &s.labels)
+		if rule.NotifyOnResolve {
+			s.silenced = w.matchingSilence(ctx, rule.GroupID, s.labels)
+		}
+		series = append(series, s)
+	}
+	if len(series) == 0 {
+		return
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].key.fingerprint < series[j].key.fingerprint })
+
+	var notify []recoveredSeries
+	for _, s := range series {
+		if s.silenced == nil {
+			notify = append(notify, s)
+		}
+	}
+	var payload *AlertPayload
+	if rule.NotifyOnResolve && len(notify) > 0 {
+		payload = w.groupRecoveryPayload(ctx, rule, notify, now)
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		log.Printf("AlertNotificationWorker: resolve group of %s: %v", rule.ID, err)
+		stats.fail(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+	for _, s := range series {
+		if err := w.historyRepo.MarkResolvedByRuleAndFingerprintTx(ctx, tx, s.key.ruleID, s.key.fingerprint, now); err != nil {
+			log.Printf("AlertNotificationWorker: mark resolved %s/%s: %v", s.key.ruleID, s.key.fingerprint, err)
+			stats.fail(err)
+			return
+		}
+	}
+	if payload != nil {
+		if err := w.outbox.Enqueue(ctx, tx, notify[0].hist.ID, payload); err != nil {
+			log.Printf("AlertNotificationWorker: enqueue group recovery of %s: %v", rule.ID, err)
+			stats.fail(err)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("AlertNotificationWorker: resolve group of %s: %v", rule.ID, err)
+		stats.fail(err)
+		return
+	}
+	if payload != nil {
+		w.outbox.Notify()
+	}
+
+	for _, s := range series {
+		if s.silenced != nil {
+			log.Printf("AlertNotificationWorker: %s resolved is silenced by %q (%s); left out of the group recovery notification",
+				s.hist.AlertNo, s.silenced.Name, s.silenced.ID)
+		}
+		w.stateWebhook.Emit(AlertStateEvent{
+			Event:     AlertEventResolved,
+			AlertID:   s.hist.ID,
+			AlertNo:   s.hist.AlertNo,
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Severity:  s.hist.Severity,
+			OldState:  "firing",
+			NewState:  "resolved",
+			Timestamp: now,
+		})
+		if w.slaSvc != nil {
+			if err := w.slaSvc.MarkResolved(ctx, s.hist.ID, now); err != nil {
+				log.Printf("AlertNotificationWorker: mark alert_sla resolved %s: %v", s.hist.ID, err)
+			}
+		}
+		if w.broadcaster != nil {
+			w.broadcaster.SendAlertNotification(&AlertNotification{
+				AlertID:   s.hist.ID.String(),
+				RuleID:    rule.ID.String(),
+				RuleName:  rule.Name,
+				GroupID:   rule.GroupID.String(),
+				Severity:  s.hist.Severity,
+				Status:    "resolved",
+				Timestamp: time.Now(),
+			})
+		}
+	}
+}
+
+// groupRecoveryPayload builds the single recovery notification of a grouped recovery. Its labels are
+// the labels all series share, and its summary lists each fingerprint with the labels that set it
+// apart. The earliest start time and the highest severity are reported.
+func (w *AlertNotificationWorker) groupRecoveryPayload(ctx context.Context, rule models.AlertRule, series []recoveredSeries, now time.Time) *AlertPayload {
+	common := make(map[string]string, len(series[0].labels))
+	for k, v := range series[0].labels {
+		common[k] = v
+	}
+	startedAt, severity := series[0].hist.StartedAt, series[0].hist.Severity
+	fingerprints := make([]string, 0, len(series))
+	for _, s := range series[1:] {
+		for k, v := range common {
+			if s.labels[k] != v {
+				delete(common, k)
+			}
+		}
+		if s.hist.StartedAt.Before(startedAt) {
+			startedAt = s.hist.StartedAt
+		}
+		if severityRank(s.hist.Severity) > severityRank(severity) {
+			severity = s.hist.Severity
+		}
+	}
+
+	lines := []string{fmt.Sprintf("%d series recovered", len(series))}
+	for _, s := range series {
+		fingerprints = append(fingerprints, s.key.fingerprint)
+		var distinct []string
+		for k, v := range s.labels {
+			if _, ok := common[k]; !ok {
+				distinct = append(distinct, k+"="+v)
+			}
+		}
+		sort.Strings(distinct)
+		lines = append(lines, s.key.fingerprint+" "+strings.Join(distinct, ", "))
+	}
+	summary := strings.Join(lines, "\n")
+	labelsJSON, _ := json.Marshal(common)
+
+	var renderedContent string
+	if rule.TemplateID != nil && w.templateSvc != nil {
+		data := map[string]interface{}{
+			"ruleName":             rule.Name,
+			"severity":             severity,
+			"status":               "resolved",
+			"startTime":            startedAt.Format("2006-01-02 15:04:05"),
+			"duration":             now.Sub(startedAt).Round(time.Second).String(),
+			"endTime":              now.Format("2006-01-02 15:04:05"),
+			"labels":               string(labelsJSON),
+			"annotations":          "{}",
+			"labelsFormatted":      formatMapToKeyValueLines(string(labelsJSON)),
+			"annotationsFormatted": "-",
+			"summary":              summary,
+			"recoveredCount":       len(series),
+			"recentCount":          0,
+			"lastResolved":         "-",
+		}
+		if r, err := w.templateSvc.Render(ctx, *rule.TemplateID, data); err == nil {
+			renderedContent = r
+		} else {
+			log.Printf("AlertNotificationWorker: render template for group recovery %s: %v", rule.TemplateID, err)
+		}
+	}
+
+	return &AlertPayload{
+		AlertNo:         series[0].hist.AlertNo,
+		RuleID:          rule.ID,
+		RuleName:        rule.Name,
+		Severity:        severity,
+		Status:          "resolved",
+		Description:     rule.Description,
+		Labels:          string(labelsJSON),
+		Summary:         summary,
+		RecoveredCount:  len(series),
+		Fingerprints:    fingerprints,
+		StartedAt:       startedAt,
+		EndedAt:         &now,
+		RenderedContent: renderedContent,
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"alert-center/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSeverity is returned when a rule or SLA config names a severity outside the configured set.
+var ErrInvalidSeverity = errors.New("invalid severity")
+
+// SeverityLevel is one configured alert severity. Color is the Lark card header template
+// (red, orange, yellow, green, blue, purple, grey, ...). ResponseMins and ResolutionMins are the
+// targets of the default SLA config seeded for this severity; zero derives them from the level's position.
+type SeverityLevel struct {
+	Name           string `json:"name"`
+	Color          string `json:"color"`
+	ResponseMins   int    `json:"response_mins,omitempty"`
+	ResolutionMins int    `json:"resolution_mins,omitempty"`
+}
+
+var defaultSeverityLevels = []SeverityLevel{
+	{Name: "critical", Color: "red"},
+	{Name: "warning", Color: "orange"},
+	{Name: "info", Color: "blue"},
+}
+
+// severityLevels is the allowed severity set, most severe first. It defaults to critical, warning, info and
+// can be replaced from config (severities) via SetSeverityLevels.
+var severityLevels = defaultSeverityLevels
+
+// SetSeverityLevels replaces the allowed severity set, most severe first. Names are lowercased and
+// deduplicated; a missing color falls back to the built-in color for that name, else blue. An empty
+// list keeps the defaults.
+func SetSeverityLevels(levels []SeverityLevel) {
+	out := make([]SeverityLevel, 0, len(levels))
+	seen := make(map[string]bool)
+	for _, l := range levels {
+		l.Name = strings.ToLower(strings.TrimSpace(l.Name))
+		if l.Name == "" || seen[l.Name] {
+			continue
+		}
+		seen[l.Name] = true
+		if l.Color == "" {
+			l.Color = "blue"
+			for _, d := range defaultSeverityLevels {
+				if d.Name == l.Name {
+					l.Color = d.Color
+				}
+			}
+		}
+		out = append(out, l)
+	}
+	if len(out) > 0 {
+		severityLevels = out
+	}
+}
+
+// SeverityLevels returns the configured severity levels, most severe first.
+func SeverityLevels() []SeverityLevel {
+	return severityLevels
+}
+
+// Severities returns the allowed severity names, most severe first.
+func Severities() []string {
+	names := make([]string, len(severityLevels))
+	for i, l := range severityLevels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// NormalizeSeverity lowercases and trims s and checks it against the allowed set, so that "Critical "
+// is stored as "critical" and matches severity-based SLA configs and statistics.
+func NormalizeSeverity(s string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	if severityRank(v) == 0 {
+		return "", fmt.Errorf("%w %q (must be one of %s)", ErrInvalidSeverity, s, strings.Join(Severities(), ", "))
+	}
+	return v, nil
+}
+
+// severityRank orders severities for binding thresholds and aggregation, higher is more severe;
+// unknown severities rank 0.
+func severityRank(severity string) int {
+	for i, l := range severityLevels {
+		if l.Name == severity {
+			return len(severityLevels) - i
+		}
+	}
+	return 0
+}
+
+// SeverityColor returns the configured display color for severity; unknown severities are blue.
+func SeverityColor(severity string) string {
+	for _, l := range severityLevels {
+		if l.Name == severity {
+			return l.Color
+		}
+	}
+	return "blue"
+}
+
+// DefaultSLAConfigs returns one SLA config per configured severity. Unless set on the level, targets
+// double at each step down from 15m response / 60m resolution for the most severe level.
+func DefaultSLAConfigs() []repository.SLAConfig {
+	configs := make([]repository.SLAConfig, 0, len(severityLevels))
+	for i, l := range severityLevels {
+		step := i
+		if step > 6 {
+			step = 6
+		}
+		responseMins, resolutionMins := l.ResponseMins, l.ResolutionMins
+		if responseMins <= 0 {
+			responseMins = 15 << step
+		}
+		if resolutionMins <= 0 {
+			resolutionMins = 60 << step
+		}
+		configs = append(configs, repository.SLAConfig{
+			Name:               strings.ToUpper(l.Name[:1]) + l.Name[1:] + " SLA",
+			Severity:           l.Name,
+			ResponseTimeMins:   responseMins,
+			ResolutionTimeMins: resolutionMins,
+			Priority:           10 * severityRank(l.Name),
+		})
+	}
+	return configs
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Silence matcher operators, as in Alertmanager.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// silenceRegexPrefix marks a legacy matcher value as a regular expression, anchored to the whole label value.
+const silenceRegexPrefix = "~"
+
+// SilenceMatcher is one label matcher. Regexes are anchored to the whole label value, and a label the
+// alert does not have matches as the empty string, so env!="prod" also matches alerts without env.
+type SilenceMatcher struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// SilenceMatchers holds a silence's matchers in one of two JSON forms: a list of {name, operator, value}
+// matchers that must all match (Matchers), or the legacy list of label maps (Sets), of which any one
+// must match with every label present and equal, or matching the regex for "~"-prefixed values.
+type SilenceMatchers struct {
+	Matchers []SilenceMatcher
+	Sets     []map[string]string
+}
+
+// MarshalJSON encodes the matchers in the form they were given.
+func (m SilenceMatchers) MarshalJSON() ([]byte, error) {
+	if len(m.Matchers) > 0 {
+		return json.Marshal(m.Matchers)
+	}
+	if m.Sets == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(m.Sets)
+}
+
+// UnmarshalJSON accepts either form. An element is a matcher when it has "name" and "operator" and no
+// keys besides "value"; otherwise it is a legacy label map. The forms cannot be mixed.
+func (m *SilenceMatchers) UnmarshalJSON(data []byte) error {
+	*m = SilenceMatchers{}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	var elems []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return fmt.Errorf("matchers must be a list of {name, operator, value} matchers or label maps: %w", err)
+	}
+	for _, e := range elems {
+		if isMatcherObject(e) {
+			var sm SilenceMatcher
+			for key, dst := range map[string]*string{"name": &sm.Name, "operator": &sm.Operator, "value": &sm.Value} {
+				if raw, ok := e[key]; ok {
+					if err := json.Unmarshal(raw, dst); err != nil {
+						return fmt.Errorf("matcher %s must be a string", key)
+					}
+				}
+			}
+			m.Matchers = append(m.Matchers, sm)
+			continue
+		}
+		set := make(map[string]string, len(e))
+		for key, raw := range e {
+			var v string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return fmt.Errorf("matcher label %q must be a string", key)
+			}
+			set[key] = v
+		}
+		m.Sets = append(m.Sets, set)
+	}
+	if len(m.Matchers) > 0 && len(m.Sets) > 0 {
+		return fmt.Errorf("matchers cannot mix {name, operator, value} matchers and label maps")
+	}
+	return nil
+}
+
+func isMatcherObject(e map[string]json.RawMessage) bool {
+	_, hasName := e["name"]
+	_, hasOperator := e["operator"]
+	if !hasName || !hasOperator {
+		return false
+	}
+	_, hasValue := e["value"]
+	return len(e) == 2 || (len(e) == 3 && hasValue)
+}
+
+// parseSilenceMatchers decodes a silence's stored matchers.
+func parseSilenceMatchers(s string) (SilenceMatchers, error) {
+	var m SilenceMatchers
+	if s == "" {
+		return m, nil
+	}
+	err := json.Unmarshal([]byte(s), &m)
+	return m, err
+}
+
+// labelMatcher is a validated matcher with its regex compiled. present requires the label to exist,
+// as legacy matchers do.
+type labelMatcher struct {
+	name, op, value string
+	re              *regexp.Regexp
+	present         bool
+}
+
+func (lm labelMatcher) matches(labels map[string]string) bool {
+	v, ok := labels[lm.name]
+	if !ok && lm.present {
+		return false
+	}
+	switch lm.op {
+	case MatchNotEqual:
+		return v != lm.value
+	case MatchRegexp:
+		return lm.re.MatchString(v)
+	case MatchNotRegexp:
+		return !lm.re.MatchString(v)
+	}
+	return v == lm.value
+}
+
+// matchesEmpty reports whether the matcher matches an alert without the label.
+func (lm labelMatcher) matchesEmpty() bool {
+	return !lm.present && lm.matches(nil)
+}
+
+// compile validates the matchers and returns them as sets, any one of which silences an alert when all
+// its matchers match. It rejects matchers that would never match or would match everything: no
+// matchers, an empty legacy map, an empty label name, an unknown operator, an invalid regex, or
+// operator-form matchers that all match an alert lacking their labels.
+func (m SilenceMatchers) compile() ([][]labelMatcher, error) {
+	if len(m.Matchers) == 0 && len(m.Sets) == 0 {
+		return nil, fmt.Errorf("%w: at least one matcher is required", ErrInvalidSilence)
+	}
+	if len(m.Matchers) > 0 {
+		set := make([]labelMatcher, 0, len(m.Matchers))
+		matchesEmpty := true
+		for i, sm := range m.Matchers {
+			lm, err := newLabelMatcher(sm.Name, sm.Operator, sm.Value, false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: matcher %d: %v", ErrInvalidSilence, i+1, err)
+			}
+			matchesEmpty = matchesEmpty && lm.matchesEmpty()
+			set = append(set, lm)
+		}
+		if matchesEmpty {
+			return nil, fmt.Errorf("%w: at least one matcher must not match an empty label, or the silence would silence every alert without these labels", ErrInvalidSilence)
+		}
+		return [][]labelMatcher{set}, nil
+	}
+
+	sets := make([][]labelMatcher, 0, len(m.Sets))
+	for i, s := range m.Sets {
+		if len(s) == 0 {
+			return nil, fmt.Errorf("%w: matcher %d is empty and would silence every alert", ErrInvalidSilence, i+1)
+		}
+		set := make([]labelMatcher, 0, len(s))
+		for key, pattern := range s {
+			op, value := MatchEqual, pattern
+			if expr, ok := strings.CutPrefix(pattern, silenceRegexPrefix); ok {
+				op, value = MatchRegexp, expr
+			}
+			lm, err := newLabelMatcher(key, op, value, true)
+			if err != nil {
+				return nil, fmt.Errorf("%w: matcher %d: %v", ErrInvalidSilence, i+1, err)
+			}
+			set = append(set, lm)
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func newLabelMatcher(name, op, value string, present bool) (labelMatcher, error) {
+	if strings.TrimSpace(name) == "" {
+		return labelMatcher{}, fmt.Errorf("empty label name")
+	}
+	lm := labelMatcher{name: name, op: op, value: value, present: present}
+	switch op {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return labelMatcher{}, fmt.Errorf("label %q: invalid regex: %v", name, err)
+		}
+		lm.re = re
+	default:
+		return labelMatcher{}, fmt.Errorf("label %q: unknown operator %q, must be =, !=, =~ or !~", name, op)
+	}
+	return lm, nil
+}
+
+// silenceMatches reports whether labels satisfy all matchers of any of the sets.
+func silenceMatches(sets [][]labelMatcher, labels map[string]string) bool {
+	for _, set := range sets {
+		match := true
+		for _, lm := range set {
+			if !lm.matches(labels) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// silenceLabelCondition translates matcher sets into a JSONB prefilter on the alert_history labels
+// column, named column (qualified, e.g. "h.labels", when the query joins alert_rules, which has labels
+// too), that the GIN index serves: per set, exact values become a containment (@>) and labels that must
+// exist a key check (?&); sets are ORed. Regex and negative matchers are not checked here, so callers
+// still confirm with silenceMatches. No sets at all yields "".
+func silenceLabelCondition(sets [][]labelMatcher, column string) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	for _, set := range sets {
+		exact := make(map[string]string)
+		keys := []string{}
+		for _, lm := range set {
+			if lm.matchesEmpty() || lm.op == MatchNotEqual || lm.op == MatchNotRegexp {
+				continue
+			}
+			keys = append(keys, lm.name)
+			if lm.op == MatchEqual {
+				exact[lm.name] = lm.value
+			}
+		}
+		if len(keys) == 0 {
+			return "TRUE", nil
+		}
+		containment, _ := json.Marshal(exact)
+		args = append(args, string(containment), keys)
+		conds = append(conds, fmt.Sprintf("(%s @> $%d::jsonb AND %s ?& $%d::text[])", column, len(args)-1, column, len(args)))
+	}
+	return strings.Join(conds, " OR "), args
+}
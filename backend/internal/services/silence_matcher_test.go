@@ -0,0 +1,80 @@
+package services
+
+import (
+	"alert-center/internal/models"
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSilenceNegativeMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		matchers []SilenceMatcher
+		labels   map[string]string
+		want     bool
+	}{
+		{"!= other value", []SilenceMatcher{{"job", "=", "node"}, {"env", "!=", "prod"}}, map[string]string{"job": "node", "env": "staging"}, true},
+		{"!= same value", []SilenceMatcher{{"job", "=", "node"}, {"env", "!=", "prod"}}, map[string]string{"job": "node", "env": "prod"}, false},
+		{"!= missing label", []SilenceMatcher{{"job", "=", "node"}, {"env", "!=", "prod"}}, map[string]string{"job": "node"}, true},
+		{"!= empty value", []SilenceMatcher{{"job", "=", "node"}, {"env", "!=", ""}}, map[string]string{"job": "node"}, false},
+		{"!~ not matching", []SilenceMatcher{{"job", "=", "node"}, {"instance", "!~", "db-.*"}}, map[string]string{"job": "node", "instance": "web-1"}, true},
+		{"!~ matching", []SilenceMatcher{{"job", "=", "node"}, {"instance", "!~", "db-.*"}}, map[string]string{"job": "node", "instance": "db-1"}, false},
+		{"!~ anchored", []SilenceMatcher{{"job", "=", "node"}, {"instance", "!~", "db"}}, map[string]string{"job": "node", "instance": "db-1"}, true},
+		{"!~ missing label", []SilenceMatcher{{"job", "=", "node"}, {"instance", "!~", "db-.*"}}, map[string]string{"job": "node"}, true},
+		{"!~ alternation", []SilenceMatcher{{"job", "=", "node"}, {"env", "!~", "prod|staging"}}, map[string]string{"job": "node", "env": "staging"}, false},
+	} {
+		sets, err := SilenceMatchers{Matchers: tc.matchers}.compile()
+		if err != nil {
+			t.Fatalf("%s: compile: %v", tc.name, err)
+		}
+		if got := silenceMatches(sets, tc.labels); got != tc.want {
+			t.Errorf("%s: silenceMatches(%v) = %v, want %v", tc.name, tc.labels, got, tc.want)
+		}
+	}
+}
+
+func TestSilenceRejectsOnlyNegativeMatchers(t *testing.T) {
+	for _, matchers := range [][]SilenceMatcher{
+		{{"env", "!=", "prod"}},
+		{{"instance", "!~", "db-.*"}},
+		{{"env", "!=", "prod"}, {"instance", "!~", "db-.*"}},
+	} {
+		if _, err := (SilenceMatchers{Matchers: matchers}).compile(); !errors.Is(err, ErrInvalidSilence) {
+			t.Errorf("%v: want ErrInvalidSilence, got %v", matchers, err)
+		}
+	}
+	if _, err := (SilenceMatchers{Matchers: []SilenceMatcher{{"job", "=", "node"}, {"instance", "!~", "db-("}}}).compile(); !errors.Is(err, ErrInvalidSilence) {
+		t.Errorf("invalid !~ regex: want ErrInvalidSilence, got %v", err)
+	}
+}
+
+func TestInvalidStoredSilenceIsLoggedOnce(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(prev)
+
+	silence := &models.AlertSilence{ID: uuid.New(), Name: "maintenance", Matchers: `[{"name": "env", "operator": "!=", "value": "prod"}]`}
+	_, err := compileSilenceMatchers(silence)
+	if err == nil {
+		t.Fatal("a silence of only negative matchers compiled")
+	}
+	logInvalidSilence(silence, err)
+	logInvalidSilence(silence, err)
+	if n := strings.Count(buf.String(), silence.ID.String()); n != 1 {
+		t.Errorf("logged %d times, want once:\n%s", n, buf.String())
+	}
+
+	// Editing it into another broken form logs again.
+	silence.Matchers = `[{"name": "env", "operator": "=~", "value": "("}]`
+	_, err = compileSilenceMatchers(silence)
+	logInvalidSilence(silence, err)
+	if n := strings.Count(buf.String(), silence.ID.String()); n != 2 {
+		t.Errorf("logged %d times after the edit, want twice", n)
+	}
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,16 +12,23 @@ import (
 
 // SLABreachService manages SLA breach records and notifications.
 type SLABreachService struct {
-	db     *pgxpool.Pool
-	sender *NotificationSender
+	db          *pgxpool.Pool
+	sender      *NotificationSender
 	broadcaster Broadcaster
+	autoTicket  bool
 }
 
-// NewSLABreachService returns a new SLABreachService.
+// NewSLABreachService returns a new SLABreachService. broadcaster may be nil.
 func NewSLABreachService(db *pgxpool.Pool, sender *NotificationSender, broadcaster Broadcaster) *SLABreachService {
 	return &SLABreachService{db: db, sender: sender, broadcaster: broadcaster}
 }
 
+// WithAutoTicket makes TriggerNotifications open a ticket for each breach of the most severe level.
+func (s *SLABreachService) WithAutoTicket(enabled bool) *SLABreachService {
+	s.autoTicket = enabled
+	return s
+}
+
 // SLABreach represents a breach record.
 type SLABreach struct {
 	ID           uuid.UUID  `json:"id"`
@@ -35,26 +44,64 @@ type SLABreach struct {
 	CreatedAt    time.Time  `json:"created_at"`
 }
 
-// GetBreaches returns paginated breach list.
-func (s *SLABreachService) GetBreaches(ctx context.Context, page, pageSize int, status string) ([]SLABreach, int, error) {
-	offset := (page - 1) * pageSize
+// SLABreachFilter scopes GetBreaches. Empty fields are not filtered on; Start is inclusive and End exclusive.
+type SLABreachFilter struct {
+	BreachType string // response, resolution
+	Severity   string
+	Start      *time.Time
+	End        *time.Time
+}
+
+func (f SLABreachFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.BreachType != "" {
+		add("breach_type = $%d", f.BreachType)
+	}
+	if f.Severity != "" {
+		add("severity = $%d", f.Severity)
+	}
+	if f.Start != nil {
+		add("breach_time >= $%d", *f.Start)
+	}
+	if f.End != nil {
+		add("breach_time < $%d", *f.End)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// GetBreaches returns a page of the breaches matching filter, newest first, and the number of matches.
+func (s *SLABreachService) GetBreaches(ctx context.Context, page, pageSize int, filter SLABreachFilter) ([]SLABreach, int, error) {
+	if page < 1 {
+		page = 1
+	}
 	if pageSize <= 0 {
 		pageSize = 10
 	}
+	offset := (page - 1) * pageSize
+	where, args := filter.where()
+
 	var total int
-	err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM sla_breaches`).Scan(&total)
+	err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM sla_breaches`+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 	rows, err := s.db.Query(ctx, `
 		SELECT id, alert_id, rule_id, severity, breach_type, breach_time, response_time, assigned_to, assigned_name, notified, created_at
-		FROM sla_breaches ORDER BY breach_time DESC LIMIT $1 OFFSET $2
-	`, pageSize, offset)
+		FROM sla_breaches`+where+fmt.Sprintf(`
+		ORDER BY breach_time DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2), append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
-	var list []SLABreach
+	list := []SLABreach{}
 	for rows.Next() {
 		var b SLABreach
 		if err := rows.Scan(&b.ID, &b.AlertID, &b.RuleID, &b.Severity, &b.BreachType, &b.BreachTime, &b.ResponseTime, &b.AssignedTo, &b.AssignedName, &b.Notified, &b.CreatedAt); err != nil {
@@ -62,7 +109,7 @@ func (s *SLABreachService) GetBreaches(ctx context.Context, page, pageSize int,
 		}
 		list = append(list, b)
 	}
-	return list, total, nil
+	return list, total, rows.Err()
 }
 
 // GetBreachStats returns stats for a time range.
@@ -113,12 +160,13 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 
 	// Response breaches: deadline passed, not breached yet, not acknowledged.
 	rows, err := tx.Query(ctx, `
-		SELECT alert_id, rule_id, severity, response_deadline, created_at
-		FROM alert_slas
-		WHERE response_deadline IS NOT NULL
-		  AND response_deadline <= $1
-		  AND response_breached = false
-		  AND first_acked_at IS NULL
+		SELECT s.alert_id, s.rule_id, s.severity, s.response_deadline, s.created_at, r.group_id
+		FROM alert_slas s
+		LEFT JOIN alert_rules r ON r.id = s.rule_id
+		WHERE s.response_deadline IS NOT NULL
+		  AND s.response_deadline <= $1
+		  AND s.response_breached = false
+		  AND s.first_acked_at IS NULL
 	`, now)
 	if err != nil {
 		return 0, err
@@ -129,11 +177,12 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 		severity  string
 		breachAt  time.Time
 		createdAt time.Time
+		groupID   *uuid.UUID
 	}
 	var responseRows []breachRow
 	for rows.Next() {
 		var r breachRow
-		if err := rows.Scan(&r.alertID, &r.ruleID, &r.severity, &r.breachAt, &r.createdAt); err != nil {
+		if err := rows.Scan(&r.alertID, &r.ruleID, &r.severity, &r.breachAt, &r.createdAt, &r.groupID); err != nil {
 			rows.Close()
 			return 0, err
 		}
@@ -158,6 +207,7 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 				AlertID:   r.alertID.String(),
 				Severity:  r.severity,
 				BreachType: "response",
+				GroupID:   groupIDString(r.groupID),
 				Timestamp: now,
 			})
 		}
@@ -165,12 +215,13 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 
 	// Resolution breaches: deadline passed, not breached yet, not resolved.
 	rows, err = tx.Query(ctx, `
-		SELECT alert_id, rule_id, severity, resolution_deadline, created_at
-		FROM alert_slas
-		WHERE resolution_deadline IS NOT NULL
-		  AND resolution_deadline <= $1
-		  AND resolution_breached = false
-		  AND resolved_at IS NULL
+		SELECT s.alert_id, s.rule_id, s.severity, s.resolution_deadline, s.created_at, r.group_id
+		FROM alert_slas s
+		LEFT JOIN alert_rules r ON r.id = s.rule_id
+		WHERE s.resolution_deadline IS NOT NULL
+		  AND s.resolution_deadline <= $1
+		  AND s.resolution_breached = false
+		  AND s.resolved_at IS NULL
 	`, now)
 	if err != nil {
 		return 0, err
@@ -178,7 +229,7 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 	var resolutionRows []breachRow
 	for rows.Next() {
 		var r breachRow
-		if err := rows.Scan(&r.alertID, &r.ruleID, &r.severity, &r.breachAt, &r.createdAt); err != nil {
+		if err := rows.Scan(&r.alertID, &r.ruleID, &r.severity, &r.breachAt, &r.createdAt, &r.groupID); err != nil {
 			rows.Close()
 			return 0, err
 		}
@@ -203,6 +254,7 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 				AlertID:   r.alertID.String(),
 				Severity:  r.severity,
 				BreachType: "resolution",
+				GroupID:   groupIDString(r.groupID),
 				Timestamp: now,
 			})
 		}
@@ -214,38 +266,128 @@ func (s *SLABreachService) TriggerCheck(ctx context.Context) (int, error) {
 	return created, nil
 }
 
-// TriggerNotifications sends notifications for unnotified breaches (stub).
-func (s *SLABreachService) TriggerNotifications(ctx context.Context) (int, error) {
-	// For now, just mark unnotified breaches as notified.
+// TriggerNotifications broadcasts the unnotified breaches and marks them notified. With auto tickets
+// on, a breach of the most severe level (critical by default) also opens a ticket for its alert, unless
+// one exists for the same alert and breach type. It returns the number of breaches notified and the IDs
+// of the tickets created. A breach whose ticket cannot be created stays unnotified for the next call.
+func (s *SLABreachService) TriggerNotifications(ctx context.Context) (int, []uuid.UUID, error) {
 	rows, err := s.db.Query(ctx, `
-		SELECT id, alert_id, severity, breach_type
-		FROM sla_breaches WHERE notified=false
+		SELECT b.id, b.alert_id, b.rule_id, b.severity, b.breach_type, COALESCE(r.name, ''), r.group_id
+		FROM sla_breaches b
+		LEFT JOIN alert_rules r ON r.id = b.rule_id
+		WHERE b.notified = false
+		ORDER BY b.breach_time
 	`)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	defer rows.Close()
-	count := 0
+	type pendingBreach struct {
+		id, alertID, ruleID            uuid.UUID
+		severity, breachType, ruleName string
+		groupID                        *uuid.UUID
+	}
+	var breaches []pendingBreach
 	for rows.Next() {
-		var id, alertID uuid.UUID
-		var severity, breachType string
-		if err := rows.Scan(&id, &alertID, &severity, &breachType); err != nil {
-			return 0, err
+		var b pendingBreach
+		if err := rows.Scan(&b.id, &b.alertID, &b.ruleID, &b.severity, &b.breachType, &b.ruleName, &b.groupID); err != nil {
+			rows.Close()
+			return 0, nil, err
 		}
-		_, err := s.db.Exec(ctx, `UPDATE sla_breaches SET notified=true WHERE id=$1`, id)
-		if err != nil {
-			return 0, err
+		breaches = append(breaches, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	count := 0
+	ticketIDs := []uuid.UUID{}
+	for _, b := range breaches {
+		if s.autoTicket && severityRank(b.severity) == len(severityLevels) {
+			ticketID, err := s.createBreachTicket(ctx, b.alertID, b.ruleID, b.groupID, b.severity, b.breachType, b.ruleName)
+			if err != nil {
+				return count, ticketIDs, fmt.Errorf("create ticket for breach %s: %w", b.id, err)
+			}
+			if ticketID != nil {
+				ticketIDs = append(ticketIDs, *ticketID)
+			}
+		}
+		if _, err := s.db.Exec(ctx, `UPDATE sla_breaches SET notified=true WHERE id=$1`, b.id); err != nil {
+			return count, ticketIDs, err
 		}
 		count++
 		if s.broadcaster != nil {
 			s.broadcaster.SendSLABreachNotification(&SLABreachNotification{
-				BreachID:  id.String(),
-				AlertID:   alertID.String(),
-				Severity:  severity,
-				BreachType: breachType,
-				Timestamp: time.Now(),
+				BreachID:   b.id.String(),
+				AlertID:    b.alertID.String(),
+				Severity:   b.severity,
+				BreachType: b.breachType,
+				GroupID:    groupIDString(b.groupID),
+				Timestamp:  time.Now(),
 			})
 		}
 	}
-	return count, nil
+	return count, ticketIDs, nil
+}
+
+// breachTicketTitles names the ticket of each breach type.
+var breachTicketTitles = map[string]string{
+	"response":   "SLA 响应超时",
+	"resolution": "SLA 解决超时",
+}
+
+// ticketPriority maps a severity to a ticket priority by its rank: the most severe level is critical,
+// the next high, the next medium and any other low.
+func ticketPriority(severity string) string {
+	switch len(severityLevels) - severityRank(severity) {
+	case 0:
+		return "critical"
+	case 1:
+		return "high"
+	case 2:
+		return "medium"
+	}
+	return "low"
+}
+
+// createBreachTicket opens a ticket, created by "system", for an SLA breach of alertID and broadcasts
+// it to the rule's business group (groupID). It returns nil when the alert already has a ticket for
+// breachType.
+func (s *SLABreachService) createBreachTicket(ctx context.Context, alertID, ruleID uuid.UUID, groupID *uuid.UUID, severity, breachType, ruleName string) (*uuid.UUID, error) {
+	title, ok := breachTicketTitles[breachType]
+	if !ok {
+		title = "SLA " + breachType + " 超时"
+	}
+	if ruleName != "" {
+		title += ": " + ruleName
+	}
+	var alertNo string
+	s.db.QueryRow(ctx, `SELECT COALESCE(alert_no, '') FROM alert_history WHERE id = $1`, alertID).Scan(&alertNo)
+	description := fmt.Sprintf("告警 %s (%s) 的 %s SLA 已超时，由系统自动创建。", alertNo, severity, breachType)
+
+	id := uuid.New()
+	now := time.Now()
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO tickets (id, title, description, alert_id, rule_id, priority, status, creator_id, creator_name,
+			sla_breach_type, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'open', $7, 'system', $8, $9, $9)
+		ON CONFLICT (alert_id, sla_breach_type) WHERE sla_breach_type IS NOT NULL DO NOTHING
+	`, id, title, description, alertID, ruleID, ticketPriority(severity), uuid.Nil, breachType, now)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+	if s.broadcaster != nil {
+		s.broadcaster.SendTicketNotification(&TicketNotification{
+			TicketID:  id.String(),
+			Title:     title,
+			Status:    "open",
+			Action:    "created",
+			GroupID:   groupIDString(groupID),
+			Timestamp: now,
+		})
+	}
+	return &id, nil
 }
@@ -28,23 +28,13 @@ func (s *SLAService) SeedDefaultSLAConfigs(ctx context.Context) error {
 	if count > 0 {
 		return nil
 	}
-	defaults := []struct {
-		name                 string
-		severity             string
-		responseMins, resolveMins int
-		priority             int
-	}{
-		{"Critical SLA", "critical", 15, 60, 100},
-		{"Warning SLA", "warning", 30, 120, 50},
-		{"Info SLA", "info", 60, 240, 10},
-	}
-	for _, d := range defaults {
+	for _, d := range DefaultSLAConfigs() {
 		id := uuid.New()
 		now := time.Now()
 		_, err := s.db.Exec(ctx, `
 			INSERT INTO sla_configs (id, name, severity, response_time_mins, resolution_time_mins, priority, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		`, id, d.name, d.severity, d.responseMins, d.resolveMins, d.priority, now, now)
+		`, id, d.Name, d.Severity, d.ResponseTimeMins, d.ResolutionTimeMins, d.Priority, now, now)
 		if err != nil {
 			return err
 		}
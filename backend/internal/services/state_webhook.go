@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alert state transition events sent to the state-change webhook.
+const (
+	AlertEventCreated   = "created"
+	AlertEventAcked     = "acked"
+	AlertEventEscalated = "escalated"
+	AlertEventResolved  = "resolved"
+)
+
+const (
+	stateWebhookQueueSize   = 1000
+	stateWebhookMaxAttempts = 3
+)
+
+// AlertStateEvent is the compact JSON body posted to the state-change webhook for one transition.
+type AlertStateEvent struct {
+	Event     string    `json:"event"` // created, acked, escalated, resolved
+	AlertID   uuid.UUID `json:"alert_id"`
+	AlertNo   string    `json:"alert_no"`
+	RuleID    uuid.UUID `json:"rule_id"`
+	RuleName  string    `json:"rule_name"`
+	Severity  string    `json:"severity"`
+	OldState  string    `json:"old_state"`
+	NewState  string    `json:"new_state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StateWebhook posts every alert state transition to a single configured URL (state_webhook.url), as a
+// firehose for external consumers such as a SIEM or data lake. Unlike channel notifications it is not
+// human-facing and is best-effort: events are queued in memory, retried a few times, and dropped when the
+// queue is full or the endpoint keeps failing. A nil *StateWebhook is valid and discards events.
+type StateWebhook struct {
+	url    string
+	client *http.Client
+	queue  chan AlertStateEvent
+}
+
+// NewStateWebhook returns a StateWebhook posting to url, or nil when url is empty (disabled).
+// A zero timeout defaults to 5s.
+func NewStateWebhook(url string, timeout time.Duration) *StateWebhook {
+	if url == "" {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &StateWebhook{
+		url:    url,
+		client: newHTTPClient(timeout),
+		queue:  make(chan AlertStateEvent, stateWebhookQueueSize),
+	}
+}
+
+// Emit queues event for delivery without blocking. The timestamp defaults to now.
+func (h *StateWebhook) Emit(event AlertStateEvent) {
+	if h == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	select {
+	case h.queue <- event:
+	default:
+		log.Printf("StateWebhook: queue full, dropping %s event for %s", event.Event, event.AlertNo)
+	}
+}
+
+// Run delivers queued events until ctx is cancelled.
+func (h *StateWebhook) Run(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-h.queue:
+			h.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver posts one event, retrying with a short backoff.
+func (h *StateWebhook) deliver(ctx context.Context, event AlertStateEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("StateWebhook: encode %s event for %s: %v", event.Event, event.AlertNo, err)
+		return
+	}
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = h.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempt >= stateWebhookMaxAttempts {
+			log.Printf("StateWebhook: dropping %s event for %s after %d attempts: %v", event.Event, event.AlertNo, attempt, err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (h *StateWebhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value formats a rule can set (value_format) to render its firing value in notifications.
+const (
+	ValueFormatPercent  = "percent"  // ratio 0-1 rendered as a percentage: 0.953 -> 95.3%
+	ValueFormatBytes    = "bytes"    // byte count with binary units: 1288490188 -> 1.2 GB
+	ValueFormatDuration = "duration" // seconds rendered as a duration: 90 -> 1m30s
+)
+
+// FormatValue renders value according to format. An empty or unknown format renders the raw value.
+func FormatValue(value float64, format string) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	switch format {
+	case ValueFormatPercent:
+		return trimFloat(value*100, 1) + "%"
+	case ValueFormatBytes:
+		return formatBytes(value)
+	case ValueFormatDuration:
+		return formatSeconds(value)
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// trimFloat formats v with at most prec decimals, dropping trailing zeros.
+func trimFloat(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+func formatBytes(v float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	abs := math.Abs(v)
+	i := 0
+	for abs >= 1024 && i < len(units)-1 {
+		abs /= 1024
+		v /= 1024
+		i++
+	}
+	return trimFloat(v, 1) + " " + units[i]
+}
+
+func formatSeconds(v float64) string {
+	d := time.Duration(v * float64(time.Second))
+	switch {
+	case math.Abs(v) >= 1:
+		d = d.Round(time.Second)
+	case math.Abs(v) >= 0.001:
+		d = d.Round(time.Millisecond)
+	}
+	return d.String()
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WorkerStatus is shared between the alert worker, which records each evaluation cycle, and the API,
+// which reports it from the worker health endpoint.
+type WorkerStatus struct {
+	mu                   sync.RWMutex
+	startedAt            time.Time
+	checkInterval        time.Duration
+	lastRunAt            time.Time
+	lastRunDuration      time.Duration
+	rulesEvaluated       int
+	rulesSkipped         int
+	openDataSources      []string
+	errors               int
+	lastError            string
+	pendingAlerts        int
+	pendingNotifications int
+	restarts             int
+	lastRestartAt        time.Time
+	lastRestartReason    string
+	evalDurations        EvalDurations
+
+	// Live gauges of the running cycle, updated per rule without taking mu.
+	evalsInFlight  atomic.Int64
+	evalQueueDepth atomic.Int64
+}
+
+// EvalDurations are percentiles of the per-rule evaluation durations of the last cycle, in milliseconds.
+type EvalDurations struct {
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+// WorkerStatusSnapshot is a point-in-time copy of WorkerStatus.
+type WorkerStatusSnapshot struct {
+	Status               string        `json:"status"` // starting, ok, stale
+	StartedAt            *time.Time    `json:"started_at"`
+	CheckInterval        string        `json:"check_interval"`
+	LastRunAt            *time.Time    `json:"last_run_at"`
+	LastRunDurationMs    int64         `json:"last_run_duration_ms"`
+	RulesEvaluated       int           `json:"rules_evaluated"`
+	RulesSkipped         int           `json:"rules_skipped"`     // rules not evaluated because their data source circuit is open
+	EvalsInFlight        int64         `json:"evals_in_flight"`   // rule evaluations running now
+	EvalQueueDepth       int64         `json:"eval_queue_depth"`  // rules of the running cycle not yet evaluated
+	EvalDurationMs       EvalDurations `json:"eval_duration_ms"`  // per-rule evaluation duration percentiles of the last cycle
+	OpenDataSources      []string      `json:"open_data_sources"` // data source endpoints with an open or half-open circuit
+	Errors               int           `json:"errors"`
+	LastError            string        `json:"last_error,omitempty"`
+	PendingAlerts        int           `json:"pending_alerts"`        // firing series waiting for for_duration or recovery confirmation
+	PendingNotifications int           `json:"pending_notifications"` // undelivered outbox notifications
+	NotificationsDryRun  bool          `json:"notifications_dry_run"` // notifications are logged, not sent
+	Restarts             int           `json:"restarts"`              // times the supervisor restarted the worker
+	LastRestartAt        *time.Time    `json:"last_restart_at,omitempty"`
+	LastRestartReason    string        `json:"last_restart_reason,omitempty"`
+}
+
+// workerRunStats accumulates the results of one evaluation cycle.
+type workerRunStats struct {
+	evalDurations   []time.Duration
+	rulesEvaluated  int
+	rulesSkipped    int
+	openDataSources []string
+	errors          int
+	lastError       string
+}
+
+func (s *workerRunStats) fail(err error) {
+	s.errors++
+	s.lastError = err.Error()
+}
+
+// percentiles returns the nearest-rank percentiles of the evaluation durations.
+func (s *workerRunStats) percentiles() EvalDurations {
+	n := len(s.evalDurations)
+	if n == 0 {
+		return EvalDurations{}
+	}
+	sorted := append([]time.Duration(nil), s.evalDurations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p int) int64 {
+		i := (p*n+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i].Milliseconds()
+	}
+	return EvalDurations{P50: rank(50), P90: rank(90), P99: rank(99), Max: sorted[n-1].Milliseconds()}
+}
+
+// NewWorkerStatus returns an empty WorkerStatus.
+func NewWorkerStatus() *WorkerStatus {
+	return &WorkerStatus{}
+}
+
+func (s *WorkerStatus) started(checkInterval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startedAt = time.Now()
+	s.checkInterval = checkInterval
+}
+
+// evalQueued sets the number of rules waiting to be evaluated in the cycle that is starting.
+func (s *WorkerStatus) evalQueued(n int) {
+	s.evalQueueDepth.Store(int64(n))
+}
+
+// evalStarted moves a rule from the queue to in flight; the returned func marks it done.
+func (s *WorkerStatus) evalStarted() func() {
+	s.evalQueueDepth.Add(-1)
+	s.evalsInFlight.Add(1)
+	return func() { s.evalsInFlight.Add(-1) }
+}
+
+// evalDequeued removes a rule from the queue without evaluating it.
+func (s *WorkerStatus) evalDequeued() {
+	s.evalQueueDepth.Add(-1)
+}
+
+func (s *WorkerStatus) recordRun(at time.Time, duration time.Duration, stats workerRunStats, pendingAlerts, pendingNotifications int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRunAt = at
+	s.lastRunDuration = duration
+	s.rulesEvaluated = stats.rulesEvaluated
+	s.rulesSkipped = stats.rulesSkipped
+	s.openDataSources = stats.openDataSources
+	s.evalDurations = stats.percentiles()
+	s.evalQueueDepth.Store(0)
+	s.errors = stats.errors
+	s.lastError = stats.lastError
+	s.pendingAlerts = pendingAlerts
+	s.pendingNotifications = pendingNotifications
+}
+
+func (s *WorkerStatus) recordRestart(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restarts++
+	s.lastRestartAt = time.Now()
+	s.lastRestartReason = reason
+}
+
+// Snapshot returns the current status. It is "stale" when the worker has not completed a cycle within
+// three check intervals (or never started), "starting" before the first cycle, and "ok" otherwise.
+func (s *WorkerStatus) Snapshot() WorkerStatusSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := WorkerStatusSnapshot{
+		CheckInterval:        s.checkInterval.String(),
+		LastRunDurationMs:    s.lastRunDuration.Milliseconds(),
+		RulesEvaluated:       s.rulesEvaluated,
+		RulesSkipped:         s.rulesSkipped,
+		EvalsInFlight:        s.evalsInFlight.Load(),
+		EvalQueueDepth:       s.evalQueueDepth.Load(),
+		EvalDurationMs:       s.evalDurations,
+		OpenDataSources:      s.openDataSources,
+		Errors:               s.errors,
+		LastError:            s.lastError,
+		PendingAlerts:        s.pendingAlerts,
+		PendingNotifications: s.pendingNotifications,
+		NotificationsDryRun:  NotificationsDryRun(),
+		Restarts:             s.restarts,
+		LastRestartReason:    s.lastRestartReason,
+	}
+	if !s.lastRestartAt.IsZero() {
+		t := s.lastRestartAt
+		snap.LastRestartAt = &t
+	}
+	if !s.startedAt.IsZero() {
+		t := s.startedAt
+		snap.StartedAt = &t
+	}
+	if !s.lastRunAt.IsZero() {
+		t := s.lastRunAt
+		snap.LastRunAt = &t
+	}
+
+	deadline := 3 * s.checkInterval
+	switch {
+	case s.startedAt.IsZero():
+		snap.Status = "stale"
+	case s.lastRunAt.IsZero() && time.Since(s.startedAt) <= deadline:
+		snap.Status = "starting"
+	case !s.lastRunAt.IsZero() && time.Since(s.lastRunAt) <= deadline:
+		snap.Status = "ok"
+	default:
+		snap.Status = "stale"
+	}
+	return snap
+}
+
+var workerLastRunAge = prometheus.NewDesc("alert_center_worker_last_run_age_seconds",
+	"Seconds since the alert worker last completed an evaluation cycle.", nil, nil)
+
+// Describe and Collect make WorkerStatus a prometheus.Collector of the age of the worker's last completed
+// cycle, which keeps growing when the worker hangs or dies. Before the first cycle the age counts from
+// the worker's start; it is not collected when the worker never started.
+func (s *WorkerStatus) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workerLastRunAge
+}
+
+func (s *WorkerStatus) Collect(ch chan<- prometheus.Metric) {
+	s.mu.RLock()
+	since := s.lastRunAt
+	if since.IsZero() {
+		since = s.startedAt
+	}
+	s.mu.RUnlock()
+	if since.IsZero() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(workerLastRunAge, prometheus.GaugeValue, time.Since(since).Seconds())
+}
@@ -1,5 +1,6 @@
 import { useEffect, useState, useCallback, useRef } from 'react';
 import { message } from 'antd';
+import { useAuthStore } from '../store/auth';
 
 interface AlertMessage {
   type: string;
@@ -31,8 +32,29 @@ interface TicketMessage {
   timestamp: string;
 }
 
+interface AlertAckMessage {
+  type: string;
+  alert_id: string;
+  alert_no: string;
+  rule_id: string;
+  group_id?: string;
+  severity: string;
+  acked_by: string;
+  acked_by_name: string;
+  timestamp: string;
+}
+
+/** 订阅过滤：只接收匹配的告警通知，为空的字段不过滤 */
+export interface SubscriptionFilter {
+  severity?: string;
+  group_id?: string;
+  rule_id?: string;
+}
+
 interface UseWebSocketOptions {
+  filter?: SubscriptionFilter;
   onAlert?: (alert: AlertMessage) => void;
+  onAlertAck?: (ack: AlertAckMessage) => void;
   onSLABreach?: (breach: SLABreachMessage) => void;
   onTicket?: (ticket: TicketMessage) => void;
 }
@@ -55,12 +77,22 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
       return;
     }
 
+    // 浏览器无法为握手设置 Authorization 头，JWT 通过子协议 ["bearer", token] 传递
+    const token = useAuthStore.getState().token;
+    if (!token) {
+      return;
+    }
+
     try {
-      wsRef.current = new WebSocket(wsUrl);
+      wsRef.current = new WebSocket(wsUrl, ['bearer', token]);
 
       wsRef.current.onopen = () => {
         console.log('WebSocket connected');
         setConnected(true);
+        const filter = optionsRef.current.filter;
+        if (filter) {
+          wsRef.current?.send(JSON.stringify({ type: 'subscribe', filter }));
+        }
       };
 
       wsRef.current.onmessage = (event) => {
@@ -74,12 +106,22 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
               message.info(`新告警: ${alert.rule_name} - ${alert.severity}`);
               opts.onAlert?.(alert);
               break;
+            case 'alert_ack':
+              const ack: AlertAckMessage = data;
+              message.info(`告警已确认: ${ack.alert_no} - ${ack.acked_by_name}`);
+              opts.onAlertAck?.(ack);
+              break;
             case 'sla_breach':
               const breach: SLABreachMessage = data;
               setSLABreaches((prev) => [breach, ...prev].slice(0, 50));
               message.warning(`SLA违约: ${breach.breach_type} - ${breach.severity}`);
               opts.onSLABreach?.(breach);
               break;
+            case 'subscribed':
+              break;
+            case 'error':
+              message.warning(`订阅失败: ${data.payload}`);
+              break;
             case 'ticket':
               const ticket: TicketMessage = data;
               setTickets((prev) => [ticket, ...prev].slice(0, 50));
@@ -143,6 +185,14 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
     setAlerts((prev) => prev.filter((a) => a.alert_id !== alertId));
   };
 
+  /** 替换当前连接的订阅过滤，传空对象恢复接收全部告警 */
+  const subscribe = useCallback((filter: SubscriptionFilter) => {
+    optionsRef.current = { ...optionsRef.current, filter };
+    if (wsRef.current?.readyState === WebSocket.OPEN) {
+      wsRef.current.send(JSON.stringify({ type: 'subscribe', filter }));
+    }
+  }, []);
+
   return {
     alerts,
     slaBreaches,
@@ -152,6 +202,7 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
     clearSLABreaches,
     clearTickets,
     removeAlert,
+    subscribe,
     alertCount: alerts.length,
     slaBreachCount: slaBreaches.length,
     ticketCount: tickets.length,
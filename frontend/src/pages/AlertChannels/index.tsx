@@ -1,4 +1,4 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { Table, Button, Space, Tag, message, Modal, Form, Input, Select, Drawer, Dropdown, Tooltip } from 'antd';
 import { PlusOutlined, EditOutlined, DeleteOutlined, ExportOutlined, DownOutlined, SendOutlined } from '@ant-design/icons';
@@ -7,11 +7,46 @@ import dayjs from 'dayjs';
 
 const channelTypes = [
   { value: 'lark', label: '飞书', icon: '📱' },
+  { value: 'dingtalk', label: '钉钉', icon: '🔔' },
   { value: 'telegram', label: 'Telegram', icon: '✈️' },
   { value: 'email', label: '邮件', icon: '📧' },
   { value: 'webhook', label: 'Webhook', icon: '🔗' },
+  { value: 'federation', label: '告警联邦', icon: '🌐' },
 ];
 
+/**
+ * Edits a webhook field_mapping object as JSON text. The form value is the parsed object, or the raw
+ * text while it is not a JSON object, so the field rule can reject it.
+ */
+function FieldMappingInput({ value, onChange }: { value?: unknown; onChange?: (v: unknown) => void }) {
+  const [text, setText] = useState('');
+  useEffect(() => {
+    if (typeof value === 'string') return;
+    setText(value && typeof value === 'object' ? JSON.stringify(value, null, 2) : '');
+  }, [value]);
+  return (
+    <Input.TextArea
+      rows={4}
+      value={text}
+      placeholder={'{\n  "summary": "message",\n  "severity": "priority"\n}'}
+      onChange={(e) => {
+        const next = e.target.value;
+        setText(next);
+        if (!next.trim()) {
+          onChange?.(undefined);
+          return;
+        }
+        try {
+          const parsed = JSON.parse(next);
+          onChange?.(parsed && typeof parsed === 'object' && !Array.isArray(parsed) ? parsed : next);
+        } catch {
+          onChange?.(next);
+        }
+      }}
+    />
+  );
+}
+
 export default function AlertChannels() {
   const [page, setPage] = useState(1);
   const [pageSize, setPageSize] = useState(10);
@@ -77,6 +112,21 @@ export default function AlertChannels() {
       message.error(err?.response?.data?.message || '测试发送失败'),
   });
 
+  const validateConfigMutation = useMutation({
+    mutationFn: (data: { type: string; config: Record<string, unknown> }) => alertChannelApi.validateConfig(data),
+    onSuccess: (res) => {
+      const body = res.data as unknown as { data?: { valid: boolean; errors: { field: string; message: string }[] } };
+      const { valid, errors } = body?.data ?? { valid: true, errors: [] };
+      form.setFields(
+        errors.map((e) => ({ name: e.field === 'type' ? 'type' : ['config', e.field], errors: [e.message] })),
+      );
+      if (valid) message.success('配置校验通过');
+      else message.warning(`配置有 ${errors.length} 处错误`);
+    },
+    onError: (err: { response?: { data?: { message?: string } } }) =>
+      message.error(err?.response?.data?.message || '配置校验失败'),
+  });
+
   const handleExportChannels = async () => {
     try {
       const res = await batchApi.exportChannels(filters);
@@ -100,6 +150,12 @@ export default function AlertChannels() {
     },
   ];
 
+  const larkFormatOptions = [
+    { value: 'card', label: '卡片' },
+    { value: 'text', label: '文本' },
+    { value: 'markdown', label: 'Markdown' },
+  ];
+
   const renderConfigFields = (type: string) => {
     switch (type) {
       case 'lark':
@@ -108,6 +164,20 @@ export default function AlertChannels() {
             <Form.Item name={['config', 'webhook_url']} label="Webhook URL" rules={[{ required: true }]}>
               <Input.Password placeholder="飞书机器人 Webhook URL" />
             </Form.Item>
+            <Form.Item name={['config', 'format']} label="消息格式" extra="默认卡片">
+              <Select allowClear placeholder="卡片" options={larkFormatOptions} />
+            </Form.Item>
+          </>
+        );
+      case 'dingtalk':
+        return (
+          <>
+            <Form.Item name={['config', 'webhook_url']} label="Webhook URL" rules={[{ required: true }]}>
+              <Input.Password placeholder="钉钉机器人 Webhook URL" />
+            </Form.Item>
+            <Form.Item name={['config', 'secret']} label="加签密钥" extra="机器人安全设置为「加签」时填写（SEC 开头）">
+              <Input.Password placeholder="SEC..." />
+            </Form.Item>
           </>
         );
       case 'telegram':
@@ -127,24 +197,78 @@ export default function AlertChannels() {
             <Form.Item name={['config', 'smtp_host']} label="SMTP 主机" rules={[{ required: true }]}>
               <Input placeholder="smtp.example.com" />
             </Form.Item>
-            <Form.Item name={['config', 'smtp_port']} label="SMTP 端口" rules={[{ required: true }]}>
-              <Input type="number" placeholder="587" />
+            <Form.Item name={['config', 'smtp_port']} label="SMTP 端口" extra="465 使用隐式 TLS，其他端口使用 STARTTLS">
+              <Input type="number" placeholder="465" />
+            </Form.Item>
+            <Form.Item name={['config', 'username']} label="用户名">
+              <Input placeholder="SMTP 用户名" />
+            </Form.Item>
+            <Form.Item name={['config', 'password']} label="密码">
+              <Input.Password placeholder="SMTP 密码" />
             </Form.Item>
-            <Form.Item name={['config', 'from_address']} label="发件地址" rules={[{ required: true, type: 'email' }]}>
+            <Form.Item name={['config', 'from']} label="发件地址" rules={[{ required: true, type: 'email' }]}>
               <Input placeholder="alert@example.com" />
             </Form.Item>
+            <Form.Item name={['config', 'to']} label="收件地址" rules={[{ required: true }]}>
+              <Input placeholder="ops@example.com, oncall@example.com" />
+            </Form.Item>
+          </>
+        );
+      case 'federation':
+        return (
+          <>
+            <Form.Item
+              name={['config', 'url']}
+              label="中心实例地址"
+              rules={[{ required: true, message: '请输入中心实例接收地址' }]}
+              extra="告警将保留告警编号和标签转发到中心 alert-center"
+            >
+              <Input placeholder="https://central.example.com/api/v1/federation/alerts" />
+            </Form.Item>
+            <Form.Item
+              name={['config', 'api_key']}
+              label="API Key"
+              rules={[{ required: true }]}
+              extra="中心实例 federation.sources 中为本实例配置的 API Key"
+            >
+              <Input.Password placeholder="API Key" />
+            </Form.Item>
           </>
         );
       case 'webhook':
         return (
-          <Form.Item
-            name={['config', 'url']}
-            label="Webhook URL"
-            rules={[{ required: true, message: '请输入 Webhook URL' }]}
-            extra="支持通用 Webhook；飞书机器人地址也可填于此，将自动按飞书格式推送。"
-          >
-            <Input placeholder="https://your-webhook.com 或飞书机器人 Webhook 地址" />
-          </Form.Item>
+          <>
+            <Form.Item
+              name={['config', 'url']}
+              label="Webhook URL"
+              rules={[{ required: true, message: '请输入 Webhook URL' }]}
+              extra="支持通用 Webhook；飞书机器人地址也可填于此，将自动按飞书格式推送。"
+            >
+              <Input placeholder="https://your-webhook.com 或飞书机器人 Webhook 地址" />
+            </Form.Item>
+            <Form.Item
+              name={['config', 'format']}
+              label="飞书消息格式"
+              extra="留空时按地址自动识别；飞书代理等无法识别的地址可在此强制按飞书格式推送。"
+            >
+              <Select allowClear placeholder="自动识别" options={larkFormatOptions} />
+            </Form.Item>
+            <Form.Item
+              name={['config', 'field_mapping']}
+              label="字段映射"
+              extra={'重命名推送 JSON 的字段以适配下游接口，未列出的字段保持原名；目标含 "." 时嵌套（如 "details.labels"），"-" 表示去掉该字段。仅对非飞书格式生效。'}
+              rules={[
+                {
+                  validator: (_, v) =>
+                    v === undefined || (typeof v === 'object' && v !== null)
+                      ? Promise.resolve()
+                      : Promise.reject(new Error('字段映射须为 JSON 对象')),
+                },
+              ]}
+            >
+              <FieldMappingInput />
+            </Form.Item>
+          </>
         );
       default:
         return null;
@@ -362,11 +486,33 @@ export default function AlertChannels() {
           <Form.Item noStyle dependencies={['type']}>
             {() => renderConfigFields(form.getFieldValue('type'))}
           </Form.Item>
+          <Form.Item
+            name={['config', 'max_per_minute']}
+            label="每分钟上限"
+            extra="超出的告警被丢弃，恢复配额后汇总为一条抑制通知；留空不限"
+          >
+            <Input type="number" min={0} placeholder="不限" />
+          </Form.Item>
           <Form.Item>
             <Space>
               <Button type="primary" htmlType="submit" loading={createMutation.isPending || updateMutation.isPending}>
                 保存
               </Button>
+              <Button
+                onClick={() => {
+                  const type = form.getFieldValue('type');
+                  const config = form.getFieldValue('config');
+                  if (!type) {
+                    message.warning('请先选择渠道类型');
+                    return;
+                  }
+                  const configObj = config && typeof config === 'object' ? config : {};
+                  validateConfigMutation.mutate({ type, config: configObj });
+                }}
+                loading={validateConfigMutation.isPending}
+              >
+                校验
+              </Button>
               <Button
                 onClick={async () => {
                   try {
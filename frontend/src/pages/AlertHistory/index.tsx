@@ -1,9 +1,9 @@
 import { useState } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { Table, Tag, Space, DatePicker, Select, Button, Form, Input, message, Drawer, Tooltip } from 'antd';
-import { DownloadOutlined, StopOutlined } from '@ant-design/icons';
+import { CheckOutlined, DownloadOutlined, StopOutlined } from '@ant-design/icons';
 import { alertHistoryApi } from '../../services/api';
-import type { AlertHistory } from '../../services/api';
+import type { AlertHistory, SilenceMatcher } from '../../services/api';
 import { silenceApi } from '../../services/api';
 import dayjs from 'dayjs';
 
@@ -44,8 +44,24 @@ export default function AlertHistory() {
     },
   });
 
+  const handleExport = async () => {
+    try {
+      const params = Object.fromEntries(Object.entries(filters).filter(([, v]) => v !== ''));
+      const res = await alertHistoryApi.export(params);
+      const blob = new Blob([res.data], { type: 'text/csv' });
+      const url = window.URL.createObjectURL(blob);
+      const link = document.createElement('a');
+      link.href = url;
+      link.download = `alert_history_${dayjs().format('YYYYMMDDHHmmss')}.csv`;
+      link.click();
+      window.URL.revokeObjectURL(url);
+    } catch {
+      message.error('导出失败');
+    }
+  };
+
   const createSilenceMutation = useMutation({
-    mutationFn: (data: { name: string; description?: string; matchers: Record<string, string>[]; start_time: string; end_time: string }) =>
+    mutationFn: (data: { name: string; description?: string; matchers: SilenceMatcher[]; start_time: string; end_time: string }) =>
       silenceApi.create(data),
     onSuccess: () => {
       message.success('静默规则创建成功');
@@ -56,6 +72,16 @@ export default function AlertHistory() {
     onError: () => message.error('创建失败'),
   });
 
+  const ackMutation = useMutation({
+    mutationFn: (id: string) => alertHistoryApi.ack(id),
+    onSuccess: () => {
+      message.success('告警已确认');
+      queryClient.invalidateQueries({ queryKey: ['alertHistory'] });
+    },
+    onError: (err: { response?: { data?: { message?: string } } }) =>
+      message.error(err?.response?.data?.message || '确认失败'),
+  });
+
   const columns = [
     {
       title: '告警编号',
@@ -115,24 +141,41 @@ export default function AlertHistory() {
       width: 180,
       render: (time: string | null) => time ? dayjs(time).format('YYYY-MM-DD HH:mm:ss') : '-',
     },
+    {
+      title: '确认人',
+      dataIndex: 'acked_by_name',
+      key: 'acked_by_name',
+      width: 160,
+      render: (name: string | undefined, record: AlertHistory) =>
+        record.acked_at ? (
+          <Tooltip title={dayjs(record.acked_at).format('YYYY-MM-DD HH:mm:ss')}>{name || '-'}</Tooltip>
+        ) : '-',
+    },
     {
       title: '操作',
       key: 'actions',
-      width: 120,
+      width: 180,
       render: (_: unknown, record: AlertHistory) => (
         <Space>
+          {record.status === 'firing' && !record.acked_at && (
+            <Button
+              type="link"
+              icon={<CheckOutlined />}
+              loading={ackMutation.isPending && ackMutation.variables === record.id}
+              onClick={() => ackMutation.mutate(record.id)}
+            >
+              确认
+            </Button>
+          )}
           <Tooltip title="快速静默此告警">
           <Button
             type="link"
             icon={<StopOutlined />}
             onClick={() => {
                 setSelectedAlert(record);
-                const labels = typeof record.labels === 'string' ? JSON.parse(record.labels) : record.labels;
-                const matchers = Object.entries(labels).map(([key, value]) => ({ [key]: value }));
                 form.setFieldsValue({
                   name: `静默-${record.alert_no || record.rule_id?.slice(0, 8)}`,
                   description: `静默此告警 (${dayjs(record.started_at).format('YYYY-MM-DD HH:mm')})`,
-                  matchers,
                   start_time: dayjs(),
                   end_time: dayjs().add(2, 'hour'),
                 });
@@ -152,8 +195,8 @@ export default function AlertHistory() {
       <div className="page-header">
         <h1 className="page-title">告警历史</h1>
         <Space>
-          <Button icon={<DownloadOutlined />}>
-            导出报表
+          <Button icon={<DownloadOutlined />} onClick={handleExport}>
+            导出 CSV
           </Button>
         </Space>
       </div>
@@ -179,9 +222,7 @@ export default function AlertHistory() {
             ]}
             onChange={(value) => setFilters({ ...filters, status: value || '' })}
           />
-          <Button type="primary" onClick={() => {
-            // Export functionality
-          }}>
+          <Button type="primary" onClick={handleExport}>
             导出
           </Button>
         </Space>
@@ -216,10 +257,11 @@ export default function AlertHistory() {
           form={form}
           layout="vertical"
           onFinish={(values) => {
-            const matchers = values.matchers?.map((m: Record<string, string>) => {
-              const [key, value] = Object.entries(m)[0];
-              return { [key]: value };
-            }) || [];
+            // Every label of the alert must match, so only this alert's series is silenced.
+            const labels: Record<string, string> = selectedAlert
+              ? (typeof selectedAlert.labels === 'string' ? JSON.parse(selectedAlert.labels) : selectedAlert.labels)
+              : {};
+            const matchers: SilenceMatcher[] = Object.entries(labels).map(([name, value]) => ({ name, operator: '=', value }));
             createSilenceMutation.mutate({
               name: values.name,
               description: values.description,
@@ -1,8 +1,8 @@
 import { useState, useEffect } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
-import { Table, Button, Space, Tag, message, Modal, Form, Input, Select, InputNumber, Drawer, Checkbox, Upload, Typography } from 'antd';
-import { PlusOutlined, EditOutlined, DeleteOutlined, ExportOutlined, ImportOutlined, InboxOutlined } from '@ant-design/icons';
-import { alertRuleApi, alertChannelApi, bindingApi, businessGroupApi, batchApi, dataSourceApi, templateApi, AlertRule, AlertChannel, type AlertChannelBinding, type BusinessGroup, type DataSource, type ExclusionWindow } from '../../services/api';
+import { Table, Button, Space, Tag, message, Modal, Form, Input, Select, InputNumber, Drawer, Checkbox, Upload, Typography, Switch, Row, Col, Tooltip } from 'antd';
+import { PlusOutlined, EditOutlined, DeleteOutlined, ExportOutlined, ImportOutlined, InboxOutlined, AppstoreAddOutlined, SwapOutlined } from '@ant-design/icons';
+import { alertRuleApi, alertChannelApi, severityApi, bindingApi, businessGroupApi, batchApi, dataSourceApi, templateApi, AlertRule, AlertChannel, type AlertChannelBinding, type BusinessGroup, type DataSource, type ExclusionWindow, type SeverityLevel, type AlertRulePreset } from '../../services/api';
 import dayjs from 'dayjs';
 
 const { Text } = Typography;
@@ -39,10 +39,16 @@ function ExpressionInputWithTest({
   );
 }
 
-const severityColors: Record<string, string> = {
-  critical: 'red',
-  warning: 'orange',
-  info: 'blue',
+const defaultSeverityLevels: SeverityLevel[] = [
+  { name: 'critical', color: 'red' },
+  { name: 'warning', color: 'orange' },
+  { name: 'info', color: 'blue' },
+];
+
+const severityLabels: Record<string, string> = {
+  critical: '严重',
+  warning: '警告',
+  info: '信息',
 };
 
 export default function AlertRules() {
@@ -54,6 +60,8 @@ export default function AlertRules() {
   const [editingRule, setEditingRule] = useState<AlertRule | null>(null);
   const [currentRuleId, setCurrentRuleId] = useState<string>('');
   const [form] = Form.useForm();
+  const formChannelIds = Form.useWatch('channel_ids', form) as string[] | undefined;
+  const formGroupId = Form.useWatch('group_id', form) as string | undefined;
   const queryClient = useQueryClient();
 
   const { data: rulesData, isLoading } = useQuery({
@@ -77,6 +85,23 @@ export default function AlertRules() {
     },
   });
 
+  // Severity levels and colors are configured server-side (severities in config).
+  const { data: severityLevels = defaultSeverityLevels } = useQuery({
+    queryKey: ['severities'],
+    queryFn: async () => {
+      const res = await severityApi.list();
+      const body = res.data as unknown as { data?: SeverityLevel[] };
+      return Array.isArray(body?.data) && body.data.length > 0 ? body.data : defaultSeverityLevels;
+    },
+    staleTime: 5 * 60 * 1000,
+  });
+  const severityColors: Record<string, string> = Object.fromEntries(severityLevels.map((l) => [l.name, l.color]));
+  const severityOptions = severityLevels.map((l) => ({ value: l.name, label: severityLabels[l.name] || l.name.toUpperCase() }));
+
+  // Rules without bound channels fall back to their group's default channel (or the global one, if configured).
+  const groupHasDefaultChannel = (groupId?: string) =>
+    (Array.isArray(groupsData?.data) ? groupsData.data : []).some((g) => g.id === groupId && !!g.default_channel_id);
+
   const { data: channelsData, isLoading: channelsLoading } = useQuery({
     queryKey: ['channels'],
     queryFn: async () => {
@@ -128,12 +153,12 @@ export default function AlertRules() {
   }, [isDrawerOpen, editingRule?.id, currentRuleId, boundChannels, form]);
 
   const createMutation = useMutation({
-    mutationFn: (data: Partial<AlertRule>) => alertRuleApi.create(data),
+    mutationFn: (data: Partial<AlertRule> & { channel_ids?: string[] }) => alertRuleApi.create(data),
     onError: () => message.error('创建失败'),
   });
 
   const updateMutation = useMutation({
-    mutationFn: ({ id, data }: { id: string; data: Partial<AlertRule> }) => alertRuleApi.update(id, data),
+    mutationFn: ({ id, data }: { id: string; data: Partial<AlertRule> & { channel_ids?: string[] } }) => alertRuleApi.update(id, data),
     onError: () => message.error('更新失败'),
   });
 
@@ -197,7 +222,11 @@ export default function AlertRules() {
       render: (_: unknown, record: AlertRule) => {
         const channels = record.bound_channels;
         if (!Array.isArray(channels) || channels.length === 0) {
-          return <Text type="secondary">—</Text>;
+          return groupHasDefaultChannel(record.group_id) ? (
+            <Text type="secondary" title="未绑定渠道，告警将发送到业务组默认渠道">业务组默认渠道</Text>
+          ) : (
+            <Tag color="warning" title="未绑定渠道，仅当配置了全局默认渠道时才会通知">未绑定渠道</Tag>
+          );
         }
         return (
           <span title={channels.map((c) => `${c.name} (${c.type})`).join('、')}>
@@ -217,6 +246,28 @@ export default function AlertRules() {
         </Tag>
       ),
     },
+    {
+      title: '评估',
+      dataIndex: 'last_eval_status',
+      key: 'last_eval_status',
+      width: 90,
+      render: (_: string, record: AlertRule) => {
+        if (!record.last_eval_status) return <Text type="secondary">-</Text>;
+        const at = record.last_evaluated_at ? dayjs(record.last_evaluated_at).format('YYYY-MM-DD HH:mm:ss') : '';
+        if (record.last_eval_status === 'error') {
+          return (
+            <Tooltip title={`${at} ${record.last_eval_error || ''}`}>
+              <Tag color="red">错误</Tag>
+            </Tooltip>
+          );
+        }
+        return (
+          <Tooltip title={at}>
+            {record.last_eval_status === 'no_data' ? <Tag color="orange">无数据</Tag> : <Tag color="green">正常</Tag>}
+          </Tooltip>
+        );
+      },
+    },
     {
       title: '创建时间',
       dataIndex: 'created_at',
@@ -277,6 +328,7 @@ export default function AlertRules() {
                 labels: record.labels,
                 annotations: record.annotations,
                 data_source_id: matchingDs?.id ?? undefined,
+                backup_data_source_ids: (record.data_source_ids ?? []).filter((id) => id !== matchingDs?.id),
                 channel_ids: Array.isArray(record.bound_channels) && record.bound_channels.length
                   ? record.bound_channels.map((c) => c.id)
                   : [],
@@ -284,6 +336,13 @@ export default function AlertRules() {
                 effective_end_time: record.effective_end_time ?? '23:59',
                 exclusion_windows: exclusionList.length > 0 ? exclusionList : undefined,
                 status: record.status ?? 1,
+                notify_on_resolve: record.notify_on_resolve ?? true,
+                group_recovery: record.group_recovery ?? false,
+                evaluation_mode: record.evaluation_mode ?? 'instant',
+                comparison_operator: record.comparison_operator ?? '',
+                threshold: record.threshold ?? undefined,
+                range_duration: record.range_duration ?? 600,
+                range_step: record.range_step ?? 60,
                 template_id: record.template_id ?? undefined,
               });
               setIsDrawerOpen(true);
@@ -351,11 +410,61 @@ export default function AlertRules() {
     },
   });
 
+  const [selectedRuleIds, setSelectedRuleIds] = useState<string[]>([]);
+  const [isMoveModalOpen, setIsMoveModalOpen] = useState(false);
+  const [moveTargetGroupId, setMoveTargetGroupId] = useState<string>();
+
+  const moveMutation = useMutation({
+    mutationFn: (data: { rule_ids: string[]; target_group_id: string }) => alertRuleApi.bulkMove(data),
+    onSuccess: (res) => {
+      const result = (res.data as unknown as { data?: { moved: number; not_found: string[] } })?.data;
+      const notFound = result?.not_found?.length ?? 0;
+      message.success(`已移动 ${result?.moved ?? 0} 条规则${notFound > 0 ? `，${notFound} 条不存在` : ''}`);
+      setIsMoveModalOpen(false);
+      setMoveTargetGroupId(undefined);
+      setSelectedRuleIds([]);
+      queryClient.invalidateQueries({ queryKey: ['alertRules'] });
+    },
+    onError: (error: any) => message.error(error.response?.data?.message || '移动失败'),
+  });
+
+  const [isPresetModalOpen, setIsPresetModalOpen] = useState(false);
+  const [presetForm] = Form.useForm();
+
+  const { data: presets = [] } = useQuery({
+    queryKey: ['alertRulePresets'],
+    queryFn: async () => {
+      const res = await alertRuleApi.presets();
+      const body = res.data as unknown as { data?: AlertRulePreset[] };
+      return Array.isArray(body?.data) ? body.data : [];
+    },
+    enabled: isPresetModalOpen,
+    staleTime: 5 * 60 * 1000,
+  });
+
+  const presetMutation = useMutation({
+    mutationFn: ({ presetId, ...data }: { presetId: string; group_id: string; data_source_id: string; name?: string }) =>
+      alertRuleApi.createFromPreset(presetId, data),
+    onSuccess: () => {
+      message.success('已从预设创建规则');
+      setIsPresetModalOpen(false);
+      presetForm.resetFields();
+      queryClient.invalidateQueries({ queryKey: ['alertRules'] });
+    },
+    onError: (error: any) => message.error(error.response?.data?.message || '创建失败'),
+  });
+
   return (
     <div>
       <div className="page-header">
         <h1 className="page-title">告警规则</h1>
         <Space>
+          <Button icon={<SwapOutlined />} disabled={selectedRuleIds.length === 0} onClick={() => setIsMoveModalOpen(true)}>
+            移动到业务组{selectedRuleIds.length > 0 ? ` (${selectedRuleIds.length})` : ''}
+          </Button>
+          <Button icon={<AppstoreAddOutlined />} onClick={() => setIsPresetModalOpen(true)}>
+            从预设创建
+          </Button>
           <Button icon={<ImportOutlined />} onClick={() => setIsImportModalOpen(true)}>
             导入
           </Button>
@@ -396,11 +505,7 @@ export default function AlertRules() {
               placeholder="全部"
               allowClear
               style={{ width: 120 }}
-              options={[
-                { value: 'critical', label: '严重' },
-                { value: 'warning', label: '警告' },
-                { value: 'info', label: '信息' },
-              ]}
+              options={severityOptions}
             />
           </Form.Item>
           <Form.Item name="status" label="状态">
@@ -427,6 +532,11 @@ export default function AlertRules() {
           columns={columns}
           dataSource={Array.isArray(rulesData?.data) ? rulesData.data : []}
           rowKey="id"
+          rowSelection={{
+            selectedRowKeys: selectedRuleIds,
+            preserveSelectedRowKeys: true,
+            onChange: (keys) => setSelectedRuleIds(keys as string[]),
+          }}
           loading={isLoading}
           scroll={{ x: 1280 }}
           pagination={{
@@ -455,11 +565,12 @@ export default function AlertRules() {
         <Form
           form={form}
           layout="vertical"
-          initialValues={{ effective_start_time: '00:00', effective_end_time: '23:59', evaluation_interval_seconds: 60, status: 1 }}
+          initialValues={{ effective_start_time: '00:00', effective_end_time: '23:59', evaluation_interval_seconds: 60, status: 1, notify_on_resolve: true, evaluation_mode: 'instant', comparison_operator: '', range_duration: 600, range_step: 60 }}
           onFinish={async (values) => {
-          const { data_source_id, channel_ids = [], exclusion_windows, template_id, ...rest } = values;
+          const { data_source_id, backup_data_source_ids = [], channel_ids = [], exclusion_windows, template_id, ...rest } = values;
           const data = {
             ...rest,
+            data_source_ids: backup_data_source_ids.length > 0 ? [data_source_id, ...backup_data_source_ids] : [],
             template_id: template_id ? template_id : (editingRule ? null : undefined),
             status: rest.status !== undefined && rest.status !== null ? Number(rest.status) : 1,
             evaluation_interval_seconds: rest.evaluation_interval_seconds != null && rest.evaluation_interval_seconds >= 1 ? rest.evaluation_interval_seconds : 60,
@@ -470,21 +581,25 @@ export default function AlertRules() {
             annotations: typeof rest.annotations === 'object' ? JSON.stringify(rest.annotations || {}) : rest.annotations,
           };
           const channelIdList = Array.isArray(channel_ids) ? channel_ids : [];
+          const warnIfNoChannels = (saved?: AlertRule) => {
+            if (saved?.no_channels) {
+              message.warning('该规则未绑定任何告警渠道，且业务组未配置默认渠道，触发时可能无人收到通知');
+            }
+          };
           try {
             if (editingRule) {
-              await updateMutation.mutateAsync({ id: editingRule.id, data });
-              await bindMutation.mutateAsync({ ruleId: editingRule.id, channelIds: channelIdList });
+              const res = await updateMutation.mutateAsync({ id: editingRule.id, data: { ...data, channel_ids: channelIdList } });
               message.success('更新成功');
+              warnIfNoChannels((res?.data as { data?: AlertRule })?.data);
               queryClient.invalidateQueries({ queryKey: ['alertRules'] });
               queryClient.invalidateQueries({ queryKey: ['bindings', editingRule.id] });
               setIsDrawerOpen(false);
               setEditingRule(null);
               form.resetFields();
             } else {
-              const res = await createMutation.mutateAsync(data);
-              const newId = (res?.data as { data?: { id?: string } })?.data?.id ?? (res?.data as { id?: string })?.id;
-              if (newId) await bindMutation.mutateAsync({ ruleId: newId, channelIds: channelIdList });
+              const res = await createMutation.mutateAsync({ ...data, channel_ids: channelIdList });
               message.success('创建成功');
+              warnIfNoChannels((res?.data as { data?: AlertRule })?.data);
               queryClient.invalidateQueries({ queryKey: ['alertRules'] });
               setIsDrawerOpen(false);
               form.resetFields();
@@ -509,6 +624,7 @@ export default function AlertRules() {
                 const expression = form.getFieldValue('expression')?.trim();
                 const dataSourceUrl = form.getFieldValue('data_source_url');
                 const dataSourceType = form.getFieldValue('data_source_type') || 'prometheus';
+                const evaluationMode = form.getFieldValue('evaluation_mode') || 'instant';
                 if (!expression) {
                   message.warning('请先输入表达式');
                   return;
@@ -522,8 +638,16 @@ export default function AlertRules() {
                     expression,
                     data_source_type: dataSourceType,
                     data_source_url: dataSourceUrl,
+                    ...(evaluationMode === 'range'
+                      ? {
+                          evaluation_mode: 'range' as const,
+                          range_duration: form.getFieldValue('range_duration'),
+                          range_step: form.getFieldValue('range_step'),
+                          range_condition: form.getFieldValue('range_condition')?.trim(),
+                        }
+                      : {}),
                   });
-                  const payload = (res.data as { data?: { count?: number; data?: unknown[] } })?.data;
+                  const payload = (res.data as { data?: { count?: number; firing?: number; data?: unknown[] } })?.data;
                   const count = payload?.count ?? 0;
                   const data = payload?.data ?? [];
                   Modal.success({
@@ -531,7 +655,10 @@ export default function AlertRules() {
                     width: 560,
                     content: (
                       <div>
-                        <p>返回 <strong>{count}</strong> 条结果。</p>
+                        <p>
+                          返回 <strong>{count}</strong> 条结果
+                          {payload?.firing !== undefined && <>，其中 <strong>{payload.firing}</strong> 条满足条件</>}。
+                        </p>
                         {Array.isArray(data) && data.length > 0 && (
                           <pre style={{ marginTop: 8, padding: 12, background: '#f5f5f5', borderRadius: 4, fontSize: 12, maxHeight: 240, overflow: 'auto' }}>
                             {JSON.stringify(data.slice(0, 10), null, 2)}
@@ -552,16 +679,76 @@ export default function AlertRules() {
           <Form.Item name="evaluation_interval_seconds" label="执行频率(秒)" rules={[{ required: true }]} initialValue={60}>
             <InputNumber min={1} style={{ width: '100%' }} placeholder="60" />
           </Form.Item>
+          <Form.Item name="evaluation_mode" label="评估模式" tooltip="range 模式按查询区间内的样本计算条件，适用于变化率类告警">
+            <Select
+              options={[
+                { value: 'instant', label: '即时查询 (instant)' },
+                { value: 'range', label: '区间查询 (range)' },
+              ]}
+            />
+          </Form.Item>
+          <Form.Item noStyle shouldUpdate={(prev, cur) => prev.evaluation_mode !== cur.evaluation_mode}>
+            {({ getFieldValue }) =>
+              getFieldValue('evaluation_mode') === 'range' ? (
+                <>
+                  <Form.Item name="range_duration" label="查询区间(秒)">
+                    <InputNumber min={1} style={{ width: '100%' }} placeholder="600" />
+                  </Form.Item>
+                  <Form.Item name="range_step" label="查询步长(秒)">
+                    <InputNumber min={1} style={{ width: '100%' }} placeholder="60" />
+                  </Form.Item>
+                  <Form.Item
+                    name="range_condition"
+                    label="区间条件"
+                    rules={[{ required: true, message: '请输入区间条件' }]}
+                    tooltip="格式：函数 比较符 阈值。函数：change_percent, change, min, max, avg, last；比较符：>, >=, <, <="
+                  >
+                    <Input placeholder="change_percent > 50" />
+                  </Form.Item>
+                </>
+              ) : (
+                <Row gutter={12}>
+                  <Col span={12}>
+                    <Form.Item name="comparison_operator" label="阈值比较" tooltip="不设置时表达式返回值 > 0 即告警">
+                      <Select
+                        options={[
+                          { value: '', label: '不比较 (值 > 0)' },
+                          { value: 'gt', label: '> 大于' },
+                          { value: 'gte', label: '>= 大于等于' },
+                          { value: 'lt', label: '< 小于' },
+                          { value: 'lte', label: '<= 小于等于' },
+                          { value: 'eq', label: '== 等于' },
+                          { value: 'neq', label: '!= 不等于' },
+                        ]}
+                      />
+                    </Form.Item>
+                  </Col>
+                  <Col span={12}>
+                    <Form.Item noStyle shouldUpdate={(prev, cur) => prev.comparison_operator !== cur.comparison_operator}>
+                      {() => (
+                        <Form.Item
+                          name="threshold"
+                          label="阈值"
+                          rules={getFieldValue('comparison_operator') ? [{ required: true, message: '请输入阈值' }] : []}
+                        >
+                          <InputNumber style={{ width: '100%' }} disabled={!getFieldValue('comparison_operator')} placeholder="如 90" />
+                        </Form.Item>
+                      )}
+                    </Form.Item>
+                  </Col>
+                </Row>
+              )
+            }
+          </Form.Item>
           <Form.Item name="for_duration" label="持续时间(秒)" rules={[{ required: true }]}>
             <InputNumber min={1} style={{ width: '100%' }} placeholder="60" />
           </Form.Item>
           <Form.Item name="severity" label="严重级别" rules={[{ required: true }]}>
             <Select
-              options={[
-                { value: 'critical', label: '严重 (Critical)' },
-                { value: 'warning', label: '警告 (Warning)' },
-                { value: 'info', label: '信息 (Info)' },
-              ]}
+              options={severityLevels.map((l) => ({
+                value: l.name,
+                label: severityLabels[l.name] ? `${severityLabels[l.name]} (${l.name.charAt(0).toUpperCase()}${l.name.slice(1)})` : l.name.toUpperCase(),
+              }))}
             />
           </Form.Item>
           <Form.Item name="status" label="状态">
@@ -572,6 +759,12 @@ export default function AlertRules() {
               ]}
             />
           </Form.Item>
+          <Form.Item name="notify_on_resolve" label="发送恢复通知" valuePropName="checked" tooltip="关闭后告警恢复时仍会记录为已恢复，但不再发送恢复消息">
+            <Switch />
+          </Form.Item>
+          <Form.Item name="group_recovery" label="合并恢复通知" valuePropName="checked" tooltip="同一轮评估中多个序列同时恢复时只发送一条恢复通知，列出恢复的序列数量、指纹和共同标签">
+            <Switch />
+          </Form.Item>
           <Form.Item name="group_id" label="业务组" rules={[{ required: true, message: '请选择业务组' }]}>
             <Select
               placeholder="请选择业务组"
@@ -630,7 +823,32 @@ export default function AlertRules() {
           <Form.Item name="data_source_url" hidden>
             <Input />
           </Form.Item>
-          <Form.Item name="channel_ids" label="告警渠道">
+          <Form.Item name="backup_data_source_ids" label="备用数据源" tooltip="主数据源查询失败时按顺序尝试，直到有一个响应">
+            <Select
+              mode="multiple"
+              placeholder="可选，按选择顺序故障切换"
+              allowClear
+              loading={dataSourcesLoading}
+              optionFilterProp="label"
+              options={(Array.isArray(dataSourcesData?.data) ? dataSourcesData.data : []).map((ds) => ({
+                value: ds.id,
+                label: `${ds.name} (${ds.type}) · ${ds.endpoint}`,
+              }))}
+            />
+          </Form.Item>
+          <Form.Item
+            name="channel_ids"
+            label="告警渠道"
+            extra={
+              !formChannelIds?.length && (
+                <Text type="warning">
+                  {groupHasDefaultChannel(formGroupId)
+                    ? '未选择渠道，告警将发送到业务组默认渠道'
+                    : '未选择渠道，告警仅在配置了全局默认渠道时才会通知'}
+                </Text>
+              )
+            }
+          >
             <Select
               mode="multiple"
               placeholder="从已配置的渠道中选择，告警将通知到所选渠道"
@@ -802,6 +1020,77 @@ export default function AlertRules() {
           </div>
         )}
       </Modal>
+
+      <Modal
+        title={`移动 ${selectedRuleIds.length} 条规则到业务组`}
+        open={isMoveModalOpen}
+        onCancel={() => setIsMoveModalOpen(false)}
+        onOk={() => moveTargetGroupId && moveMutation.mutate({ rule_ids: selectedRuleIds, target_group_id: moveTargetGroupId })}
+        okButtonProps={{ disabled: !moveTargetGroupId }}
+        confirmLoading={moveMutation.isPending}
+      >
+        <Select
+          style={{ width: '100%' }}
+          loading={groupsLoading}
+          placeholder="选择目标业务组"
+          value={moveTargetGroupId}
+          onChange={setMoveTargetGroupId}
+          options={(groupsData?.data ?? []).map((g) => ({ value: g.id, label: g.name }))}
+        />
+      </Modal>
+
+      <Modal
+        title="从预设创建规则"
+        open={isPresetModalOpen}
+        onCancel={() => setIsPresetModalOpen(false)}
+        onOk={() => presetForm.submit()}
+        confirmLoading={presetMutation.isPending}
+        width={600}
+      >
+        <Form form={presetForm} layout="vertical" onFinish={(values) => presetMutation.mutate(values)}>
+          <Form.Item name="presetId" label="预设" rules={[{ required: true, message: '请选择预设' }]}>
+            <Select
+              showSearch
+              optionFilterProp="label"
+              placeholder="选择常用规则预设"
+              options={presets.map((p) => ({ value: p.id, label: `[${p.category}] ${p.name}` }))}
+              onChange={(id: string) => presetForm.setFieldValue('name', presets.find((p) => p.id === id)?.name)}
+            />
+          </Form.Item>
+          <Form.Item noStyle shouldUpdate={(prev, cur) => prev.presetId !== cur.presetId}>
+            {({ getFieldValue }) => {
+              const preset = presets.find((p) => p.id === getFieldValue('presetId'));
+              return preset ? (
+                <div style={{ marginBottom: 16, padding: 12, background: '#f5f5f5', borderRadius: 8 }}>
+                  <Text type="secondary">{preset.description}</Text>
+                  <div style={{ marginTop: 8 }}><Text code>{preset.expression}</Text></div>
+                  <div style={{ marginTop: 8 }}>
+                    <Tag color={severityColors[preset.severity]}>{preset.severity.toUpperCase()}</Tag>
+                    <Text type="secondary">持续 {preset.for_duration} 秒</Text>
+                  </div>
+                </div>
+              ) : null;
+            }}
+          </Form.Item>
+          <Form.Item name="name" label="规则名称">
+            <Input placeholder="默认使用预设名称" />
+          </Form.Item>
+          <Form.Item name="group_id" label="业务组" rules={[{ required: true, message: '请选择业务组' }]}>
+            <Select
+              loading={groupsLoading}
+              placeholder="选择业务组"
+              options={(groupsData?.data ?? []).map((g) => ({ value: g.id, label: g.name }))}
+            />
+          </Form.Item>
+          <Form.Item name="data_source_id" label="数据源" rules={[{ required: true, message: '请选择数据源' }]}>
+            <Select
+              loading={dataSourcesLoading}
+              placeholder="选择数据源"
+              options={(dataSourcesData?.data ?? []).map((d) => ({ value: d.id, label: `${d.name} (${d.type})` }))}
+            />
+          </Form.Item>
+        </Form>
+      </Modal>
     </div>
   );
 }
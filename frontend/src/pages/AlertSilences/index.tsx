@@ -1,14 +1,21 @@
 import { useState } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
-import { Table, Button, Space, Tag, message, Modal, Form, Input, Drawer, DatePicker, Tooltip, Typography, Badge, Collapse, Row, Col, Result, Upload, Dropdown } from 'antd';
+import { Table, Button, Space, Tag, message, Modal, Form, Input, Drawer, DatePicker, Tooltip, Typography, Badge, Collapse, Row, Col, Result, Upload, Dropdown, Select } from 'antd';
 import { PlusOutlined, EditOutlined, DeleteOutlined, InfoCircleOutlined, CheckCircleOutlined, ExperimentOutlined, ImportOutlined, ExportOutlined, DownOutlined, InboxOutlined } from '@ant-design/icons';
-import { silenceApi, batchApi, AlertSilence, SilenceMatcher } from '../../services/api';
+import { silenceApi, batchApi, businessGroupApi, AlertSilence, BusinessGroup, SilenceMatcher, SilencePreview, parseSilenceMatchers } from '../../services/api';
 import dayjs from 'dayjs';
 
 const { Text } = Typography;
 const { Panel } = Collapse;
 const { Dragger } = Upload;
 
+const matcherOperatorLabels: Record<SilenceMatcher['operator'], string> = {
+  '=': '等于',
+  '!=': '不等于',
+  '=~': '正则匹配',
+  '!~': '正则不匹配',
+};
+
 const getSilenceStatus = (silence: AlertSilence) => {
   if (silence.status !== 1) {
     return { status: 'disabled', text: '已禁用', color: 'default' };
@@ -45,8 +52,18 @@ export default function AlertSilences() {
     },
   });
 
+  const { data: groups = [] } = useQuery({
+    queryKey: ['businessGroups', 'all'],
+    queryFn: async (): Promise<BusinessGroup[]> => {
+      const res = await businessGroupApi.list({ page: 1, page_size: 100 });
+      const body = res.data as unknown as { data?: { data?: BusinessGroup[] } };
+      return Array.isArray(body?.data?.data) ? body.data.data : [];
+    },
+  });
+  const groupName = (id: string) => groups.find((g) => g.id === id)?.name ?? id;
+
   const createMutation = useMutation({
-    mutationFn: (data: { name: string; description?: string; matchers: SilenceMatcher[]; start_time: string; end_time: string }) =>
+    mutationFn: (data: { name: string; description?: string; matchers: SilenceMatcher[]; business_group_id?: string; start_time: string; end_time: string }) =>
       silenceApi.create(data),
     onSuccess: () => {
       message.success('创建成功');
@@ -58,7 +75,7 @@ export default function AlertSilences() {
   });
 
   const updateMutation = useMutation({
-    mutationFn: ({ id, data }: { id: string; data: Partial<AlertSilence> }) => silenceApi.update(id, data),
+    mutationFn: ({ id, data }: { id: string; data: Omit<Partial<AlertSilence>, 'matchers'> & { matchers?: SilenceMatcher[] } }) => silenceApi.update(id, data),
     onSuccess: () => {
       message.success('更新成功');
       queryClient.invalidateQueries({ queryKey: ['silences'] });
@@ -114,6 +131,65 @@ export default function AlertSilences() {
     onError: () => message.error('批量删除失败'),
   });
 
+  // Before saving, preview the firing alerts the silence would suppress and ask for confirmation if any.
+  const [isPreviewing, setIsPreviewing] = useState(false);
+  const confirmSilence = async (data: { matchers: SilenceMatcher[]; business_group_id?: string | null; start_time: string; end_time: string }, save: () => void) => {
+    setIsPreviewing(true);
+    let preview: SilencePreview | undefined;
+    try {
+      const res = await silenceApi.preview(data);
+      const body = res.data as unknown as { data?: SilencePreview };
+      preview = body?.data;
+    } catch (err) {
+      const msg = (err as { response?: { data?: { message?: string } } }).response?.data?.message;
+      message.error(msg ? `预览失败: ${msg}` : '预览失败');
+      return;
+    } finally {
+      setIsPreviewing(false);
+    }
+    if (!preview || preview.count === 0) {
+      save();
+      return;
+    }
+    Modal.confirm({
+      title: `该静默将屏蔽 ${preview.count} 条正在触发的告警`,
+      width: 640,
+      content: (
+        <div>
+          <Space wrap style={{ marginBottom: 8 }}>
+            {Object.entries(preview.by_severity).map(([severity, count]) => (
+              <Tag key={severity} color={severity === 'critical' ? 'red' : undefined}>
+                {severity}: {count}
+              </Tag>
+            ))}
+          </Space>
+          {!preview.active_now && (
+            <div style={{ marginBottom: 8 }}>
+              <Text type="secondary">静默尚未生效，以上为当前匹配结果</Text>
+            </div>
+          )}
+          <Table
+            size="small"
+            rowKey="id"
+            pagination={false}
+            scroll={{ y: 240 }}
+            dataSource={preview.alerts}
+            columns={[
+              { title: '告警编号', dataIndex: 'alert_no', width: 200 },
+              { title: '规则', dataIndex: 'rule_name' },
+              { title: '级别', dataIndex: 'severity', width: 90 },
+            ]}
+          />
+          {preview.count > preview.alerts.length && (
+            <Text type="secondary">仅显示最近 {preview.alerts.length} 条</Text>
+          )}
+        </div>
+      ),
+      okText: '确认保存',
+      onOk: save,
+    });
+  };
+
   const [testLabels, setTestLabels] = useState<{ key: string; value: string }[]>([
     { key: 'severity', value: 'critical' },
     { key: 'instance', value: 'localhost:9090' },
@@ -220,18 +296,21 @@ export default function AlertSilences() {
       dataIndex: 'matchers',
       key: 'matchers',
       width: 250,
-      render: (matchers: string) => {
+      render: (matchers: string, record: AlertSilence) => {
         try {
-          const parsed = JSON.parse(matchers || '[]');
           return (
             <Space wrap size={[4, 4]}>
-              {parsed.map((m: Record<string, string>, idx: number) => {
-                const [key, value] = Object.entries(m)[0];
-                const isRegex = value.startsWith('~');
+              {record.business_group_id && (
+                <Tooltip title="业务组静默：只屏蔽该组规则的告警">
+                  <Tag color="geekblue" style={{ margin: 0 }}>业务组: {groupName(record.business_group_id)}</Tag>
+                </Tooltip>
+              )}
+              {parseSilenceMatchers(matchers).map((m, idx) => {
+                const isRegex = m.operator.endsWith('~');
                 return (
-                  <Tooltip key={idx} title={isRegex ? '正则表达式匹配' : '精确匹配'}>
-                    <Tag color={isRegex ? 'purple' : 'blue'} style={{ margin: 0 }}>
-                      {key}=~{isRegex ? value.slice(1) : value}
+                  <Tooltip key={idx} title={matcherOperatorLabels[m.operator]}>
+                    <Tag color={m.operator.startsWith('!') ? 'orange' : isRegex ? 'purple' : 'blue'} style={{ margin: 0 }}>
+                      {m.name}{m.operator}"{m.value}"
                     </Tag>
                   </Tooltip>
                 );
@@ -286,16 +365,13 @@ export default function AlertSilences() {
             icon={<EditOutlined />}
             onClick={() => {
               setEditingSilence(record);
-              const matchers = JSON.parse(record.matchers || '[]');
               form.setFieldsValue({
                 ...record,
                 start_time: dayjs(record.start_time),
                 end_time: dayjs(record.end_time),
-                matchers: matchers.map((m: Record<string, string>) => {
-                  const [key, value] = Object.entries(m)[0];
-                  return { key, value: value.startsWith('~') ? value.slice(1) : value, isRegex: value.startsWith('~') };
-                }),
               });
+              const matchers = parseSilenceMatchers(record.matchers);
+              setMatcherForms(matchers.length ? matchers : [{ name: '', operator: '=', value: '' }]);
               setIsDrawerOpen(true);
             }}
           >
@@ -320,12 +396,12 @@ export default function AlertSilences() {
     },
   ];
 
-  const [matcherForms, setMatcherForms] = useState<{ key: string; value: string; isRegex: boolean }[]>([
-    { key: '', value: '', isRegex: false },
+  const [matcherForms, setMatcherForms] = useState<SilenceMatcher[]>([
+    { name: '', operator: '=', value: '' },
   ]);
 
   const addMatcher = () => {
-    setMatcherForms([...matcherForms, { key: '', value: '', isRegex: false }]);
+    setMatcherForms([...matcherForms, { name: '', operator: '=', value: '' }]);
   };
 
   const removeMatcher = (index: number) => {
@@ -333,13 +409,9 @@ export default function AlertSilences() {
     setMatcherForms(newMatchers);
   };
 
-  const updateMatcher = (index: number, field: 'key' | 'value' | 'isRegex', val: string | boolean) => {
+  const updateMatcher = <K extends keyof SilenceMatcher>(index: number, field: K, val: SilenceMatcher[K]) => {
     const newMatchers = [...matcherForms];
-    if (field === 'isRegex') {
-      newMatchers[index].isRegex = val as boolean;
-    } else {
-      newMatchers[index][field] = val as string;
-    }
+    newMatchers[index] = { ...newMatchers[index], [field]: val };
     setMatcherForms(newMatchers);
   };
 
@@ -366,7 +438,7 @@ export default function AlertSilences() {
             onClick={() => {
               setEditingSilence(null);
               form.resetFields();
-              setMatcherForms([{ key: '', value: '', isRegex: false }]);
+              setMatcherForms([{ name: '', operator: '=', value: '' }]);
               setIsDrawerOpen(true);
             }}
           >
@@ -530,30 +602,27 @@ export default function AlertSilences() {
           form={form}
           layout="vertical"
           onFinish={(values) => {
-            const matchers = matcherForms
-              .filter((m) => m.key && m.value)
-              .map((m) => {
-                const pattern = m.isRegex ? '~' + m.value : m.value;
-                return { [m.key]: pattern };
-              });
+            const matchers = matcherForms.filter((m) => m.name);
             if (editingSilence) {
               const data = {
                 name: values.name,
                 description: values.description,
-                matchers: JSON.stringify(matchers),
+                matchers,
+                business_group_id: values.business_group_id ?? null,
                 start_time: values.start_time.toISOString(),
                 end_time: values.end_time.toISOString(),
               };
-              updateMutation.mutate({ id: editingSilence.id, data });
+              confirmSilence(data, () => updateMutation.mutate({ id: editingSilence.id, data }));
             } else {
               const data = {
                 name: values.name,
                 description: values.description,
                 matchers,
+                business_group_id: values.business_group_id || undefined,
                 start_time: values.start_time.toISOString(),
                 end_time: values.end_time.toISOString(),
               };
-              createMutation.mutate(data);
+              confirmSilence(data, () => createMutation.mutate(data));
             }
           }}
         >
@@ -564,13 +633,25 @@ export default function AlertSilences() {
             <Input.TextArea rows={2} placeholder="规则描述" />
           </Form.Item>
 
+          <Form.Item
+            name="business_group_id"
+            label="业务组"
+            extra="选择后只屏蔽该业务组规则的告警，匹配标签可留空（维护窗口整组静默）"
+          >
+            <Select
+              allowClear
+              placeholder="不限业务组"
+              options={groups.map((g) => ({ value: g.id, label: g.name }))}
+            />
+          </Form.Item>
+
           <Form.Item label="匹配标签">
             <div style={{ marginBottom: 8 }}>
               <Text type="secondary">
-                <Tooltip title="使用 ~ 前缀启用正则表达式匹配，例如: ~.*error.* 匹配包含 error 的值">
+                <Tooltip title="所有条件同时满足才静默；=~ / !~ 为整值正则匹配，例如 .*error.*；告警缺少的标签按空值匹配">
                   <span>
                     <InfoCircleOutlined style={{ marginRight: 4 }} />
-                    支持正则表达式匹配
+                    支持 =、!=、=~、!~
                   </span>
                 </Tooltip>
               </Text>
@@ -579,25 +660,20 @@ export default function AlertSilences() {
               <Space key={index} style={{ display: 'flex', marginBottom: 8 }}>
                 <Input
                   placeholder="标签键"
-                  value={matcher.key}
-                  onChange={(e) => updateMatcher(index, 'key', e.target.value)}
+                  value={matcher.name}
+                  onChange={(e) => updateMatcher(index, 'name', e.target.value)}
                   style={{ width: 150 }}
                 />
-                <span>=</span>
+                <Select
+                  value={matcher.operator}
+                  onChange={(v) => updateMatcher(index, 'operator', v)}
+                  options={(Object.keys(matcherOperatorLabels) as SilenceMatcher['operator'][]).map((op) => ({ value: op, label: op }))}
+                  style={{ width: 70 }}
+                />
                 <Input
-                  placeholder={matcher.isRegex ? '正则表达式，如: .*error.*' : '标签值'}
+                  placeholder={matcher.operator.endsWith('~') ? '正则表达式，如: .*error.*' : '标签值'}
                   value={matcher.value}
                   onChange={(e) => updateMatcher(index, 'value', e.target.value)}
-                  addonBefore={
-                    <Button
-                      type={matcher.isRegex ? 'primary' : 'default'}
-                      size="small"
-                      onClick={() => updateMatcher(index, 'isRegex', !matcher.isRegex)}
-                      style={{ margin: -5, height: 20, padding: '0 8px' }}
-                    >
-                      ~
-                    </Button>
-                  }
                   style={{ width: 200 }}
                 />
                 {matcherForms.length > 1 && (
@@ -621,7 +697,7 @@ export default function AlertSilences() {
 
           <Form.Item>
             <Space>
-              <Button type="primary" htmlType="submit" loading={createMutation.isPending || updateMutation.isPending}>
+              <Button type="primary" htmlType="submit" loading={isPreviewing || createMutation.isPending || updateMutation.isPending}>
                 保存
               </Button>
               <Button onClick={() => setIsDrawerOpen(false)}>取消</Button>
@@ -70,14 +70,14 @@ export default function AuditLogs() {
     setFilters(filters);
   };
 
-  const handleExport = async () => {
+  const handleExport = async (format: 'json' | 'csv') => {
     try {
-      const res = await auditLogApi.export(filters);
-      const blob = new Blob([res.data], { type: 'application/json' });
+      const res = await auditLogApi.export({ ...filters, format });
+      const blob = new Blob([res.data], { type: format === 'csv' ? 'text/csv' : 'application/json' });
       const url = window.URL.createObjectURL(blob);
       const link = document.createElement('a');
       link.href = url;
-      link.download = `audit_logs_${dayjs().format('YYYYMMDDHHmmss')}.json`;
+      link.download = `audit_logs_${dayjs().format('YYYYMMDDHHmmss')}.${format}`;
       link.click();
     } catch {
       console.error('导出失败');
@@ -163,9 +163,12 @@ export default function AuditLogs() {
       <div className="page-header">
         <h1 className="page-title">审计日志</h1>
         <Space>
-          <Button icon={<DownloadOutlined />} onClick={handleExport}>
+          <Button icon={<DownloadOutlined />} onClick={() => handleExport('json')}>
             导出
           </Button>
+          <Button icon={<DownloadOutlined />} onClick={() => handleExport('csv')}>
+            导出 CSV
+          </Button>
         </Space>
       </div>
 
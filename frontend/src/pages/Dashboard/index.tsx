@@ -6,6 +6,7 @@ import {
   ApiOutlined,
   BellOutlined,
   CalendarOutlined,
+  FieldTimeOutlined,
   RightOutlined,
 } from '@ant-design/icons';
 import { Link } from 'react-router-dom';
@@ -26,6 +27,12 @@ const statusColors: Record<string, string> = {
   resolved: 'green',
 };
 
+/** Formats a latency in ms as seconds, or minutes past 2 minutes. */
+const formatLatency = (ms?: number) => {
+  if (!ms) return '—';
+  return ms >= 120000 ? `${(ms / 60000).toFixed(1)} 分钟` : `${(ms / 1000).toFixed(1)} 秒`;
+};
+
 export default function Dashboard() {
   const { data: summary, isLoading: summaryLoading } = useQuery({
     queryKey: ['dashboardSummary'],
@@ -143,6 +150,24 @@ export default function Dashboard() {
                   />
                 </Card>
               </Col>
+              <Col xs={24} sm={12} lg={6}>
+                <Card className="dashboard-stat-card" hoverable>
+                  <Statistic
+                    title="通知耗时 P50 (24h)"
+                    value={formatLatency(summary?.notify_latency_p50_ms)}
+                    prefix={<FieldTimeOutlined className="stat-icon stat-icon--blue" />}
+                  />
+                </Card>
+              </Col>
+              <Col xs={24} sm={12} lg={6}>
+                <Card className="dashboard-stat-card" hoverable>
+                  <Statistic
+                    title="通知耗时 P95 (24h)"
+                    value={formatLatency(summary?.notify_latency_p95_ms)}
+                    prefix={<FieldTimeOutlined className="stat-icon stat-icon--cyan" />}
+                  />
+                </Card>
+              </Col>
             </Row>
           </section>
 
@@ -1,11 +1,11 @@
 import { useState } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
-import { Table, Button, Space, Tag, message, Modal, Form, Input, Select, Drawer, Badge, Tooltip } from 'antd';
-import { PlusOutlined, EditOutlined, DeleteOutlined, ReloadOutlined } from '@ant-design/icons';
-import { dataSourceApi, type DataSource } from '../../services/api';
+import { Table, Button, Space, Tag, message, Modal, Form, Input, Select, Drawer, Badge, Tooltip, Switch } from 'antd';
+import { PlusOutlined, EditOutlined, DeleteOutlined, ReloadOutlined, HistoryOutlined } from '@ant-design/icons';
+import { dataSourceApi, type DataSource, type DataSourceHealthCheck, type DataSourceTypeSchema } from '../../services/api';
 import dayjs from 'dayjs';
 
-const typeOptions = [
+const defaultTypeOptions = [
   { value: 'prometheus', label: 'Prometheus' },
   { value: 'victoria-metrics', label: 'VictoriaMetrics' },
 ];
@@ -22,6 +22,7 @@ export default function DataSources() {
   const [filters, setFilters] = useState({ type: '', status: '' });
   const [isDrawerOpen, setIsDrawerOpen] = useState(false);
   const [editingSource, setEditingSource] = useState<any>(null);
+  const [historySource, setHistorySource] = useState<DataSource | null>(null);
   const [form] = Form.useForm();
   const queryClient = useQueryClient();
 
@@ -35,6 +36,29 @@ export default function DataSources() {
     },
   });
 
+  const { data: healthHistory, isLoading: historyLoading } = useQuery({
+    queryKey: ['dataSourceHealthHistory', historySource?.id],
+    queryFn: async () => {
+      const res = await dataSourceApi.healthHistory(historySource!.id);
+      const body = res.data as unknown as { data?: { data: DataSourceHealthCheck[] } };
+      return body?.data?.data ?? [];
+    },
+    enabled: !!historySource,
+  });
+
+  const { data: typeSchemas } = useQuery({
+    queryKey: ['dataSourceTypes'],
+    queryFn: async () => {
+      const res = await dataSourceApi.types();
+      const body = res.data as unknown as { data?: DataSourceTypeSchema[] };
+      return Array.isArray(body?.data) ? body.data : [];
+    },
+    staleTime: 5 * 60 * 1000,
+  });
+  const typeOptions = typeSchemas?.length
+    ? typeSchemas.map((t) => ({ value: t.type, label: t.name }))
+    : defaultTypeOptions;
+
   const createMutation = useMutation({
     mutationFn: (data: any) => dataSourceApi.create(data),
     onSuccess: () => {
@@ -149,12 +173,18 @@ export default function DataSources() {
           >
             检查
           </Button>
+          <Button type="link" icon={<HistoryOutlined />} onClick={() => setHistorySource(record)}>
+            历史
+          </Button>
           <Button
             type="link"
             icon={<EditOutlined />}
             onClick={() => {
               setEditingSource(record);
-              form.setFieldsValue(record);
+              form.setFieldsValue({
+                ...record,
+                config: typeof record.config === 'string' ? JSON.parse(record.config || '{}') : record.config,
+              });
               setIsDrawerOpen(true);
             }}
           >
@@ -272,6 +302,18 @@ export default function DataSources() {
           <Form.Item name="endpoint" label="端点地址" rules={[{ required: true }]}>
             <Input placeholder="http://prometheus:9090" style={{ width: '100%', minWidth: 0, boxSizing: 'border-box' }} />
           </Form.Item>
+          <Form.Item name={['config', 'basic_auth', 'username']} label="Basic Auth 用户名">
+            <Input placeholder="未启用认证可留空" />
+          </Form.Item>
+          <Form.Item name={['config', 'basic_auth', 'password']} label="Basic Auth 密码">
+            <Input.Password />
+          </Form.Item>
+          <Form.Item name={['config', 'bearer_token']} label="Bearer Token" extra="未配置 Basic Auth 时使用">
+            <Input.Password />
+          </Form.Item>
+          <Form.Item name={['config', 'insecure_skip_verify']} label="跳过 TLS 证书校验" valuePropName="checked">
+            <Switch />
+          </Form.Item>
           <Form.Item name="status" label="状态">
             <Select
               options={[
@@ -290,6 +332,49 @@ export default function DataSources() {
           </Form.Item>
         </Form>
       </Drawer>
+
+      <Modal
+        title={`健康检查历史 - ${historySource?.name ?? ''}`}
+        open={!!historySource}
+        onCancel={() => setHistorySource(null)}
+        footer={null}
+        width={800}
+      >
+        <Table
+          rowKey="id"
+          size="small"
+          loading={historyLoading}
+          dataSource={healthHistory ?? []}
+          pagination={{ pageSize: 10 }}
+          columns={[
+            {
+              title: '时间',
+              dataIndex: 'checked_at',
+              width: 170,
+              render: (t: string) => dayjs(t).format('YYYY-MM-DD HH:mm:ss'),
+            },
+            {
+              title: '结果',
+              dataIndex: 'status',
+              width: 90,
+              render: (status: string) => <Badge status={(healthStatusColors[status] || 'default') as 'success' | 'error' | 'default'} text={status} />,
+            },
+            {
+              title: '来源',
+              dataIndex: 'source',
+              width: 80,
+              render: (source: string) => (source === 'breaker' ? '熔断器' : '手动'),
+            },
+            {
+              title: '耗时',
+              dataIndex: 'latency_ms',
+              width: 80,
+              render: (ms: number, row: DataSourceHealthCheck) => (row.source === 'breaker' ? '-' : `${ms} ms`),
+            },
+            { title: '错误', dataIndex: 'error', ellipsis: true },
+          ]}
+        />
+      </Modal>
     </div>
   );
 }
@@ -20,6 +20,8 @@ export default function OnCallSchedules() {
   const [isDrawerOpen, setIsDrawerOpen] = useState(false);
   const [, setEditingSchedule] = useState<OnCallSchedule | null>(null);
   const [selectedScheduleId, setSelectedScheduleId] = useState<string | null>(null);
+  const [schedulePage, setSchedulePage] = useState(1);
+  const [schedulePageSize, setSchedulePageSize] = useState(20);
   const [form] = Form.useForm();
   const queryClient = useQueryClient();
 
@@ -30,10 +32,12 @@ export default function OnCallSchedules() {
   }
 
   const { data: schedulesData, isLoading, refetch } = useQuery({
-    queryKey: ['oncall-schedules'],
+    queryKey: ['oncall-schedules', schedulePage, schedulePageSize],
     queryFn: async () => {
-      const res = await oncallApi.listSchedules();
-      return unwrapList<OnCallSchedule>(res);
+      const res = await oncallApi.listSchedules({ page: schedulePage, page_size: schedulePageSize });
+      const total = (res.data as { data?: { total?: number } })?.data?.total;
+      const { data } = unwrapList<OnCallSchedule>(res);
+      return { data, total: typeof total === 'number' ? total : data.length };
     },
   });
 
@@ -268,7 +272,7 @@ export default function OnCallSchedules() {
           <Card>
             <Statistic
               title="值班表数量"
-              value={schedules.length}
+              value={schedulesData?.total ?? schedules.length}
               prefix={<CalendarOutlined />}
             />
           </Card>
@@ -367,7 +371,17 @@ export default function OnCallSchedules() {
               dataSource={schedules}
               rowKey="id"
               loading={isLoading}
-              pagination={{ pageSize: 10 }}
+              pagination={{
+                current: schedulePage,
+                pageSize: schedulePageSize,
+                total: schedulesData?.total ?? 0,
+                onChange: (p, ps) => {
+                  setSchedulePage(p);
+                  setSchedulePageSize(ps);
+                },
+                showSizeChanger: true,
+                pageSizeOptions: [10, 20, 50, 100],
+              }}
             />
           </TabPane>
 
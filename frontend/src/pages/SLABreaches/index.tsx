@@ -1,6 +1,6 @@
 import { useState } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
-import { Table, Button, Space, Tag, Card, Row, Col, Statistic, DatePicker, Typography, message, Progress, Drawer, Descriptions, Badge } from 'antd';
+import { Table, Button, Space, Tag, Card, Row, Col, Statistic, DatePicker, Typography, Select, message, Progress, Drawer, Descriptions, Badge } from 'antd';
 import { ReloadOutlined, WarningOutlined, BellOutlined, ExclamationCircleOutlined, SendOutlined, ClockCircleOutlined } from '@ant-design/icons';
 import { slaBreachApi, SLABreach, SLABreachStats } from '../../services/api';
 import dayjs from 'dayjs';
@@ -31,14 +31,23 @@ export default function SLABreaches() {
     dayjs().subtract(30, 'days'),
     dayjs(),
   ]);
+  const [breachType, setBreachType] = useState<'response' | 'resolution' | undefined>();
+  const [severity, setSeverity] = useState<string | undefined>();
   const [selectedBreach, setSelectedBreach] = useState<SLABreach | null>(null);
   const [isDrawerOpen, setIsDrawerOpen] = useState(false);
   const queryClient = useQueryClient();
 
   const { data: breachesData, isLoading, refetch } = useQuery({
-    queryKey: ['sla-breaches', page, pageSize, dateRange],
+    queryKey: ['sla-breaches', page, pageSize, dateRange, breachType, severity],
     queryFn: async () => {
-      const res = await slaBreachApi.getBreaches({ page, page_size: pageSize });
+      const res = await slaBreachApi.getBreaches({
+        page,
+        page_size: pageSize,
+        breach_type: breachType,
+        severity,
+        start_time: dateRange[0].format('YYYY-MM-DD'),
+        end_time: dateRange[1].format('YYYY-MM-DD'),
+      });
       const body = res.data as unknown as { data?: { data?: SLABreach[]; total?: number }; total?: number };
       const inner = body?.data ?? body;
       const list = Array.isArray(inner?.data) ? inner.data : Array.isArray(inner) ? inner : [];
@@ -224,11 +233,34 @@ export default function SLABreaches() {
         }
         extra={
           <Space>
+            <Select
+              allowClear
+              placeholder="违约类型"
+              style={{ width: 120 }}
+              value={breachType}
+              onChange={(v) => {
+                setBreachType(v);
+                setPage(1);
+              }}
+              options={Object.entries(breachTypeLabels).map(([value, label]) => ({ value, label }))}
+            />
+            <Select
+              allowClear
+              placeholder="级别"
+              style={{ width: 110 }}
+              value={severity}
+              onChange={(v) => {
+                setSeverity(v);
+                setPage(1);
+              }}
+              options={Object.keys(severityColors).map((s) => ({ value: s, label: s }))}
+            />
             <RangePicker
               value={dateRange}
               onChange={(dates) => {
                 if (dates && dates[0] && dates[1]) {
                   setDateRange([dates[0], dates[1]]);
+                  setPage(1);
                 }
               }}
             />
@@ -3,6 +3,7 @@ import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { Table, Button, Space, Tag, message, Form, Input, InputNumber, Drawer, Select, Typography, Popconfirm, Statistic, Row, Col, Card, Progress, Tooltip } from 'antd';
 import { PlusOutlined, EditOutlined, DeleteOutlined, ReloadOutlined, SafetyCertificateOutlined, CheckCircleOutlined, ClockCircleOutlined, WarningOutlined, ExperimentOutlined } from '@ant-design/icons';
 import { slaApi, SLAConfig } from '../../services/api';
+import type { SLAReport, SLAReportBucket } from '../../services/api';
 import dayjs from 'dayjs';
 
 const { Text, Title } = Typography;
@@ -51,8 +52,8 @@ export default function SLAConfigs() {
         start_time: reportDateRange[0].format('YYYY-MM-DD'),
         end_time: reportDateRange[1].format('YYYY-MM-DD'),
       });
-      const body = res.data as unknown as { data?: { met_count?: number; breached_count?: number; compliance_rate?: number } };
-      return body?.data ?? {};
+      const body = res.data as unknown as { data?: SLAReport };
+      return body?.data;
     },
     enabled: !!reportDateRange,
   });
@@ -280,6 +281,31 @@ export default function SLAConfigs() {
         </Col>
       </Row>
 
+      <Card title="SLA达成情况（近30天，按级别）" style={{ marginBottom: 24 }}>
+        <Table<SLAReportBucket>
+          rowKey={(r) => r.severity || ''}
+          dataSource={reportData?.by_severity ?? []}
+          pagination={false}
+          size="small"
+          columns={[
+            {
+              title: '级别',
+              dataIndex: 'severity',
+              render: (severity: string) => <Tag color={severityColors[severity] || 'default'}>{severity?.toUpperCase()}</Tag>,
+            },
+            { title: '告警数', dataIndex: 'total_alerts' },
+            { title: '已满足', dataIndex: 'met_count' },
+            { title: '违反', dataIndex: 'breached_count' },
+            { title: '进行中', render: (_: unknown, r: SLAReportBucket) => r.pending_count + r.in_progress_count },
+            { title: '达成率', dataIndex: 'compliance_rate', render: (v: number) => `${v.toFixed(1)}%` },
+            { title: '响应达成率', dataIndex: 'response_compliance_rate', render: (v: number) => `${v.toFixed(1)}%` },
+            { title: '解决达成率', dataIndex: 'resolution_compliance_rate', render: (v: number) => `${v.toFixed(1)}%` },
+            { title: '平均响应', dataIndex: 'avg_response_time_secs', render: (v: number) => `${Math.round(v / 60)} 分钟` },
+            { title: '平均解决', dataIndex: 'avg_resolution_time_secs', render: (v: number) => `${Math.round(v / 60)} 分钟` },
+          ]}
+        />
+      </Card>
+
       <Card
         title="SLA配置管理"
         extra={
@@ -1,6 +1,6 @@
 import { useState } from 'react';
 import { useQuery } from '@tanstack/react-query';
-import { Row, Col, Card, Statistic, DatePicker, Spin, Table, Tag, Button, Space, Dropdown, message } from 'antd';
+import { Row, Col, Card, Statistic, DatePicker, Spin, Table, Tag, Button, Space, Dropdown, Input, message } from 'antd';
 import {
   WarningOutlined,
   CheckCircleOutlined,
@@ -27,13 +27,20 @@ const severityColors: Record<string, string> = {
 export default function Statistics() {
   const [dateRange, setDateRange] = useState<[dayjs.Dayjs, dayjs.Dayjs] | null>(null);
   const [filters, setFilters] = useState<{ start_time?: string; end_time?: string }>({});
+  // Label scope, "key=value" (e.g. env=prod) or just "key".
+  const [labelFilter, setLabelFilter] = useState('');
 
   const { data: stats, isLoading } = useQuery({
-    queryKey: ['statistics', filters],
+    queryKey: ['statistics', filters, labelFilter],
     queryFn: async (): Promise<AlertStatistics | null> => {
-      const params: { start_time?: string; end_time?: string } = {};
+      const params: { start_time?: string; end_time?: string; label_key?: string; label_value?: string } = {};
       if (dateRange?.[0]) params.start_time = dateRange[0].format('YYYY-MM-DD');
       if (dateRange?.[1]) params.end_time = dateRange[1].format('YYYY-MM-DD');
+      if (labelFilter) {
+        const [key, ...rest] = labelFilter.split('=');
+        params.label_key = key.trim();
+        if (rest.length > 0) params.label_value = rest.join('=').trim();
+      }
       const res = await statisticsApi.getStatistics(params);
       const body = res.data as unknown as { data?: AlertStatistics };
       return body?.data ?? null;
@@ -140,6 +147,12 @@ export default function Statistics() {
         </div>
         <Space className="statistics-toolbar" size="middle">
           <RangePicker value={dateRange ?? undefined} onChange={handleDateChange} />
+          <Input.Search
+            placeholder="标签过滤，如 env=prod"
+            allowClear
+            style={{ width: 220 }}
+            onSearch={(v) => setLabelFilter(v.trim())}
+          />
           <Dropdown menu={{ items: exportItems }} placement="bottomRight">
             <Button icon={<DownloadOutlined />}>
               导出 <DownOutlined />
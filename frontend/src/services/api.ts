@@ -55,6 +55,10 @@ export interface AlertRule {
   template_id?: string | null;
   data_source_type: string;
   data_source_url: string;
+  /** 按顺序尝试的数据源 ID（故障切换），为空则只使用 data_source_url */
+  data_source_ids?: string[];
+  /** 最近一次成功评估所用的数据源 */
+  last_eval_data_source_id?: string;
   status: number;
   /** 生效开始时间 HH:mm，默认 00:00 */
   effective_start_time?: string;
@@ -62,8 +66,31 @@ export interface AlertRule {
   effective_end_time?: string;
   /** 排除时间列表 */
   exclusion_windows?: ExclusionWindow[];
+  /** 恢复时是否发送恢复通知，默认 true */
+  notify_on_resolve?: boolean;
+  /** 同一轮评估中多个序列恢复时合并为一条恢复通知，默认 false */
+  group_recovery?: boolean;
+  /** 最近一次评估时间（由 worker 写入） */
+  last_evaluated_at?: string;
+  /** 最近一次评估结果：ok / error / no_data；未评估过为空 */
+  last_eval_status?: '' | 'ok' | 'error' | 'no_data';
+  last_eval_error?: string;
+  /** 评估模式：instant 即时查询，range 区间查询并按 range_condition 判断；默认 instant */
+  evaluation_mode?: 'instant' | 'range';
+  /** range 模式查询区间(秒)，默认 600 */
+  range_duration?: number;
+  /** range 模式查询步长(秒)，默认 60 */
+  range_step?: number;
+  /** range 模式条件，如 change_percent > 50 */
+  range_condition?: string;
+  /** instant 模式阈值比较符，为空则序列值 > 0 即告警 */
+  comparison_operator?: '' | 'gt' | 'gte' | 'lt' | 'lte' | 'eq' | 'neq';
+  /** 与 comparison_operator 配合使用的阈值 */
+  threshold?: number | null;
   /** 绑定的告警渠道（列表接口返回） */
   bound_channels?: { id: string; name: string; type: string }[];
+  /** Set on create/update responses when the rule has no bound channel and no group default channel */
+  no_channels?: boolean;
   created_at: string;
   updated_at: string;
 }
@@ -92,6 +119,97 @@ export interface AlertHistory {
   ended_at: string | null;
   labels: Record<string, string>;
   annotations: Record<string, string>;
+  /** Set once the alert is acknowledged */
+  acked_by?: string;
+  acked_by_name?: string;
+  acked_at?: string;
+  created_at: string;
+}
+
+/** One alert with its raw payload, rule and SLA state (GET /alert-history/:id) */
+export interface AlertHistoryDetail extends AlertHistory {
+  payload: string;
+  rule_name: string;
+  group_id?: string;
+  group_name?: string;
+  /** pending, acknowledged, resolved or breached; absent without an SLA */
+  sla_status?: string;
+  response_deadline?: string;
+  resolution_deadline?: string;
+  response_breached: boolean;
+  resolution_breached: boolean;
+}
+
+/** A firing alert in the triage view (GET /alerts/active) */
+export interface ActiveAlert {
+  id: string;
+  alert_no: string;
+  rule_id: string;
+  rule_name: string;
+  group_id?: string;
+  group_name?: string;
+  fingerprint: string;
+  severity: string;
+  labels: string;
+  started_at: string;
+  age_secs: number;
+  acked: boolean;
+  acked_by_name?: string;
+  acked_at?: string;
+  /** Empty when the alert has no SLA */
+  sla_status?: string;
+  response_deadline?: string;
+  resolution_deadline?: string;
+  response_breached: boolean;
+  resolution_breached: boolean;
+}
+
+/** Active alerts sharing a rule, business group, severity or label value */
+export interface ActiveAlertGroup {
+  key: string;
+  name: string;
+  count: number;
+  unacked: number;
+  /** Most severe severity in the group */
+  severity: string;
+  alerts: ActiveAlert[];
+}
+
+/** One channel delivery attempt of an alert notification */
+export interface NotificationLog {
+  id: string;
+  alert_id: string | null;
+  alert_no: string;
+  rule_id: string | null;
+  channel_id: string;
+  channel_name: string;
+  channel_type: string;
+  alert_status: string;
+  success: boolean;
+  /** Status of the last HTTP response, 0 when there was none (email, connection failure) */
+  http_status: number;
+  attempts: number;
+  /** Fire-to-delivery latency in ms; set on successful firing deliveries of recorded alerts */
+  latency_ms: number | null;
+  error: string;
+  created_at: string;
+}
+
+/** Notification that failed on every channel after all outbox retries */
+export interface NotificationDeadLetter {
+  id: string;
+  alert_id: string;
+  rule_id: string;
+  alert_no: string;
+  rule_name: string;
+  severity: string;
+  alert_status: string;
+  payload: string;
+  status: 'pending' | 'delivered' | 'expired';
+  attempts: number;
+  last_error: string;
+  next_attempt_at: string;
+  delivered_at: string | null;
   created_at: string;
 }
 
@@ -101,11 +219,25 @@ export interface BusinessGroup {
   description: string;
   parent_id: string | null;
   manager_id: string | null;
+  /** Fallback channel for the group's rules that have no bound channels */
+  default_channel_id?: string | null;
   status: number;
   created_at: string;
   updated_at: string;
 }
 
+/** A user's membership of a business group; non-admins only see their groups' data */
+export interface UserGroupMembership {
+  id: string;
+  user_id: string;
+  username: string;
+  group_id: string;
+  group_name: string;
+  role: 'manager' | 'member';
+  created_at: string;
+  updated_at: string;
+}
+
 export const alertRuleApi = {
   list: (params: { page?: number; page_size?: number; group_id?: string; severity?: string; status?: string }) =>
     api.get<PaginatedResponse<AlertRule>>('/alert-rules', { params }),
@@ -113,20 +245,93 @@ export const alertRuleApi = {
   getById: (id: string) =>
     api.get<AlertRule>(`/alert-rules/${id}`),
 
-  create: (data: Partial<AlertRule>) =>
+  status: (id: string) =>
+    api.get<{ rule_id: string; enabled: boolean; last_evaluated_at?: string; last_eval_status: string; last_eval_error?: string; firing_series: number }>(`/alert-rules/${id}/status`),
+
+  /** channel_ids, when given, replaces the rule's channel bindings as part of the save */
+  create: (data: Partial<AlertRule> & { channel_ids?: string[] }) =>
     api.post<AlertRule>('/alert-rules', data),
 
-  update: (id: string, data: Partial<AlertRule>) =>
+  update: (id: string, data: Partial<AlertRule> & { channel_ids?: string[] }) =>
     api.put<AlertRule>(`/alert-rules/${id}`, data),
 
   delete: (id: string) =>
     api.delete(`/alert-rules/${id}`),
 
-  testExpression: (data: { expression: string; data_source_type?: string; data_source_url: string }) =>
+  testExpression: (data: {
+    expression: string;
+    data_source_type?: string;
+    data_source_url: string;
+    evaluation_mode?: 'instant' | 'range';
+    range_duration?: number;
+    range_step?: number;
+    range_condition?: string;
+  }) =>
     api.post<{ data?: { count: number; data: Array<{ metric?: Record<string, string>; value?: { value: number } }> } }>('/alert-rules/test-expression', data),
 
   export: (params: { start_time?: string; end_time?: string }) =>
     api.get('/alert-rules/export', { params, responseType: 'blob' }),
+
+  presets: () =>
+    api.get<AlertRulePreset[]>('/alert-rules/presets'),
+
+  /** either data_source_id or data_source_url selects the data source; name/severity/for_duration override the preset */
+  createFromPreset: (id: string, data: {
+    group_id: string;
+    data_source_id?: string;
+    data_source_type?: string;
+    data_source_url?: string;
+    name?: string;
+    severity?: string;
+    for_duration?: number;
+    status?: number;
+    channel_ids?: string[];
+  }) =>
+    api.post<AlertRule>(`/alert-rules/from-preset/${id}`, data),
+
+  /** moves rules to another business group in one transaction */
+  bulkMove: (data: { rule_ids: string[]; target_group_id: string }) =>
+    api.post<{ requested: number; moved: number; not_found: string[] }>('/alert-rules/bulk-move', data),
+};
+
+export interface AlertRulePreset {
+  id: string;
+  name: string;
+  category: string;
+  description: string;
+  expression: string;
+  for_duration: number;
+  severity: string;
+  labels: string;
+  annotations: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ConfigField {
+  name: string;
+  type: string;
+  required: boolean;
+  secret: boolean;
+  description: string;
+}
+
+export interface ChannelTypeSchema {
+  type: string;
+  name: string;
+  fields: ConfigField[];
+}
+
+export interface SeverityLevel {
+  name: string;
+  color: string;
+  response_mins?: number;
+  resolution_mins?: number;
+}
+
+export const severityApi = {
+  list: () =>
+    api.get<SeverityLevel[]>('/severities'),
 };
 
 export const alertChannelApi = {
@@ -151,16 +356,70 @@ export const alertChannelApi = {
   /** Test channel with current form config (e.g. before save in create/edit drawer). */
   testWithConfig: (data: { type: string; config: Record<string, unknown> }) =>
     api.post('/channels/test-config', data),
+
+  /** Statically checks a config's required keys and types for its channel type, without sending */
+  validateConfig: (data: { type: string; config: Record<string, unknown> }) =>
+    api.post<{ valid: boolean; errors: { field: string; message: string }[] }>('/channels/validate-config', data),
+
+  types: () =>
+    api.get<ChannelTypeSchema[]>('/channels/types'),
 };
 
 export const alertHistoryApi = {
-  list: (params: { page?: number; page_size?: number; rule_id?: string; status?: string; start_time?: string; end_time?: string }) =>
+  list: (params: { page?: number; page_size?: number; rule_id?: string; status?: string; start_time?: string; end_time?: string; label_key?: string; label_value?: string }) =>
     api.get<PaginatedResponse<AlertHistory>>('/alert-history', { params }),
+  /** CSV of every alert matching the filters (no paging) */
+  export: (params: { rule_id?: string; status?: string; start_time?: string; end_time?: string; label_key?: string; label_value?: string }) =>
+    api.get('/alert-history/export', { params, responseType: 'blob' }),
+  get: (id: string) =>
+    api.get<AlertHistoryDetail>(`/alert-history/${id}`),
+  getByNo: (alertNo: string) =>
+    api.get<AlertHistoryDetail>(`/alert-history/by-no/${encodeURIComponent(alertNo)}`),
+  notifications: (id: string) =>
+    api.get<NotificationLog[]>(`/alert-history/${id}/notifications`),
+  ack: (id: string) =>
+    api.post<AlertHistory>(`/alert-history/${id}/ack`),
+  /** Acknowledges every unacknowledged firing alert matching all matchers (and rule_id) */
+  bulkAck: (data: { matchers: SilenceMatcher[]; rule_id?: string }) =>
+    api.post<{ count: number; alerts: BulkAlert[] }>('/alert-history/bulk/ack', data),
+  /** Resolves every firing alert matching all matchers (and rule_id), without a recovery notification */
+  bulkResolve: (data: { matchers: SilenceMatcher[]; rule_id?: string }) =>
+    api.post<{ count: number; alerts: BulkAlert[] }>('/alert-history/bulk/resolve', data),
+  /** group_by: rule, group, severity or label:<key>; grouped responses carry groups instead of data */
+  active: (params?: { group_by?: string }) =>
+    api.get<{
+      data?: ActiveAlert[];
+      groups?: ActiveAlertGroup[];
+      group_by?: string;
+      total: number;
+      oncall: { schedule_id: string; schedule_name: string; assignment: OnCallAssignment }[];
+      generated_at: string;
+    }>('/alerts/active', { params }),
+};
+
+export const deadLetterApi = {
+  list: (params: { page?: number; page_size?: number; status?: string }) =>
+    api.get<PaginatedResponse<NotificationDeadLetter>>('/notifications/deadletter', { params }),
+  retry: (id: string) =>
+    api.post<NotificationDeadLetter>(`/notifications/deadletter/${id}/retry`),
 };
 
 export const businessGroupApi = {
   list: (params?: { page?: number; page_size?: number; status?: number }) =>
     api.get<PaginatedResponse<BusinessGroup>>('/business-groups', { params }),
+  setDefaultChannel: (id: string, channelId: string | null) =>
+    api.put<BusinessGroup>(`/business-groups/${id}/default-channel`, { channel_id: channelId }),
+};
+
+export const groupMembershipApi = {
+  list: (params?: { user_id?: string; group_id?: string }) =>
+    api.get<{ data: UserGroupMembership[]; total: number }>('/group-memberships', { params }),
+  create: (data: { user_id: string; group_id: string; role?: UserGroupMembership['role'] }) =>
+    api.post<UserGroupMembership>('/group-memberships', data),
+  update: (id: string, role: UserGroupMembership['role']) =>
+    api.put<UserGroupMembership>(`/group-memberships/${id}`, { role }),
+  delete: (id: string) =>
+    api.delete(`/group-memberships/${id}`),
 };
 
 /** Backend success response wrapper (code, message, data) */
@@ -283,7 +542,7 @@ export const auditLogApi = {
   list: (params: { page?: number; page_size?: number; user_id?: string; action?: string; resource?: string; start_time?: string; end_time?: string }) =>
     api.get<PaginatedResponse<AuditLog>>('/audit-logs', { params }),
 
-  export: (params: { user_id?: string; action?: string; resource?: string; start_time?: string; end_time?: string }) =>
+  export: (params: { user_id?: string; action?: string; resource?: string; start_time?: string; end_time?: string; format?: 'json' | 'csv' }) =>
     api.get('/audit-logs/export', { params, responseType: 'blob' }),
 };
 
@@ -301,6 +560,16 @@ export interface DataSource {
   updated_at: string;
 }
 
+export interface DataSourceHealthCheck {
+  id: string;
+  data_source_id: string;
+  status: string;
+  source: 'manual' | 'breaker';
+  latency_ms: number;
+  error?: string;
+  checked_at: string;
+}
+
 export interface AlertStatistics {
   total_alerts: number;
   firing_alerts: number;
@@ -321,6 +590,18 @@ export interface DashboardSummary {
   enabled_channels: number;
   today_alerts: number;
   firing_alerts: number;
+  /** 最近 24 小时告警触发到通知送达耗时 p50(毫秒) */
+  notify_latency_p50_ms: number;
+  /** 最近 24 小时告警触发到通知送达耗时 p95(毫秒) */
+  notify_latency_p95_ms: number;
+}
+
+export interface DataSourceTypeSchema {
+  type: string;
+  name: string;
+  health_check_path: string;
+  fields: ConfigField[];
+  auth_fields: ConfigField[];
 }
 
 export const dataSourceApi = {
@@ -340,11 +621,17 @@ export const dataSourceApi = {
     api.delete(`/data-sources/${id}`),
 
   healthCheck: (id: string) =>
-    api.post(`/data-sources/${id}/health-check`),
+    api.post<DataSourceHealthCheck>(`/data-sources/${id}/health-check`),
+
+  healthHistory: (id: string, params?: { limit?: number }) =>
+    api.get<{ data: DataSourceHealthCheck[] }>(`/data-sources/${id}/health-history`, { params }),
+
+  types: () =>
+    api.get<DataSourceTypeSchema[]>('/data-sources/types'),
 };
 
 export const statisticsApi = {
-  getStatistics: (params?: { start_time?: string; end_time?: string; group_id?: string }) =>
+  getStatistics: (params?: { start_time?: string; end_time?: string; group_id?: string; label_key?: string; label_value?: string }) =>
     api.get<AlertStatistics>('/statistics', { params }),
 
   getDashboard: () =>
@@ -356,6 +643,7 @@ export interface AlertSilence {
   name: string;
   description: string;
   matchers: string;
+  business_group_id?: string | null; // 业务组静默：只屏蔽该组规则的告警，可不带匹配标签
   start_time: string;
   end_time: string;
   created_by: string;
@@ -364,8 +652,49 @@ export interface AlertSilence {
   updated_at: string;
 }
 
+/** Alertmanager-style label matcher; all matchers of a silence must match */
 export interface SilenceMatcher {
-  [key: string]: string;
+  name: string;
+  operator: '=' | '!=' | '=~' | '!~';
+  value: string;
+}
+
+/** A firing alert a bulk acknowledge or resolve was applied to */
+export interface BulkAlert {
+  id: string;
+  alert_no: string;
+  rule_id: string;
+  rule_name: string;
+  severity: string;
+}
+
+/** Parses stored silence matchers, converting legacy label maps ("~"-prefixed values are regexes) */
+export const parseSilenceMatchers = (matchers: string): SilenceMatcher[] => {
+  const parsed: Record<string, string>[] = JSON.parse(matchers || '[]');
+  return parsed.flatMap((m): SilenceMatcher[] => {
+    if ('operator' in m && 'name' in m) return [m as unknown as SilenceMatcher];
+    return Object.entries(m).map(([name, value]) =>
+      value.startsWith('~') ? { name, operator: '=~', value: value.slice(1) } : { name, operator: '=', value });
+  });
+};
+
+/** A firing alert a proposed silence would match */
+export interface SilencePreviewAlert {
+  id: string;
+  alert_no: string;
+  rule_id: string;
+  rule_name: string;
+  severity: string;
+  labels: Record<string, string>;
+  started_at: string;
+}
+
+/** Firing alerts a proposed silence would suppress; alerts is a sample of the most recent */
+export interface SilencePreview {
+  count: number;
+  by_severity: Record<string, number>;
+  alerts: SilencePreviewAlert[];
+  active_now: boolean;
 }
 
 export const silenceApi = {
@@ -375,17 +704,20 @@ export const silenceApi = {
   getById: (id: string) =>
     api.get<AlertSilence>(`/silences/${id}`),
 
-  create: (data: { name: string; description?: string; matchers: SilenceMatcher[]; start_time: string; end_time: string }) =>
+  create: (data: { name: string; description?: string; matchers: SilenceMatcher[]; business_group_id?: string; start_time: string; end_time: string }) =>
     api.post<AlertSilence>('/silences', data),
 
-  update: (id: string, data: Partial<AlertSilence>) =>
+  update: (id: string, data: Omit<Partial<AlertSilence>, 'matchers'> & { matchers?: SilenceMatcher[] }) =>
     api.put<AlertSilence>(`/silences/${id}`, data),
 
   delete: (id: string) =>
     api.delete(`/silences/${id}`),
 
-  check: (labels: Record<string, string>) =>
-    api.post<{ silenced: boolean }>('/silences/check', { labels }),
+  check: (labels: Record<string, string>, ruleId?: string) =>
+    api.post<{ silenced: boolean }>('/silences/check', { labels, rule_id: ruleId }),
+
+  preview: (data: { matchers: SilenceMatcher[]; business_group_id?: string | null; start_time?: string; end_time?: string; limit?: number }) =>
+    api.post<SilencePreview>('/silences/preview', data),
 };
 
 export const batchApi = {
@@ -395,9 +727,18 @@ export const batchApi = {
   exportRules: (params?: { group_id?: string; severity?: string; status?: string }) =>
     api.get('/batch/export/rules', { params, responseType: 'blob' }),
 
-  exportChannels: (params?: { type?: string }) =>
+  importPrometheusRules: (yaml: string, params: { group_id: string; default_severity?: string; mode?: 'upsert' }) =>
+    api.post<{ success: number; created: number; updated: number; failed: number; errors: string[] }>('/batch/import/rules/prometheus', yaml, { params, headers: { 'Content-Type': 'application/yaml' } }),
+
+  exportPrometheusRules: (params?: { group_id?: string; severity?: string; status?: string }) =>
+    api.get('/batch/export/rules/prometheus', { params, responseType: 'blob' }),
+
+  exportChannels: (params?: { type?: string; include_secrets?: boolean }) =>
     api.get('/batch/export/channels', { params, responseType: 'blob' }),
 
+  importChannels: (channels: { name: string; slug?: string; type: string; description?: string; config: Record<string, unknown>; group_id?: string }[], mode?: 'upsert') =>
+    api.post<{ success: number; created: number; updated: number; failed: number; errors: string[] }>('/batch/import/channels', { channels }, { params: mode ? { mode } : undefined }),
+
   importSilences: (silences: { name: string; description?: string; matchers: SilenceMatcher[]; start_time: string; end_time: string }[]) =>
     api.post<{ success: number; failed: number; errors: string[] }>('/batch/import/silences', { silences }),
 
@@ -432,19 +773,35 @@ export interface AlertSLA {
   resolution_time_secs: number;
 }
 
-export interface SLAReport {
-  period_start: string;
-  period_end: string;
+/** SLA compliance of a report period, overall, for one severity or for one SLA config */
+export interface SLAReportBucket {
+  severity?: string;
+  sla_config_id?: string;
+  sla_config_name?: string;
   total_alerts: number;
   met_count: number;
   breached_count: number;
   in_progress_count: number;
   pending_count: number;
+  response_met: number;
+  response_breached: number;
+  resolution_met: number;
+  resolution_breached: number;
+  /** Percent of decided alerts (met + breached) that met the SLA; 100 when none decided */
   compliance_rate: number;
+  response_compliance_rate: number;
+  resolution_compliance_rate: number;
   avg_response_time_secs: number;
   avg_resolution_time_secs: number;
 }
 
+export interface SLAReport extends SLAReportBucket {
+  period_start: string;
+  period_end: string;
+  by_severity: SLAReportBucket[];
+  by_config: SLAReportBucket[];
+}
+
 export const slaApi = {
   listConfigs: () =>
     api.get<{ data: SLAConfig[]; total: number }>('/sla/configs'),
@@ -502,11 +859,25 @@ export interface OnCallAssignment {
   end_time: string;
   email: string;
   phone: string;
+  override_id?: string;
+}
+
+export interface OnCallOverride {
+  id: string;
+  schedule_id: string;
+  original_user_id: string;
+  original_username: string;
+  override_user_id: string;
+  override_username: string;
+  start_time: string;
+  end_time: string;
+  reason: string;
+  created_at: string;
 }
 
 export const oncallApi = {
-  listSchedules: () =>
-    api.get<{ data: OnCallSchedule[] }>('/oncall/schedules'),
+  listSchedules: (params?: { page?: number; page_size?: number; enabled?: boolean }) =>
+    api.get<{ data: OnCallSchedule[]; total: number }>('/oncall/schedules', { params }),
 
   createSchedule: (data: { name: string; description?: string; timezone?: string; rotation_type?: string; rotation_start?: string }) =>
     api.post<OnCallSchedule>('/oncall/schedules', data),
@@ -532,6 +903,17 @@ export const oncallApi = {
   generateRotations: (scheduleId: string, data: { end_time: string }) =>
     api.post(`/oncall/schedules/${scheduleId}/generate-rotations`, data),
 
+  listOverrides: (scheduleId: string) =>
+    api.get<{ data: OnCallOverride[] }>(`/oncall/schedules/${scheduleId}/overrides`),
+
+  createOverride: (
+    scheduleId: string,
+    data: { original_user_id?: string; override_user_id: string; start_time: string; end_time: string; reason?: string }
+  ) => api.post<OnCallOverride>(`/oncall/schedules/${scheduleId}/overrides`, data),
+
+  deleteOverride: (scheduleId: string, overrideId: string) =>
+    api.delete(`/oncall/schedules/${scheduleId}/overrides/${overrideId}`),
+
   escalate: (scheduleId: string, data: { current_user_id: string }) =>
     api.post<OnCallAssignment>(`/oncall/schedules/${scheduleId}/escalate`, data),
 
@@ -649,7 +1031,16 @@ export interface SLABreachStats {
 }
 
 export const slaBreachApi = {
-  getBreaches: (params?: { page?: number; page_size?: number; status?: string }) =>
+  getBreaches: (params?: {
+    page?: number;
+    page_size?: number;
+    breach_type?: 'response' | 'resolution';
+    severity?: string;
+    /** YYYY-MM-DD, inclusive */
+    start_time?: string;
+    /** YYYY-MM-DD, inclusive */
+    end_time?: string;
+  }) =>
     api.get<{ data: SLABreach[]; total: number }>('/sla/breaches', { params }),
 
   getStats: (params?: { start_time?: string; end_time?: string }) =>
@@ -659,7 +1050,7 @@ export const slaBreachApi = {
     api.post<{ breaches_found: number }>('/sla/breaches/check'),
 
   triggerNotifications: () =>
-    api.post<{ notifications: number }>('/sla/breaches/notify'),
+    api.post<{ notifications: number; ticket_ids: string[] }>('/sla/breaches/notify'),
 };
 
 export const correlationApi = {